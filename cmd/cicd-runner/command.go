@@ -0,0 +1,166 @@
+// Package main provides the /ai commands of the serve command
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/cicd-ai-toolkit/cicd-runner/pkg/chatops"
+	"github.com/cicd-ai-toolkit/cicd-runner/pkg/platform"
+	"github.com/cicd-ai-toolkit/cicd-runner/pkg/queue"
+	"github.com/cicd-ai-toolkit/cicd-runner/pkg/runner"
+)
+
+// command runs the /ai command of a comment job and replies in the PR.
+// Invalid, unauthorized and failed commands are answered rather than
+// retried; the commenter can post the command again.
+func (d *daemon) command(ctx context.Context, r *runner.DefaultRunner, client platform.Platform, ignores *chatops.IgnoreStore, job queue.Job) error {
+	event := job.Event
+	if d.auth == nil {
+		log.Printf("job %s: ChatOps is disabled, skipping comment %d", job.ID, event.Comment.ID)
+		return nil
+	}
+	cmd, ok := chatops.Parse(event.Comment.Body)
+	if !ok {
+		return nil
+	}
+
+	reply, err := d.runCommand(ctx, r, client, ignores, event, cmd)
+	if err != nil {
+		log.Printf("job %s: %s by %s on %s#%d failed: %v", job.ID, cmd, event.Comment.Author, event.Repo, event.PRID, err)
+		reply = fmt.Sprintf("`%s` failed: %v", cmd, err)
+	} else {
+		log.Printf("job %s: %s by %s on %s#%d done", job.ID, cmd, event.Comment.Author, event.Repo, event.PRID)
+	}
+
+	body := fmt.Sprintf("> %s\n\n@%s %s", cmd, event.Comment.Author, reply)
+	if err := client.PostComment(ctx, platform.CommentOptions{PRID: event.PRID, Body: body}); err != nil {
+		return fmt.Errorf("failed to reply to comment %d: %w", event.Comment.ID, err)
+	}
+	return nil
+}
+
+// runCommand checks and runs a command, returning the markdown reply
+func (d *daemon) runCommand(ctx context.Context, r *runner.DefaultRunner, client platform.Platform, ignores *chatops.IgnoreStore, event platform.WebhookEvent, cmd chatops.Command) (string, error) {
+	if err := cmd.Validate(); err != nil {
+		return fmt.Sprintf("%v\n\n%s", err, chatops.Usage), nil
+	}
+	if err := d.auth.Authorize(event.Platform, event.Comment.Author, cmd); err != nil {
+		log.Printf("%s by %s on %s#%d refused: %v", cmd, event.Comment.Author, event.Repo, event.PRID, err)
+		return fmt.Sprintf("you are not permitted to run `%s %s` (%v).", chatops.Prefix, cmd.Name, err), nil
+	}
+
+	switch cmd.Name {
+	case chatops.CommandHelp:
+		return "\n\n" + chatops.Usage, nil
+	case chatops.CommandIgnore:
+		added, err := ignores.Add(event.PRID, cmd.Args[0])
+		if err != nil {
+			return "", err
+		}
+		if !added {
+			return fmt.Sprintf("`%s` is already ignored in this pull request.", cmd.Args[0]), nil
+		}
+		return fmt.Sprintf("`%s` will no longer be reported in reviews of this pull request.", cmd.Args[0]), nil
+	}
+
+	diff, err := client.GetDiff(ctx, event.PRID)
+	if err != nil {
+		return "", fmt.Errorf("failed to get diff: %w", err)
+	}
+	if strings.TrimSpace(diff) == "" {
+		return "This pull request has no changes.", nil
+	}
+
+	switch cmd.Name {
+	case chatops.CommandReview:
+		rules, err := ignores.Rules(event.PRID)
+		if err != nil {
+			return "", err
+		}
+		result, err := r.Review(ctx, runner.ReviewOptions{PRID: event.PRID, Diff: diff, Skills: cmd.Args, Ignore: rules})
+		if err != nil {
+			return "", err
+		}
+		return "\n\n" + result.PlatformComment, nil
+
+	case chatops.CommandAnalyze:
+		result, err := d.analyze(ctx, r, event, diff, cmd.Args)
+		if err != nil {
+			return "", err
+		}
+		return formatAnalysis(result), nil
+
+	case chatops.CommandTestGen:
+		opts := runner.TestGenOptions{Diff: diff}
+		if len(cmd.Args) > 0 {
+			opts.TestFramework = cmd.Args[0]
+		}
+		result, err := r.GenerateTests(ctx, opts)
+		if err != nil {
+			return "", err
+		}
+		return formatTests(result), nil
+
+	case chatops.CommandExplain:
+		file, line, err := cmd.Location()
+		if err != nil {
+			return "", err
+		}
+		result, err := r.Explain(ctx, runner.ExplainOptions{PRID: event.PRID, Diff: diff, File: file, Line: line})
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("`%s:%d`\n\n%s", result.File, result.Line, result.Explanation), nil
+	}
+	return "", fmt.Errorf("unsupported command")
+}
+
+// formatAnalysis renders a change analysis as a reply
+func formatAnalysis(result *runner.AnalyzeResult) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Risk score: **%d/10**", result.Risk.Score)
+	if result.Risk.TestingLevel != "" {
+		fmt.Fprintf(&b, ", testing: %s", result.Risk.TestingLevel)
+	}
+	b.WriteString("\n")
+	if result.Summary.Description != "" {
+		b.WriteString("\n" + result.Summary.Description + "\n")
+	}
+	writeList(&b, "Risk factors", result.Risk.Factors)
+	writeList(&b, "Breaking changes", result.Impact.BreakingChanges)
+	writeList(&b, "Suggestions", result.Suggestions)
+	return b.String()
+}
+
+// formatTests renders generated tests as a reply, each file in a
+// collapsed code block
+func formatTests(result *runner.TestGenResult) string {
+	if len(result.TestFiles) == 0 {
+		return "No tests were generated for these changes."
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "Generated %d tests in %d files", result.Summary.TotalTests, len(result.TestFiles))
+	if result.Summary.CoverageEst != "" {
+		fmt.Fprintf(&b, " (estimated coverage: %s)", result.Summary.CoverageEst)
+	}
+	b.WriteString(".\n")
+	for _, f := range result.TestFiles {
+		fmt.Fprintf(&b, "\n<details>\n<summary><code>%s</code></summary>\n\n```%s\n%s\n```\n\n</details>\n",
+			f.Path, strings.ToLower(f.Language), strings.TrimRight(f.Content, "\n"))
+	}
+	return b.String()
+}
+
+// writeList writes a titled markdown list when items is not empty
+func writeList(b *strings.Builder, title string, items []string) {
+	if len(items) == 0 {
+		return
+	}
+	fmt.Fprintf(b, "\n**%s**\n\n", title)
+	for _, item := range items {
+		fmt.Fprintf(b, "- %s\n", item)
+	}
+}
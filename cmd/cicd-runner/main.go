@@ -2,22 +2,48 @@
 package main
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"runtime/debug"
 )
 
+// Exit codes reported to the CI job
+const (
+	// ExitError indicates a command or configuration error
+	ExitError = 1
+	// ExitPanic indicates an unexpected crash
+	ExitPanic = 2
+	// ExitQualityGate indicates the review broke a quality gate rule
+	ExitQualityGate = 3
+	// ExitInfraFailure indicates an AI or platform failure configured to block CI
+	ExitInfraFailure = 4
+)
+
+// exitError carries a specific process exit code
+type exitError struct {
+	code int
+	err  error
+}
+
+func (e *exitError) Error() string { return e.err.Error() }
+func (e *exitError) Unwrap() error { return e.err }
+
 func main() {
 	// Panic recovery to prevent crashes and provide diagnostic info
 	defer func() {
 		if r := recover(); r != nil {
 			fmt.Fprintf(os.Stderr, "PANIC: %v\n", r)
 			fmt.Fprintf(os.Stderr, "\nStack trace:\n%s\n", debug.Stack())
-			os.Exit(2)
+			os.Exit(ExitPanic)
 		}
 	}()
 
 	if err := Execute(); err != nil {
-		os.Exit(1)
+		var exitErr *exitError
+		if errors.As(err, &exitErr) {
+			os.Exit(exitErr.code)
+		}
+		os.Exit(ExitError)
 	}
 }
@@ -4,13 +4,22 @@ package main
 import (
 	"context"
 	"fmt"
+	"io"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
+	"time"
 
+	"github.com/cicd-ai-toolkit/cicd-runner/pkg/ai"
 	"github.com/cicd-ai-toolkit/cicd-runner/pkg/buildcontext"
+	"github.com/cicd-ai-toolkit/cicd-runner/pkg/claude"
 	"github.com/cicd-ai-toolkit/cicd-runner/pkg/config"
+	"github.com/cicd-ai-toolkit/cicd-runner/pkg/errors"
+	"github.com/cicd-ai-toolkit/cicd-runner/pkg/observability"
 	"github.com/cicd-ai-toolkit/cicd-runner/pkg/platform"
+	"github.com/cicd-ai-toolkit/cicd-runner/pkg/report"
+	"github.com/cicd-ai-toolkit/cicd-runner/pkg/retry"
 	"github.com/cicd-ai-toolkit/cicd-runner/pkg/runner"
 	"github.com/spf13/cobra"
 )
@@ -20,6 +29,19 @@ var (
 	verbose bool
 )
 
+// progressOpts are shared by the commands that run AI executions
+var progressOpts struct {
+	enabled bool
+	events  string
+}
+
+// outputOpts select how the commands that run AI executions write their
+// result
+var outputOpts struct {
+	format string // text or json
+	file   string // Write to this file instead of stdout
+}
+
 // rootCmd represents the base command
 var rootCmd = &cobra.Command{
 	Use:   "cicd-runner",
@@ -45,6 +67,9 @@ var reviewOpts struct {
 	skills      []string
 	force       bool
 	postComment bool
+	inline      bool
+	status      bool
+	format      string
 }
 
 // analyzeCmd runs change analysis
@@ -77,16 +102,87 @@ var testGenOpts struct {
 	outputDir     string
 }
 
+// cacheCmd manages the review cache
+var cacheCmd = &cobra.Command{
+	Use:   "cache",
+	Short: "Manage the review cache",
+	Long:  "Inspect, prune and clear cached review results",
+}
+
+var cacheLsCmd = &cobra.Command{
+	Use:   "ls",
+	Short: "List cache entries and statistics",
+	Args:  cobra.NoArgs,
+	RunE:  runCacheLs,
+}
+
+var cachePruneCmd = &cobra.Command{
+	Use:   "prune",
+	Short: "Remove expired entries and enforce the size bound",
+	Args:  cobra.NoArgs,
+	RunE:  runCachePrune,
+}
+
+var cacheClearCmd = &cobra.Command{
+	Use:   "clear",
+	Short: "Remove all cache entries",
+	Args:  cobra.NoArgs,
+	RunE:  runCacheClear,
+}
+
+// sessionCmd manages the Claude sessions resumed across runs
+var sessionCmd = &cobra.Command{
+	Use:   "session",
+	Short: "Manage resumed Claude sessions",
+	Long:  "Inspect and clear the Claude sessions kept per PR when claude.use_explicit_id is set",
+}
+
+var sessionLsCmd = &cobra.Command{
+	Use:   "ls",
+	Short: "List sessions and pool statistics",
+	Args:  cobra.NoArgs,
+	RunE:  runSessionLs,
+}
+
+var sessionClearCmd = &cobra.Command{
+	Use:   "clear [key]",
+	Short: "Forget all sessions, or those whose key starts with the given prefix",
+	Args:  cobra.MaximumNArgs(1),
+	RunE:  runSessionClear,
+}
+
+// budgetCmd inspects AI spending
+var budgetCmd = &cobra.Command{
+	Use:   "budget",
+	Short: "Inspect AI spending",
+	Long:  "Report AI spend recorded in the budget ledger",
+}
+
+var budgetReportCmd = &cobra.Command{
+	Use:   "report",
+	Short: "Show spend totals per day, repository, PR or run",
+	Args:  cobra.NoArgs,
+	RunE:  runBudgetReport,
+}
+
+var budgetOpts struct {
+	by   string
+	days int
+}
+
 // initCommands initializes all commands
 func initCommands() {
 	// Review flags
 	reviewCmd.Flags().IntVarP(&reviewOpts.prID, "pr", "p", 0, "Pull request ID")
 	reviewCmd.Flags().StringVarP(&reviewOpts.diff, "diff", "d", "", "Diff string to review")
 	reviewCmd.Flags().StringVar(&reviewOpts.baseSHA, "base", "", "Base commit SHA")
-	reviewCmd.Flags().StringVar(&reviewOpts.headSHA, "head", "", "Head commit SHA")
+	reviewCmd.Flags().StringVar(&reviewOpts.headSHA, "head", "", "Head commit SHA (enables incremental re-review of later pushes)")
 	reviewCmd.Flags().StringSliceVarP(&reviewOpts.skills, "skills", "s", nil, "Skills to run")
 	reviewCmd.Flags().BoolVarP(&reviewOpts.force, "force", "f", false, "Skip cache")
 	reviewCmd.Flags().BoolVarP(&reviewOpts.postComment, "post", "o", false, "Post comment to platform")
+	reviewCmd.Flags().BoolVar(&reviewOpts.inline, "inline", false, "Post issues as inline comments on diff lines (with --post)")
+	reviewCmd.Flags().BoolVar(&reviewOpts.status, "status", false, "Report review progress as a commit status / check run")
+	reviewCmd.Flags().StringVar(&reviewOpts.format, "format", "markdown", "Output format (markdown, sarif, junit)")
 
 	// Analyze flags
 	analyzeCmd.Flags().IntVarP(&analyzeOpts.prID, "pr", "p", 0, "Pull request ID")
@@ -98,12 +194,39 @@ func initCommands() {
 	testGenCmd.Flags().StringSliceVarP(&testGenOpts.targetFiles, "files", "f", nil, "Target files")
 	testGenCmd.Flags().StringVarP(&testGenOpts.testFramework, "framework", "F", "", "Test framework")
 	testGenCmd.Flags().BoolVarP(&testGenOpts.createFiles, "write", "w", false, "Write test files")
-	testGenCmd.Flags().StringVarP(&testGenOpts.outputDir, "output", "o", "", "Output directory")
+	testGenCmd.Flags().StringVarP(&testGenOpts.outputDir, "output-dir", "o", "", "Output directory")
+	// -o/--output used to name the output directory; --output now selects
+	// the result format, so keep -o working until scripts move over
+	_ = testGenCmd.Flags().MarkShorthandDeprecated("output-dir", "use --output-dir instead")
+
+	// Progress and result flags
+	for _, c := range []*cobra.Command{reviewCmd, analyzeCmd, testGenCmd} {
+		c.Flags().BoolVar(&progressOpts.enabled, "progress", false, "Stream live AI progress to stderr")
+		c.Flags().StringVar(&progressOpts.events, "events", "", "Write progress events as JSON Lines to this file")
+		c.Flags().StringVar(&outputOpts.format, "output", "text", "Result format (text, json)")
+		c.Flags().StringVar(&outputOpts.file, "output-file", "", "Write the result to this file instead of stdout")
+	}
+
+	// Serve flags
+	serveCmd.Flags().StringVar(&serveOpts.addr, "addr", "", "Listen address (default: server.address or :8080)")
+	serveCmd.Flags().BoolVar(&serveOpts.status, "status", false, "Report review progress as a commit status / check run")
+
+	// Budget report flags
+
+	budgetReportCmd.Flags().StringVar(&budgetOpts.by, "by", "day", "Group totals by day, repo, pr or run")
+	budgetReportCmd.Flags().IntVar(&budgetOpts.days, "days", 30, "Only include the last N days (0 for all)")
 
 	// Add subcommands
 	rootCmd.AddCommand(reviewCmd)
 	rootCmd.AddCommand(analyzeCmd)
 	rootCmd.AddCommand(testGenCmd)
+	cacheCmd.AddCommand(cacheLsCmd, cachePruneCmd, cacheClearCmd)
+	rootCmd.AddCommand(cacheCmd)
+	budgetCmd.AddCommand(budgetReportCmd)
+	rootCmd.AddCommand(budgetCmd)
+	sessionCmd.AddCommand(sessionLsCmd, sessionClearCmd)
+	rootCmd.AddCommand(sessionCmd)
+	rootCmd.AddCommand(serveCmd)
 
 	// Global flags
 	rootCmd.PersistentFlags().StringVarP(&cfgFile, "config", "c", "", "Config file path")
@@ -112,6 +235,16 @@ func initCommands() {
 
 // runReview executes the review command
 func runReview(cmd *cobra.Command, args []string) error {
+	if err := validateReviewFormat(reviewOpts.format); err != nil {
+		return err
+	}
+	if err := validateOutput(); err != nil {
+		return err
+	}
+	if outputOpts.format == "json" && reviewOpts.format != "markdown" {
+		return fmt.Errorf("--format %s cannot be combined with --output json", reviewOpts.format)
+	}
+
 	ctx, cancel := signalContext()
 	defer cancel()
 
@@ -135,13 +268,45 @@ func runReview(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("failed to create runner: %w", err)
 	}
 
+	closeProgress, err := setupProgress(r)
+	if err != nil {
+		return err
+	}
+	defer closeProgress()
+	defer setupRetry(cfg, r)()
+
 	// Build review options
 	opts := runner.ReviewOptions{
-		PRID:   reviewOpts.prID,
-		Force:  reviewOpts.force,
-		Skills: reviewOpts.skills,
+		PRID:    reviewOpts.prID,
+		BaseSHA: reviewOpts.baseSHA,
+		HeadSHA: reviewOpts.headSHA,
+		Force:   reviewOpts.force,
+		Skills:  reviewOpts.skills,
 	}
 
+	// Report the review as pending on the reviewed commit
+	statusSHA := ""
+	if reviewOpts.status {
+		statusSHA = r.StatusSHA(ctx, opts)
+		r.ReportStatus(ctx, platform.CommitStatus{
+			SHA:         statusSHA,
+			State:       platform.StatusPending,
+			Description: "Review queued",
+		})
+	}
+	// Every return before the final status leaves the review failed rather
+	// than pending forever. The context may already be cancelled by then.
+	finalReported := false
+	defer func() {
+		if !finalReported {
+			r.ReportStatus(context.WithoutCancel(ctx), platform.CommitStatus{
+				SHA:         statusSHA,
+				State:       platform.StatusError,
+				Description: "Review could not be completed",
+			})
+		}
+	}()
+
 	// Get diff if not provided
 	if reviewOpts.diff == "" {
 		builder := buildcontext.NewBuilder(baseDir, cfg.Global.DiffContext, cfg.Global.Exclude)
@@ -159,33 +324,196 @@ func runReview(cmd *cobra.Command, args []string) error {
 
 	// Run review
 	if verbose {
-		fmt.Println("Running code review...")
+		fmt.Fprintln(os.Stderr, "Running code review...")
 	}
 
+	r.ReportStatus(ctx, platform.CommitStatus{
+		SHA:         statusSHA,
+		State:       platform.StatusRunning,
+		Description: "Reviewing changes",
+	})
+
 	result, err := r.Review(ctx, opts)
 	if err != nil {
-		return fmt.Errorf("review failed: %w", err)
+		return infraFailure(cmd, cfg, platformClient.Name(), fmt.Errorf("review failed: %w", err))
 	}
 
 	// Print results
-	fmt.Println(result.PlatformComment)
+	if err := writeResult(
+		func(w io.Writer) error { return printReview(w, result, opts.Diff) },
+		func() *report.JSONResult { return report.BuildReviewJSON(result, jsonOptions()) },
+	); err != nil {
+		return err
+	}
+
+	if stats, ok := r.SessionStats(); ok && verbose {
+		fmt.Fprintf(os.Stderr, "Claude sessions: %d active, %d turns (%s)\n", stats.ActiveSessions, stats.Turns, stats.BaseDir)
+	}
 
 	// Post comment if requested
 	if reviewOpts.postComment {
-		if err := platformClient.PostComment(ctx, platform.CommentOptions{
-			PRID: opts.PRID,
-			Body: result.PlatformComment,
-		}); err != nil {
-			return fmt.Errorf("failed to post comment: %w", err)
+		// GitLab merge_request_discussion implies inline discussions
+		inline := reviewOpts.inline ||
+			(platformClient.Name() == "gitlab" && cfg.Platform.GitLab.MergeRequestDiscussion)
+
+		if inline {
+			posted, err := r.PostInlineReview(ctx, opts.PRID, opts.Diff, result)
+			if err != nil {
+				if err := infraFailure(cmd, cfg, platformClient.Name(), fmt.Errorf("failed to post inline review: %w", err)); err != nil {
+					return err
+				}
+			} else {
+				fmt.Fprintf(os.Stderr, "Posted %d inline comments (%d in summary).\n", posted.Posted, len(posted.Fallback))
+			}
+		} else {
+			if err := platformClient.PostComment(ctx, platform.CommentOptions{
+				PRID: opts.PRID,
+				Body: result.PlatformComment,
+			}); err != nil {
+				if err := infraFailure(cmd, cfg, platformClient.Name(), fmt.Errorf("failed to post comment: %w", err)); err != nil {
+					return err
+				}
+			} else {
+				fmt.Fprintln(os.Stderr, "Comment posted to platform.")
+			}
+		}
+	}
+
+	// Report the outcome and enforce the quality gate
+	var gate *runner.GateResult
+	if cfg.QualityGate.Enabled() {
+		gate = runner.EvaluateGate(cfg.QualityGate, result.Issues)
+	}
+	r.ReportStatus(ctx, runner.ReviewStatus(statusSHA, result, gate))
+	finalReported = true
+
+	if gate != nil {
+		fmt.Fprint(os.Stderr, gate.Report())
+		if !gate.Passed() {
+			cmd.SilenceUsage = true
+			return &exitError{
+				code: ExitQualityGate,
+				err:  fmt.Errorf("quality gate failed: %d rules broken", len(gate.Violations)),
+			}
 		}
-		fmt.Println("\nComment posted to platform.")
 	}
 
 	return nil
 }
 
+// infraFailure decides whether an AI or platform failure fails the job.
+// Errors that errors.ShouldBlockCI reports (configuration, validation) always
+// fail; other failures only fail when the platform's fail_on_error is set,
+// so flaky AI calls don't block merges. Non-blocking failures are reported
+// as warnings and nil is returned.
+func infraFailure(cmd *cobra.Command, cfg *config.Config, platformName string, err error) error {
+	if errors.ShouldBlockCI(err) {
+		return err
+	}
+
+	cmd.SilenceUsage = true
+	if failOnError(cfg, platformName) {
+		return &exitError{code: ExitInfraFailure, err: err}
+	}
+
+	fmt.Fprintf(os.Stderr, "Warning: %v (not blocking CI; set fail_on_error to block)\n", err)
+	return nil
+}
+
+// failOnError returns the fail_on_error setting of the given platform
+func failOnError(cfg *config.Config, platformName string) bool {
+	switch platformName {
+	case "github":
+		return cfg.Platform.GitHub.FailOnError
+	case "gitlab":
+		return cfg.Platform.GitLab.FailOnError
+	case "bitbucket":
+		return cfg.Platform.Bitbucket.FailOnError
+	default:
+		return false
+	}
+}
+
+// printReview writes the review result in the format selected by --format.
+// The JUnit report lists the files changed in diff; both reports match
+// issue paths against them.
+func printReview(w io.Writer, result *runner.ReviewResult, diff string) error {
+	var files []string
+	for _, f := range buildcontext.ParseDiff(diff) {
+		files = append(files, f.Path())
+	}
+
+	switch reviewOpts.format {
+	case "sarif":
+		return report.WriteSARIF(w, result.Issues, report.SARIFOptions{
+			ToolVersion: rootCmd.Version,
+			Files:       files,
+		})
+	case "junit":
+		return report.WriteJUnit(w, result, report.JUnitOptions{Files: files})
+	default:
+		_, err := fmt.Fprintln(w, result.PlatformComment)
+		return err
+	}
+}
+
+// validateOutput checks the --output flag value
+func validateOutput() error {
+	switch outputOpts.format {
+	case "text", "json":
+		return nil
+	default:
+		return fmt.Errorf("unsupported output %q (expected text or json)", outputOpts.format)
+	}
+}
+
+// writeResult writes a command's result to --output-file, or stdout. text
+// renders the human-readable result; with --output json the document built
+// by doc is written instead.
+func writeResult(text func(io.Writer) error, doc func() *report.JSONResult) error {
+	render := text
+	if outputOpts.format == "json" {
+		render = func(w io.Writer) error { return report.WriteJSON(w, doc()) }
+	}
+
+	if outputOpts.file == "" {
+		return render(os.Stdout)
+	}
+	f, err := os.Create(outputOpts.file)
+	if err != nil {
+		return fmt.Errorf("failed to create output file: %w", err)
+	}
+	if err := render(f); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("failed to write output file: %w", err)
+	}
+	return nil
+}
+
+// jsonOptions identifies this build in JSON result documents
+func jsonOptions() report.JSONOptions {
+	return report.JSONOptions{ToolVersion: rootCmd.Version}
+}
+
+// validateReviewFormat checks the --format flag value
+func validateReviewFormat(format string) error {
+	switch format {
+	case "markdown", "sarif", "junit":
+		return nil
+	default:
+		return fmt.Errorf("unsupported output format %q (expected markdown, sarif or junit)", format)
+	}
+}
+
 // runAnalyze executes the analyze command
 func runAnalyze(cmd *cobra.Command, args []string) error {
+	if err := validateOutput(); err != nil {
+		return err
+	}
+
 	ctx, cancel := signalContext()
 	defer cancel()
 
@@ -209,6 +537,13 @@ func runAnalyze(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("failed to create runner: %w", err)
 	}
 
+	closeProgress, err := setupProgress(r)
+	if err != nil {
+		return err
+	}
+	defer closeProgress()
+	defer setupRetry(cfg, r)()
+
 	// Build analyze options
 	opts := runner.AnalyzeOptions{
 		PRID:   analyzeOpts.prID,
@@ -217,7 +552,7 @@ func runAnalyze(cmd *cobra.Command, args []string) error {
 
 	// Run analysis
 	if verbose {
-		fmt.Println("Running change analysis...")
+		fmt.Fprintln(os.Stderr, "Running change analysis...")
 	}
 
 	result, err := r.Analyze(ctx, opts)
@@ -226,14 +561,21 @@ func runAnalyze(cmd *cobra.Command, args []string) error {
 	}
 
 	// Print results
-	fmt.Printf("Analysis complete in %v\n", result.Duration)
-	fmt.Printf("Risk Score: %d/10\n", result.Risk.Score)
-
-	return nil
+	return writeResult(
+		func(w io.Writer) error {
+			_, err := fmt.Fprintf(w, "Analysis complete in %v\nRisk Score: %d/10\n", result.Duration, result.Risk.Score)
+			return err
+		},
+		func() *report.JSONResult { return report.BuildAnalysisJSON(result, jsonOptions()) },
+	)
 }
 
 // runTestGen executes the test generation command
 func runTestGen(cmd *cobra.Command, args []string) error {
+	if f := outputOpts.format; f != "text" && f != "json" {
+		return fmt.Errorf("--output now selects the result format (text or json); use --output-dir %s for the output directory", f)
+	}
+
 	ctx, cancel := signalContext()
 	defer cancel()
 
@@ -257,6 +599,13 @@ func runTestGen(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("failed to create runner: %w", err)
 	}
 
+	closeProgress, err := setupProgress(r)
+	if err != nil {
+		return err
+	}
+	defer closeProgress()
+	defer setupRetry(cfg, r)()
+
 	// Build test generation options
 	opts := runner.TestGenOptions{
 		Diff:          testGenOpts.diff,
@@ -267,7 +616,7 @@ func runTestGen(cmd *cobra.Command, args []string) error {
 
 	// Run test generation
 	if verbose {
-		fmt.Println("Generating tests...")
+		fmt.Fprintln(os.Stderr, "Generating tests...")
 	}
 
 	result, err := r.GenerateTests(ctx, opts)
@@ -276,8 +625,250 @@ func runTestGen(cmd *cobra.Command, args []string) error {
 	}
 
 	// Print results
-	fmt.Printf("Generated %d test files with %d tests\n", result.Summary.FilesCreated, result.Summary.TotalTests)
-	fmt.Printf("Estimated coverage: %s\n", result.Summary.CoverageEst)
+	return writeResult(
+		func(w io.Writer) error {
+			_, err := fmt.Fprintf(w, "Generated %d test files with %d tests\nEstimated coverage: %s\n",
+				result.Summary.FilesCreated, result.Summary.TotalTests, result.Summary.CoverageEst)
+			return err
+		},
+		func() *report.JSONResult { return report.BuildTestGenJSON(result, jsonOptions()) },
+	)
+}
+
+// setupProgress reports the runner's AI progress as requested by the
+// --progress and --events flags. The returned function closes the events file.
+func setupProgress(r *runner.DefaultRunner) (func(), error) {
+	var out, events io.Writer
+	closeEvents := func() {}
+	if progressOpts.enabled {
+		out = os.Stderr
+	}
+	if progressOpts.events != "" {
+		f, err := os.Create(progressOpts.events)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create events file: %w", err)
+		}
+		events = f
+		closeEvents = func() { f.Close() }
+	}
+
+	if out != nil || events != nil {
+		r.SetProgress(runner.NewProgressWriter(out, events).Handle)
+	}
+	return closeEvents, nil
+}
+
+// setupRetry applies the configured retry policy to the runner and its
+// platform, counting the attempts. The returned function reports the
+// number of retries in verbose mode.
+func setupRetry(cfg *config.Config, r *runner.DefaultRunner) func() {
+	metrics := observability.NewMetricsCollector(observability.MetricConfig{Enabled: true})
+	policy := retry.FromConfig(cfg)
+	policy.Metrics = metrics
+	r.SetRetryPolicy(policy)
+
+	return func() {
+		if n := metrics.RetryCount(); n > 0 && verbose {
+			fmt.Fprintf(os.Stderr, "Retried %d failed AI executions or API requests\n", n)
+		}
+		_ = metrics.Close()
+	}
+}
+
+// openCache opens the review cache configured for the working directory
+func openCache() (*runner.Cache, error) {
+	cfg, err := loadConfig()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load config: %w", err)
+	}
+
+	baseDir, err := os.Getwd()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get working directory: %w", err)
+	}
+
+	if !cfg.Global.EnableCache {
+		return nil, fmt.Errorf("cache is disabled (global.enable_cache: false)")
+	}
+
+	return runner.NewCacheFromConfig(cfg, baseDir)
+}
+
+// runCacheLs executes the cache ls command
+func runCacheLs(cmd *cobra.Command, args []string) error {
+	cache, err := openCache()
+	if err != nil {
+		return err
+	}
+
+	entries, err := cache.List()
+	if err != nil {
+		return fmt.Errorf("failed to list cache: %w", err)
+	}
+
+	for _, e := range entries {
+		status := ""
+		if e.Expired {
+			status = " (expired)"
+		}
+		fmt.Printf("%-7s %8d  %s  issues=%d  %s%s\n",
+			e.Kind, e.Size, e.ModTime.Format(time.RFC3339), e.Issues, e.Name, status)
+	}
+
+	stats, err := cache.Stats()
+	if err != nil {
+		return fmt.Errorf("failed to read cache stats: %w", err)
+	}
+
+	limit := "unbounded"
+	if stats.MaxBytes > 0 {
+		limit = fmt.Sprintf("%d bytes", stats.MaxBytes)
+	}
+	fmt.Printf("%d reviews, %d PR states, %d expired, %d bytes (limit: %s)\n",
+		stats.Entries, stats.PRStates, stats.Expired, stats.SizeBytes, limit)
+
+	return nil
+}
+
+// runCachePrune executes the cache prune command
+func runCachePrune(cmd *cobra.Command, args []string) error {
+	cache, err := openCache()
+	if err != nil {
+		return err
+	}
+
+	removed, err := cache.Prune()
+	if err != nil {
+		return fmt.Errorf("failed to prune cache: %w", err)
+	}
+
+	fmt.Printf("Removed %d cache entries\n", removed)
+	return nil
+}
+
+// runCacheClear executes the cache clear command
+func runCacheClear(cmd *cobra.Command, args []string) error {
+	cache, err := openCache()
+	if err != nil {
+		return err
+	}
+
+	if err := cache.Clear(); err != nil {
+		return fmt.Errorf("failed to clear cache: %w", err)
+	}
+
+	fmt.Println("Cache cleared")
+	return nil
+}
+
+// openSessionPool opens the Claude session pool configured for the working
+// directory
+func openSessionPool() (*claude.SessionPool, error) {
+	cfg, err := loadConfig()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load config: %w", err)
+	}
+
+	baseDir, err := os.Getwd()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get working directory: %w", err)
+	}
+
+	return claude.NewSessionPool(claude.PoolConfig{
+		BaseDir: ai.SessionDir(cfg, baseDir),
+		TTL:     cfg.Claude.GetSessionTTL(),
+	})
+}
+
+// runSessionLs executes the session ls command
+func runSessionLs(cmd *cobra.Command, args []string) error {
+	pool, err := openSessionPool()
+	if err != nil {
+		return err
+	}
+	defer pool.Close()
+
+	for _, s := range pool.List() {
+		fmt.Printf("%s  turns=%-3d %s  %s\n", s.ID, s.Turns, s.LastUsed.Format(time.RFC3339), s.Key)
+	}
+
+	stats := pool.GetStats()
+	fmt.Printf("%d sessions, %d turns (%s)\n", stats.TotalSessions, stats.Turns, stats.BaseDir)
+	return nil
+}
+
+// runSessionClear executes the session clear command
+func runSessionClear(cmd *cobra.Command, args []string) error {
+	pool, err := openSessionPool()
+	if err != nil {
+		return err
+	}
+	defer pool.Close()
+
+	prefix := ""
+	if len(args) > 0 {
+		prefix = args[0]
+	}
+
+	removed := 0
+	for _, s := range pool.List() {
+		if !strings.HasPrefix(s.Key, prefix) {
+			continue
+		}
+		if err := pool.Remove(s.ID); err != nil {
+			return fmt.Errorf("failed to remove session %s: %w", s.ID, err)
+		}
+		removed++
+	}
+
+	fmt.Printf("Removed %d sessions\n", removed)
+	return nil
+}
+
+// runBudgetReport executes the budget report command
+func runBudgetReport(cmd *cobra.Command, args []string) error {
+	cfg, err := loadConfig()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	baseDir, err := os.Getwd()
+	if err != nil {
+		return fmt.Errorf("failed to get working directory: %w", err)
+	}
+
+	var since time.Time
+	if budgetOpts.days > 0 {
+		since = time.Now().UTC().Truncate(24*time.Hour).AddDate(0, 0, 1-budgetOpts.days)
+	}
+
+	ledger := runner.NewLedgerFromConfig(cfg, baseDir)
+	entries, err := ledger.Entries(since)
+	if err != nil {
+		return err
+	}
+
+	totals, err := runner.SummarizeLedger(entries, budgetOpts.by)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("%-32s %5s %6s %10s %10s %10s\n", budgetOpts.by, "runs", "execs", "input", "output", "cost")
+	var sum runner.LedgerTotal
+	for _, t := range totals {
+		fmt.Printf("%-32s %5d %6d %10d %10d %10s\n",
+			t.Key, t.Runs, t.Executions, t.InputTokens, t.OutputTokens, fmt.Sprintf("$%.4f", t.CostUSD))
+		sum.Executions += t.Executions
+		sum.InputTokens += t.InputTokens
+		sum.OutputTokens += t.OutputTokens
+		sum.CostUSD += t.CostUSD
+	}
+	runs, _ := runner.SummarizeLedger(entries, "run")
+	fmt.Printf("Total: %d runs, %d executions, $%.4f (%s)\n", len(runs), sum.Executions, sum.CostUSD, ledger.Path())
+
+	if b := cfg.Budget; b.Enabled() {
+		fmt.Printf("Limits: per run $%.2f, per PR $%.2f, per day $%.2f (0 = unlimited)\n", b.PerRunUSD, b.PerPRUSD, b.PerDayUSD)
+	}
 
 	return nil
 }
@@ -292,17 +883,24 @@ func loadConfig() (*config.Config, error) {
 
 // createPlatform creates the appropriate platform client based on environment detection
 func createPlatform(cfg *config.Config) (platform.Platform, error) {
-	platformName := platform.DetectPlatform()
+	return newPlatform(cfg, platform.DetectPlatform(), "")
+}
 
+// newPlatform creates the client of a platform acting on repo, or on the
+// repository determined from the CI environment when repo is empty. For
+// Jenkins, repo is the job name.
+func newPlatform(cfg *config.Config, platformName, repo string) (platform.Platform, error) {
+	var err error
 	switch platformName {
 	case "github":
 		token := os.Getenv("GITHUB_TOKEN")
 		if token == "" {
 			token = cfg.Platform.GitHub.Token
 		}
-		repo, err := platform.ParseRepoFromEnv()
-		if err != nil {
-			return nil, fmt.Errorf("failed to determine repository: %w", err)
+		if repo == "" {
+			if repo, err = platform.ParseRepoFromEnv(); err != nil {
+				return nil, fmt.Errorf("failed to determine repository: %w", err)
+			}
 		}
 		client := platform.NewGitHubClient(token, repo)
 		if cfg.Platform.GitHub.APIURL != "" {
@@ -317,9 +915,10 @@ func createPlatform(cfg *config.Config) (platform.Platform, error) {
 		if token == "" {
 			token = cfg.Platform.GitLab.Token
 		}
-		repo, err := platform.ParseRepoFromGitLabEnv()
-		if err != nil {
-			return nil, fmt.Errorf("failed to determine repository: %w", err)
+		if repo == "" {
+			if repo, err = platform.ParseRepoFromGitLabEnv(); err != nil {
+				return nil, fmt.Errorf("failed to determine repository: %w", err)
+			}
 		}
 		client := platform.NewGitLabClient(token, repo)
 		if cfg.Platform.GitLab.APIURL != "" {
@@ -334,9 +933,10 @@ func createPlatform(cfg *config.Config) (platform.Platform, error) {
 		if token == "" {
 			token = cfg.Platform.Gitee.Token
 		}
-		repo, err := platform.ParseRepoFromGiteeEnv()
-		if err != nil {
-			return nil, fmt.Errorf("failed to determine repository: %w", err)
+		if repo == "" {
+			if repo, err = platform.ParseRepoFromGiteeEnv(); err != nil {
+				return nil, fmt.Errorf("failed to determine repository: %w", err)
+			}
 		}
 		client := platform.NewGiteeClient(token, repo)
 		if cfg.Platform.Gitee.APIURL != "" {
@@ -346,8 +946,51 @@ func createPlatform(cfg *config.Config) (platform.Platform, error) {
 		}
 		return client, nil
 
+	case "bitbucket":
+		token := os.Getenv("BITBUCKET_TOKEN")
+		if token == "" {
+			token = cfg.Platform.Bitbucket.Token
+		}
+		if repo == "" {
+			if repo, err = platform.ParseRepoFromBitbucketEnv(); err != nil {
+				return nil, fmt.Errorf("failed to determine repository: %w", err)
+			}
+		}
+		client := platform.NewBitbucketClient(token, repo)
+		if cfg.Platform.Bitbucket.APIURL != "" {
+			if err := client.SetBaseURL(cfg.Platform.Bitbucket.APIURL); err != nil {
+				return nil, fmt.Errorf("failed to set Bitbucket API URL: %w", err)
+			}
+		}
+		username := os.Getenv("BITBUCKET_USERNAME")
+		if username == "" {
+			username = cfg.Platform.Bitbucket.Username
+		}
+		if username != "" {
+			client.SetBasicAuth(username, token)
+		}
+		return client, nil
+
+	case "jenkins":
+		baseURL := os.Getenv("JENKINS_URL")
+		if baseURL == "" {
+			baseURL = cfg.Platform.Jenkins.URL
+		}
+		username := os.Getenv("JENKINS_USER")
+		if username == "" {
+			username = cfg.Platform.Jenkins.Username
+		}
+		token := os.Getenv("JENKINS_API_TOKEN")
+		if token == "" {
+			token = cfg.Platform.Jenkins.Token
+		}
+		if repo == "" {
+			repo = os.Getenv("JOB_NAME")
+		}
+		return platform.NewJenkinsClient(baseURL, username, token, repo)
+
 	default:
-		return nil, fmt.Errorf("unsupported platform: %s (supported: github, gitlab, gitee)", platformName)
+		return nil, fmt.Errorf("unsupported platform: %s (supported: github, gitlab, gitee, bitbucket, jenkins)", platformName)
 	}
 }
 
@@ -0,0 +1,368 @@
+// Package main provides the serve command, a webhook-driven review daemon
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/cicd-ai-toolkit/cicd-runner/pkg/ai"
+	"github.com/cicd-ai-toolkit/cicd-runner/pkg/buildcontext"
+	"github.com/cicd-ai-toolkit/cicd-runner/pkg/chatops"
+	"github.com/cicd-ai-toolkit/cicd-runner/pkg/config"
+	"github.com/cicd-ai-toolkit/cicd-runner/pkg/observability"
+	"github.com/cicd-ai-toolkit/cicd-runner/pkg/platform"
+	"github.com/cicd-ai-toolkit/cicd-runner/pkg/queue"
+	"github.com/cicd-ai-toolkit/cicd-runner/pkg/runner"
+	"github.com/cicd-ai-toolkit/cicd-runner/pkg/webhook"
+	"github.com/spf13/cobra"
+)
+
+// serveCmd runs the webhook server
+var serveCmd = &cobra.Command{
+	Use:   "serve",
+	Short: "Review pull requests from platform webhooks",
+	Long: `Run an HTTP server that receives GitHub, GitLab, Gitee, Bitbucket and
+Jenkins webhooks on /webhook/<platform> and queues a job running the
+configured operations (server.operations) when a pull request is opened,
+updated or reopened. A newer push to the same pull request supersedes its
+queued and running jobs. Jobs are stored in server.queue_dir, retried on
+failure and resumed after a restart.
+
+GET /healthz reports liveness and queue stats. With CICD_SERVER_TOKEN (or
+server.api_token) set, GET /jobs lists jobs, GET /jobs/<id> shows one and
+POST /jobs/<id>/cancel cancels it. SIGINT/SIGTERM stop accepting deliveries
+and wait for running jobs; interrupted jobs run again on the next start.
+
+With server.chatops.enabled, PR comments starting with /ai run commands
+(/ai review, /ai explain <file:line>, /ai ignore <rule>, ...) for commenters
+whose role permits them, and the result is posted as a reply.`,
+	Args: cobra.NoArgs,
+	RunE: runServe,
+}
+
+var serveOpts struct {
+	addr   string
+	status bool
+}
+
+// daemon runs the operations triggered by webhook events
+type daemon struct {
+	cfg     *config.Config
+	baseDir string
+	brain   ai.Brain            // Shared by the jobs, with its session pool
+	auth    *chatops.Authorizer // nil when ChatOps is disabled
+}
+
+// runServe executes the serve command
+func runServe(cmd *cobra.Command, args []string) error {
+	ctx, cancel := signalContext()
+	defer cancel()
+
+	cfg, err := loadConfig()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	baseDir, err := os.Getwd()
+	if err != nil {
+		return fmt.Errorf("failed to get working directory: %w", err)
+	}
+
+	addr := serveOpts.addr
+	if addr == "" {
+		addr = cfg.Server.GetAddress()
+	}
+
+	secrets := webhookSecrets(cfg)
+	if secrets == (webhook.Secrets{}) {
+		return fmt.Errorf("no webhook secret configured: set server.secrets or GITHUB_WEBHOOK_SECRET, GITLAB_WEBHOOK_TOKEN, GITEE_WEBHOOK_SECRET, BITBUCKET_WEBHOOK_SECRET or JENKINS_WEBHOOK_TOKEN")
+	}
+
+	queueDir := cfg.Server.GetQueueDir(cfg.Global.CacheDir)
+	if !filepath.IsAbs(queueDir) {
+		queueDir = filepath.Join(baseDir, queueDir)
+	}
+	policy := queue.DefaultRetryPolicy()
+	policy.MaxRetries = cfg.Server.GetJobRetries()
+	policy.BaseDelay = cfg.Server.GetJobRetryDelay()
+	if policy.MaxDelay < policy.BaseDelay {
+		policy.MaxDelay = policy.BaseDelay
+	}
+
+	// One backend serves every job, so jobs share the Claude session pool
+	// instead of each starting one that is never closed
+	brain, err := ai.NewFactory(baseDir).CreateFromConfig(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to create AI brain: %w", err)
+	}
+	defer func() {
+		if err := ai.CloseBrain(brain); err != nil {
+			log.Printf("[WARNING] failed to close AI backend: %v", err)
+		}
+	}()
+
+	d := &daemon{cfg: cfg, baseDir: baseDir, brain: brain}
+	if chatOps := cfg.Server.ChatOps; chatOps.Enabled {
+		var audit *observability.AuditLogger
+		if chatOps.AuditLog != "" {
+			audit, err = observability.NewAuditLogger(chatOps.AuditLog)
+			if err != nil {
+				return err
+			}
+			defer audit.Close()
+		}
+		d.auth, err = chatops.NewAuthorizer(chatOps.Users, chatOps.DefaultRole, commentPlatforms(secrets), audit)
+		if err != nil {
+			return fmt.Errorf("invalid chatops config: %w", err)
+		}
+	}
+
+	q, err := queue.Open(queue.Options{
+		Dir:        queueDir,
+		Workers:    cfg.Server.GetMaxConcurrent(),
+		JobTimeout: cfg.Server.GetJobTimeout(),
+		Retry:      &policy,
+		Logger:     log.Printf,
+	}, d.handle)
+	if err != nil {
+		return fmt.Errorf("failed to open job queue: %w", err)
+	}
+	if stats := q.Stats(); stats.Queued > 0 {
+		log.Printf("resuming %d queued jobs from %s", stats.Queued, queueDir)
+	}
+	q.Start()
+
+	srv := webhook.NewServer(webhook.ServerOptions{
+		Address:  addr,
+		Secrets:  secrets,
+		APIToken: envOr("CICD_SERVER_TOKEN", cfg.Server.APIToken),
+		Commands: d.auth != nil,
+		Logger:   log.Printf,
+	}, q)
+
+	errCh := make(chan error, 1)
+	go func() { errCh <- srv.ListenAndServe() }()
+
+	var serveErr error
+	serving := true
+	select {
+	case serveErr = <-errCh:
+		serving = false
+	case <-ctx.Done():
+	}
+
+	timeout := cfg.Server.GetShutdownTimeout()
+	log.Printf("shutting down, waiting up to %s for %d running jobs", timeout, q.Stats().Running)
+	shutdownCtx, cancelShutdown := context.WithTimeout(context.Background(), timeout)
+	defer cancelShutdown()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		serveErr = errors.Join(serveErr, fmt.Errorf("shutdown incomplete: %w", err))
+	}
+	if err := q.Stop(shutdownCtx); err != nil {
+		serveErr = errors.Join(serveErr, fmt.Errorf("running jobs cancelled: %w", err))
+	}
+	if serving {
+		serveErr = errors.Join(serveErr, <-errCh)
+	}
+	return serveErr
+}
+
+// webhookSecrets returns the webhook secrets from the environment, falling
+// back to server.secrets
+func webhookSecrets(cfg *config.Config) webhook.Secrets {
+	secrets := cfg.Server.Secrets
+	return webhook.Secrets{
+		GitHub:    envOr("GITHUB_WEBHOOK_SECRET", secrets.GitHub),
+		GitLab:    envOr("GITLAB_WEBHOOK_TOKEN", secrets.GitLab),
+		Gitee:     envOr("GITEE_WEBHOOK_SECRET", secrets.Gitee),
+		Bitbucket: envOr("BITBUCKET_WEBHOOK_SECRET", secrets.Bitbucket),
+		Jenkins:   envOr("JENKINS_WEBHOOK_TOKEN", secrets.Jenkins),
+	}
+}
+
+// commentPlatforms returns the platforms whose comments are accepted: those
+// with a webhook secret, except Jenkins, which has no pull request comments
+func commentPlatforms(secrets webhook.Secrets) []string {
+	var platforms []string
+	for _, p := range []struct{ name, secret string }{
+		{"github", secrets.GitHub},
+		{"gitlab", secrets.GitLab},
+		{"gitee", secrets.Gitee},
+		{"bitbucket", secrets.Bitbucket},
+	} {
+		if p.secret != "" {
+			platforms = append(platforms, p.name)
+		}
+	}
+	return platforms
+}
+
+// envOr returns the environment variable, or fallback when it is unset
+func envOr(name, fallback string) string {
+	if v := os.Getenv(name); v != "" {
+		return v
+	}
+	return fallback
+}
+
+// handle runs the configured operations on the pull request of a job, or
+// the command of a comment job. Each repository gets its own review cache
+// and ignored rules so PR numbers of different repositories do not
+// collide; the budget ledger is shared.
+func (d *daemon) handle(ctx context.Context, job queue.Job) error {
+	event := job.Event
+	client, err := newPlatform(d.cfg, event.Platform, event.Repo)
+	if err != nil {
+		return fmt.Errorf("failed to create platform: %w", err)
+	}
+
+	r, err := runner.NewRunnerWithBrain(d.cfg, client, d.baseDir, d.brain)
+	if err != nil {
+		return fmt.Errorf("failed to create runner: %w", err)
+	}
+	repoCfg := *d.cfg
+	repoCfg.Global.CacheDir = filepath.Join(d.cfg.Global.CacheDir, "repos", event.Platform, pathSafe(event.Repo))
+	cache, err := runner.NewCacheFromConfig(&repoCfg, d.baseDir)
+	if err != nil {
+		return err
+	}
+	r.SetCache(cache)
+	ignores := chatops.NewIgnoreStore(filepath.Join(d.baseDir, repoCfg.Global.CacheDir, "ignores"))
+
+	if event.Comment != nil {
+		return d.command(ctx, r, client, ignores, job)
+	}
+
+	diff, err := client.GetDiff(ctx, event.PRID)
+	if err != nil {
+		return fmt.Errorf("failed to get diff: %w", err)
+	}
+	if strings.TrimSpace(diff) == "" {
+		log.Printf("job %s: %s %s#%d has no changes to review", job.ID, event.Platform, event.Repo, event.PRID)
+		return nil
+	}
+
+	var errs []error
+	for _, op := range d.cfg.Server.GetOperations() {
+		switch op {
+		case "review":
+			err = d.review(ctx, r, client, ignores, event, diff)
+		case "analyze":
+			_, err = d.analyze(ctx, r, event, diff, nil)
+		default:
+			err = fmt.Errorf("unsupported operation")
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", op, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// review reviews a PR diff without the rules ignored in the PR, posts the
+// comment when the platform's post_comment is set and, with --status,
+// reports the outcome on the head commit
+func (d *daemon) review(ctx context.Context, r *runner.DefaultRunner, client platform.Platform, ignores *chatops.IgnoreStore, event platform.WebhookEvent, diff string) error {
+	rules, err := ignores.Rules(event.PRID)
+	if err != nil {
+		return err
+	}
+
+	statusSHA := ""
+	if serveOpts.status {
+		statusSHA = event.SHA
+	}
+	r.ReportStatus(ctx, platform.CommitStatus{
+		SHA:         statusSHA,
+		State:       platform.StatusRunning,
+		Description: "Reviewing changes",
+	})
+
+	result, err := r.Review(ctx, runner.ReviewOptions{PRID: event.PRID, Diff: diff, Ignore: rules})
+	if err != nil {
+		r.ReportStatus(ctx, platform.CommitStatus{
+			SHA:         statusSHA,
+			State:       platform.StatusError,
+			Description: "Review could not be completed",
+		})
+		return err
+	}
+	log.Printf("%s %s#%d reviewed: %d issues (%d critical, %d high), cached=%v",
+		event.Platform, event.Repo, event.PRID, result.Summary.TotalIssues, result.Summary.Critical, result.Summary.High, result.Cached)
+
+	if postComment(d.cfg, client.Name()) {
+		if client.Name() == "gitlab" && d.cfg.Platform.GitLab.MergeRequestDiscussion {
+			if _, err := r.PostInlineReview(ctx, event.PRID, diff, result); err != nil {
+				log.Printf("[WARNING] failed to post inline review on %s#%d: %v", event.Repo, event.PRID, err)
+			}
+		} else if err := client.PostComment(ctx, platform.CommentOptions{PRID: event.PRID, Body: result.PlatformComment}); err != nil {
+			log.Printf("[WARNING] failed to post comment on %s#%d: %v", event.Repo, event.PRID, err)
+		}
+	}
+
+	var gate *runner.GateResult
+	if d.cfg.QualityGate.Enabled() {
+		gate = runner.EvaluateGate(d.cfg.QualityGate, result.Issues)
+	}
+	r.ReportStatus(ctx, runner.ReviewStatus(statusSHA, result, gate))
+	return nil
+}
+
+// analyze runs change analysis on a PR diff, with the given skills or
+// the configured ones, and logs the risk score
+func (d *daemon) analyze(ctx context.Context, r *runner.DefaultRunner, event platform.WebhookEvent, diff string, skills []string) (*runner.AnalyzeResult, error) {
+	opts := runner.AnalyzeOptions{PRID: event.PRID, Diff: diff, Skills: skills}
+	for _, f := range buildcontext.ParseDiff(diff) {
+		opts.FileCount++
+		for _, h := range f.Hunks {
+			for _, line := range h.Lines {
+				switch line.Kind {
+				case buildcontext.LineAdded:
+					opts.Additions++
+				case buildcontext.LineRemoved:
+					opts.Deletions++
+				}
+			}
+		}
+	}
+
+	result, err := r.Analyze(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	log.Printf("%s %s#%d analyzed: risk score %d/10", event.Platform, event.Repo, event.PRID, result.Risk.Score)
+	return result, nil
+}
+
+// postComment returns the post_comment setting of the given platform
+func postComment(cfg *config.Config, platformName string) bool {
+	switch platformName {
+	case "github":
+		return cfg.Platform.GitHub.PostComment
+	case "gitlab":
+		return cfg.Platform.GitLab.PostComment
+	case "gitee":
+		return cfg.Platform.Gitee.PostComment
+	case "bitbucket":
+		return cfg.Platform.Bitbucket.PostComment
+	default:
+		return false
+	}
+}
+
+// pathSafe turns a repository name into a single path element
+func pathSafe(name string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-', r == '_':
+			return r
+		default:
+			return '_'
+		}
+	}, name)
+}
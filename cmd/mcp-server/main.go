@@ -3,16 +3,20 @@ package main
 
 import (
 	"context"
+	"crypto/tls"
+	"crypto/x509"
 	"fmt"
 	"log/slog"
 	"net/http"
 	"os"
 	"runtime/debug"
+	"sort"
 	"sync"
 	"time"
 
 	"github.com/cicd-ai-toolkit/cicd-runner/pkg/config"
 	"github.com/cicd-ai-toolkit/cicd-runner/pkg/mcp"
+	"github.com/cicd-ai-toolkit/cicd-runner/pkg/observability"
 	"github.com/cicd-ai-toolkit/cicd-runner/pkg/platform"
 )
 
@@ -54,31 +58,66 @@ func run() error {
 
 	// Create MCP server
 	server := mcp.NewServer(platformClient, logger)
+	server.SetSkillsDir(skillsDir())
+	if prID, err := parsePRID(platformClient.Name()); err == nil {
+		server.SetPRID(prID)
+	}
 
 	// Check transport mode (stdio is default for Claude Code)
 	transport := os.Getenv("MCP_TRANSPORT")
 	if transport == "http" {
-		// HTTP mode - useful for testing
-		return runHTTPServer(ctx, server)
+		// Streamable HTTP mode - for a server shared by several agents
+		return runHTTPServer(ctx, server, cfg.MCP)
 	}
 
 	// Stdio mode - for direct Claude Code integration
 	return server.ServeStdio(ctx, os.Stdin, os.Stdout)
 }
 
-func runHTTPServer(ctx context.Context, server *mcp.Server) error {
-	// HTTP server for testing
+func runHTTPServer(ctx context.Context, server *mcp.Server, cfg config.MCPConfig) error {
+	auth, tlsConfig, err := httpAuth(cfg)
+	if err != nil {
+		return err
+	}
+
+	var audit *observability.AuditLogger
+	if cfg.AuditLog != "" {
+		audit, err = observability.NewAuditLogger(cfg.AuditLog)
+		if err != nil {
+			return fmt.Errorf("failed to open audit log: %w", err)
+		}
+		defer audit.Close()
+	}
+	rbac := observability.NewRBAC(audit)
+	users := make([]string, 0, len(cfg.Users))
+	for id := range cfg.Users {
+		users = append(users, id)
+	}
+	sort.Strings(users)
+	for _, id := range users {
+		if err := rbac.AddUser(&observability.User{ID: id, Name: id, Roles: cfg.Users[id]}); err != nil {
+			return fmt.Errorf("invalid MCP user %s: %w", id, err)
+		}
+	}
+	server.SetAuthorization(rbac, audit)
+
+	handler := server.NewHTTPHandler(mcp.HTTPOptions{
+		Authenticate:   auth,
+		SessionTimeout: cfg.GetSessionTimeout(),
+		AllowedOrigins: cfg.AllowedOrigins,
+	})
 	mux := http.NewServeMux()
-	mux.HandleFunc("/mcp", server.ServeHTTP)
+	mux.Handle("/mcp", handler)
 
 	addr := os.Getenv("MCP_SERVER_ADDR")
 	if addr == "" {
-		addr = ":8080"
+		addr = cfg.GetAddress()
 	}
 
 	srv := &http.Server{
 		Addr:              addr,
 		Handler:           mux,
+		TLSConfig:         tlsConfig,
 		ReadHeaderTimeout: 10 * time.Second, // Prevent Slowloris attacks
 	}
 
@@ -88,12 +127,21 @@ func runHTTPServer(ctx context.Context, server *mcp.Server) error {
 	go func() {
 		defer wg.Done()
 		<-ctx.Done()
+		// End the sessions first: their event streams would keep
+		// Shutdown waiting
+		handler.Close()
 		//nolint:errcheck // Best-effort shutdown during context cancellation
 		srv.Shutdown(context.Background())
 	}()
 
-	slog.Info("MCP server listening", "address", addr)
-	if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+	slog.Info("MCP server listening", "address", addr, "tls", tlsConfig != nil)
+	if tlsConfig != nil {
+		err = srv.ListenAndServeTLS(cfg.TLSCertFile, cfg.TLSKeyFile)
+	} else {
+		err = srv.ListenAndServe()
+	}
+	if err != nil && err != http.ErrServerClosed {
+		handler.Close()
 		return fmt.Errorf("HTTP server error: %w", err)
 	}
 
@@ -101,6 +149,88 @@ func runHTTPServer(ctx context.Context, server *mcp.Server) error {
 	return nil
 }
 
+// httpAuth returns the authenticator of the HTTP transport and its TLS
+// configuration, nil for plain HTTP. Callers present a bearer token of the
+// tokens file or a client certificate of the client CA; at least one must
+// be configured.
+func httpAuth(cfg config.MCPConfig) (mcp.Authenticator, *tls.Config, error) {
+	var auths []mcp.Authenticator
+
+	tokensFile := os.Getenv("MCP_TOKENS_FILE")
+	if tokensFile == "" {
+		tokensFile = cfg.TokensFile
+	}
+	if tokensFile != "" {
+		tokens, err := mcp.LoadTokens(tokensFile)
+		if err != nil {
+			return nil, nil, err
+		}
+		auths = append(auths, mcp.TokenAuth(tokens))
+	}
+
+	if (cfg.TLSCertFile == "") != (cfg.TLSKeyFile == "") {
+		return nil, nil, fmt.Errorf("mcp.tls_cert_file and mcp.tls_key_file must be set together")
+	}
+	var tlsConfig *tls.Config
+	if cfg.TLSCertFile != "" {
+		tlsConfig = &tls.Config{MinVersion: tls.VersionTLS12}
+	}
+	if cfg.ClientCAFile != "" {
+		if tlsConfig == nil {
+			return nil, nil, fmt.Errorf("mcp.client_ca_file requires mcp.tls_cert_file and mcp.tls_key_file")
+		}
+		pem, err := os.ReadFile(cfg.ClientCAFile)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to read client CA: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, nil, fmt.Errorf("no certificates in client CA file %s", cfg.ClientCAFile)
+		}
+		tlsConfig.ClientCAs = pool
+		// Token callers connect without a certificate
+		tlsConfig.ClientAuth = tls.VerifyClientCertIfGiven
+		if len(auths) == 0 {
+			tlsConfig.ClientAuth = tls.RequireAndVerifyClientCert
+		}
+		auths = append(auths, mcp.CertAuth())
+	}
+
+	if len(auths) == 0 {
+		return nil, nil, fmt.Errorf("MCP HTTP transport requires authentication: set mcp.tokens_file (or MCP_TOKENS_FILE) or mcp.client_ca_file")
+	}
+	if tlsConfig == nil {
+		slog.Warn("MCP bearer tokens are sent unencrypted; set mcp.tls_cert_file or terminate TLS in front of the server")
+	}
+	return mcp.AnyAuth(auths...), tlsConfig, nil
+}
+
+// skillsDir returns the directory of the skills served as resources and
+// prompts: MCP_SKILLS_DIR, or skills in the working directory
+func skillsDir() string {
+	if dir := os.Getenv("MCP_SKILLS_DIR"); dir != "" {
+		return dir
+	}
+	return "skills"
+}
+
+// parsePRID returns the pull request of the CI environment, listed as
+// resources
+func parsePRID(platformName string) (int, error) {
+	switch platformName {
+	case "github":
+		return platform.ParsePRIDFromEnv()
+	case "gitlab":
+		return platform.ParsePRIDFromGitLabEnv()
+	case "gitee":
+		return platform.ParsePRIDFromGiteeEnv()
+	case "bitbucket":
+		return platform.ParsePRIDFromBitbucketEnv()
+	default:
+		return 0, fmt.Errorf("unsupported platform: %s", platformName)
+	}
+}
+
 func loadConfig() (*config.Config, error) {
 	if cfgFile := os.Getenv("CONFIG_FILE"); cfgFile != "" {
 		return config.Load(cfgFile)
@@ -163,6 +293,30 @@ func createPlatform(cfg *config.Config) (platform.Platform, error) {
 		}
 		return client, nil
 
+	case "bitbucket":
+		token := os.Getenv("BITBUCKET_TOKEN")
+		if token == "" {
+			token = cfg.Platform.Bitbucket.Token
+		}
+		repo, err := platform.ParseRepoFromBitbucketEnv()
+		if err != nil {
+			return nil, fmt.Errorf("failed to determine repository: %w", err)
+		}
+		client := platform.NewBitbucketClient(token, repo)
+		if cfg.Platform.Bitbucket.APIURL != "" {
+			if err := client.SetBaseURL(cfg.Platform.Bitbucket.APIURL); err != nil {
+				return nil, fmt.Errorf("failed to set Bitbucket API URL: %w", err)
+			}
+		}
+		username := os.Getenv("BITBUCKET_USERNAME")
+		if username == "" {
+			username = cfg.Platform.Bitbucket.Username
+		}
+		if username != "" {
+			client.SetBasicAuth(username, token)
+		}
+		return client, nil
+
 	default:
 		return nil, fmt.Errorf("unsupported platform: %s", platformName)
 	}
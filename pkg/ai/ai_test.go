@@ -121,6 +121,9 @@ func TestBrainInterfaceContract(t *testing.T) {
 func TestBackendConstants(t *testing.T) {
 	backends := []BackendType{
 		BackendClaude,
+		BackendCrush,
+		BackendAPI,
+		BackendReplay,
 	}
 
 	for _, b := range backends {
@@ -132,6 +135,9 @@ func TestBackendConstants(t *testing.T) {
 	if BackendClaude != "claude" {
 		t.Errorf("BackendClaude = %s, want claude", BackendClaude)
 	}
+	if BackendCrush != "crush" {
+		t.Errorf("BackendCrush = %s, want crush", BackendCrush)
+	}
 }
 
 // TestValidatePromptDisabled verifies validation passes when disabled
@@ -199,6 +205,9 @@ func TestValidatePromptSafePrompts(t *testing.T) {
 func TestBackendTypeIsValid(t *testing.T) {
 	validBackends := []BackendType{
 		BackendClaude,
+		BackendCrush,
+		BackendAPI,
+		BackendReplay,
 	}
 
 	for _, b := range validBackends {
@@ -0,0 +1,506 @@
+// Package ai provides a backend that calls model HTTP APIs directly
+package ai
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/cicd-ai-toolkit/cicd-runner/pkg/config"
+	cicderrors "github.com/cicd-ai-toolkit/cicd-runner/pkg/errors"
+	"github.com/cicd-ai-toolkit/cicd-runner/pkg/retry"
+)
+
+const (
+	// DefaultAPIMaxTokens is the default output token limit per request
+	DefaultAPIMaxTokens = 4096
+
+	// minAPIOutputTokens is the smallest response worth requesting when the
+	// budget caps max_tokens
+	minAPIOutputTokens = 256
+
+	// anthropicAPIVersion is the Anthropic Messages API version sent
+	anthropicAPIVersion = "2023-06-01"
+
+	// maxAPIErrorBody limits how much of an error response is read
+	maxAPIErrorBody = 4096
+)
+
+// reviewJSONInstructions asks the model for the document parseReviewOutput reads
+const reviewJSONInstructions = `Respond with a single JSON object and nothing else, in this format:
+{"result": "<one paragraph summary>", "issues": [{"severity": "critical|high|medium|low", "category": "security|performance|logic|architecture|style", "file": "<path>", "line": <line number>, "message": "<what is wrong>", "suggestion": "<how to fix it>"}]}
+Use an empty issues array when there is nothing to report.`
+
+// modelPrice is the list price of a model family in USD per million tokens
+type modelPrice struct {
+	prefix string
+	input  float64
+	output float64
+}
+
+// modelPrices are matched by model prefix; more specific prefixes come first.
+// Models not listed (e.g. local Ollama or vLLM models) are treated as free.
+var modelPrices = []modelPrice{
+	{"claude-opus-4-5", 5, 25},
+	{"claude-opus-4", 15, 75},
+	{"claude-sonnet-4", 3, 15},
+	{"claude-3-7-sonnet", 3, 15},
+	{"claude-3-5-sonnet", 3, 15},
+	{"claude-haiku-4", 1, 5},
+	{"claude-3-5-haiku", 0.8, 4},
+	{"claude-3-haiku", 0.25, 1.25},
+	{"gpt-4o-mini", 0.15, 0.6},
+	{"gpt-4o", 2.5, 10},
+	{"gpt-4.1-nano", 0.1, 0.4},
+	{"gpt-4.1-mini", 0.4, 1.6},
+	{"gpt-4.1", 2, 8},
+}
+
+// APIBackend implements the Brain interface by calling an Anthropic
+// Messages or OpenAI-compatible chat completions endpoint directly, so no
+// CLI has to be installed. Skill instructions become the system prompt.
+type APIBackend struct {
+	cfg       *config.APIConfig
+	provider  string
+	baseURL   string
+	apiKey    string
+	skillsDir string
+	client    *http.Client
+}
+
+// apiMessage is a chat message in either API
+type apiMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+// anthropicRequest is an Anthropic Messages API request
+type anthropicRequest struct {
+	Model     string       `json:"model"`
+	MaxTokens int          `json:"max_tokens"`
+	System    string       `json:"system,omitempty"`
+	Messages  []apiMessage `json:"messages"`
+}
+
+// anthropicResponse is an Anthropic Messages API response
+type anthropicResponse struct {
+	Content []struct {
+		Type string `json:"type"`
+		Text string `json:"text"`
+	} `json:"content"`
+	StopReason string `json:"stop_reason"`
+	Usage      struct {
+		InputTokens  int `json:"input_tokens"`
+		OutputTokens int `json:"output_tokens"`
+	} `json:"usage"`
+}
+
+// openAIRequest is an OpenAI-compatible chat completions request
+type openAIRequest struct {
+	Model          string       `json:"model"`
+	MaxTokens      int          `json:"max_tokens"`
+	Messages       []apiMessage `json:"messages"`
+	ResponseFormat struct {
+		Type string `json:"type"`
+	} `json:"response_format"`
+}
+
+// openAIResponse is an OpenAI-compatible chat completions response
+type openAIResponse struct {
+	Choices []struct {
+		Message struct {
+			Content string `json:"content"`
+		} `json:"message"`
+		FinishReason string `json:"finish_reason"`
+	} `json:"choices"`
+	Usage struct {
+		PromptTokens     int `json:"prompt_tokens"`
+		CompletionTokens int `json:"completion_tokens"`
+		TotalTokens      int `json:"total_tokens"`
+	} `json:"usage"`
+}
+
+// NewAPIBackend creates a new direct model API backend. The API key is
+// read from cfg.APIKeyEnv, or ANTHROPIC_API_KEY / OPENAI_API_KEY.
+func NewAPIBackend(cfg *config.APIConfig) *APIBackend {
+	if cfg == nil {
+		cfg = &config.APIConfig{
+			Provider: "anthropic",
+			Model:    "claude-sonnet-4-20250514",
+		}
+	}
+
+	b := &APIBackend{
+		cfg:       cfg,
+		provider:  strings.ToLower(cfg.Provider),
+		baseURL:   strings.TrimRight(cfg.BaseURL, "/"),
+		skillsDir: "skills",
+		client:    &http.Client{},
+	}
+	if b.provider == "" {
+		b.provider = "anthropic"
+	}
+
+	keyEnv := cfg.APIKeyEnv
+	switch b.provider {
+	case "anthropic":
+		if b.baseURL == "" {
+			b.baseURL = "https://api.anthropic.com"
+		}
+		if keyEnv == "" {
+			keyEnv = "ANTHROPIC_API_KEY"
+		}
+	default:
+		if b.baseURL == "" {
+			b.baseURL = "https://api.openai.com/v1"
+		}
+		if keyEnv == "" {
+			keyEnv = "OPENAI_API_KEY"
+		}
+	}
+	b.apiKey = os.Getenv(keyEnv)
+
+	return b
+}
+
+// SetSkillsDir sets the directory skills are loaded from
+func (b *APIBackend) SetSkillsDir(dir string) {
+	b.skillsDir = dir
+}
+
+// Execute sends the prompt to the model API
+func (b *APIBackend) Execute(ctx context.Context, prompt string, opts ExecuteOptions) (*Output, error) {
+	execOpts := b.mergeOptions(opts)
+
+	system, err := loadSkillInstructions(b.skillsDir, execOpts.Skills)
+	if err != nil {
+		return nil, err
+	}
+	if system != "" {
+		system += "\n\n"
+	}
+	system += reviewJSONInstructions
+
+	user := prompt
+	if execOpts.StdinContent != "" {
+		user += "\n\n" + execOpts.StdinContent
+	}
+
+	maxTokens, err := b.outputTokenLimit(execOpts.Model, system+user, execOpts.MaxBudgetUSD)
+	if err != nil {
+		return nil, err
+	}
+
+	if execOpts.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, execOpts.Timeout)
+		defer cancel()
+	}
+
+	start := time.Now()
+
+	var text string
+	var usage TokenUsage
+	if b.provider == "anthropic" {
+		text, usage, err = b.callAnthropic(ctx, execOpts.Model, maxTokens, system, user)
+	} else {
+		text, usage, err = b.callOpenAI(ctx, execOpts.Model, maxTokens, system, user)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s API request failed: %w", b.provider, classifyAPIError(ctx, err))
+	}
+
+	if price, ok := b.price(execOpts.Model); ok {
+		usage.CostUSD = (float64(usage.InputTokens)*price.input + float64(usage.OutputTokens)*price.output) / 1e6
+	}
+
+	output := parseReviewOutput(text)
+	output.TokensUsed = &usage
+	output.Duration = time.Since(start)
+	output.Model = execOpts.Model
+	output.Backend = BackendAPI
+
+	return output, nil
+}
+
+// ExecuteWithSkill sends the prompt with a specific skill as system prompt
+func (b *APIBackend) ExecuteWithSkill(ctx context.Context, prompt string, skill string, opts ExecuteOptions) (*Output, error) {
+	opts.Skills = append(opts.Skills, skill)
+	return b.Execute(ctx, prompt, opts)
+}
+
+// Validate checks that a model and, for hosted APIs, an API key are
+// configured. Custom base URLs (Ollama, vLLM) may not need a key.
+func (b *APIBackend) Validate(ctx context.Context) error {
+	if b.cfg.Model == "" {
+		return fmt.Errorf("api model is required")
+	}
+	if b.apiKey == "" && b.cfg.BaseURL == "" {
+		return fmt.Errorf("no API key set for %s (set %s)", b.provider, b.keyEnvName())
+	}
+	return nil
+}
+
+// Type returns the backend type
+func (b *APIBackend) Type() BackendType {
+	return BackendAPI
+}
+
+// Version returns the API flavor the backend speaks
+func (b *APIBackend) Version(ctx context.Context) (string, error) {
+	if b.provider == "anthropic" {
+		return "anthropic-messages/" + anthropicAPIVersion, nil
+	}
+	return "openai-chat-completions", nil
+}
+
+// keyEnvName returns the environment variable the API key is read from
+func (b *APIBackend) keyEnvName() string {
+	if b.cfg.APIKeyEnv != "" {
+		return b.cfg.APIKeyEnv
+	}
+	if b.provider == "anthropic" {
+		return "ANTHROPIC_API_KEY"
+	}
+	return "OPENAI_API_KEY"
+}
+
+// mergeOptions merges default config with runtime options
+func (b *APIBackend) mergeOptions(opts ExecuteOptions) ExecuteOptions {
+	merged := ExecuteOptions{
+		Model:        b.cfg.Model,
+		MaxBudgetUSD: b.cfg.MaxBudgetUSD,
+		Timeout:      opts.Timeout,
+		StdinContent: opts.StdinContent,
+		Skills:       opts.Skills,
+	}
+
+	if model := opts.modelFor(BackendAPI); model != "" {
+		merged.Model = model
+	}
+	if opts.MaxBudgetUSD > 0 {
+		merged.MaxBudgetUSD = opts.MaxBudgetUSD
+	}
+	if merged.Timeout == 0 {
+		merged.Timeout = ParseTimeout(b.cfg.Timeout, 0)
+	}
+
+	return merged
+}
+
+// price returns the configured or built-in price of a model
+func (b *APIBackend) price(model string) (modelPrice, bool) {
+	if b.cfg.InputCostPerMTok > 0 || b.cfg.OutputCostPerMTok > 0 {
+		return modelPrice{input: b.cfg.InputCostPerMTok, output: b.cfg.OutputCostPerMTok}, true
+	}
+	model = strings.ToLower(model)
+	for _, p := range modelPrices {
+		if strings.HasPrefix(model, p.prefix) {
+			return p, true
+		}
+	}
+	return modelPrice{}, false
+}
+
+// outputTokenLimit returns max_tokens for a request, lowered so that the
+// worst-case cost of the request stays within budget. Input tokens are
+// estimated at four bytes per token.
+func (b *APIBackend) outputTokenLimit(model, input string, budget float64) (int, error) {
+	limit := b.cfg.MaxTokens
+	if limit <= 0 {
+		limit = DefaultAPIMaxTokens
+	}
+
+	price, ok := b.price(model)
+	if budget <= 0 || !ok || price.output <= 0 {
+		return limit, nil
+	}
+
+	inputCost := float64((len(input)+3)/4) * price.input / 1e6
+	affordable := int((budget - inputCost) / price.output * 1e6)
+	if affordable < minAPIOutputTokens {
+		return 0, fmt.Errorf("request exceeds budget: estimated input cost $%.4f leaves too little of $%.2f for a response", inputCost, budget)
+	}
+
+	return min(limit, affordable), nil
+}
+
+// callAnthropic sends a Messages API request
+func (b *APIBackend) callAnthropic(ctx context.Context, model string, maxTokens int, system, user string) (string, TokenUsage, error) {
+	req := anthropicRequest{
+		Model:     model,
+		MaxTokens: maxTokens,
+		System:    system,
+		Messages:  []apiMessage{{Role: "user", Content: user}},
+	}
+	headers := map[string]string{
+		"x-api-key":         b.apiKey,
+		"anthropic-version": anthropicAPIVersion,
+	}
+
+	var resp anthropicResponse
+	if err := b.post(ctx, "/v1/messages", headers, req, &resp); err != nil {
+		return "", TokenUsage{}, err
+	}
+
+	var text strings.Builder
+	for _, block := range resp.Content {
+		if block.Type == "text" {
+			text.WriteString(block.Text)
+		}
+	}
+	if resp.StopReason == "max_tokens" {
+		log.Printf("[WARNING] %s response truncated at %d output tokens", model, maxTokens)
+	}
+
+	return text.String(), TokenUsage{
+		InputTokens:  resp.Usage.InputTokens,
+		OutputTokens: resp.Usage.OutputTokens,
+		TotalTokens:  resp.Usage.InputTokens + resp.Usage.OutputTokens,
+	}, nil
+}
+
+// callOpenAI sends a chat completions request in JSON mode
+func (b *APIBackend) callOpenAI(ctx context.Context, model string, maxTokens int, system, user string) (string, TokenUsage, error) {
+	req := openAIRequest{
+		Model:     model,
+		MaxTokens: maxTokens,
+		Messages: []apiMessage{
+			{Role: "system", Content: system},
+			{Role: "user", Content: user},
+		},
+	}
+	req.ResponseFormat.Type = "json_object"
+
+	headers := map[string]string{}
+	if b.apiKey != "" {
+		headers["Authorization"] = "Bearer " + b.apiKey
+	}
+
+	var resp openAIResponse
+	if err := b.post(ctx, "/chat/completions", headers, req, &resp); err != nil {
+		return "", TokenUsage{}, err
+	}
+	if len(resp.Choices) == 0 {
+		return "", TokenUsage{}, fmt.Errorf("response contains no choices")
+	}
+	if resp.Choices[0].FinishReason == "length" {
+		log.Printf("[WARNING] %s response truncated at %d output tokens", model, maxTokens)
+	}
+
+	usage := TokenUsage{
+		InputTokens:  resp.Usage.PromptTokens,
+		OutputTokens: resp.Usage.CompletionTokens,
+		TotalTokens:  resp.Usage.TotalTokens,
+	}
+	if usage.TotalTokens == 0 {
+		usage.TotalTokens = usage.InputTokens + usage.OutputTokens
+	}
+
+	return resp.Choices[0].Message.Content, usage, nil
+}
+
+// post sends a JSON request to the API and decodes the JSON response
+func (b *APIBackend) post(ctx context.Context, path string, headers map[string]string, body, out any) error {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return fmt.Errorf("failed to encode request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, b.baseURL+path, bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "cicd-ai-toolkit")
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := b.client.Do(req)
+	if err != nil {
+		return &apiRequestError{err: err}
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		raw, _ /*nolint:errcheck */ := io.ReadAll(io.LimitReader(resp.Body, maxAPIErrorBody))
+		return &apiStatusError{
+			status:     resp.StatusCode,
+			message:    apiErrorMessage(raw),
+			retryAfter: retry.ParseRetryAfter(resp.Header.Get("Retry-After"), time.Now()),
+		}
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	return nil
+}
+
+// apiStatusError is a non-2xx API response
+type apiStatusError struct {
+	status     int
+	message    string
+	retryAfter time.Duration // Delay requested via Retry-After, if any
+}
+
+func (e *apiStatusError) Error() string {
+	return fmt.Sprintf("status %d: %s", e.status, e.message)
+}
+
+// RetryAfter returns the delay the API asked for before retrying
+func (e *apiStatusError) RetryAfter() time.Duration { return e.retryAfter }
+
+// apiRequestError is a failure to reach the API
+type apiRequestError struct {
+	err error
+}
+
+func (e *apiRequestError) Error() string { return e.err.Error() }
+
+func (e *apiRequestError) Unwrap() error { return e.err }
+
+// classifyAPIError marks rate limits (429), server errors and overload
+// (5xx, 529) and unreachable endpoints as retryable. Timeouts wrap
+// ErrTimedOut.
+func classifyAPIError(ctx context.Context, err error) error {
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return fmt.Errorf("model API %w: %w", ErrTimedOut, err)
+	}
+
+	var statusErr *apiStatusError
+	if errors.As(err, &statusErr) {
+		if statusErr.status == http.StatusTooManyRequests || statusErr.status >= 500 {
+			return cicderrors.UnavailableError("model API unavailable", err)
+		}
+		return err
+	}
+
+	var reqErr *apiRequestError
+	if errors.As(err, &reqErr) && ctx.Err() == nil {
+		return cicderrors.UnavailableError("model API unreachable", err)
+	}
+	return err
+}
+
+// apiErrorMessage extracts the message from an Anthropic or OpenAI error body
+func apiErrorMessage(body []byte) string {
+	var e struct {
+		Error struct {
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	if err := json.Unmarshal(body, &e); err == nil && e.Error.Message != "" {
+		return e.Error.Message
+	}
+	return strings.TrimSpace(string(body))
+}
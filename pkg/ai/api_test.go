@@ -0,0 +1,282 @@
+package ai
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cicd-ai-toolkit/cicd-runner/pkg/config"
+	"github.com/cicd-ai-toolkit/cicd-runner/pkg/retry"
+)
+
+// reviewReply is the review document the stand-in APIs answer with
+const reviewReply = `{"result": "1 issue found", "issues": [{"severity": "critical", "category": "security", "file": "db.go", "line": 7, "message": "SQL injection", "suggestion": "Use placeholders"}]}`
+
+// writeSkill creates skillsDir/name/SKILL.md with the given instructions
+func writeSkill(t *testing.T, skillsDir, name, instructions string) {
+	t.Helper()
+	dir := filepath.Join(skillsDir, name)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	content := "---\nname: " + name + "\ndescription: test skill\n---\n\n" + instructions + "\n"
+	if err := os.WriteFile(filepath.Join(dir, "SKILL.md"), []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestAPIBackendAnthropic(t *testing.T) {
+	var got anthropicRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/messages" {
+			t.Errorf("path = %s, want /v1/messages", r.URL.Path)
+		}
+		if r.Header.Get("x-api-key") != "sk-ant-test" {
+			t.Errorf("x-api-key = %q", r.Header.Get("x-api-key"))
+		}
+		if r.Header.Get("anthropic-version") != anthropicAPIVersion {
+			t.Errorf("anthropic-version = %q", r.Header.Get("anthropic-version"))
+		}
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			t.Fatal(err)
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"content":     []map[string]string{{"type": "text", "text": reviewReply}},
+			"stop_reason": "end_turn",
+			"usage":       map[string]int{"input_tokens": 2000, "output_tokens": 500},
+		})
+	}))
+	defer server.Close()
+
+	t.Setenv("ANTHROPIC_API_KEY", "sk-ant-test")
+	skillsDir := t.TempDir()
+	writeSkill(t, skillsDir, "security-scanner", "Look for injection flaws.")
+
+	backend := NewAPIBackend(&config.APIConfig{
+		Provider: "anthropic",
+		Model:    "claude-sonnet-4-20250514",
+		BaseURL:  server.URL,
+	})
+	backend.SetSkillsDir(skillsDir)
+
+	output, err := backend.ExecuteWithSkill(context.Background(), "Review this diff", "security-scanner", ExecuteOptions{})
+	if err != nil {
+		t.Fatalf("ExecuteWithSkill() error = %v", err)
+	}
+
+	if got.Model != "claude-sonnet-4-20250514" || got.MaxTokens != DefaultAPIMaxTokens {
+		t.Errorf("model = %s, max_tokens = %d", got.Model, got.MaxTokens)
+	}
+	if !strings.HasPrefix(got.System, `<skill name="security-scanner">`) || !strings.Contains(got.System, "Look for injection flaws.") {
+		t.Errorf("system prompt does not inline the skill:\n%s", got.System)
+	}
+	if !strings.Contains(got.System, `"issues"`) {
+		t.Errorf("system prompt does not request JSON issues:\n%s", got.System)
+	}
+	if len(got.Messages) != 1 || got.Messages[0].Role != "user" || got.Messages[0].Content != "Review this diff" {
+		t.Errorf("messages = %+v", got.Messages)
+	}
+
+	if output.Backend != BackendAPI {
+		t.Errorf("Backend = %s, want api", output.Backend)
+	}
+	if len(output.Issues) != 1 || output.Issues[0].Severity != "critical" || output.Issues[0].Line != 7 {
+		t.Errorf("Issues = %+v", output.Issues)
+	}
+	if output.Result != "1 issue found" {
+		t.Errorf("Result = %v", output.Result)
+	}
+	usage := output.TokensUsed
+	if usage == nil || usage.InputTokens != 2000 || usage.OutputTokens != 500 || usage.TotalTokens != 2500 {
+		t.Fatalf("TokensUsed = %+v", usage)
+	}
+	// 2000 * $3/M + 500 * $15/M
+	if math.Abs(usage.CostUSD-0.0135) > 1e-9 {
+		t.Errorf("CostUSD = %v, want 0.0135", usage.CostUSD)
+	}
+}
+
+func TestAPIBackendOpenAICompatible(t *testing.T) {
+	var got openAIRequest
+	var auth string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/chat/completions" {
+			t.Errorf("path = %s, want /v1/chat/completions", r.URL.Path)
+		}
+		auth = r.Header.Get("Authorization")
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			t.Fatal(err)
+		}
+		// Ollama wraps JSON mode output in a code fence for some models
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"choices": []map[string]any{{
+				"message":       map[string]string{"role": "assistant", "content": "```json\n" + reviewReply + "\n```"},
+				"finish_reason": "stop",
+			}},
+			"usage": map[string]int{"prompt_tokens": 900, "completion_tokens": 100, "total_tokens": 1000},
+		})
+	}))
+	defer server.Close()
+
+	// A local endpoint needs no API key
+	t.Setenv("OPENAI_API_KEY", "")
+	backend := NewAPIBackend(&config.APIConfig{
+		Provider: "openai",
+		Model:    "llama3:70b",
+		BaseURL:  server.URL + "/v1/",
+	})
+	if err := backend.Validate(context.Background()); err != nil {
+		t.Fatalf("Validate() error = %v", err)
+	}
+
+	output, err := backend.Execute(context.Background(), "Review", ExecuteOptions{
+		StdinContent: "diff --git a/db.go b/db.go",
+		MaxBudgetUSD: 0.01,
+	})
+	if err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+
+	if auth != "" {
+		t.Errorf("Authorization = %q, want none", auth)
+	}
+	if got.ResponseFormat.Type != "json_object" {
+		t.Errorf("response_format = %q, want json_object", got.ResponseFormat.Type)
+	}
+	if len(got.Messages) != 2 || got.Messages[0].Role != "system" || got.Messages[1].Content != "Review\n\ndiff --git a/db.go b/db.go" {
+		t.Errorf("messages = %+v", got.Messages)
+	}
+	// Unpriced local models are not limited by the budget
+	if got.MaxTokens != DefaultAPIMaxTokens {
+		t.Errorf("max_tokens = %d, want %d", got.MaxTokens, DefaultAPIMaxTokens)
+	}
+
+	if len(output.Issues) != 1 || output.Issues[0].File != "db.go" {
+		t.Errorf("Issues = %+v", output.Issues)
+	}
+	if output.TokensUsed == nil || output.TokensUsed.TotalTokens != 1000 || output.TokensUsed.CostUSD != 0 {
+		t.Errorf("TokensUsed = %+v", output.TokensUsed)
+	}
+}
+
+func TestAPIBackendBudget(t *testing.T) {
+	var maxTokens int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req openAIRequest
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		maxTokens = req.MaxTokens
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"choices": []map[string]any{{"message": map[string]string{"content": reviewReply}}},
+			"usage":   map[string]int{"prompt_tokens": 100, "completion_tokens": 50},
+		})
+	}))
+	defer server.Close()
+
+	t.Setenv("OPENAI_API_KEY", "sk-test")
+	backend := NewAPIBackend(&config.APIConfig{
+		Provider:     "openai",
+		Model:        "gpt-4o",
+		BaseURL:      server.URL,
+		MaxBudgetUSD: 1.0,
+	})
+
+	// $0.005 at $10/M output tokens affords about 500 tokens
+	output, err := backend.Execute(context.Background(), "Review", ExecuteOptions{MaxBudgetUSD: 0.005})
+	if err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	if maxTokens < minAPIOutputTokens || maxTokens > 500 {
+		t.Errorf("max_tokens = %d, want capped to about 500", maxTokens)
+	}
+	// 100 * $2.5/M + 50 * $10/M
+	if math.Abs(output.TokensUsed.CostUSD-0.00075) > 1e-9 {
+		t.Errorf("CostUSD = %v, want 0.00075", output.TokensUsed.CostUSD)
+	}
+
+	// The input alone exceeds a tiny budget; nothing is sent
+	maxTokens = 0
+	_, err = backend.Execute(context.Background(), strings.Repeat("x", 40000), ExecuteOptions{MaxBudgetUSD: 0.001})
+	if err == nil || !strings.Contains(err.Error(), "exceeds budget") {
+		t.Errorf("Execute() error = %v, want budget error", err)
+	}
+	if maxTokens != 0 {
+		t.Error("request was sent despite exceeding the budget")
+	}
+}
+
+func TestAPIBackendErrors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", "7")
+		w.WriteHeader(http.StatusTooManyRequests)
+		_, _ = w.Write([]byte(`{"type": "error", "error": {"type": "rate_limit_error", "message": "Number of requests has exceeded your rate limit"}}`))
+	}))
+	defer server.Close()
+
+	t.Setenv("ANTHROPIC_API_KEY", "sk-ant-test")
+	backend := NewAPIBackend(&config.APIConfig{Model: "claude-3-5-haiku-latest", BaseURL: server.URL})
+
+	_, err := backend.Execute(context.Background(), "Review", ExecuteOptions{})
+	if err == nil || !strings.Contains(err.Error(), "status 429: Number of requests has exceeded your rate limit") {
+		t.Errorf("Execute() error = %v", err)
+	}
+	// The requested delay survives classification for the retry policy
+	if delay, ok := retry.After(err); !ok || delay != 7*time.Second {
+		t.Errorf("retry.After() = %v, %v, want 7s", delay, ok)
+	}
+
+	_, err = backend.ExecuteWithSkill(context.Background(), "Review", "missing-skill", ExecuteOptions{})
+	if err == nil || !strings.Contains(err.Error(), "missing-skill") {
+		t.Errorf("ExecuteWithSkill() error = %v, want missing skill error", err)
+	}
+}
+
+func TestAPIBackendValidate(t *testing.T) {
+	t.Setenv("ANTHROPIC_API_KEY", "")
+	t.Setenv("REVIEW_KEY", "secret")
+	ctx := context.Background()
+
+	if err := NewAPIBackend(&config.APIConfig{Model: "claude-sonnet-4-20250514"}).Validate(ctx); err == nil || !strings.Contains(err.Error(), "ANTHROPIC_API_KEY") {
+		t.Errorf("Validate() without key error = %v", err)
+	}
+	if err := NewAPIBackend(&config.APIConfig{Model: "claude-sonnet-4-20250514", APIKeyEnv: "REVIEW_KEY"}).Validate(ctx); err != nil {
+		t.Errorf("Validate() with api_key_env error = %v", err)
+	}
+	if err := NewAPIBackend(&config.APIConfig{APIKeyEnv: "REVIEW_KEY"}).Validate(ctx); err == nil {
+		t.Error("Validate() without model should fail")
+	}
+
+	version, err := NewAPIBackend(&config.APIConfig{Provider: "openai", Model: "gpt-4o"}).Version(ctx)
+	if err != nil || version != "openai-chat-completions" {
+		t.Errorf("Version() = %q, %v", version, err)
+	}
+}
+
+func TestAPIBackendPrice(t *testing.T) {
+	tests := []struct {
+		model  string
+		cfg    config.APIConfig
+		input  float64
+		output float64
+		ok     bool
+	}{
+		{model: "claude-sonnet-4-20250514", input: 3, output: 15, ok: true},
+		{model: "gpt-4o-mini-2024-07-18", input: 0.15, output: 0.6, ok: true},
+		{model: "gpt-4o", input: 2.5, output: 10, ok: true},
+		{model: "llama3:70b", ok: false},
+		{model: "llama3:70b", cfg: config.APIConfig{InputCostPerMTok: 0.5, OutputCostPerMTok: 1}, input: 0.5, output: 1, ok: true},
+	}
+	for _, tt := range tests {
+		b := NewAPIBackend(&tt.cfg)
+		price, ok := b.price(tt.model)
+		if ok != tt.ok || price.input != tt.input || price.output != tt.output {
+			t.Errorf("price(%q) = %+v, %v; want %v/%v, %v", tt.model, price, ok, tt.input, tt.output, tt.ok)
+		}
+	}
+}
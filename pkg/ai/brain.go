@@ -1,9 +1,11 @@
 // Package ai provides a pluggable abstraction layer for AI CLI backends
-// Supported backend: Claude Code CLI
+// Supported backends: Claude Code CLI, Crush CLI, direct model HTTP APIs,
+// a record/replay backend for tests, and a fallback chain over the others
 package ai
 
 import (
 	"context"
+	"io"
 	"time"
 
 	"github.com/cicd-ai-toolkit/cicd-runner/pkg/security"
@@ -15,6 +17,18 @@ type BackendType string
 const (
 	// BackendClaude uses Claude Code CLI
 	BackendClaude BackendType = "claude"
+
+	// BackendCrush uses the Crush CLI
+	BackendCrush BackendType = "crush"
+
+	// BackendAPI calls an Anthropic or OpenAI-compatible HTTP API directly
+	BackendAPI BackendType = "api"
+
+	// BackendReplay serves recorded outputs from fixture files
+	BackendReplay BackendType = "replay"
+
+	// BackendChain tries several backends in order with routing rules
+	BackendChain BackendType = "chain"
 )
 
 // Brain is the abstraction interface for AI CLI backends
@@ -39,12 +53,34 @@ type Brain interface {
 	Version(ctx context.Context) (string, error)
 }
 
+// modelFor returns the requested model if it applies to backend t
+func (o ExecuteOptions) modelFor(t BackendType) string {
+	if o.ModelBackend != "" && o.ModelBackend != t {
+		return ""
+	}
+	return o.Model
+}
+
+// CloseBrain releases what a backend holds open, such as the session pool
+// of the Claude backend. Backends holding nothing are left as they are.
+func CloseBrain(b Brain) error {
+	if c, ok := b.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
+
 // ExecuteOptions contains options for AI execution
 type ExecuteOptions struct {
 	// Model specifies which model to use
 	// For Claude: sonnet, opus, haiku
+	// For Crush: a provider model ID, optionally prefixed with provider/
 	Model string
 
+	// ModelBackend is the backend Model names a model of; other backends
+	// use their configured model. Empty when Model applies to any backend.
+	ModelBackend BackendType
+
 	// MaxTurns limits the number of reasoning iterations (Claude-specific)
 	MaxTurns int
 
@@ -66,6 +102,23 @@ type ExecuteOptions struct {
 	// Skills is a list of skill paths to load
 	Skills []string
 
+	// Operation names the runner operation (review, analyze, test-gen,
+	// explain); used by the fallback chain for routing
+	Operation string
+
+	// DiffLines is the number of changed lines in the prompt's diff, or 0
+	// if unknown; used by the fallback chain for routing
+	DiffLines int
+
+	// Progress receives progress events while the execution runs, or nil.
+	// Backends that stream (Claude) report tool calls and tokens so far.
+	Progress ProgressFunc
+
+	// Conversation identifies a multi-turn conversation, such as the review
+	// of one pull request. Backends with sessions (Claude with
+	// use_explicit_id) resume it, so the model remembers earlier runs.
+	Conversation string
+
 	// EnablePromptInjectionValidation enables prompt injection detection
 	// When true, prompts are validated before being sent to the AI backend
 	EnablePromptInjectionValidation bool
@@ -101,8 +154,13 @@ type Output struct {
 	// Model used for this execution
 	Model string
 
-	// Backend used for this execution
+	// Backend used for this execution; for a fallback chain, the backend
+	// that produced the output
 	Backend BackendType
+
+	// Fallbacks lists the backends a fallback chain tried before Backend,
+	// each as "backend: error"
+	Fallbacks []string
 }
 
 // Issue represents a code review issue or finding
@@ -117,6 +175,7 @@ type Issue struct {
 	Suggestion  string `json:"suggestion,omitempty"`
 	CodeSnippet string `json:"code_snippet,omitempty"`
 	Note        string `json:"note,omitempty"`
+	Skill       string `json:"skill,omitempty"` // Skill that reported the issue
 }
 
 // TokenUsage contains token usage statistics
@@ -152,5 +211,10 @@ func (b BackendType) String() string {
 
 // IsValid checks if the backend type is valid
 func (b BackendType) IsValid() bool {
-	return b == BackendClaude
+	switch b {
+	case BackendClaude, BackendCrush, BackendAPI, BackendReplay, BackendChain:
+		return true
+	default:
+		return false
+	}
 }
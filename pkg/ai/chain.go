@@ -0,0 +1,204 @@
+// Package ai provides a fallback and routing chain over several backends
+package ai
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+)
+
+// ChainRoute selects the backend and model for executions matching all of
+// its conditions. A route without conditions matches every execution.
+type ChainRoute struct {
+	Skills       []string    // Matches when any requested skill is listed
+	Operation    string      // Matches the runner operation (review, analyze, test-gen, explain)
+	MaxDiffLines int         // Matches diffs with at most this many changed lines
+	Backend      BackendType // Backend tried first; empty for the chain's first backend
+	Model        string      // Model for that backend; empty for its configured model
+}
+
+// ChainBackend implements the Brain interface over several backends. Each
+// execution is routed by the first matching route, then fails over to the
+// remaining backends in order after retryable failures (rate limits,
+// unavailable backends), timeouts and missing CLIs.
+type ChainBackend struct {
+	backends []Brain
+	routes   []ChainRoute
+}
+
+// chainStep is one backend attempt of an execution
+type chainStep struct {
+	backend Brain
+	model   string
+}
+
+// NewChainBackend creates a fallback chain trying backends in order
+func NewChainBackend(backends []Brain, routes []ChainRoute) *ChainBackend {
+	return &ChainBackend{backends: backends, routes: routes}
+}
+
+// Execute runs the prompt on the routed backend, failing over on
+// retryable errors, timeouts and missing CLIs
+func (c *ChainBackend) Execute(ctx context.Context, prompt string, opts ExecuteOptions) (*Output, error) {
+	steps := c.plan(opts)
+	if len(steps) == 0 {
+		return nil, fmt.Errorf("fallback chain has no backends")
+	}
+
+	var fallbacks []string
+	var errs []error
+	for i, step := range steps {
+		stepOpts := opts
+		if step.model != "" {
+			stepOpts.Model = step.model
+			stepOpts.ModelBackend = ""
+		}
+
+		output, err := step.backend.Execute(ctx, prompt, stepOpts)
+		if err == nil {
+			if output.Backend == "" {
+				output.Backend = step.backend.Type()
+			}
+			if output.Model == "" {
+				output.Model = stepOpts.modelFor(step.backend.Type())
+			}
+			output.Fallbacks = fallbacks
+			return output, nil
+		}
+
+		errs = append(errs, fmt.Errorf("%s: %w", step.backend.Type(), err))
+		fallbacks = append(fallbacks, fmt.Sprintf("%s: %v", step.backend.Type(), err))
+		if !shouldFailover(err) || ctx.Err() != nil {
+			break
+		}
+		if i+1 < len(steps) {
+			log.Printf("[WARNING] %s backend failed, falling back to %s: %v", step.backend.Type(), steps[i+1].backend.Type(), err)
+		}
+	}
+
+	if len(errs) == 1 {
+		return nil, errs[0]
+	}
+	return nil, fmt.Errorf("%d backends failed: %w", len(errs), errors.Join(errs...))
+}
+
+// ExecuteWithSkill runs the prompt with a specific skill loaded
+func (c *ChainBackend) ExecuteWithSkill(ctx context.Context, prompt string, skill string, opts ExecuteOptions) (*Output, error) {
+	opts.Skills = append(opts.Skills, skill)
+	return c.Execute(ctx, prompt, opts)
+}
+
+// Validate succeeds when at least one backend in the chain is ready
+func (c *ChainBackend) Validate(ctx context.Context) error {
+	var errs []error
+	for _, b := range c.backends {
+		err := b.Validate(ctx)
+		if err == nil {
+			return nil
+		}
+		errs = append(errs, fmt.Errorf("%s: %w", b.Type(), err))
+	}
+	if len(errs) == 0 {
+		return fmt.Errorf("fallback chain has no backends")
+	}
+	return fmt.Errorf("no backend in the fallback chain is available: %w", errors.Join(errs...))
+}
+
+// Type returns the backend type
+func (c *ChainBackend) Type() BackendType {
+	return BackendChain
+}
+
+// Version returns the versions of the backends in chain order
+func (c *ChainBackend) Version(ctx context.Context) (string, error) {
+	versions := make([]string, 0, len(c.backends))
+	for _, b := range c.backends {
+		v, err := b.Version(ctx)
+		if err != nil {
+			v = "unknown"
+		}
+		versions = append(versions, fmt.Sprintf("%s %s", b.Type(), v))
+	}
+	return strings.Join(versions, " > "), nil
+}
+
+// Close closes the backends of the chain
+func (c *ChainBackend) Close() error {
+	var errs []error
+	for _, b := range c.backends {
+		errs = append(errs, CloseBrain(b))
+	}
+	return errors.Join(errs...)
+}
+
+// plan returns the backends to try for an execution: the routed backend
+// with the routed model first, then the others in chain order
+func (c *ChainBackend) plan(opts ExecuteOptions) []chainStep {
+	first, model := -1, ""
+	if route, ok := c.route(opts); ok {
+		model = route.Model
+		first = 0
+		if route.Backend != "" {
+			first = c.indexOf(route.Backend)
+		}
+	}
+
+	steps := make([]chainStep, 0, len(c.backends))
+	if first >= 0 {
+		steps = append(steps, chainStep{backend: c.backends[first], model: model})
+	}
+	for i, b := range c.backends {
+		if i != first {
+			steps = append(steps, chainStep{backend: b})
+		}
+	}
+	return steps
+}
+
+// route returns the first route matching the execution
+func (c *ChainBackend) route(opts ExecuteOptions) (ChainRoute, bool) {
+	for _, r := range c.routes {
+		if r.matches(opts) {
+			return r, true
+		}
+	}
+	return ChainRoute{}, false
+}
+
+// indexOf returns the position of a backend type in the chain, or -1
+func (c *ChainBackend) indexOf(t BackendType) int {
+	for i, b := range c.backends {
+		if b.Type() == t {
+			return i
+		}
+	}
+	return -1
+}
+
+// matches reports whether the execution satisfies all route conditions
+func (r ChainRoute) matches(opts ExecuteOptions) bool {
+	if r.Operation != "" && !strings.EqualFold(r.Operation, opts.Operation) {
+		return false
+	}
+	if r.MaxDiffLines > 0 && (opts.DiffLines <= 0 || opts.DiffLines > r.MaxDiffLines) {
+		return false
+	}
+	if len(r.Skills) > 0 && !containsAny(r.Skills, opts.Skills) {
+		return false
+	}
+	return true
+}
+
+// containsAny reports whether any of values is in list
+func containsAny(list, values []string) bool {
+	for _, v := range values {
+		for _, l := range list {
+			if l == v {
+				return true
+			}
+		}
+	}
+	return false
+}
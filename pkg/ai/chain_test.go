@@ -0,0 +1,246 @@
+package ai
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os/exec"
+	"strings"
+	"testing"
+
+	"github.com/cicd-ai-toolkit/cicd-runner/pkg/config"
+	cicderrors "github.com/cicd-ai-toolkit/cicd-runner/pkg/errors"
+)
+
+// stubBrain is a chain member that records the models it was asked for
+type stubBrain struct {
+	backend BackendType
+	err     error
+	models  []string
+}
+
+func (b *stubBrain) Execute(ctx context.Context, prompt string, opts ExecuteOptions) (*Output, error) {
+	b.models = append(b.models, opts.Model)
+	if b.err != nil {
+		return nil, b.err
+	}
+	return &Output{Raw: string(b.backend), Model: opts.Model, Backend: b.backend}, nil
+}
+
+func (b *stubBrain) ExecuteWithSkill(ctx context.Context, prompt string, skill string, opts ExecuteOptions) (*Output, error) {
+	opts.Skills = append(opts.Skills, skill)
+	return b.Execute(ctx, prompt, opts)
+}
+
+func (b *stubBrain) Validate(ctx context.Context) error { return b.err }
+
+func (b *stubBrain) Type() BackendType { return b.backend }
+
+func (b *stubBrain) Version(ctx context.Context) (string, error) { return "1.0", nil }
+
+func TestChainFailover(t *testing.T) {
+	ctx := context.Background()
+	rateLimited := fmt.Errorf("claude execution failed: %w", cicderrors.UnavailableError("claude is rate limited or overloaded", errors.New("429")))
+
+	t.Run("retryable error fails over", func(t *testing.T) {
+		claude := &stubBrain{backend: BackendClaude, err: rateLimited}
+		api := &stubBrain{backend: BackendAPI}
+		chain := NewChainBackend([]Brain{claude, api}, nil)
+
+		output, err := chain.Execute(ctx, "prompt", ExecuteOptions{})
+		if err != nil {
+			t.Fatalf("Execute() error = %v", err)
+		}
+		if output.Backend != BackendAPI {
+			t.Errorf("Backend = %s, want api", output.Backend)
+		}
+		if len(output.Fallbacks) != 1 || !strings.HasPrefix(output.Fallbacks[0], "claude: ") {
+			t.Errorf("Fallbacks = %v", output.Fallbacks)
+		}
+	})
+
+	t.Run("non-retryable error stops", func(t *testing.T) {
+		claude := &stubBrain{backend: BackendClaude, err: errors.New("invalid skill")}
+		api := &stubBrain{backend: BackendAPI}
+		chain := NewChainBackend([]Brain{claude, api}, nil)
+
+		if _, err := chain.Execute(ctx, "prompt", ExecuteOptions{}); err == nil || !strings.Contains(err.Error(), "invalid skill") {
+			t.Errorf("Execute() error = %v", err)
+		}
+		if len(api.models) != 0 {
+			t.Error("chain failed over on a non-retryable error")
+		}
+	})
+
+	t.Run("all backends fail", func(t *testing.T) {
+		timeout := cicderrors.TimeoutError("crush execution timed out", context.DeadlineExceeded)
+		chain := NewChainBackend([]Brain{
+			&stubBrain{backend: BackendClaude, err: rateLimited},
+			&stubBrain{backend: BackendCrush, err: timeout},
+		}, nil)
+
+		_, err := chain.Execute(ctx, "prompt", ExecuteOptions{})
+		if err == nil || !strings.Contains(err.Error(), "2 backends failed") {
+			t.Fatalf("Execute() error = %v", err)
+		}
+		if !cicderrors.IsRetryable(err) {
+			t.Error("exhausted chain error should stay retryable")
+		}
+	})
+}
+
+func TestChainRouting(t *testing.T) {
+	ctx := context.Background()
+	claude := &stubBrain{backend: BackendClaude}
+	api := &stubBrain{backend: BackendAPI}
+	chain := NewChainBackend([]Brain{claude, api}, []ChainRoute{
+		{Skills: []string{"security-scanner"}, Model: "opus"},
+		{Operation: "analyze", Backend: BackendAPI, Model: "gpt-4o-mini"},
+		{MaxDiffLines: 50, Model: "haiku"},
+	})
+
+	tests := []struct {
+		name        string
+		opts        ExecuteOptions
+		wantBackend BackendType
+		wantModel   string
+	}{
+		{"security skill", ExecuteOptions{Skills: []string{"security-scanner"}, DiffLines: 10}, BackendClaude, "opus"},
+		{"analyze operation", ExecuteOptions{Operation: "analyze", DiffLines: 500}, BackendAPI, "gpt-4o-mini"},
+		{"small diff", ExecuteOptions{Operation: "review", DiffLines: 12}, BackendClaude, "haiku"},
+		{"large diff", ExecuteOptions{Operation: "review", DiffLines: 800}, BackendClaude, ""},
+		{"unknown diff size", ExecuteOptions{Operation: "review"}, BackendClaude, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			output, err := chain.Execute(ctx, "prompt", tt.opts)
+			if err != nil {
+				t.Fatalf("Execute() error = %v", err)
+			}
+			if output.Backend != tt.wantBackend || output.Model != tt.wantModel {
+				t.Errorf("used %s/%q, want %s/%q", output.Backend, output.Model, tt.wantBackend, tt.wantModel)
+			}
+		})
+	}
+}
+
+func TestChainRoutedModelNotUsedOnFallback(t *testing.T) {
+	claude := &stubBrain{backend: BackendClaude, err: cicderrors.TimeoutError("claude execution timed out", nil)}
+	api := &stubBrain{backend: BackendAPI}
+	chain := NewChainBackend([]Brain{claude, api}, []ChainRoute{{Model: "opus"}})
+
+	output, err := chain.Execute(context.Background(), "prompt", ExecuteOptions{})
+	if err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	if claude.models[0] != "opus" {
+		t.Errorf("claude model = %q, want opus", claude.models[0])
+	}
+	// The Claude model name means nothing to the API backend
+	if output.Backend != BackendAPI || api.models[0] != "" {
+		t.Errorf("fallback used %s with model %q", output.Backend, api.models[0])
+	}
+}
+
+func TestAPIErrorsAreRetryable(t *testing.T) {
+	statuses := map[int]bool{
+		http.StatusTooManyRequests:     true,
+		http.StatusServiceUnavailable:  true,
+		529:                            true,
+		http.StatusBadRequest:          false,
+		http.StatusUnauthorized:        false,
+		http.StatusInternalServerError: true,
+	}
+	for status, retryable := range statuses {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(status)
+		}))
+		t.Setenv("ANTHROPIC_API_KEY", "sk-ant-test")
+		backend := NewAPIBackend(&config.APIConfig{Model: "claude-sonnet-4-20250514", BaseURL: server.URL})
+
+		_, err := backend.Execute(context.Background(), "prompt", ExecuteOptions{})
+		if err == nil || cicderrors.IsRetryable(err) != retryable {
+			t.Errorf("status %d: IsRetryable(%v) = %v, want %v", status, err, cicderrors.IsRetryable(err), retryable)
+		}
+		server.Close()
+	}
+}
+
+func TestFactoryCreateChainSkipsUnavailable(t *testing.T) {
+	t.Setenv("ANTHROPIC_API_KEY", "sk-ant-test")
+	cfg := &config.Config{
+		AIBackend: "chain",
+		Crush:     config.CrushConfig{Model: "gpt-4o"},
+		API:       config.APIConfig{Model: "claude-sonnet-4-20250514"},
+		Chain: config.ChainConfig{
+			// No crush executable in the test environment
+			Backends: []string{"crush", "api"},
+			Routes:   []config.RouteConfig{{Operation: "analyze", Backend: "api", Model: "claude-3-5-haiku-latest"}},
+		},
+	}
+	t.Setenv("PATH", t.TempDir())
+
+	brain, err := NewFactory(t.TempDir()).CreateFromConfig(cfg)
+	if err != nil {
+		t.Fatalf("CreateFromConfig() error = %v", err)
+	}
+	chain, ok := brain.(*ChainBackend)
+	if !ok {
+		t.Fatalf("brain = %T, want *ChainBackend", brain)
+	}
+	if len(chain.backends) != 1 || chain.backends[0].Type() != BackendAPI {
+		t.Errorf("chain backends = %v, want only api", chain.backends)
+	}
+	if len(chain.routes) != 1 || chain.routes[0].Backend != BackendAPI {
+		t.Errorf("routes = %+v", chain.routes)
+	}
+
+	cfg.Chain.Backends = []string{"crush"}
+	if _, err := NewFactory(t.TempDir()).CreateFromConfig(cfg); err == nil {
+		t.Error("CreateFromConfig() should fail when no backend is available")
+	}
+}
+
+func TestExecErrorsFailOverWithoutRetry(t *testing.T) {
+	expired, cancel := context.WithTimeout(context.Background(), 0)
+	defer cancel()
+	<-expired.Done()
+
+	tests := []struct {
+		name string
+		err  error
+		want error
+	}{
+		{"missing CLI", classifyExecError(context.Background(), BackendClaude, exec.ErrNotFound, ""), ErrCLINotFound},
+		{"timeout", classifyExecError(expired, BackendClaude, errors.New("signal: killed"), ""), ErrTimedOut},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if !errors.Is(tt.err, tt.want) || cicderrors.IsRetryable(tt.err) {
+				t.Fatalf("error = %v, want non-retryable %v", tt.err, tt.want)
+			}
+
+			api := &stubBrain{backend: BackendAPI}
+			chain := NewChainBackend([]Brain{&stubBrain{backend: BackendClaude, err: tt.err}, api}, nil)
+			output, err := chain.Execute(context.Background(), "prompt", ExecuteOptions{})
+			if err != nil || output.Backend != BackendAPI {
+				t.Errorf("Execute() = %v, %v; want fallback to api", output, err)
+			}
+		})
+	}
+}
+
+func TestModelOfOtherBackendIgnored(t *testing.T) {
+	claude := NewClaudeBackend(&config.ClaudeConfig{Model: "sonnet"})
+	api := NewAPIBackend(&config.APIConfig{Provider: "openai", Model: "gpt-4o"})
+	opts := ExecuteOptions{Model: "haiku", ModelBackend: BackendClaude}
+
+	if got := claude.mergeOptions(opts).Model; got != "haiku" {
+		t.Errorf("claude model = %q, want haiku", got)
+	}
+	if got := api.mergeOptions(opts).Model; got != "gpt-4o" {
+		t.Errorf("api model = %q, want its configured gpt-4o", got)
+	}
+}
@@ -16,6 +16,7 @@ type ClaudeBackend struct {
 	cfg       *config.ClaudeConfig
 	cliPath   string
 	validator func(ctx context.Context) error
+	sessions  *claude.SessionPool // Resumed sessions per conversation; nil to disable
 }
 
 // NewClaudeBackend creates a new Claude Code CLI backend
@@ -44,13 +45,6 @@ func (b *ClaudeBackend) Execute(ctx context.Context, prompt string, opts Execute
 
 	start := time.Now()
 
-	// Create Claude session
-	session, err := claude.NewSession(ctx)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create claude session: %w", err)
-	}
-	defer func() { /*nolint:errcheck */ session.Close() }()
-
 	// Build execute options for Claude
 	claudeOpts := claude.ExecuteOptions{
 		Prompt:          prompt,
@@ -72,10 +66,18 @@ func (b *ClaudeBackend) Execute(ctx context.Context, prompt string, opts Execute
 		defer cancel()
 	}
 
-	// Execute
-	result, err := session.Execute(ctx, claudeOpts)
+	// Execute, streaming events when progress is reported
+	var handler claude.EventHandler
+	if execOpts.Progress != nil {
+		handler = newStreamProgress(execOpts.Progress)
+	}
+	result, err := b.run(ctx, claudeOpts, execOpts.Conversation, handler)
 	if err != nil {
-		return nil, fmt.Errorf("claude execution failed: %w", err)
+		detail := ""
+		if result != nil {
+			detail = result.Raw
+		}
+		return nil, fmt.Errorf("claude execution failed: %w", classifyExecError(ctx, BackendClaude, err, detail))
 	}
 
 	// Convert claude.Issue to ai.Issue
@@ -121,6 +123,53 @@ func (b *ClaudeBackend) Execute(ctx context.Context, prompt string, opts Execute
 	return output, nil
 }
 
+// run executes Claude in the conversation's pooled session when sessions
+// are enabled, otherwise in a new one-shot session. A missing CLI wraps
+// ErrCLINotFound, so it fails over without being retried.
+func (b *ClaudeBackend) run(ctx context.Context, opts claude.ExecuteOptions, conversation string, handler claude.EventHandler) (*claude.Output, error) {
+	if b.sessions != nil && conversation != "" {
+		pooled, err := b.sessions.ForKey(ctx, conversation)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get claude session: %w", classifyExecError(ctx, BackendClaude, err, ""))
+		}
+		return b.sessions.Execute(ctx, pooled, opts, handler)
+	}
+
+	session, err := claude.NewSession(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create claude session: %w", classifyExecError(ctx, BackendClaude, err, ""))
+	}
+	defer func() { /*nolint:errcheck */ session.Close() }()
+
+	if handler != nil {
+		return claude.ExecuteStream(ctx, session, opts, handler)
+	}
+	return session.Execute(ctx, opts)
+}
+
+// SetSessionPool enables multi-turn conversations: executions with a
+// Conversation resume that conversation's session from the pool
+func (b *ClaudeBackend) SetSessionPool(pool *claude.SessionPool) {
+	b.sessions = pool
+}
+
+// SessionStats returns the statistics of the session pool, or false if
+// sessions are disabled
+func (b *ClaudeBackend) SessionStats() (claude.PoolStats, bool) {
+	if b.sessions == nil {
+		return claude.PoolStats{}, false
+	}
+	return b.sessions.GetStats(), true
+}
+
+// Close closes the session pool, if any
+func (b *ClaudeBackend) Close() error {
+	if b.sessions == nil {
+		return nil
+	}
+	return b.sessions.Close()
+}
+
 // ExecuteWithSkill runs Claude Code CLI with a specific skill loaded
 func (b *ClaudeBackend) ExecuteWithSkill(ctx context.Context, prompt string, skill string, opts ExecuteOptions) (*Output, error) {
 	// Claude uses --skill flag - add it to the options
@@ -155,11 +204,14 @@ func (b *ClaudeBackend) mergeOptions(opts ExecuteOptions) ExecuteOptions {
 		MaxBudgetUSD: b.cfg.MaxBudgetUSD,
 		Timeout:      opts.Timeout,
 		Env:          opts.Env,
+		Skills:       opts.Skills,
+		Progress:     opts.Progress,
+		Conversation: opts.Conversation,
 	}
 
 	// Override with runtime options
-	if opts.Model != "" {
-		merged.Model = opts.Model
+	if model := opts.modelFor(BackendClaude); model != "" {
+		merged.Model = model
 	}
 	if opts.OutputFormat != "" {
 		merged.OutputFormat = opts.OutputFormat
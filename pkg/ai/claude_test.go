@@ -0,0 +1,83 @@
+// Package ai provides Claude backend tests
+package ai
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/cicd-ai-toolkit/cicd-runner/pkg/claude"
+	"github.com/cicd-ai-toolkit/cicd-runner/pkg/config"
+	cicderrors "github.com/cicd-ai-toolkit/cicd-runner/pkg/errors"
+)
+
+func TestClaudeBackendResumesConversation(t *testing.T) {
+	session := &streamSession{transcript: `{"type":"content_block_delta","data":{"text":"no issues"}}` + "\n"}
+	pool, err := claude.NewSessionPool(claude.PoolConfig{
+		BaseDir:    t.TempDir(),
+		NewSession: func(ctx context.Context) (claude.Session, error) { return session, nil },
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	backend := NewClaudeBackend(&config.ClaudeConfig{Model: "sonnet"})
+	backend.SetSessionPool(pool)
+	defer backend.Close()
+
+	opts := ExecuteOptions{Conversation: "acme/api#7/review/code-reviewer", Progress: func(ProgressEvent) {}}
+	if _, err := backend.Execute(context.Background(), "review", opts); err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	id := session.opts.SessionID
+	if id == "" || !session.opts.IsNewSession {
+		t.Fatalf("first execution: SessionID = %q, IsNewSession = %v", id, session.opts.IsNewSession)
+	}
+
+	output, err := backend.Execute(context.Background(), "re-check", opts)
+	if err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	if session.opts.SessionID != id || session.opts.IsNewSession {
+		t.Errorf("second execution: SessionID = %q, IsNewSession = %v, want resumed %q", session.opts.SessionID, session.opts.IsNewSession, id)
+	}
+	if output.Result != "no issues" {
+		t.Errorf("Result = %v", output.Result)
+	}
+
+	if stats, ok := backend.SessionStats(); !ok || stats.Turns != 2 {
+		t.Errorf("SessionStats() = %+v, %v", stats, ok)
+	}
+}
+
+func TestClaudeBackendMissingCLI(t *testing.T) {
+	// No claude executable on the PATH
+	t.Setenv("PATH", t.TempDir())
+
+	pool, err := claude.NewSessionPool(claude.PoolConfig{BaseDir: t.TempDir()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	pooled := NewClaudeBackend(&config.ClaudeConfig{Model: "sonnet"})
+	pooled.SetSessionPool(pool)
+	defer pooled.Close()
+
+	tests := []struct {
+		name    string
+		backend *ClaudeBackend
+		opts    ExecuteOptions
+	}{
+		{"one-shot session", NewClaudeBackend(&config.ClaudeConfig{Model: "sonnet"}), ExecuteOptions{}},
+		{"pooled session", pooled, ExecuteOptions{Conversation: "acme/api#7/review"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := tt.backend.Execute(context.Background(), "review", tt.opts)
+			if !errors.Is(err, ErrCLINotFound) || cicderrors.IsRetryable(err) {
+				t.Fatalf("Execute() error = %v, want non-retryable ErrCLINotFound", err)
+			}
+			if !shouldFailover(err) {
+				t.Errorf("missing CLI does not fail over: %v", err)
+			}
+		})
+	}
+}
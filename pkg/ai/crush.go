@@ -0,0 +1,266 @@
+// Package ai provides Crush CLI backend implementation
+package ai
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cicd-ai-toolkit/cicd-runner/pkg/config"
+)
+
+// CrushBackend implements the Brain interface using the Crush CLI.
+// Crush has no notion of skills, so SKILL.md instructions are inlined
+// into the prompt sent on stdin. It cannot limit spending either; budget
+// caps are dropped and its spend is recorded from the usage it reports.
+type CrushBackend struct {
+	cfg       *config.CrushConfig
+	cliPath   string
+	skillsDir string
+}
+
+// NewCrushBackend creates a new Crush CLI backend
+func NewCrushBackend(cfg *config.CrushConfig) *CrushBackend {
+	if cfg == nil {
+		cfg = &config.CrushConfig{
+			Provider:     "anthropic",
+			Model:        "claude-sonnet-4-20250514",
+			OutputFormat: "json",
+		}
+	}
+
+	return &CrushBackend{
+		cfg:       cfg,
+		cliPath:   "crush",
+		skillsDir: "skills",
+	}
+}
+
+// SetCLIPath sets the path of the crush executable
+func (b *CrushBackend) SetCLIPath(path string) {
+	b.cliPath = path
+}
+
+// SetSkillsDir sets the directory skills are loaded from
+func (b *CrushBackend) SetSkillsDir(dir string) {
+	b.skillsDir = dir
+}
+
+// Execute runs the Crush CLI with the given prompt
+func (b *CrushBackend) Execute(ctx context.Context, prompt string, opts ExecuteOptions) (*Output, error) {
+	execOpts := b.mergeOptions(opts)
+
+	input, err := b.buildPrompt(prompt, execOpts)
+	if err != nil {
+		return nil, err
+	}
+
+	if execOpts.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, execOpts.Timeout)
+		defer cancel()
+	}
+
+	start := time.Now()
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, b.cliPath, b.buildArgs(execOpts)...)
+	cmd.Stdin = strings.NewReader(input)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	cmd.Env = append(os.Environ(), b.buildEnv(execOpts)...)
+
+	if err := cmd.Run(); err != nil {
+		msg := strings.TrimSpace(stderr.String())
+		err = classifyExecError(ctx, BackendCrush, err, msg)
+		if msg != "" {
+			return nil, fmt.Errorf("crush execution failed: %w: %s", err, msg)
+		}
+		return nil, fmt.Errorf("crush execution failed: %w", err)
+	}
+
+	output := parseCrushOutput(stdout.String(), stderr.String())
+	output.Duration = time.Since(start)
+	output.Model = execOpts.Model
+	output.Backend = BackendCrush
+
+	return output, nil
+}
+
+// ExecuteWithSkill runs the Crush CLI with a specific skill inlined
+func (b *CrushBackend) ExecuteWithSkill(ctx context.Context, prompt string, skill string, opts ExecuteOptions) (*Output, error) {
+	opts.Skills = append(opts.Skills, skill)
+	return b.Execute(ctx, prompt, opts)
+}
+
+// Validate checks if the Crush CLI is available and a model is configured
+func (b *CrushBackend) Validate(ctx context.Context) error {
+	if b.cfg.Model == "" {
+		return fmt.Errorf("crush model is required")
+	}
+	return validateCommand(ctx, b.cliPath, "--version")
+}
+
+// Type returns the backend type
+func (b *CrushBackend) Type() BackendType {
+	return BackendCrush
+}
+
+// Version returns the Crush CLI version
+func (b *CrushBackend) Version(ctx context.Context) (string, error) {
+	return getCommandVersion(ctx, b.cliPath, "--version")
+}
+
+// mergeOptions merges default config with runtime options. Limits (turns,
+// budget) have no Crush equivalent and are dropped.
+func (b *CrushBackend) mergeOptions(opts ExecuteOptions) ExecuteOptions {
+	merged := ExecuteOptions{
+		Model:        b.cfg.Model,
+		OutputFormat: b.cfg.OutputFormat,
+		Timeout:      opts.Timeout,
+		Env:          opts.Env,
+		StdinContent: opts.StdinContent,
+		Skills:       opts.Skills,
+	}
+
+	if model := opts.modelFor(BackendCrush); model != "" {
+		merged.Model = model
+	}
+	if opts.OutputFormat != "" {
+		merged.OutputFormat = opts.OutputFormat
+	}
+	if merged.Timeout == 0 {
+		merged.Timeout = ParseTimeout(b.cfg.Timeout, 0)
+	}
+
+	return merged
+}
+
+// buildArgs builds the crush command line. The prompt is sent on stdin so
+// large diffs do not hit argument length limits.
+func (b *CrushBackend) buildArgs(opts ExecuteOptions) []string {
+	args := []string{"run", "--quiet"}
+	if model := b.qualifiedModel(opts.Model); model != "" {
+		args = append(args, "--model", model)
+	}
+	return args
+}
+
+// qualifiedModel prefixes the model with the configured provider, which is
+// how Crush selects among providers serving the same model name
+func (b *CrushBackend) qualifiedModel(model string) string {
+	if model == "" || b.cfg.Provider == "" || strings.Contains(model, "/") {
+		return model
+	}
+	return b.cfg.Provider + "/" + model
+}
+
+// buildEnv returns the environment for the crush process. A configured
+// base_url is exported in the variable the provider's client reads.
+func (b *CrushBackend) buildEnv(opts ExecuteOptions) []string {
+	var env []string
+	if b.cfg.BaseURL != "" {
+		env = append(env, crushBaseURLVar(b.cfg.Provider)+"="+b.cfg.BaseURL)
+	}
+	return append(env, opts.Env...)
+}
+
+// crushBaseURLVar returns the base URL environment variable for a provider
+func crushBaseURLVar(provider string) string {
+	switch strings.ToLower(provider) {
+	case "", "anthropic":
+		return "ANTHROPIC_BASE_URL"
+	case "openai":
+		return "OPENAI_BASE_URL"
+	case "ollama":
+		return "OLLAMA_HOST"
+	default:
+		name := strings.NewReplacer("-", "_", ".", "_").Replace(strings.ToUpper(provider))
+		return name + "_BASE_URL"
+	}
+}
+
+// buildPrompt prepends the instructions of each requested skill to the
+// prompt and appends any stdin content
+func (b *CrushBackend) buildPrompt(prompt string, opts ExecuteOptions) (string, error) {
+	instructions, err := loadSkillInstructions(b.skillsDir, opts.Skills)
+	if err != nil {
+		return "", err
+	}
+
+	var sb strings.Builder
+	if instructions != "" {
+		sb.WriteString(instructions)
+		sb.WriteString("\n\n")
+	}
+	sb.WriteString(prompt)
+	if opts.StdinContent != "" {
+		sb.WriteString("\n\n")
+		sb.WriteString(opts.StdinContent)
+	}
+
+	return sb.String(), nil
+}
+
+// parseCrushOutput maps crush output into the shared Output. Token usage
+// not reported in the review document is taken from the stats crush
+// prints on stderr.
+func parseCrushOutput(stdout, stderr string) *Output {
+	output := parseReviewOutput(stdout)
+	if output.TokensUsed == nil {
+		output.TokensUsed = parseCrushUsage(stderr)
+	}
+	return output
+}
+
+// parseCrushUsage reads the "Input tokens: 1,200" style statistics lines
+// crush prints on stderr, or returns nil if there are none
+func parseCrushUsage(stderr string) *TokenUsage {
+	usage := &TokenUsage{}
+	found := false
+	for _, line := range strings.Split(stderr, "\n") {
+		label, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		value = strings.NewReplacer(",", "", "$", "").Replace(strings.TrimSpace(value))
+
+		var err error
+		switch strings.ToLower(strings.TrimSpace(label)) {
+		case "input tokens":
+			usage.InputTokens, err = strconv.Atoi(value)
+		case "output tokens":
+			usage.OutputTokens, err = strconv.Atoi(value)
+		case "total tokens":
+			usage.TotalTokens, err = strconv.Atoi(value)
+		case "cost":
+			usage.CostUSD, err = strconv.ParseFloat(value, 64)
+		default:
+			continue
+		}
+		found = found || err == nil
+	}
+
+	if !found {
+		return nil
+	}
+	if usage.TotalTokens == 0 {
+		usage.TotalTokens = usage.InputTokens + usage.OutputTokens
+	}
+	return usage
+}
+
+// GetDefaultCrushConfig returns default Crush configuration
+func GetDefaultCrushConfig() config.CrushConfig {
+	return config.CrushConfig{
+		Provider:     "anthropic",
+		Model:        "claude-sonnet-4-20250514",
+		Timeout:      "5m",
+		OutputFormat: "json",
+	}
+}
@@ -0,0 +1,269 @@
+package ai
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cicd-ai-toolkit/cicd-runner/pkg/config"
+)
+
+// fakeCrushScript stands in for the crush CLI. It records its arguments,
+// stdin and base URL environment in $CRUSH_RECORD and prints the output
+// selected by $CRUSH_MODE.
+const fakeCrushScript = `#!/bin/sh
+if [ "$1" = "--version" ]; then
+	echo "crush version v0.7.1"
+	exit 0
+fi
+echo "$@" > "$CRUSH_RECORD.args"
+cat > "$CRUSH_RECORD.stdin"
+echo "$ANTHROPIC_BASE_URL$OLLAMA_HOST" > "$CRUSH_RECORD.env"
+case "$CRUSH_MODE" in
+json)
+	cat <<'EOF'
+{"result": "1 issue found", "issues": [{"severity": "high", "category": "security", "file": "main.go", "line": 12, "message": "SQL injection", "suggestion": "Use query parameters"}], "usage": {"input_tokens": 1200, "output_tokens": 300, "cost_usd": 0.02}}
+EOF
+	;;
+markdown)
+	echo "<thinking>Checking the query builder</thinking>"
+	echo "Found one problem."
+	echo '` + "```json" + `'
+	echo '{"issues": [{"severity": "low", "category": "style", "file": "util.go", "line": 3, "message": "Unused variable"}]}'
+	echo '` + "```" + `'
+	echo "Input tokens: 800" >&2
+	echo "Output tokens: 150" >&2
+	echo "Cost: \$0.0041" >&2
+	;;
+fail)
+	echo "provider returned 401 Unauthorized" >&2
+	exit 1
+	;;
+esac
+`
+
+// newFakeCrush returns a backend that runs the fake crush script, and the
+// prefix of the files the script records its invocation in
+func newFakeCrush(t *testing.T, cfg *config.CrushConfig) (*CrushBackend, string) {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("fake crush CLI requires a POSIX shell")
+	}
+	// Keep base URLs from the surrounding environment out of the record
+	t.Setenv("ANTHROPIC_BASE_URL", "")
+	t.Setenv("OLLAMA_HOST", "")
+
+	dir := t.TempDir()
+	cli := filepath.Join(dir, "crush")
+	if err := os.WriteFile(cli, []byte(fakeCrushScript), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	skillsDir := filepath.Join(dir, "skills")
+	skillDir := filepath.Join(skillsDir, "code-reviewer")
+	if err := os.MkdirAll(skillDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	skillMD := "---\nname: code-reviewer\ndescription: Reviews code\n---\n\nReport issues as JSON.\n"
+	if err := os.WriteFile(filepath.Join(skillDir, "SKILL.md"), []byte(skillMD), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	backend := NewCrushBackend(cfg)
+	backend.SetCLIPath(cli)
+	backend.SetSkillsDir(skillsDir)
+	return backend, filepath.Join(dir, "record")
+}
+
+func readRecord(t *testing.T, record, suffix string) string {
+	t.Helper()
+	data, err := os.ReadFile(record + suffix)
+	if err != nil {
+		t.Fatalf("fake crush did not record %s: %v", suffix, err)
+	}
+	return strings.TrimSpace(string(data))
+}
+
+func TestCrushExecuteJSON(t *testing.T) {
+	backend, record := newFakeCrush(t, &config.CrushConfig{
+		Provider: "anthropic",
+		Model:    "claude-sonnet-4-20250514",
+		BaseURL:  "http://proxy.internal:8080",
+	})
+
+	// Crush cannot cap spending; the cap is dropped rather than refused
+	opts := ExecuteOptions{
+		MaxBudgetUSD: 0.5,
+		Env:          []string{"CRUSH_MODE=json", "CRUSH_RECORD=" + record},
+	}
+	output, err := backend.ExecuteWithSkill(context.Background(), "Review this diff", "code-reviewer", opts)
+	if err != nil {
+		t.Fatalf("ExecuteWithSkill() error = %v", err)
+	}
+
+	if got := readRecord(t, record, ".args"); got != "run --quiet --model anthropic/claude-sonnet-4-20250514" {
+		t.Errorf("args = %q", got)
+	}
+	stdin := readRecord(t, record, ".stdin")
+	if !strings.HasPrefix(stdin, `<skill name="code-reviewer">`) || !strings.Contains(stdin, "Report issues as JSON.") {
+		t.Errorf("stdin does not start with the inlined skill:\n%s", stdin)
+	}
+	if strings.Contains(stdin, "description: Reviews code") {
+		t.Errorf("stdin contains skill frontmatter:\n%s", stdin)
+	}
+	if !strings.HasSuffix(stdin, "Review this diff") {
+		t.Errorf("stdin does not end with the prompt:\n%s", stdin)
+	}
+	if got := readRecord(t, record, ".env"); got != "http://proxy.internal:8080" {
+		t.Errorf("ANTHROPIC_BASE_URL = %q", got)
+	}
+
+	if output.Backend != BackendCrush {
+		t.Errorf("Backend = %s, want crush", output.Backend)
+	}
+	if output.Model != "claude-sonnet-4-20250514" {
+		t.Errorf("Model = %s", output.Model)
+	}
+	if output.Result != "1 issue found" {
+		t.Errorf("Result = %v", output.Result)
+	}
+	if len(output.Issues) != 1 {
+		t.Fatalf("len(Issues) = %d, want 1", len(output.Issues))
+	}
+	issue := output.Issues[0]
+	if issue.Severity != "high" || issue.File != "main.go" || issue.Line != 12 || issue.Suggestion != "Use query parameters" {
+		t.Errorf("Issue = %+v", issue)
+	}
+	if output.TokensUsed == nil {
+		t.Fatal("TokensUsed = nil")
+	}
+	if *output.TokensUsed != (TokenUsage{InputTokens: 1200, OutputTokens: 300, TotalTokens: 1500, CostUSD: 0.02}) {
+		t.Errorf("TokensUsed = %+v", *output.TokensUsed)
+	}
+}
+
+func TestCrushExecuteMarkdown(t *testing.T) {
+	backend, record := newFakeCrush(t, &config.CrushConfig{
+		Provider: "ollama",
+		Model:    "llama3:70b",
+		BaseURL:  "http://localhost:11434",
+	})
+
+	opts := ExecuteOptions{
+		Model:        "qwen2.5-coder:32b",
+		StdinContent: "diff --git a/util.go b/util.go",
+		Env:          []string{"CRUSH_MODE=markdown", "CRUSH_RECORD=" + record},
+	}
+	output, err := backend.Execute(context.Background(), "Review", opts)
+	if err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+
+	if got := readRecord(t, record, ".args"); got != "run --quiet --model ollama/qwen2.5-coder:32b" {
+		t.Errorf("args = %q", got)
+	}
+	if got := readRecord(t, record, ".stdin"); got != "Review\n\ndiff --git a/util.go b/util.go" {
+		t.Errorf("stdin = %q", got)
+	}
+	if got := readRecord(t, record, ".env"); got != "http://localhost:11434" {
+		t.Errorf("OLLAMA_HOST = %q", got)
+	}
+
+	if output.Thinking != "Checking the query builder" {
+		t.Errorf("Thinking = %q", output.Thinking)
+	}
+	if len(output.Issues) != 1 || output.Issues[0].Message != "Unused variable" {
+		t.Errorf("Issues = %+v", output.Issues)
+	}
+	if output.TokensUsed == nil || *output.TokensUsed != (TokenUsage{InputTokens: 800, OutputTokens: 150, TotalTokens: 950, CostUSD: 0.0041}) {
+		t.Errorf("TokensUsed = %+v", output.TokensUsed)
+	}
+}
+
+func TestParseCrushUsage(t *testing.T) {
+	tests := []struct {
+		name   string
+		stderr string
+		want   *TokenUsage
+	}{
+		{"none", "warning: config not found\n", nil},
+		{"counts", "Input tokens: 1,200\nOutput tokens: 300\n", &TokenUsage{InputTokens: 1200, OutputTokens: 300, TotalTokens: 1500}},
+		{"total and cost", "Total tokens: 2000\nCost: $0.013\n", &TokenUsage{TotalTokens: 2000, CostUSD: 0.013}},
+		// Other lines mentioning tokens are not statistics
+		{"unrelated", "error: max tokens: unknown\nprompt tokens exceeded: 5\n", nil},
+	}
+	for _, tt := range tests {
+		got := parseCrushUsage(tt.stderr)
+		if (got == nil) != (tt.want == nil) || (got != nil && *got != *tt.want) {
+			t.Errorf("%s: parseCrushUsage() = %+v, want %+v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestCrushExecuteErrors(t *testing.T) {
+	backend, record := newFakeCrush(t, &config.CrushConfig{Provider: "openai", Model: "gpt-4o"})
+
+	_, err := backend.Execute(context.Background(), "Review", ExecuteOptions{
+		Env: []string{"CRUSH_MODE=fail", "CRUSH_RECORD=" + record},
+	})
+	if err == nil || !strings.Contains(err.Error(), "401 Unauthorized") {
+		t.Errorf("Execute() error = %v, want stderr in error", err)
+	}
+
+	_, err = backend.ExecuteWithSkill(context.Background(), "Review", "no-such-skill", ExecuteOptions{
+		Env: []string{"CRUSH_MODE=json", "CRUSH_RECORD=" + record},
+	})
+	if err == nil || !strings.Contains(err.Error(), "no-such-skill") {
+		t.Errorf("ExecuteWithSkill() error = %v, want missing skill error", err)
+	}
+}
+
+func TestCrushValidateAndVersion(t *testing.T) {
+	backend, _ := newFakeCrush(t, &config.CrushConfig{Model: "gpt-4o", Timeout: "1m"})
+	ctx := context.Background()
+
+	if err := backend.Validate(ctx); err != nil {
+		t.Errorf("Validate() error = %v", err)
+	}
+	version, err := backend.Version(ctx)
+	if err != nil || version != "crush version v0.7.1" {
+		t.Errorf("Version() = %q, %v", version, err)
+	}
+	if backend.Type() != BackendCrush {
+		t.Errorf("Type() = %s, want crush", backend.Type())
+	}
+	if got := backend.mergeOptions(ExecuteOptions{}).Timeout; got != time.Minute {
+		t.Errorf("merged Timeout = %v, want 1m", got)
+	}
+
+	backend.cfg.Model = ""
+	if err := backend.Validate(ctx); err == nil {
+		t.Error("Validate() without model should fail")
+	}
+
+	backend.cfg.Model = "gpt-4o"
+	backend.SetCLIPath(filepath.Join(t.TempDir(), "missing"))
+	if err := backend.Validate(ctx); err == nil {
+		t.Error("Validate() with missing CLI should fail")
+	}
+}
+
+func TestCrushBaseURLVar(t *testing.T) {
+	tests := map[string]string{
+		"":           "ANTHROPIC_BASE_URL",
+		"anthropic":  "ANTHROPIC_BASE_URL",
+		"OpenAI":     "OPENAI_BASE_URL",
+		"ollama":     "OLLAMA_HOST",
+		"groq":       "GROQ_BASE_URL",
+		"azure-open": "AZURE_OPEN_BASE_URL",
+	}
+	for provider, want := range tests {
+		if got := crushBaseURLVar(provider); got != want {
+			t.Errorf("crushBaseURLVar(%q) = %s, want %s", provider, got, want)
+		}
+	}
+}
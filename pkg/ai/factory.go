@@ -3,8 +3,13 @@ package ai
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"log"
+	"path/filepath"
+	"strings"
 
+	"github.com/cicd-ai-toolkit/cicd-runner/pkg/claude"
 	"github.com/cicd-ai-toolkit/cicd-runner/pkg/config"
 )
 
@@ -32,6 +37,14 @@ func (f *Factory) Create(backendType BackendType, cfg *config.Config) (Brain, er
 	switch backendType {
 	case BackendClaude:
 		return f.createClaudeBackend(cfg)
+	case BackendCrush:
+		return f.createCrushBackend(cfg)
+	case BackendAPI:
+		return f.createAPIBackend(cfg)
+	case BackendReplay:
+		return f.createReplayBackend(cfg)
+	case BackendChain:
+		return f.createChainBackend(cfg)
 	default:
 		return nil, fmt.Errorf("unsupported backend type: %s", backendType)
 	}
@@ -52,7 +65,9 @@ func (f *Factory) CreateFromConfig(cfg *config.Config) (Brain, error) {
 	return f.Create(backendType, cfg)
 }
 
-// createClaudeBackend creates a Claude Code CLI backend
+// createClaudeBackend creates a Claude Code CLI backend. With
+// claude.use_explicit_id, conversations resume sessions from a pool
+// persisted in the session directory.
 func (f *Factory) createClaudeBackend(cfg *config.Config) (Brain, error) {
 	backend := NewClaudeBackend(&cfg.Claude)
 
@@ -62,11 +77,138 @@ func (f *Factory) createClaudeBackend(cfg *config.Config) (Brain, error) {
 		return nil, fmt.Errorf("claude backend validation failed: %w", err)
 	}
 
+	if cfg.Claude.UseExplicitID {
+		pool, err := claude.NewSessionPool(claude.PoolConfig{
+			BaseDir:     SessionDir(cfg, f.baseDir),
+			TTL:         cfg.Claude.GetSessionTTL(),
+			MaxSessions: claude.DefaultMaxSessions,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to create claude session pool: %w", err)
+		}
+		backend.SetSessionPool(pool)
+	}
+
+	return backend, nil
+}
+
+// SessionDir returns the directory of the Claude session pool:
+// claude.session_dir if set, otherwise "sessions" in the cache directory.
+// Relative paths are resolved against baseDir.
+func SessionDir(cfg *config.Config, baseDir string) string {
+	dir := cfg.Claude.SessionDir
+	if dir == "" {
+		dir = filepath.Join(cfg.Global.CacheDir, "sessions")
+	}
+	if !filepath.IsAbs(dir) {
+		dir = filepath.Join(baseDir, dir)
+	}
+	return dir
+}
+
+// createCrushBackend creates a Crush CLI backend
+func (f *Factory) createCrushBackend(cfg *config.Config) (Brain, error) {
+	backend := NewCrushBackend(&cfg.Crush)
+	backend.SetSkillsDir(filepath.Join(f.baseDir, "skills"))
+
+	// Validate the backend is available
+	ctx := context.Background()
+	if err := backend.Validate(ctx); err != nil {
+		return nil, fmt.Errorf("crush backend validation failed: %w", err)
+	}
+
+	return backend, nil
+}
+
+// createAPIBackend creates a direct model API backend
+func (f *Factory) createAPIBackend(cfg *config.Config) (Brain, error) {
+	backend := NewAPIBackend(&cfg.API)
+	backend.SetSkillsDir(filepath.Join(f.baseDir, "skills"))
+
+	ctx := context.Background()
+	if err := backend.Validate(ctx); err != nil {
+		return nil, fmt.Errorf("api backend validation failed: %w", err)
+	}
+
 	return backend, nil
 }
 
+// createReplayBackend creates a record/replay backend. In record and auto
+// modes the configured replay.backend is created to record from.
+func (f *Factory) createReplayBackend(cfg *config.Config) (Brain, error) {
+	mode := ReplayMode(cfg.Replay.Mode)
+	if mode == "" {
+		mode = ReplayModeReplay
+	}
+
+	dir := cfg.Replay.Dir
+	if dir == "" {
+		dir = filepath.Join("testdata", "ai-fixtures")
+	}
+	if !filepath.IsAbs(dir) {
+		dir = filepath.Join(f.baseDir, dir)
+	}
+
+	var inner Brain
+	if mode != ReplayModeReplay {
+		innerType := BackendType(strings.ToLower(cfg.Replay.Backend))
+		if innerType == BackendReplay {
+			return nil, fmt.Errorf("replay backend cannot record from itself")
+		}
+		var err error
+		if inner, err = f.Create(innerType, cfg); err != nil {
+			return nil, err
+		}
+	}
+
+	backend := NewReplayBackend(dir, mode, inner)
+	if mode == ReplayModeReplay {
+		if err := backend.Validate(context.Background()); err != nil {
+			return nil, fmt.Errorf("replay backend validation failed: %w", err)
+		}
+	}
+
+	return backend, nil
+}
+
+// createChainBackend creates a fallback chain. Backends that fail
+// validation are left out with a warning; at least one must be available.
+func (f *Factory) createChainBackend(cfg *config.Config) (Brain, error) {
+	var backends []Brain
+	var errs []error
+	for _, name := range cfg.Chain.Backends {
+		backendType := BackendType(strings.ToLower(name))
+		if backendType == BackendChain {
+			return nil, fmt.Errorf("fallback chain cannot contain itself")
+		}
+		backend, err := f.Create(backendType, cfg)
+		if err != nil {
+			log.Printf("[WARNING] %s backend unavailable, skipping it in the fallback chain: %v", backendType, err)
+			errs = append(errs, err)
+			continue
+		}
+		backends = append(backends, backend)
+	}
+	if len(backends) == 0 {
+		return nil, fmt.Errorf("no backend in the fallback chain is available: %w", errors.Join(errs...))
+	}
+
+	routes := make([]ChainRoute, len(cfg.Chain.Routes))
+	for i, r := range cfg.Chain.Routes {
+		routes[i] = ChainRoute{
+			Skills:       r.Skills,
+			Operation:    r.Operation,
+			MaxDiffLines: r.MaxDiffLines,
+			Backend:      BackendType(strings.ToLower(r.Backend)),
+			Model:        r.Model,
+		}
+	}
+
+	return NewChainBackend(backends, routes), nil
+}
+
 // DetectBackend attempts to auto-detect the best available backend
-// It checks for Claude Code CLI
+// It checks for Claude Code CLI first, then Crush
 func (f *Factory) DetectBackend() (BackendType, error) {
 	ctx := context.Background()
 
@@ -75,7 +217,12 @@ func (f *Factory) DetectBackend() (BackendType, error) {
 		return BackendClaude, nil
 	}
 
-	return "", fmt.Errorf("no supported AI backend found (need claude)")
+	// Try Crush
+	if err := validateCommand(ctx, "crush", "--version"); err == nil {
+		return BackendCrush, nil
+	}
+
+	return "", fmt.Errorf("no supported AI backend found (need claude or crush)")
 }
 
 // ListAvailableBackends returns a list of available backends
@@ -86,6 +233,9 @@ func (f *Factory) ListAvailableBackends() []BackendType {
 	if err := validateCommand(ctx, "claude", "--version"); err == nil {
 		available = append(available, BackendClaude)
 	}
+	if err := validateCommand(ctx, "crush", "--version"); err == nil {
+		available = append(available, BackendCrush)
+	}
 
 	return available
 }
@@ -97,6 +247,8 @@ func (f *Factory) IsBackendAvailable(backendType BackendType) bool {
 	switch backendType {
 	case BackendClaude:
 		return validateCommand(ctx, "claude", "--version") == nil
+	case BackendCrush:
+		return validateCommand(ctx, "crush", "--version") == nil
 	default:
 		return false
 	}
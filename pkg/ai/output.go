@@ -0,0 +1,105 @@
+// Package ai provides review output parsing shared by the backends
+package ai
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/cicd-ai-toolkit/cicd-runner/pkg/claude"
+	"github.com/cicd-ai-toolkit/cicd-runner/pkg/skill"
+)
+
+// reviewDocument is the JSON document a review prompt asks the model to emit
+type reviewDocument struct {
+	Result       any     `json:"result"`
+	Issues       []Issue `json:"issues"`
+	TotalCostUSD float64 `json:"total_cost_usd"`
+	Usage        *struct {
+		InputTokens  int     `json:"input_tokens"`
+		OutputTokens int     `json:"output_tokens"`
+		TotalTokens  int     `json:"total_tokens"`
+		CostUSD      float64 `json:"cost_usd"`
+	} `json:"usage"`
+}
+
+// loadSkillInstructions returns the SKILL.md instructions of the named
+// skills, each wrapped in a <skill> tag, for backends that have no native
+// skill support
+func loadSkillInstructions(skillsDir string, names []string) (string, error) {
+	if len(names) == 0 {
+		return "", nil
+	}
+
+	loader := skill.NewLoader(skillsDir)
+	blocks := make([]string, 0, len(names))
+	for _, name := range names {
+		s, err := loader.Load(name)
+		if err != nil {
+			return "", fmt.Errorf("failed to load skill %s: %w", name, err)
+		}
+		blocks = append(blocks, fmt.Sprintf("<skill name=%q>\n%s\n</skill>", s.Name, strings.TrimSpace(s.Content)))
+	}
+	return strings.Join(blocks, "\n\n"), nil
+}
+
+// parseReviewOutput maps model output text into the shared Output. A bare
+// JSON document is used directly; otherwise issues are taken from the
+// first fenced JSON block. Text without JSON yields no issues.
+func parseReviewOutput(text string) *Output {
+	output := &Output{
+		Raw:    text,
+		Result: text,
+	}
+	parser := claude.NewParser()
+	output.Thinking = parser.ExtractThinking(text)
+
+	doc := strings.TrimSpace(text)
+	if !strings.HasPrefix(doc, "{") {
+		block, err := parser.ExtractJSONBlock(text)
+		if err != nil {
+			return output
+		}
+		doc = block
+	}
+
+	var result reviewDocument
+	if err := json.Unmarshal([]byte(doc), &result); err != nil {
+		return output
+	}
+	//nolint:errcheck // Same document as above; cannot fail
+	_ = json.Unmarshal([]byte(doc), &output.JSON)
+	output.Issues = result.Issues
+	if result.Result != nil {
+		output.Result = result.Result
+	}
+	if result.Usage != nil {
+		output.TokensUsed = &TokenUsage{
+			InputTokens:  result.Usage.InputTokens,
+			OutputTokens: result.Usage.OutputTokens,
+			TotalTokens:  result.Usage.TotalTokens,
+			CostUSD:      result.Usage.CostUSD,
+		}
+		if output.TokensUsed.TotalTokens == 0 {
+			output.TokensUsed.TotalTokens = result.Usage.InputTokens + result.Usage.OutputTokens
+		}
+		if output.TokensUsed.CostUSD == 0 {
+			output.TokensUsed.CostUSD = result.TotalCostUSD
+		}
+	}
+
+	return output
+}
+
+// convertTokenUsage converts claude.TokenUsage to ai.TokenUsage
+func convertTokenUsage(usage *claude.TokenUsage) *TokenUsage {
+	if usage == nil {
+		return nil
+	}
+	return &TokenUsage{
+		InputTokens:  usage.InputTokens,
+		OutputTokens: usage.OutputTokens,
+		TotalTokens:  usage.TotalTokens,
+		CostUSD:      usage.CostUSD,
+	}
+}
@@ -0,0 +1,138 @@
+// Package ai provides progress reporting for running executions
+package ai
+
+import (
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/cicd-ai-toolkit/cicd-runner/pkg/claude"
+)
+
+// Progress event types
+const (
+	ProgressStart    = "start"    // An execution started
+	ProgressToolUse  = "tool_use" // The model called a tool
+	ProgressThinking = "thinking" // The model is reasoning
+	ProgressTokens   = "tokens"   // Output tokens generated so far
+	ProgressDone     = "done"     // An execution finished
+	ProgressError    = "error"    // An execution failed
+	ProgressRetry    = "retry"    // A failed execution is retried after DurationMS
+)
+
+const (
+	// progressTokenGap is the number of output tokens between tokens events
+	progressTokenGap = 500
+
+	// charsPerToken estimates output tokens from streamed text
+	charsPerToken = 4
+)
+
+// ProgressEvent reports a step of a running execution
+type ProgressEvent struct {
+	Time       time.Time `json:"time"`
+	Type       string    `json:"type"`
+	Operation  string    `json:"operation,omitempty"`
+	Skill      string    `json:"skill,omitempty"`
+	Chunk      int       `json:"chunk,omitempty"`  // 1-based diff chunk, 0 if not chunked
+	Chunks     int       `json:"chunks,omitempty"` // Number of diff chunks
+	Backend    string    `json:"backend,omitempty"`
+	Tool       string    `json:"tool,omitempty"`
+	Detail     string    `json:"detail,omitempty"` // Tool input summary or thinking excerpt
+	Tokens     int       `json:"tokens,omitempty"` // Output tokens so far, or in total when done
+	Issues     int       `json:"issues,omitempty"`
+	CostUSD    float64   `json:"cost_usd,omitempty"`
+	DurationMS int64     `json:"duration_ms,omitempty"`
+	Error      string    `json:"error,omitempty"`
+}
+
+// ProgressFunc receives progress events. Executions may run in parallel,
+// so implementations must be safe for concurrent use.
+type ProgressFunc func(ProgressEvent)
+
+// streamProgress converts Claude stream events into progress events
+type streamProgress struct {
+	emit     ProgressFunc
+	chars    int
+	reported int
+	thinking bool // Inside a thinking block that was already reported
+}
+
+// newStreamProgress creates a stream event handler reporting to emit
+func newStreamProgress(emit ProgressFunc) *streamProgress {
+	return &streamProgress{emit: emit}
+}
+
+func (h *streamProgress) OnMessage(event claude.StreamEvent) {}
+func (h *streamProgress) OnResult(event claude.StreamEvent)  {}
+func (h *streamProgress) OnError(event claude.StreamEvent)   {}
+
+// OnContentDelta reports the output tokens so far every progressTokenGap tokens
+func (h *streamProgress) OnContentDelta(event claude.StreamEvent) {
+	var delta struct {
+		Text string `json:"text"`
+	}
+	if json.Unmarshal(event.Data, &delta) != nil {
+		return
+	}
+	h.thinking = false
+	h.chars += len(delta.Text)
+	tokens := h.chars / charsPerToken
+	if tokens-h.reported >= progressTokenGap {
+		h.reported = tokens
+		h.send(ProgressEvent{Type: ProgressTokens, Tokens: tokens})
+	}
+}
+
+// OnToolUse reports the tool and a summary of its input
+func (h *streamProgress) OnToolUse(event claude.StreamEvent) {
+	var use struct {
+		Name  string         `json:"name"`
+		Input map[string]any `json:"input"`
+	}
+	_ = json.Unmarshal(event.Data, &use)
+	h.thinking = false
+	h.send(ProgressEvent{Type: ProgressToolUse, Tool: use.Name, Detail: toolInputSummary(use.Input)})
+}
+
+// OnThinking reports the start of a thinking block
+func (h *streamProgress) OnThinking(event claude.StreamEvent) {
+	if h.thinking {
+		return
+	}
+	h.thinking = true
+	var thinking struct {
+		Text string `json:"text"`
+	}
+	_ = json.Unmarshal(event.Data, &thinking)
+	h.send(ProgressEvent{Type: ProgressThinking, Detail: truncateDetail(thinking.Text)})
+}
+
+// send stamps and emits an event
+func (h *streamProgress) send(event ProgressEvent) {
+	event.Time = time.Now()
+	event.Backend = string(BackendClaude)
+	h.emit(event)
+}
+
+// toolInputSummary picks the argument that best describes a tool call
+func toolInputSummary(input map[string]any) string {
+	for _, key := range []string{"file_path", "path", "pattern", "command", "url", "query"} {
+		if v, ok := input[key].(string); ok && v != "" {
+			return truncateDetail(v)
+		}
+	}
+	return ""
+}
+
+// truncateDetail shortens text to one line of at most 80 characters
+func truncateDetail(text string) string {
+	text = strings.TrimSpace(text)
+	if i := strings.IndexByte(text, '\n'); i >= 0 {
+		text = text[:i]
+	}
+	if r := []rune(text); len(r) > 80 {
+		text = string(r[:77]) + "..."
+	}
+	return text
+}
@@ -0,0 +1,165 @@
+// Package ai provides progress reporting tests
+package ai
+
+import (
+	"context"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/cicd-ai-toolkit/cicd-runner/pkg/claude"
+)
+
+// streamSession writes a canned stream-json transcript to stdout
+type streamSession struct {
+	transcript string
+	opts       claude.ExecuteOptions
+}
+
+func (s *streamSession) Execute(ctx context.Context, opts claude.ExecuteOptions) (*claude.Output, error) {
+	return nil, io.ErrUnexpectedEOF
+}
+
+func (s *streamSession) ExecuteWithStreams(ctx context.Context, opts claude.ExecuteOptions, stdin io.Reader, stdout, stderr io.Writer) error {
+	s.opts = opts
+	_, err := io.WriteString(stdout, s.transcript)
+	return err
+}
+
+func (s *streamSession) Close() error { return nil }
+
+func TestExecuteStreamReportsProgress(t *testing.T) {
+	long := strings.Repeat("x", progressTokenGap*charsPerToken)
+	session := &streamSession{transcript: strings.Join([]string{
+		`{"type":"thinking","data":{"text":"Looking at the handler\nchanges"}}`,
+		`{"type":"thinking","data":{"text":" more"}}`,
+		`{"type":"tool_use","data":{"name":"Read","input":{"file_path":"pkg/api/handler.go"}}}`,
+		`{"type":"content_block_delta","data":{"text":"` + long + `"}}`,
+		`{"type":"content_block_delta","data":{"text":"done"}}`,
+		`{"type":"result","data":{"usage":{"input_tokens":1200,"output_tokens":510},"total_cost_usd":0.02}}`,
+	}, "\n") + "\n"}
+
+	var mu sync.Mutex
+	var events []ProgressEvent
+	output, err := claude.ExecuteStream(context.Background(), session, claude.ExecuteOptions{Prompt: "review"},
+		newStreamProgress(func(e ProgressEvent) {
+			mu.Lock()
+			events = append(events, e)
+			mu.Unlock()
+		}))
+	if err != nil {
+		t.Fatalf("ExecuteStream() error = %v", err)
+	}
+
+	if session.opts.OutputFormat != "stream-json" {
+		t.Errorf("OutputFormat = %q, want stream-json", session.opts.OutputFormat)
+	}
+	if output.Result != long+"done" {
+		t.Errorf("Result has %d characters, want %d", len(output.Result.(string)), len(long)+4)
+	}
+	if output.TokensUsed == nil || output.TokensUsed.OutputTokens != 510 || output.TokensUsed.CostUSD != 0.02 {
+		t.Errorf("TokensUsed = %+v", output.TokensUsed)
+	}
+
+	want := []ProgressEvent{
+		{Type: ProgressThinking, Detail: "Looking at the handler"},
+		{Type: ProgressToolUse, Tool: "Read", Detail: "pkg/api/handler.go"},
+		{Type: ProgressTokens, Tokens: progressTokenGap},
+	}
+	if len(events) != len(want) {
+		t.Fatalf("events = %+v", events)
+	}
+	for i, e := range events {
+		if e.Type != want[i].Type || e.Tool != want[i].Tool || e.Detail != want[i].Detail || e.Tokens != want[i].Tokens {
+			t.Errorf("event %d = %+v, want %+v", i, e, want[i])
+		}
+		if e.Backend != string(BackendClaude) || e.Time.IsZero() {
+			t.Errorf("event %d not stamped: %+v", i, e)
+		}
+	}
+}
+
+// cliTranscript is stream-json output in the shape of
+// claude -p --output-format stream-json --verbose
+var cliTranscript = strings.Join([]string{
+	`{"type":"system","subtype":"init","cwd":"/repo","session_id":"4f1c","tools":["Read","Grep"],"model":"claude-sonnet-4-5","permissionMode":"default"}`,
+	`{"type":"assistant","message":{"id":"msg_01","type":"message","role":"assistant","model":"claude-sonnet-4-5","content":[{"type":"thinking","thinking":"Check the handler first","signature":"x"}],"stop_reason":null,"usage":{"input_tokens":3,"output_tokens":8}},"parent_tool_use_id":null,"session_id":"4f1c"}`,
+	`{"type":"assistant","message":{"id":"msg_01","type":"message","role":"assistant","model":"claude-sonnet-4-5","content":[{"type":"tool_use","id":"toolu_01","name":"Read","input":{"file_path":"/repo/pkg/api/handler.go"}}],"stop_reason":null,"usage":{"input_tokens":3,"output_tokens":40}},"parent_tool_use_id":null,"session_id":"4f1c"}`,
+	`{"type":"user","message":{"role":"user","content":[{"tool_use_id":"toolu_01","type":"tool_result","content":"package api"}]},"parent_tool_use_id":null,"session_id":"4f1c"}`,
+	`{"type":"assistant","message":{"id":"msg_02","type":"message","role":"assistant","model":"claude-sonnet-4-5","content":[{"type":"text","text":"{\"issues\": [{\"severity\": \"high\"}]}"}],"stop_reason":"end_turn","usage":{"input_tokens":5,"output_tokens":20}},"parent_tool_use_id":null,"session_id":"4f1c"}`,
+	`{"type":"result","subtype":"success","is_error":false,"duration_ms":8120,"duration_api_ms":7900,"num_turns":2,"result":"{\"issues\": [{\"severity\": \"high\"}]}","session_id":"4f1c","total_cost_usd":0.0311,"usage":{"input_tokens":8,"cache_creation_input_tokens":1200,"cache_read_input_tokens":800,"output_tokens":68,"service_tier":"standard"}}`,
+}, "\n") + "\n"
+
+func TestExecuteStreamCLITranscript(t *testing.T) {
+	var events []ProgressEvent
+	output, err := claude.ExecuteStream(context.Background(), &streamSession{transcript: cliTranscript}, claude.ExecuteOptions{Prompt: "review"},
+		newStreamProgress(func(e ProgressEvent) { events = append(events, e) }))
+	if err != nil {
+		t.Fatalf("ExecuteStream() error = %v", err)
+	}
+
+	if output.Result != `{"issues": [{"severity": "high"}]}` {
+		t.Errorf("Result = %v", output.Result)
+	}
+	want := claude.TokenUsage{InputTokens: 2008, OutputTokens: 68, TotalTokens: 2076, CostUSD: 0.0311}
+	if output.TokensUsed == nil || *output.TokensUsed != want {
+		t.Errorf("TokensUsed = %+v, want %+v", output.TokensUsed, want)
+	}
+	if output.Thinking != "Check the handler first" {
+		t.Errorf("Thinking = %q", output.Thinking)
+	}
+
+	if len(events) != 2 || events[0].Type != ProgressThinking || events[0].Detail != "Check the handler first" ||
+		events[1].Type != ProgressToolUse || events[1].Tool != "Read" || events[1].Detail != "/repo/pkg/api/handler.go" {
+		t.Errorf("events = %+v", events)
+	}
+}
+
+func TestExecuteStreamFallsBackToRawOutput(t *testing.T) {
+	tests := []struct {
+		name       string
+		transcript string
+		want       string
+	}{
+		{
+			// A failed run has no result text; the streamed text is used
+			name: "result without text",
+			transcript: `{"type":"assistant","message":{"content":[{"type":"text","text":"partial"}]}}` + "\n" +
+				`{"type":"result","subtype":"error_max_turns","is_error":true,"usage":{"input_tokens":1,"output_tokens":1}}` + "\n",
+			want: "partial",
+		},
+		{
+			name:       "not a stream",
+			transcript: "Review:\n```json\n{\"issues\": []}\n```\n",
+			want:       `{"issues": []}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			output, err := claude.ExecuteStream(context.Background(), &streamSession{transcript: tt.transcript}, claude.ExecuteOptions{Prompt: "review"}, nil)
+			if err != nil {
+				t.Fatalf("ExecuteStream() error = %v", err)
+			}
+			if output.Result != tt.want {
+				t.Errorf("Result = %q, want %q", output.Result, tt.want)
+			}
+		})
+	}
+}
+
+func TestTruncateDetail(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"  short  ", "short"},
+		{"first line\nsecond line", "first line"},
+		{strings.Repeat("é", 100), strings.Repeat("é", 77) + "..."},
+	}
+	for _, tt := range tests {
+		if got := truncateDetail(tt.in); got != tt.want {
+			t.Errorf("truncateDetail(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,236 @@
+// Package ai provides a record/replay backend for testing without an AI
+package ai
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// ReplayMode selects how the replay backend treats fixtures
+type ReplayMode string
+
+const (
+	// ReplayModeReplay serves fixtures only and fails on a missing fixture
+	ReplayModeReplay ReplayMode = "replay"
+
+	// ReplayModeRecord always calls the recorded backend and overwrites fixtures
+	ReplayModeRecord ReplayMode = "record"
+
+	// ReplayModeAuto serves existing fixtures and records missing ones
+	ReplayModeAuto ReplayMode = "auto"
+)
+
+// unsafeFixtureChars matches characters not allowed in fixture file names
+var unsafeFixtureChars = regexp.MustCompile(`[^a-zA-Z0-9_-]+`)
+
+// ReplayFixture is a recorded execution stored as JSON
+type ReplayFixture struct {
+	PromptHash string      `json:"prompt_hash"`
+	Skills     []string    `json:"skills,omitempty"`
+	Prompt     string      `json:"prompt"`
+	RecordedAt time.Time   `json:"recorded_at"`
+	Backend    BackendType `json:"backend"`
+	Model      string      `json:"model,omitempty"`
+	Raw        string      `json:"raw"`
+	Thinking   string      `json:"thinking,omitempty"`
+	Result     any         `json:"result,omitempty"`
+	Issues     []Issue     `json:"issues"`
+	TokensUsed *TokenUsage `json:"tokens_used,omitempty"`
+	DurationMS int64       `json:"duration_ms"`
+}
+
+// ReplayBackend implements the Brain interface from fixture files, like VCR
+// cassettes. Fixtures are keyed by a hash of the prompt and the requested
+// skills; the model is not part of the key. In record and auto modes the
+// wrapped backend produces the outputs that are saved.
+type ReplayBackend struct {
+	dir   string
+	mode  ReplayMode
+	inner Brain
+}
+
+// NewReplayBackend creates a replay backend reading fixtures from dir.
+// inner is the backend recorded from and may be nil in replay mode.
+func NewReplayBackend(dir string, mode ReplayMode, inner Brain) *ReplayBackend {
+	if mode == "" {
+		mode = ReplayModeReplay
+	}
+	return &ReplayBackend{dir: dir, mode: mode, inner: inner}
+}
+
+// Execute returns the recorded output for the prompt, recording it first
+// when the mode allows
+func (b *ReplayBackend) Execute(ctx context.Context, prompt string, opts ExecuteOptions) (*Output, error) {
+	path, hash := b.fixturePath(prompt, opts)
+
+	if b.mode != ReplayModeRecord {
+		fixture, err := loadReplayFixture(path)
+		if err == nil {
+			return fixture.output(), nil
+		}
+		if b.mode == ReplayModeReplay || !errors.Is(err, os.ErrNotExist) {
+			return nil, fmt.Errorf("replay fixture for prompt %s (skills %v): %w", hash, opts.Skills, err)
+		}
+	}
+
+	if b.inner == nil {
+		return nil, fmt.Errorf("replay backend in %s mode has no backend to record from", b.mode)
+	}
+
+	output, err := b.inner.Execute(ctx, prompt, opts)
+	if err != nil {
+		// Failures are not recorded so a retry records the real output
+		return nil, err
+	}
+
+	fixture := &ReplayFixture{
+		PromptHash: hash,
+		Skills:     opts.Skills,
+		Prompt:     prompt,
+		RecordedAt: time.Now().UTC(),
+		Backend:    output.Backend,
+		Model:      output.Model,
+		Raw:        output.Raw,
+		Thinking:   output.Thinking,
+		Result:     output.Result,
+		Issues:     output.Issues,
+		TokensUsed: output.TokensUsed,
+		DurationMS: output.Duration.Milliseconds(),
+	}
+	if err := saveReplayFixture(path, fixture); err != nil {
+		return nil, fmt.Errorf("failed to record replay fixture: %w", err)
+	}
+
+	return output, nil
+}
+
+// ExecuteWithSkill returns the recorded output for the prompt and skill
+func (b *ReplayBackend) ExecuteWithSkill(ctx context.Context, prompt string, skill string, opts ExecuteOptions) (*Output, error) {
+	opts.Skills = append(opts.Skills, skill)
+	return b.Execute(ctx, prompt, opts)
+}
+
+// Validate checks that the fixture directory exists in replay mode and
+// that the recorded backend is ready otherwise
+func (b *ReplayBackend) Validate(ctx context.Context) error {
+	if b.mode == ReplayModeReplay {
+		info, err := os.Stat(b.dir)
+		if err != nil {
+			return fmt.Errorf("replay fixture directory: %w", err)
+		}
+		if !info.IsDir() {
+			return fmt.Errorf("replay fixture directory %s is not a directory", b.dir)
+		}
+		return nil
+	}
+
+	if b.inner == nil {
+		return fmt.Errorf("replay backend in %s mode has no backend to record from", b.mode)
+	}
+	return b.inner.Validate(ctx)
+}
+
+// Type returns the backend type
+func (b *ReplayBackend) Type() BackendType {
+	return BackendReplay
+}
+
+// Version returns the mode and, when recording, the recorded backend's version
+func (b *ReplayBackend) Version(ctx context.Context) (string, error) {
+	if b.inner == nil || b.mode == ReplayModeReplay {
+		return "replay (" + string(b.mode) + ")", nil
+	}
+	v, err := b.inner.Version(ctx)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("replay (%s) of %s %s", b.mode, b.inner.Type(), v), nil
+}
+
+// Close closes the backend recorded from, if any
+func (b *ReplayBackend) Close() error {
+	if b.inner == nil {
+		return nil
+	}
+	return CloseBrain(b.inner)
+}
+
+// fixturePath returns the fixture file for a prompt and its prompt hash.
+// Stdin content is part of the prompt for keying purposes.
+func (b *ReplayBackend) fixturePath(prompt string, opts ExecuteOptions) (string, string) {
+	sum := sha256.Sum256([]byte(prompt + "\x00" + opts.StdinContent))
+	hash := hex.EncodeToString(sum[:])[:16]
+
+	name := "prompt"
+	if len(opts.Skills) > 0 {
+		name = unsafeFixtureChars.ReplaceAllString(strings.Join(opts.Skills, "+"), "_")
+	}
+	return filepath.Join(b.dir, name+"-"+hash+".json"), hash
+}
+
+// output converts a fixture back into an Output
+func (f *ReplayFixture) output() *Output {
+	output := &Output{
+		Raw:        f.Raw,
+		Thinking:   f.Thinking,
+		Result:     f.Result,
+		Issues:     f.Issues,
+		Duration:   time.Duration(f.DurationMS) * time.Millisecond,
+		TokensUsed: f.TokensUsed,
+		Model:      f.Model,
+		Backend:    BackendReplay,
+	}
+	if m, ok := f.Result.(map[string]any); ok {
+		output.JSON = m
+	}
+	return output
+}
+
+// loadReplayFixture reads a fixture file
+func loadReplayFixture(path string) (*ReplayFixture, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var fixture ReplayFixture
+	if err := json.Unmarshal(data, &fixture); err != nil {
+		return nil, fmt.Errorf("invalid fixture %s: %w", path, err)
+	}
+	return &fixture, nil
+}
+
+// saveReplayFixture writes a fixture atomically so parallel skill
+// executions never leave a partial file behind
+func saveReplayFixture(path string, fixture *ReplayFixture) error {
+	data, err := json.MarshalIndent(fixture, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".fixture-*")
+	if err != nil {
+		return err
+	}
+	defer func() { _ = os.Remove(tmp.Name()) }()
+
+	if _, err := tmp.Write(append(data, '\n')); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
@@ -0,0 +1,183 @@
+package ai
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cicd-ai-toolkit/cicd-runner/pkg/config"
+)
+
+// recordedBrain is the live backend a replay backend records from
+type recordedBrain struct {
+	calls int
+	err   error
+}
+
+func (b *recordedBrain) Execute(ctx context.Context, prompt string, opts ExecuteOptions) (*Output, error) {
+	b.calls++
+	if b.err != nil {
+		return nil, b.err
+	}
+	return &Output{
+		Raw:    "review of " + prompt,
+		Result: map[string]any{"summary": "looks risky"},
+		Issues: []Issue{{
+			Severity: "high",
+			Category: "security",
+			File:     "auth.go",
+			Line:     42,
+			Message:  "token compared with ==",
+			Skill:    strings.Join(opts.Skills, ","),
+		}},
+		Duration:   1500 * time.Millisecond,
+		TokensUsed: &TokenUsage{InputTokens: 100, OutputTokens: 20, TotalTokens: 120, CostUSD: 0.001},
+		Model:      "sonnet",
+		Backend:    BackendClaude,
+	}, nil
+}
+
+func (b *recordedBrain) ExecuteWithSkill(ctx context.Context, prompt string, skill string, opts ExecuteOptions) (*Output, error) {
+	opts.Skills = append(opts.Skills, skill)
+	return b.Execute(ctx, prompt, opts)
+}
+
+func (b *recordedBrain) Validate(ctx context.Context) error { return nil }
+
+func (b *recordedBrain) Type() BackendType { return BackendClaude }
+
+func (b *recordedBrain) Version(ctx context.Context) (string, error) { return "1.0.0", nil }
+
+func TestReplayRecordThenReplay(t *testing.T) {
+	dir := t.TempDir()
+	ctx := context.Background()
+	opts := ExecuteOptions{Skills: []string{"security-scanner"}}
+
+	live := &recordedBrain{}
+	recorded, err := NewReplayBackend(dir, ReplayModeRecord, live).Execute(ctx, "Review PR #7", opts)
+	if err != nil {
+		t.Fatalf("record Execute() error = %v", err)
+	}
+
+	files, _ := filepath.Glob(filepath.Join(dir, "security-scanner-*.json"))
+	if len(files) != 1 {
+		t.Fatalf("fixtures = %v, want one security-scanner fixture", files)
+	}
+
+	// Replay needs no live backend and returns the recording every time
+	player := NewReplayBackend(dir, ReplayModeReplay, nil)
+	if err := player.Validate(ctx); err != nil {
+		t.Fatalf("Validate() error = %v", err)
+	}
+	for i := 0; i < 2; i++ {
+		replayed, err := player.Execute(ctx, "Review PR #7", opts)
+		if err != nil {
+			t.Fatalf("replay Execute() error = %v", err)
+		}
+		if !reflect.DeepEqual(replayed.Issues, recorded.Issues) {
+			t.Errorf("Issues = %+v, want %+v", replayed.Issues, recorded.Issues)
+		}
+		if replayed.Raw != recorded.Raw || replayed.Model != "sonnet" || replayed.Duration != recorded.Duration {
+			t.Errorf("replayed = %+v", replayed)
+		}
+		if !reflect.DeepEqual(replayed.TokensUsed, recorded.TokensUsed) {
+			t.Errorf("TokensUsed = %+v, want %+v", replayed.TokensUsed, recorded.TokensUsed)
+		}
+		if replayed.Backend != BackendReplay {
+			t.Errorf("Backend = %s, want replay", replayed.Backend)
+		}
+		if replayed.JSON["summary"] != "looks risky" {
+			t.Errorf("JSON = %v", replayed.JSON)
+		}
+	}
+	if live.calls != 1 {
+		t.Errorf("live backend called %d times, want 1", live.calls)
+	}
+}
+
+func TestReplayMissingFixture(t *testing.T) {
+	dir := t.TempDir()
+	ctx := context.Background()
+
+	live := &recordedBrain{}
+	if _, err := NewReplayBackend(dir, ReplayModeRecord, live).ExecuteWithSkill(ctx, "prompt", "code-reviewer", ExecuteOptions{}); err != nil {
+		t.Fatal(err)
+	}
+
+	player := NewReplayBackend(dir, ReplayModeReplay, nil)
+	misses := []struct {
+		name   string
+		prompt string
+		opts   ExecuteOptions
+	}{
+		{"different prompt", "other prompt", ExecuteOptions{Skills: []string{"code-reviewer"}}},
+		{"different skill", "prompt", ExecuteOptions{Skills: []string{"perf-auditor"}}},
+		{"no skill", "prompt", ExecuteOptions{}},
+		{"stdin content", "prompt", ExecuteOptions{Skills: []string{"code-reviewer"}, StdinContent: "extra"}},
+	}
+	for _, tt := range misses {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := player.Execute(ctx, tt.prompt, tt.opts)
+			if !errors.Is(err, os.ErrNotExist) {
+				t.Errorf("Execute() error = %v, want missing fixture", err)
+			}
+		})
+	}
+}
+
+func TestReplayAutoMode(t *testing.T) {
+	dir := t.TempDir()
+	ctx := context.Background()
+	live := &recordedBrain{}
+	backend := NewReplayBackend(dir, ReplayModeAuto, live)
+
+	for i := 0; i < 3; i++ {
+		if _, err := backend.Execute(ctx, "prompt", ExecuteOptions{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if live.calls != 1 {
+		t.Errorf("live backend called %d times, want 1", live.calls)
+	}
+
+	// Failed executions are not recorded
+	failing := NewReplayBackend(dir, ReplayModeAuto, &recordedBrain{err: errors.New("rate limited")})
+	if _, err := failing.Execute(ctx, "new prompt", ExecuteOptions{}); err == nil {
+		t.Fatal("Execute() should return the live backend's error")
+	}
+	files, _ := filepath.Glob(filepath.Join(dir, "*.json"))
+	if len(files) != 1 {
+		t.Errorf("fixtures = %v, want only the successful recording", files)
+	}
+}
+
+func TestFactoryCreateReplay(t *testing.T) {
+	baseDir := t.TempDir()
+	factory := NewFactory(baseDir)
+	cfg := &config.Config{AIBackend: "replay", Replay: config.ReplayConfig{Dir: "fixtures"}}
+
+	if _, err := factory.CreateFromConfig(cfg); err == nil {
+		t.Error("CreateFromConfig() should fail without a fixture directory")
+	}
+
+	if err := os.MkdirAll(filepath.Join(baseDir, "fixtures"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	brain, err := factory.CreateFromConfig(cfg)
+	if err != nil {
+		t.Fatalf("CreateFromConfig() error = %v", err)
+	}
+	if brain.Type() != BackendReplay {
+		t.Errorf("Type() = %s, want replay", brain.Type())
+	}
+
+	cfg.Replay = config.ReplayConfig{Mode: "record", Backend: "replay"}
+	if _, err := factory.CreateFromConfig(cfg); err == nil {
+		t.Error("CreateFromConfig() should refuse to record from the replay backend")
+	}
+}
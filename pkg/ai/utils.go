@@ -3,14 +3,53 @@ package ai
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os/exec"
+	"regexp"
 	"strings"
 	"time"
 
+	cicderrors "github.com/cicd-ai-toolkit/cicd-runner/pkg/errors"
 	"github.com/cicd-ai-toolkit/cicd-runner/pkg/security"
 )
 
+// ErrCLINotFound is wrapped by the errors of backends whose CLI is not
+// installed. Retrying cannot help, but the fallback chain tries its next
+// backend.
+var ErrCLINotFound = errors.New("CLI not found")
+
+// ErrTimedOut is wrapped by the errors of executions that ran out of their
+// time limit. A retry under the same limit would time out again, so it is
+// not retryable, but the fallback chain tries its next backend.
+var ErrTimedOut = errors.New("execution timed out")
+
+// unavailablePattern matches rate limit and overload messages from model providers
+var unavailablePattern = regexp.MustCompile(`(?i)rate.?limit|too many requests|overloaded|\b(429|502|503|529)\b`)
+
+// classifyExecError marks rate limits and provider overload as retryable.
+// Timeouts wrap ErrTimedOut and a missing CLI wraps ErrCLINotFound; the
+// fallback chain fails over on those without retrying. detail is any extra
+// output describing the failure, such as stderr.
+func classifyExecError(ctx context.Context, backend BackendType, err error, detail string) error {
+	switch {
+	case errors.Is(ctx.Err(), context.DeadlineExceeded):
+		return fmt.Errorf("%s %w: %w", backend, ErrTimedOut, err)
+	case errors.Is(err, exec.ErrNotFound):
+		return fmt.Errorf("%s %w: %w", backend, ErrCLINotFound, err)
+	case unavailablePattern.MatchString(detail) || unavailablePattern.MatchString(err.Error()):
+		return cicderrors.UnavailableError(fmt.Sprintf("%s is rate limited or overloaded", backend), err)
+	default:
+		return err
+	}
+}
+
+// shouldFailover reports whether the fallback chain tries its next backend
+// after err: on retryable errors, timeouts and a missing CLI
+func shouldFailover(err error) bool {
+	return cicderrors.IsRetryable(err) || errors.Is(err, ErrTimedOut) || errors.Is(err, ErrCLINotFound)
+}
+
 // validateCommand checks if a command exists and is executable
 func validateCommand(ctx context.Context, command string, args ...string) error {
 	cmd := exec.CommandContext(ctx, command, args...)
@@ -113,6 +113,75 @@ func (b *Builder) BuildDiff(ctx context.Context, opts DiffOptions) (string, erro
 	return stdout.String(), nil
 }
 
+// ResolveRef resolves a git ref (branch, tag or abbreviated SHA) to a full commit SHA
+func (b *Builder) ResolveRef(ctx context.Context, ref string) (string, error) {
+	if ref == "" {
+		ref = "HEAD"
+	}
+	if err := sanitizeGitRef(ref); err != nil {
+		return "", fmt.Errorf("invalid ref: %w", err)
+	}
+
+	cmd := exec.CommandContext(ctx, "git", "rev-parse", "--verify", "--quiet", "--end-of-options", ref+"^{commit}")
+	cmd.Dir = b.baseDir
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		return "", errors.ClaudeError(fmt.Sprintf("git rev-parse failed for %s: %s", ref, stderr.String()), err)
+	}
+
+	return strings.TrimSpace(stdout.String()), nil
+}
+
+// ResolveParent resolves the first parent of a commit to a full commit SHA
+func (b *Builder) ResolveParent(ctx context.Context, ref string) (string, error) {
+	if ref == "" {
+		ref = "HEAD"
+	}
+	if err := sanitizeGitRef(ref); err != nil {
+		return "", fmt.Errorf("invalid ref: %w", err)
+	}
+
+	cmd := exec.CommandContext(ctx, "git", "rev-parse", "--verify", "--quiet", "--end-of-options", ref+"^1^{commit}")
+	cmd.Dir = b.baseDir
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		return "", errors.ClaudeError(fmt.Sprintf("git rev-parse failed for parent of %s: %s", ref, stderr.String()), err)
+	}
+
+	return strings.TrimSpace(stdout.String()), nil
+}
+
+// MergeBase returns the best common ancestor of two refs
+func (b *Builder) MergeBase(ctx context.Context, ref1, ref2 string) (string, error) {
+	if err := sanitizeGitRef(ref1); err != nil {
+		return "", fmt.Errorf("invalid ref: %w", err)
+	}
+	if err := sanitizeGitRef(ref2); err != nil {
+		return "", fmt.Errorf("invalid ref: %w", err)
+	}
+
+	cmd := exec.CommandContext(ctx, "git", "merge-base", "--end-of-options", ref1, ref2)
+	cmd.Dir = b.baseDir
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		return "", errors.ClaudeError(fmt.Sprintf("git merge-base failed for %s and %s: %s", ref1, ref2, stderr.String()), err)
+	}
+
+	return strings.TrimSpace(stdout.String()), nil
+}
+
 // buildDiffArgs constructs git diff arguments
 func (b *Builder) buildDiffArgs(opts DiffOptions) []string {
 	args := []string{"diff", "--no-color"}
@@ -526,9 +595,60 @@ type FileStats struct {
 	Deletions int
 }
 
-// Chunks breaks a large diff into smaller chunks for processing
+// Chunks breaks a large diff into chunks of at most maxChunkSize bytes.
+// Unified diffs are split at file boundaries; files that do not fit are
+// split at hunk boundaries with the file header repeated in each chunk, so
+// a hunk is never split. A single hunk larger than maxChunkSize forms its
+// own oversized chunk. Text that is not a unified diff is split by lines.
 func (b *Builder) Chunks(diff string, maxChunkSize int) []string {
-	lines := strings.Split(diff, "\n")
+	files := ParseDiff(diff)
+	if len(files) == 0 {
+		return chunkLines(diff, maxChunkSize)
+	}
+	if maxChunkSize <= 0 {
+		return []string{diff}
+	}
+
+	var chunks []string
+	var current strings.Builder
+
+	add := func(piece string) {
+		if current.Len() > 0 && current.Len()+len(piece) > maxChunkSize {
+			chunks = append(chunks, current.String())
+			current.Reset()
+		}
+		current.WriteString(piece)
+	}
+
+	for _, file := range files {
+		text := file.Text()
+		if len(text) <= maxChunkSize || len(file.Hunks) <= 1 {
+			add(text)
+			continue
+		}
+
+		// Pack hunks into pieces that each carry the file header
+		piece := file.Header
+		for _, hunk := range file.Hunks {
+			if len(piece) > len(file.Header) && len(piece)+len(hunk.Text) > maxChunkSize {
+				add(piece)
+				piece = file.Header
+			}
+			piece += hunk.Text
+		}
+		add(piece)
+	}
+
+	if current.Len() > 0 {
+		chunks = append(chunks, current.String())
+	}
+
+	return chunks
+}
+
+// chunkLines breaks text into chunks of at most maxChunkSize bytes at line boundaries
+func chunkLines(text string, maxChunkSize int) []string {
+	lines := strings.Split(text, "\n")
 
 	var chunks []string
 	var currentChunk strings.Builder
@@ -3,6 +3,7 @@ package buildcontext
 
 import (
 	"context"
+	"fmt"
 	"os"
 	"os/exec"
 	"path/filepath"
@@ -214,6 +215,53 @@ func TestChunks(t *testing.T) {
 	}
 }
 
+func TestChunksDiffAware(t *testing.T) {
+	builder := NewBuilder(".", 3, nil)
+
+	hunk := func(start int) string {
+		return fmt.Sprintf("@@ -%d,3 +%d,4 @@\n ctx\n+added line one\n+added line two\n-removed\n ctx\n", start, start)
+	}
+	big := "diff --git a/big.go b/big.go\n--- a/big.go\n+++ b/big.go\n" + hunk(1) + hunk(20) + hunk(40)
+	small := "diff --git a/small.go b/small.go\n--- a/small.go\n+++ b/small.go\n" + hunk(5)
+
+	// Room for the header and two hunks, but not the whole file
+	maxSize := len(big) - 1
+	chunks := builder.Chunks(big+small, maxSize)
+
+	if len(chunks) != 2 {
+		t.Fatalf("Chunks() = %d chunks, want 2:\n%s", len(chunks), strings.Join(chunks, "\n=====\n"))
+	}
+
+	for i, chunk := range chunks {
+		files := ParseDiff(chunk)
+		if len(files) == 0 {
+			t.Fatalf("chunk %d is not a valid diff", i)
+		}
+		for _, f := range files {
+			for _, h := range f.Hunks {
+				if h.NewLines != 4 || len(h.Lines) != 5 {
+					t.Errorf("chunk %d contains a split hunk: %+v", i, h)
+				}
+			}
+		}
+	}
+
+	// The oversized file is split at a hunk boundary and the small file packed with its tail
+	first, second := ParseDiff(chunks[0]), ParseDiff(chunks[1])
+	if len(first) != 1 || first[0].NewPath != "big.go" || len(first[0].Hunks) != 2 {
+		t.Errorf("unexpected first chunk: %+v", first)
+	}
+	if len(second) != 2 || second[0].NewPath != "big.go" || second[1].NewPath != "small.go" {
+		t.Errorf("unexpected second chunk: %+v", second)
+	}
+
+	// A single hunk larger than the limit is kept whole
+	chunks = builder.Chunks(small, 10)
+	if len(chunks) != 1 || chunks[0] != small {
+		t.Errorf("oversized hunk was split: %q", chunks)
+	}
+}
+
 func TestChunksEmpty(t *testing.T) {
 	builder := NewBuilder(".", 3, nil)
 
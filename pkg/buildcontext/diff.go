@@ -0,0 +1,356 @@
+// Package buildcontext provides unified diff parsing
+package buildcontext
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// hunkHeaderPattern matches unified diff hunk headers: @@ -a,b +c,d @@ section
+var hunkHeaderPattern = regexp.MustCompile(`^@@ -(\d+)(?:,(\d+))? \+(\d+)(?:,(\d+))? @@(.*)$`)
+
+// LineKind classifies a line within a diff hunk
+type LineKind int
+
+const (
+	// LineContext is an unchanged line present on both sides
+	LineContext LineKind = iota
+	// LineAdded is a line present only in the new file
+	LineAdded
+	// LineRemoved is a line present only in the old file
+	LineRemoved
+)
+
+// DiffLine is a single line within a hunk
+type DiffLine struct {
+	Kind    LineKind
+	OldLine int // 0 for added lines
+	NewLine int // 0 for removed lines
+	// Position is the 1-based offset from the file's first hunk header,
+	// as used by the GitHub and Gitee review comment APIs
+	Position int
+	Content  string
+}
+
+// Hunk is a contiguous block of changes within a file diff
+type Hunk struct {
+	OldStart int
+	OldLines int
+	NewStart int
+	NewLines int
+	Section  string // Optional function/section name after the header
+	Lines    []DiffLine
+	// Text is the raw hunk text including its @@ header
+	Text string
+}
+
+// FileDiff is the parsed diff of a single file
+type FileDiff struct {
+	OldPath string // Empty for new files
+	NewPath string // Empty for deleted files
+	Hunks   []Hunk
+	// Header is the raw text preceding the first hunk (diff --git, index, ---/+++)
+	Header string
+}
+
+// Path returns the repository path the diff applies to
+func (f *FileDiff) Path() string {
+	if f.NewPath != "" {
+		return f.NewPath
+	}
+	return f.OldPath
+}
+
+// Text returns the raw diff text for the file
+func (f *FileDiff) Text() string {
+	var b strings.Builder
+	b.WriteString(f.Header)
+	for _, h := range f.Hunks {
+		b.WriteString(h.Text)
+	}
+	return b.String()
+}
+
+// FindLine returns the diff line for a line number in the new file.
+// Only added and context lines can be found; removed lines have no new-side number.
+func (f *FileDiff) FindLine(line int) (DiffLine, bool) {
+	if line <= 0 {
+		return DiffLine{}, false
+	}
+	for _, h := range f.Hunks {
+		if line < h.NewStart || line >= h.NewStart+h.NewLines {
+			continue
+		}
+		for _, l := range h.Lines {
+			if l.Kind != LineRemoved && l.NewLine == line {
+				return l, true
+			}
+		}
+	}
+	return DiffLine{}, false
+}
+
+// MapOldLine maps a line number in the old file to its number in the new file.
+// It returns false if the line was removed or modified by this diff.
+func (f *FileDiff) MapOldLine(line int) (int, bool) {
+	if f.NewPath == "" {
+		return 0, false
+	}
+	offset := 0
+	for _, h := range f.Hunks {
+		// Pure insertions ("-N,0") add lines after old line N
+		if h.OldLines == 0 {
+			if line <= h.OldStart {
+				break
+			}
+			offset += h.NewLines
+			continue
+		}
+		if line < h.OldStart {
+			break
+		}
+		if line >= h.OldStart+h.OldLines {
+			offset += h.NewLines - h.OldLines
+			continue
+		}
+		for _, l := range h.Lines {
+			if l.OldLine != line {
+				continue
+			}
+			if l.Kind == LineContext {
+				return l.NewLine, true
+			}
+			return 0, false
+		}
+	}
+	return line + offset, true
+}
+
+// ChangedLines returns the number of added and removed lines in a diff
+func ChangedLines(diff string) int {
+	n := 0
+	for _, f := range ParseDiff(diff) {
+		for _, h := range f.Hunks {
+			for _, l := range h.Lines {
+				if l.Kind != LineContext {
+					n++
+				}
+			}
+		}
+	}
+	return n
+}
+
+// ParseDiff parses a unified diff into per-file diffs.
+// It accepts `git diff` output as well as bare per-file patches that
+// start directly with a hunk header after a `diff --git` line.
+func ParseDiff(diff string) []FileDiff {
+	var (
+		files    []FileDiff
+		current  *FileDiff
+		hunk     *Hunk
+		header   strings.Builder
+		hunkText strings.Builder
+		oldLeft  int
+		newLeft  int
+		oldLine  int
+		newLine  int
+		position int
+	)
+
+	flushHunk := func() {
+		if current != nil && hunk != nil {
+			hunk.Text = hunkText.String()
+			current.Hunks = append(current.Hunks, *hunk)
+		}
+		hunk = nil
+		hunkText.Reset()
+	}
+	flushFile := func() {
+		flushHunk()
+		if current != nil {
+			if current.Header == "" {
+				current.Header = header.String()
+			}
+			if current.Path() != "" {
+				files = append(files, *current)
+			}
+		}
+		current = nil
+		header.Reset()
+		position = 0
+	}
+
+	lines := strings.SplitAfter(diff, "\n")
+	for _, raw := range lines {
+		if raw == "" {
+			continue
+		}
+		line := strings.TrimSuffix(strings.TrimSuffix(raw, "\n"), "\r")
+
+		// Inside a hunk, lines are consumed until both sides are exhausted so
+		// that removed lines such as "--- x" are not mistaken for headers.
+		if hunk != nil && (oldLeft > 0 || newLeft > 0) {
+			position++
+			hunkText.WriteString(raw)
+			switch {
+			case strings.HasPrefix(line, "+"):
+				hunk.Lines = append(hunk.Lines, DiffLine{Kind: LineAdded, NewLine: newLine, Position: position, Content: line[1:]})
+				newLine++
+				newLeft--
+			case strings.HasPrefix(line, "-"):
+				hunk.Lines = append(hunk.Lines, DiffLine{Kind: LineRemoved, OldLine: oldLine, Position: position, Content: line[1:]})
+				oldLine++
+				oldLeft--
+			case strings.HasPrefix(line, `\`):
+				// "\ No newline at end of file" occupies a position but no line
+			default:
+				content := strings.TrimPrefix(line, " ")
+				hunk.Lines = append(hunk.Lines, DiffLine{Kind: LineContext, OldLine: oldLine, NewLine: newLine, Position: position, Content: content})
+				oldLine++
+				newLine++
+				oldLeft--
+				newLeft--
+			}
+			continue
+		}
+
+		if strings.HasPrefix(line, "diff --git ") {
+			flushFile()
+			current = &FileDiff{}
+			current.OldPath, current.NewPath = parseDiffGitLine(line)
+			header.WriteString(raw)
+			continue
+		}
+
+		if m := hunkHeaderPattern.FindStringSubmatch(line); m != nil {
+			if current == nil {
+				current = &FileDiff{}
+			}
+			if hunk == nil && len(current.Hunks) == 0 {
+				current.Header = header.String()
+			}
+			flushHunk()
+			if len(current.Hunks) > 0 {
+				// Subsequent hunk headers occupy a position of their own
+				position++
+			}
+			hunk = &Hunk{
+				OldStart: atoiDefault(m[1], 0),
+				OldLines: atoiDefault(m[2], 1),
+				NewStart: atoiDefault(m[3], 0),
+				NewLines: atoiDefault(m[4], 1),
+				Section:  strings.TrimSpace(m[5]),
+			}
+			oldLine, newLine = hunk.OldStart, hunk.NewStart
+			oldLeft, newLeft = hunk.OldLines, hunk.NewLines
+			hunkText.WriteString(raw)
+			continue
+		}
+
+		// A trailing "\ No newline" directly after a completed hunk
+		if hunk != nil && strings.HasPrefix(line, `\`) {
+			position++
+			hunkText.WriteString(raw)
+			continue
+		}
+
+		flushHunk()
+		if current == nil {
+			if !strings.HasPrefix(line, "--- ") {
+				continue
+			}
+			current = &FileDiff{}
+		}
+
+		switch {
+		case strings.HasPrefix(line, "--- "):
+			current.OldPath = parseHeaderPath(line[4:])
+		case strings.HasPrefix(line, "+++ "):
+			current.NewPath = parseHeaderPath(line[4:])
+		case strings.HasPrefix(line, "rename from "):
+			current.OldPath = line[len("rename from "):]
+		case strings.HasPrefix(line, "rename to "):
+			current.NewPath = line[len("rename to "):]
+		case strings.HasPrefix(line, "new file mode"):
+			current.OldPath = ""
+		case strings.HasPrefix(line, "deleted file mode"):
+			current.NewPath = ""
+		}
+		if len(current.Hunks) == 0 {
+			header.WriteString(raw)
+		}
+	}
+	flushFile()
+
+	return files
+}
+
+// NormalizeDiffPath converts backslashes to slashes and strips a leading
+// "./" or "/" from a path. A leading a/ or b/ is kept: it may be a real
+// directory (see MatchDiffPath).
+func NormalizeDiffPath(path string) string {
+	path = strings.TrimSpace(strings.ReplaceAll(path, "\\", "/"))
+	path = strings.TrimPrefix(path, "./")
+	return strings.TrimPrefix(path, "/")
+}
+
+// MatchDiffPath normalizes a path reported for a diff, such as an issue's
+// file. Models sometimes echo the a/ or b/ prefix of diff headers; it is
+// stripped only when changed reports the path without it as a changed file
+// and the path as given is not.
+func MatchDiffPath(path string, changed func(path string) bool) string {
+	path = NormalizeDiffPath(path)
+	if stripped := stripDiffPrefix(path); stripped != path && !changed(path) && changed(stripped) {
+		return stripped
+	}
+	return path
+}
+
+// stripDiffPrefix strips the a/ or b/ prefix of a diff header path
+func stripDiffPrefix(path string) string {
+	if strings.HasPrefix(path, "a/") || strings.HasPrefix(path, "b/") {
+		return path[2:]
+	}
+	return path
+}
+
+// parseDiffGitLine extracts paths from a "diff --git a/x b/y" line
+func parseDiffGitLine(line string) (string, string) {
+	rest := strings.TrimPrefix(line, "diff --git ")
+	// Paths are separated by " b/"; this handles paths containing spaces
+	if idx := strings.Index(rest, " b/"); idx >= 0 {
+		return strings.TrimPrefix(rest[:idx], "a/"), rest[idx+3:]
+	}
+	parts := strings.Fields(rest)
+	if len(parts) == 2 {
+		return stripDiffPrefix(NormalizeDiffPath(parts[0])), stripDiffPrefix(NormalizeDiffPath(parts[1]))
+	}
+	return "", ""
+}
+
+// parseHeaderPath parses the path from a ---/+++ header, returning "" for /dev/null
+func parseHeaderPath(path string) string {
+	// Strip optional timestamp separated by a tab
+	if idx := strings.Index(path, "\t"); idx >= 0 {
+		path = path[:idx]
+	}
+	path = strings.TrimSpace(path)
+	if path == "/dev/null" {
+		return ""
+	}
+	return stripDiffPrefix(NormalizeDiffPath(path))
+}
+
+// atoiDefault parses s as an int, returning def if s is empty or invalid
+func atoiDefault(s string, def int) int {
+	if s == "" {
+		return def
+	}
+	n, err := strconv.Atoi(s)
+	if err != nil {
+		return def
+	}
+	return n
+}
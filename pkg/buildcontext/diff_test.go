@@ -0,0 +1,220 @@
+// Package buildcontext provides diff parser tests
+package buildcontext
+
+import (
+	"testing"
+)
+
+const sampleDiff = `diff --git a/pkg/foo.go b/pkg/foo.go
+index 1111111..2222222 100644
+--- a/pkg/foo.go
++++ b/pkg/foo.go
+@@ -1,4 +1,5 @@ package foo
+ package foo
+-var a = 1
++var a = 2
++var b = 3
+
+ func x() {}
+@@ -20,3 +21,3 @@ func y() {
+ 	one()
+--- removed line that looks like a header
++++ added line that looks like a header
+ 	three()
+diff --git a/new.txt b/new.txt
+new file mode 100644
+index 0000000..3333333
+--- /dev/null
++++ b/new.txt
+@@ -0,0 +1,2 @@
++hello
++world
+\ No newline at end of file
+diff --git a/old.txt b/old.txt
+deleted file mode 100644
+--- a/old.txt
++++ /dev/null
+@@ -1 +0,0 @@
+-bye
+`
+
+func TestParseDiff(t *testing.T) {
+	files := ParseDiff(sampleDiff)
+	if len(files) != 3 {
+		t.Fatalf("expected 3 files, got %d", len(files))
+	}
+
+	foo := files[0]
+	if foo.Path() != "pkg/foo.go" || foo.OldPath != "pkg/foo.go" {
+		t.Errorf("unexpected paths: old=%q new=%q", foo.OldPath, foo.NewPath)
+	}
+	if len(foo.Hunks) != 2 {
+		t.Fatalf("expected 2 hunks, got %d", len(foo.Hunks))
+	}
+	if foo.Hunks[0].Section != "package foo" {
+		t.Errorf("unexpected section %q", foo.Hunks[0].Section)
+	}
+	// Header-like lines inside a hunk must be treated as content
+	if len(foo.Hunks[1].Lines) != 4 {
+		t.Errorf("expected 4 lines in second hunk, got %d", len(foo.Hunks[1].Lines))
+	}
+
+	added := files[1]
+	if added.OldPath != "" || added.NewPath != "new.txt" {
+		t.Errorf("unexpected paths for new file: old=%q new=%q", added.OldPath, added.NewPath)
+	}
+
+	deleted := files[2]
+	if deleted.NewPath != "" || deleted.Path() != "old.txt" {
+		t.Errorf("unexpected paths for deleted file: old=%q new=%q", deleted.OldPath, deleted.NewPath)
+	}
+
+	if got := foo.Text(); got[:len("diff --git")] != "diff --git" {
+		t.Errorf("Text() should start with the file header, got %q", got[:20])
+	}
+}
+
+func TestFileDiff_FindLine(t *testing.T) {
+	foo := ParseDiff(sampleDiff)[0]
+
+	tests := []struct {
+		name     string
+		line     int
+		found    bool
+		kind     LineKind
+		position int
+	}{
+		{"context line", 1, true, LineContext, 1},
+		{"first added line", 2, true, LineAdded, 3},
+		{"second added line", 3, true, LineAdded, 4},
+		{"blank context line", 4, true, LineContext, 5},
+		{"second hunk counts header", 21, true, LineContext, 8},
+		{"added in second hunk", 22, true, LineAdded, 10},
+		{"outside hunks", 10, false, 0, 0},
+		{"zero line", 0, false, 0, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l, ok := foo.FindLine(tt.line)
+			if ok != tt.found {
+				t.Fatalf("FindLine(%d) found = %v, want %v", tt.line, ok, tt.found)
+			}
+			if !ok {
+				return
+			}
+			if l.Kind != tt.kind || l.Position != tt.position {
+				t.Errorf("FindLine(%d) = kind %d position %d, want kind %d position %d",
+					tt.line, l.Kind, l.Position, tt.kind, tt.position)
+			}
+		})
+	}
+}
+
+func TestParseDiff_BarePatch(t *testing.T) {
+	// Gitee's GetDiff emits "diff --git" lines followed directly by the patch
+	diff := "diff --git a/main.go b/main.go\n@@ -5,2 +5,3 @@\n a()\n+b()\n c()\n\n"
+
+	files := ParseDiff(diff)
+	if len(files) != 1 {
+		t.Fatalf("expected 1 file, got %d", len(files))
+	}
+	l, ok := files[0].FindLine(6)
+	if !ok || l.Kind != LineAdded || l.Position != 2 {
+		t.Errorf("FindLine(6) = %+v, %v", l, ok)
+	}
+}
+
+func TestMatchDiffPath(t *testing.T) {
+	diff := `diff --git a/main.go b/main.go
+--- a/main.go
++++ b/main.go
+@@ -1 +1 @@
+-x
++y
+diff --git a/b/api.go b/b/api.go
+--- a/b/api.go
++++ b/b/api.go
+@@ -1 +1 @@
+-x
++y
+`
+	changed := make(map[string]bool)
+	for _, f := range ParseDiff(diff) {
+		changed[f.Path()] = true
+	}
+	if !changed["main.go"] || !changed["b/api.go"] {
+		t.Fatalf("header paths = %v, want main.go and b/api.go", changed)
+	}
+
+	tests := map[string]string{
+		"b/main.go":   "main.go",     // echoed prefix
+		"a/main.go":   "main.go",     // echoed prefix
+		"b/api.go":    "b/api.go",    // real b/ directory
+		"b/b/api.go":  "b/api.go",    // echoed prefix of the real directory
+		"a/docs/x.md": "a/docs/x.md", // not in the diff
+		"./main.go":   "main.go",
+		"a\\main.go":  "main.go",
+	}
+	for in, want := range tests {
+		if got := MatchDiffPath(in, func(p string) bool { return changed[p] }); got != want {
+			t.Errorf("MatchDiffPath(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestNormalizeDiffPath(t *testing.T) {
+	tests := map[string]string{
+		"./pkg/a.go": "pkg/a.go",
+		"b/pkg/a.go": "b/pkg/a.go",
+		"/pkg/a.go":  "pkg/a.go",
+		"pkg\\a.go":  "pkg/a.go",
+		"pkg/a.go":   "pkg/a.go",
+	}
+	for in, want := range tests {
+		if got := NormalizeDiffPath(in); got != want {
+			t.Errorf("NormalizeDiffPath(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestFileDiff_MapOldLine(t *testing.T) {
+	foo := ParseDiff(sampleDiff)[0]
+
+	tests := []struct {
+		name string
+		line int
+		want int
+		ok   bool
+	}{
+		{"context line unchanged", 1, 1, true},
+		{"modified line", 2, 0, false},
+		{"shifted by first hunk", 4, 5, true},
+		{"between hunks", 10, 11, true},
+		{"removed in second hunk", 21, 0, false},
+		{"after all hunks", 30, 31, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := foo.MapOldLine(tt.line)
+			if ok != tt.ok || got != tt.want {
+				t.Errorf("MapOldLine(%d) = %d, %v; want %d, %v", tt.line, got, ok, tt.want, tt.ok)
+			}
+		})
+	}
+
+	// Lines of a deleted file cannot be mapped
+	if _, ok := ParseDiff(sampleDiff)[2].MapOldLine(1); ok {
+		t.Error("expected lines of deleted file to be unmapped")
+	}
+
+	// Pure insertions shift only the lines after the insertion point
+	insert := ParseDiff("--- a/x\n+++ b/x\n@@ -5,0 +6,2 @@\n+a\n+b\n")[0]
+	if got, _ := insert.MapOldLine(5); got != 5 {
+		t.Errorf("MapOldLine(5) = %d, want 5", got)
+	}
+	if got, _ := insert.MapOldLine(6); got != 8 {
+		t.Errorf("MapOldLine(6) = %d, want 8", got)
+	}
+}
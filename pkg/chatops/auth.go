@@ -0,0 +1,92 @@
+// Package chatops provides the role-based authorization of commands
+package chatops
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/cicd-ai-toolkit/cicd-runner/pkg/observability"
+)
+
+// Authorizer decides who may run commands. Commenters are users of an
+// observability.RBAC, identified by their platform login; "platform:login"
+// entries take precedence over plain logins, so the same name can have
+// different roles on different platforms. Plain logins are only accepted
+// when comments come from a single platform: anyone can register a listed
+// name on another one.
+type Authorizer struct {
+	rbac        *observability.RBAC
+	defaultRole string
+
+	mu sync.Mutex // Serializes adding commenters with the default role
+}
+
+// NewAuthorizer creates an authorizer from a map of logins to role names
+// (viewer, developer, admin). platforms are the platforms comments are
+// accepted from; with more than one, every login must be "platform:login".
+// Commenters not listed get defaultRole, or no permissions when it is
+// empty. Permission checks are recorded in audit when it is not nil.
+func NewAuthorizer(users map[string][]string, defaultRole string, platforms []string, audit *observability.AuditLogger) (*Authorizer, error) {
+	rbac := observability.NewRBAC(audit)
+
+	known := make(map[string]bool)
+	for _, role := range rbac.ListRoles() {
+		known[role.Name] = true
+	}
+	if defaultRole != "" && !known[defaultRole] {
+		return nil, fmt.Errorf("unknown default role: %s", defaultRole)
+	}
+
+	logins := make([]string, 0, len(users))
+	for login := range users {
+		logins = append(logins, login)
+	}
+	sort.Strings(logins)
+	for _, login := range logins {
+		if len(platforms) > 1 && !strings.Contains(login, ":") {
+			return nil, fmt.Errorf("user %s: comments are accepted from %s, so users must be given as platform:login", login, strings.Join(platforms, ", "))
+		}
+		for _, role := range users[login] {
+			if !known[role] {
+				return nil, fmt.Errorf("user %s: unknown role: %s", login, role)
+			}
+		}
+		if err := rbac.AddUser(&observability.User{ID: login, Name: login, Roles: users[login]}); err != nil {
+			return nil, err
+		}
+	}
+
+	return &Authorizer{rbac: rbac, defaultRole: defaultRole}, nil
+}
+
+// Authorize checks that a commenter on a platform may run a command
+func (a *Authorizer) Authorize(platformName, login string, cmd Command) error {
+	if login == "" {
+		return fmt.Errorf("unknown commenter")
+	}
+	return a.rbac.CheckPermission(a.user(platformName, login), cmd.Permission())
+}
+
+// user returns the RBAC user ID of a commenter, adding unlisted commenters
+// with the default role
+func (a *Authorizer) user(platformName, login string) string {
+	qualified := strings.ToLower(platformName) + ":" + login
+	if _, ok := a.rbac.GetUser(qualified); ok {
+		return qualified
+	}
+	if _, ok := a.rbac.GetUser(login); ok {
+		return login
+	}
+	if a.defaultRole == "" {
+		return qualified
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if _, ok := a.rbac.GetUser(qualified); !ok {
+		_ = a.rbac.AddUser(&observability.User{ID: qualified, Name: login, Roles: []string{a.defaultRole}})
+	}
+	return qualified
+}
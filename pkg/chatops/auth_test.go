@@ -0,0 +1,108 @@
+// Package chatops provides authorization and ignore store tests
+package chatops
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestAuthorize(t *testing.T) {
+	users := map[string][]string{
+		"alice":     {"developer"},
+		"bob":       {"viewer"},
+		"gitee:bob": {"admin"},
+		"nobody":    {},
+	}
+	review := Command{Name: "review"}
+	ignore := Command{Name: "ignore", Args: []string{"G104"}}
+
+	tests := []struct {
+		name        string
+		defaultRole string
+		platform    string
+		login       string
+		cmd         Command
+		wantErr     bool
+	}{
+		{name: "developer reviews", platform: "github", login: "alice", cmd: review},
+		{name: "developer ignores", platform: "github", login: "alice", cmd: ignore},
+		{name: "viewer reviews", platform: "github", login: "bob", cmd: review},
+		{name: "viewer cannot ignore", platform: "github", login: "bob", cmd: ignore, wantErr: true},
+		{name: "platform entry wins", platform: "gitee", login: "bob", cmd: ignore},
+		{name: "user without roles", platform: "github", login: "nobody", cmd: review, wantErr: true},
+		{name: "unlisted denied", platform: "github", login: "mallory", cmd: review, wantErr: true},
+		{name: "unlisted gets default role", defaultRole: "viewer", platform: "github", login: "carol", cmd: review},
+		{name: "default role is limited", defaultRole: "viewer", platform: "github", login: "carol", cmd: ignore, wantErr: true},
+		{name: "no login", defaultRole: "admin", platform: "github", cmd: review, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			auth, err := NewAuthorizer(users, tt.defaultRole, []string{"github"}, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := auth.Authorize(tt.platform, tt.login, tt.cmd); (err != nil) != tt.wantErr {
+				t.Errorf("Authorize() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestNewAuthorizerRejectsUnknownRoles(t *testing.T) {
+	if _, err := NewAuthorizer(map[string][]string{"a": {"owner"}}, "", nil, nil); err == nil {
+		t.Error("unknown user role accepted")
+	}
+	if _, err := NewAuthorizer(nil, "guest", nil, nil); err == nil {
+		t.Error("unknown default role accepted")
+	}
+}
+
+func TestNewAuthorizerPlatformLogins(t *testing.T) {
+	platforms := []string{"github", "gitee"}
+
+	// A plain login would match the same name on every platform
+	if _, err := NewAuthorizer(map[string][]string{"alice": {"admin"}}, "", platforms, nil); err == nil {
+		t.Error("plain login accepted with several platforms")
+	}
+
+	auth, err := NewAuthorizer(map[string][]string{"github:alice": {"admin"}}, "", platforms, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	review := Command{Name: "review"}
+	if err := auth.Authorize("github", "alice", review); err != nil {
+		t.Errorf("Authorize(github:alice) error = %v", err)
+	}
+	if err := auth.Authorize("gitee", "alice", review); err == nil {
+		t.Error("alice on gitee got the role of github:alice")
+	}
+}
+
+func TestIgnoreStore(t *testing.T) {
+	store := NewIgnoreStore(filepath.Join(t.TempDir(), "ignores"))
+
+	if rules, err := store.Rules(1); err != nil || len(rules) != 0 {
+		t.Fatalf("Rules() = %v, %v", rules, err)
+	}
+	for _, rule := range []string{"G104", "errcheck", "g104"} {
+		if _, err := store.Add(1, rule); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if added, _ := store.Add(1, "ERRCHECK"); added {
+		t.Error("Add() of an ignored rule reported added")
+	}
+
+	rules, err := store.Rules(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"G104", "errcheck"}; !reflect.DeepEqual(rules, want) {
+		t.Errorf("Rules() = %v, want %v", rules, want)
+	}
+	if rules, _ := store.Rules(2); len(rules) != 0 {
+		t.Errorf("other PR has rules %v", rules)
+	}
+}
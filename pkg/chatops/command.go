@@ -0,0 +1,125 @@
+// Package chatops provides the slash commands that can be posted in pull
+// request comments, e.g. "/ai review security-scanner", and the role-based
+// checks of who may run them
+package chatops
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/cicd-ai-toolkit/cicd-runner/pkg/observability"
+)
+
+// Prefix starts every command
+const Prefix = "/ai"
+
+// Command names
+const (
+	CommandReview  = "review"
+	CommandAnalyze = "analyze"
+	CommandTestGen = "test-gen"
+	CommandExplain = "explain"
+	CommandIgnore  = "ignore"
+	CommandHelp    = "help"
+)
+
+// permissions maps each command to the permission it requires. Commands
+// that run AI need skill:run; ignoring a rule changes what later reviews
+// report, so it needs write.
+var permissions = map[string]observability.Permission{
+	CommandReview:  observability.PermissionSkillRun,
+	CommandAnalyze: observability.PermissionSkillRun,
+	CommandTestGen: observability.PermissionSkillRun,
+	CommandExplain: observability.PermissionSkillRun,
+	CommandIgnore:  observability.PermissionWrite,
+	CommandHelp:    observability.PermissionRead,
+}
+
+// Usage is the reply to /ai help and to invalid commands
+const Usage = "| Command | Action |\n" +
+	"|---------|--------|\n" +
+	"| `/ai review [skill...]` | Review the PR, optionally with the given skills only |\n" +
+	"| `/ai analyze [skill...]` | Analyze the changes and their risk |\n" +
+	"| `/ai test-gen [framework]` | Generate tests for the changes |\n" +
+	"| `/ai explain <file:line>` | Explain the changed code at a line |\n" +
+	"| `/ai ignore <rule>` | Stop reporting a rule in reviews of this PR |\n" +
+	"| `/ai help` | Show this help |\n"
+
+// Command is a slash command from a PR comment
+type Command struct {
+	Name string   // One of the Command* names, or what was typed if unknown
+	Args []string // Arguments after the name
+}
+
+// Parse finds the first line of a comment that starts with /ai and returns
+// its command. A bare /ai is help. Lines quoting a command ("> /ai ...")
+// are not commands, so replies that quote one do not trigger again.
+func Parse(body string) (Command, bool) {
+	for _, line := range strings.Split(body, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 0 || fields[0] != Prefix {
+			continue
+		}
+		if len(fields) == 1 {
+			return Command{Name: CommandHelp}, true
+		}
+		return Command{Name: strings.ToLower(fields[1]), Args: fields[2:]}, true
+	}
+	return Command{}, false
+}
+
+// String returns the command as typed
+func (c Command) String() string {
+	return strings.Join(append([]string{Prefix, c.Name}, c.Args...), " ")
+}
+
+// Permission returns the permission the command requires
+func (c Command) Permission() observability.Permission {
+	return permissions[c.Name]
+}
+
+// Validate checks that the command exists and has the arguments it needs
+func (c Command) Validate() error {
+	switch c.Name {
+	case CommandReview, CommandAnalyze:
+		return nil
+	case CommandTestGen:
+		if len(c.Args) > 1 {
+			return fmt.Errorf("%s takes at most one test framework", c.Name)
+		}
+		return nil
+	case CommandExplain:
+		if len(c.Args) != 1 {
+			return fmt.Errorf("%s needs one location, e.g. `/ai explain main.go:42`", c.Name)
+		}
+		_, _, err := c.Location()
+		return err
+	case CommandIgnore:
+		if len(c.Args) != 1 {
+			return fmt.Errorf("%s needs one rule, e.g. `/ai ignore G104`", c.Name)
+		}
+		return nil
+	case CommandHelp:
+		return nil
+	default:
+		return fmt.Errorf("unknown command %q", c.Name)
+	}
+}
+
+// Location returns the file and line of an explain command
+func (c Command) Location() (string, int, error) {
+	if len(c.Args) == 0 {
+		return "", 0, fmt.Errorf("missing location")
+	}
+	arg := c.Args[0]
+	i := strings.LastIndex(arg, ":")
+	if i <= 0 {
+		return "", 0, fmt.Errorf("invalid location %q: want file:line", arg)
+	}
+	line, err := strconv.Atoi(arg[i+1:])
+	if err != nil || line <= 0 {
+		return "", 0, fmt.Errorf("invalid location %q: want file:line", arg)
+	}
+	return strings.TrimPrefix(arg[:i], "./"), line, nil
+}
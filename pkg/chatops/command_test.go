@@ -0,0 +1,82 @@
+// Package chatops provides command parsing tests
+package chatops
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/cicd-ai-toolkit/cicd-runner/pkg/observability"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name   string
+		body   string
+		want   Command
+		wantOK bool
+	}{
+		{name: "review", body: "/ai review", want: Command{Name: "review", Args: []string{}}, wantOK: true},
+		{name: "review with skill", body: "/ai Review security-scanner", want: Command{Name: "review", Args: []string{"security-scanner"}}, wantOK: true},
+		{name: "after text", body: "Looks odd.\n\n  /ai explain pkg/a.go:12\nthanks", want: Command{Name: "explain", Args: []string{"pkg/a.go:12"}}, wantOK: true},
+		{name: "bare prefix is help", body: "/ai", want: Command{Name: "help"}, wantOK: true},
+		{name: "quoted command", body: "> /ai review\n\nDone.", wantOK: false},
+		{name: "other prefix", body: "/aid review", wantOK: false},
+		{name: "no command", body: "LGTM", wantOK: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := Parse(tt.body)
+			if ok != tt.wantOK {
+				t.Fatalf("Parse() ok = %v, want %v", ok, tt.wantOK)
+			}
+			if ok && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Parse() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		cmd     Command
+		wantErr bool
+	}{
+		{cmd: Command{Name: "review", Args: []string{"a", "b"}}},
+		{cmd: Command{Name: "analyze"}},
+		{cmd: Command{Name: "test-gen", Args: []string{"pytest"}}},
+		{cmd: Command{Name: "test-gen", Args: []string{"a", "b"}}, wantErr: true},
+		{cmd: Command{Name: "explain", Args: []string{"main.go:3"}}},
+		{cmd: Command{Name: "explain", Args: []string{"main.go"}}, wantErr: true},
+		{cmd: Command{Name: "explain", Args: []string{"main.go:0"}}, wantErr: true},
+		{cmd: Command{Name: "explain"}, wantErr: true},
+		{cmd: Command{Name: "ignore", Args: []string{"G104"}}},
+		{cmd: Command{Name: "ignore"}, wantErr: true},
+		{cmd: Command{Name: "help"}},
+		{cmd: Command{Name: "deploy"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.cmd.String(), func(t *testing.T) {
+			if err := tt.cmd.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestLocation(t *testing.T) {
+	file, line, err := Command{Name: "explain", Args: []string{"./pkg/a:b.go:42"}}.Location()
+	if err != nil || file != "pkg/a:b.go" || line != 42 {
+		t.Errorf("Location() = %q, %d, %v", file, line, err)
+	}
+}
+
+func TestPermission(t *testing.T) {
+	if p := (Command{Name: "review"}).Permission(); p != observability.PermissionSkillRun {
+		t.Errorf("review permission = %s", p)
+	}
+	if p := (Command{Name: "ignore"}).Permission(); p != observability.PermissionWrite {
+		t.Errorf("ignore permission = %s", p)
+	}
+}
@@ -0,0 +1,89 @@
+// Package chatops provides the store of rules ignored per pull request
+package chatops
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// IgnoreStore keeps the rules ignored with /ai ignore, one JSON file per
+// PR in a directory
+type IgnoreStore struct {
+	dir string
+	mu  sync.Mutex
+}
+
+// NewIgnoreStore returns a store in dir. The directory is created on the
+// first write.
+func NewIgnoreStore(dir string) *IgnoreStore {
+	return &IgnoreStore{dir: dir}
+}
+
+// Rules returns the rules ignored for a PR, sorted
+func (s *IgnoreStore) Rules(prID int) ([]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.read(prID)
+}
+
+// Add ignores a rule for a PR. Rules are compared case-insensitively; it
+// reports whether the rule was not ignored before.
+func (s *IgnoreStore) Add(prID int, rule string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	rules, err := s.read(prID)
+	if err != nil {
+		return false, err
+	}
+	for _, r := range rules {
+		if strings.EqualFold(r, rule) {
+			return false, nil
+		}
+	}
+	rules = append(rules, rule)
+	sort.Strings(rules)
+
+	data, err := json.Marshal(rules)
+	if err != nil {
+		return false, err
+	}
+	if err := os.MkdirAll(s.dir, 0700); err != nil {
+		return false, fmt.Errorf("failed to create ignore directory: %w", err)
+	}
+	tmp := s.path(prID) + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return false, fmt.Errorf("failed to write ignored rules: %w", err)
+	}
+	if err := os.Rename(tmp, s.path(prID)); err != nil {
+		_ = os.Remove(tmp)
+		return false, fmt.Errorf("failed to write ignored rules: %w", err)
+	}
+	return true, nil
+}
+
+// read loads the rules of a PR. Caller must hold s.mu.
+func (s *IgnoreStore) read(prID int) ([]string, error) {
+	data, err := os.ReadFile(s.path(prID))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read ignored rules: %w", err)
+	}
+	var rules []string
+	if err := json.Unmarshal(data, &rules); err != nil {
+		return nil, fmt.Errorf("failed to parse ignored rules: %w", err)
+	}
+	return rules, nil
+}
+
+// path returns the file of a PR
+func (s *IgnoreStore) path(prID int) string {
+	return filepath.Join(s.dir, fmt.Sprintf("pr-%d.json", prID))
+}
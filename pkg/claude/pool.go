@@ -4,10 +4,12 @@ package claude
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"log"
 	"os"
 	"path/filepath"
+	"sort"
 	"sync"
 	"time"
 
@@ -25,30 +27,46 @@ const (
 	MaxRetryBackoff = 30 * time.Second
 	// MaxScannerCapacity is the maximum line size for stream parsing
 	MaxScannerCapacity = 1024 * 1024 // 1MB
+	// SessionIndexFile is the file in the pool directory recording the
+	// sessions to resume after a restart
+	SessionIndexFile = "sessions.json"
 )
 
 // SessionPool manages multiple Claude sessions with explicit ID strategy
 // Implements the "Explicit ID Strategy" from docs/BEST_PRACTICE_CLI_AGENT.md section 7.2
 // NOTE: SessionPool is safe for concurrent use.
 type SessionPool struct {
-	sessions  map[string]*PooledSession
-	mu        sync.RWMutex
-	baseDir   string
-	ttl       time.Duration
-	done      chan struct{}  // Signals goroutines to stop
-	cleanupWg sync.WaitGroup // Waits for cleanup goroutine to finish
+	sessions   map[string]*PooledSession
+	mu         sync.RWMutex
+	baseDir    string
+	ttl        time.Duration
+	newSession func(ctx context.Context) (Session, error)
+	removed    map[string]bool // Removed sessions the index on disk may still list
+	done       chan struct{}   // Signals goroutines to stop
+	cleanupWg  sync.WaitGroup  // Waits for cleanup goroutine to finish
 }
 
 // PooledSession represents a session in the pool with metadata
 type PooledSession struct {
 	ID        string
+	Key       string // Conversation the session belongs to, if any
 	CreatedAt time.Time
 	LastUsed  time.Time
+	Turns     int // Completed executions; the first one creates the session
 	Lock      sync.Mutex
 	Session   Session
 	Active    bool
 }
 
+// SessionInfo describes a session recorded in the pool index
+type SessionInfo struct {
+	Key       string    `json:"key,omitempty"`
+	ID        string    `json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	LastUsed  time.Time `json:"last_used"`
+	Turns     int       `json:"turns"`
+}
+
 // PoolConfig contains configuration for the session pool
 type PoolConfig struct {
 	// BaseDir is the directory for storing session data
@@ -59,6 +77,9 @@ type PoolConfig struct {
 
 	// MaxSessions is the maximum number of concurrent sessions
 	MaxSessions int
+
+	// NewSession creates the sessions of the pool (default: NewSession)
+	NewSession func(ctx context.Context) (Session, error)
 }
 
 // DefaultPoolConfig returns sensible defaults for session pool
@@ -89,11 +110,25 @@ func NewSessionPool(config PoolConfig) (*SessionPool, error) {
 		return nil, fmt.Errorf("failed to create session directory: %w", err)
 	}
 
+	if config.TTL <= 0 {
+		config.TTL = DefaultSessionTTL
+	}
+	if config.NewSession == nil {
+		config.NewSession = NewSession
+	}
+
 	pool := &SessionPool{
-		sessions: make(map[string]*PooledSession),
-		baseDir:  config.BaseDir,
-		ttl:      config.TTL,
-		done:     make(chan struct{}),
+		sessions:   make(map[string]*PooledSession),
+		baseDir:    config.BaseDir,
+		ttl:        config.TTL,
+		newSession: config.NewSession,
+		removed:    make(map[string]bool),
+		done:       make(chan struct{}),
+	}
+
+	// Resume the sessions recorded by earlier processes
+	if err := pool.load(); err != nil {
+		log.Printf("[WARNING] failed to load session index, starting new sessions: %v", err)
 	}
 
 	// Start cleanup goroutine once
@@ -130,6 +165,15 @@ func (p *SessionPool) GetOrCreate(ctx context.Context, sessionID string) (*Poole
 
 		// Double-check under session lock
 		if pooled.Active {
+			// Sessions loaded from the index start without a process
+			if pooled.Session == nil {
+				session, err := p.newSession(ctx)
+				if err != nil {
+					pooled.Lock.Unlock()
+					return nil, fmt.Errorf("failed to create session: %w", err)
+				}
+				pooled.Session = session
+			}
 			pooled.LastUsed = time.Now()
 			pooled.Lock.Unlock()
 			return pooled, nil
@@ -140,7 +184,7 @@ func (p *SessionPool) GetOrCreate(ctx context.Context, sessionID string) (*Poole
 	}
 
 	// Create new session
-	session, err := NewSession(ctx)
+	session, err := p.newSession(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create session: %w", err)
 	}
@@ -167,6 +211,87 @@ func (p *SessionPool) CreateNew(ctx context.Context) (*PooledSession, error) {
 	return p.GetOrCreate(ctx, sessionID)
 }
 
+// ForKey returns the session of a conversation, creating one if the
+// conversation has no session or its session expired. Keys are typically
+// derived from what the conversation is about, such as a pull request.
+// Concurrent calls for a new conversation get the same session.
+func (p *SessionPool) ForKey(ctx context.Context, key string) (*PooledSession, error) {
+	// Look up and register the session under one lock; its process is
+	// started outside of it, as for sessions loaded from the index
+	p.mu.Lock()
+	var found *PooledSession
+	for _, pooled := range p.sessions {
+		if pooled.Key == key && pooled.Active && time.Since(pooled.LastUsed) <= p.ttl &&
+			(found == nil || pooled.LastUsed.After(found.LastUsed)) {
+			found = pooled
+		}
+	}
+	if found == nil {
+		now := time.Now()
+		found = &PooledSession{
+			ID:        uuid.New().String(),
+			Key:       key,
+			CreatedAt: now,
+			LastUsed:  now,
+			Active:    true,
+		}
+		p.sessions[found.ID] = found
+	}
+	p.mu.Unlock()
+
+	pooled, err := p.GetOrCreate(ctx, found.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	// The session was replaced if it was removed meanwhile
+	p.mu.Lock()
+	pooled.Key = key
+	p.mu.Unlock()
+
+	return pooled, nil
+}
+
+// Execute runs Claude in a pooled session. The first completed execution
+// creates the session with --session-id; later ones resume it with --resume,
+// continuing the conversation, even after a restart. Executions in one
+// session are serialized. If handler is non-nil, the output is streamed to
+// it as with ExecuteStream.
+func (p *SessionPool) Execute(ctx context.Context, pooled *PooledSession, opts ExecuteOptions, handler EventHandler) (*Output, error) {
+	pooled.Lock.Lock()
+	defer pooled.Lock.Unlock()
+
+	if !pooled.Active {
+		return nil, fmt.Errorf("session inactive: %s", pooled.ID)
+	}
+
+	p.mu.RLock()
+	opts.SessionID = pooled.ID
+	opts.IsNewSession = pooled.Turns == 0
+	p.mu.RUnlock()
+
+	var output *Output
+	var err error
+	if handler != nil {
+		output, err = ExecuteStream(ctx, pooled.Session, opts, handler)
+	} else {
+		output, err = pooled.Session.Execute(ctx, opts)
+	}
+	if err != nil {
+		return output, err
+	}
+
+	p.mu.Lock()
+	pooled.Turns++
+	pooled.LastUsed = time.Now()
+	if err := p.saveLocked(); err != nil {
+		log.Printf("[WARNING] failed to save session index: %v", err)
+	}
+	p.mu.Unlock()
+
+	return output, nil
+}
+
 // Get returns an existing session by ID, or error if not found
 // NOTE: Acquires session lock; caller must not hold pool lock when calling.
 func (p *SessionPool) Get(sessionID string) (*PooledSession, error) {
@@ -198,6 +323,10 @@ func (p *SessionPool) Remove(sessionID string) error {
 		return nil
 	}
 	delete(p.sessions, sessionID)
+	p.removed[sessionID] = true
+	if err := p.saveLocked(); err != nil {
+		log.Printf("[WARNING] failed to save session index: %v", err)
+	}
 	p.mu.Unlock()
 
 	// Close session outside of pool lock
@@ -241,11 +370,16 @@ func (p *SessionPool) cleanup() {
 	defer p.mu.Unlock()
 
 	now := time.Now()
+	removed := false
 	for id, pooled := range p.sessions {
-		pooled.Lock.Lock()
+		// A session that is executing is in use, not expired
+		if !pooled.Lock.TryLock() {
+			continue
+		}
 
 		// Check if session is expired
 		if now.Sub(pooled.LastUsed) > p.ttl {
+			removed = true
 			pooled.Active = false
 			if pooled.Session != nil {
 				if err := pooled.Session.Close(); err != nil {
@@ -253,6 +387,7 @@ func (p *SessionPool) cleanup() {
 				}
 			}
 			delete(p.sessions, id)
+			p.removed[id] = true
 
 			// Clean up session files
 			sessionPath := filepath.Join(p.baseDir, id)
@@ -263,6 +398,12 @@ func (p *SessionPool) cleanup() {
 
 		pooled.Lock.Unlock()
 	}
+
+	if removed {
+		if err := p.saveLocked(); err != nil {
+			log.Printf("[WARNING] cleanup: failed to save session index: %v", err)
+		}
+	}
 }
 
 // Close closes all sessions in the pool and stops the cleanup goroutine
@@ -273,11 +414,15 @@ func (p *SessionPool) Close() error {
 	// Wait for cleanup goroutine to finish
 	p.cleanupWg.Wait()
 
+	// Take the sessions under the pool lock, but close them outside of it:
+	// running executions need the pool lock to finish
 	p.mu.Lock()
-	defer p.mu.Unlock()
+	sessions := p.sessions
+	p.sessions = make(map[string]*PooledSession)
+	p.mu.Unlock()
 
 	var lastErr error
-	for id, pooled := range p.sessions {
+	for id, pooled := range sessions {
 		pooled.Lock.Lock()
 		pooled.Active = false
 		if pooled.Session != nil {
@@ -286,7 +431,6 @@ func (p *SessionPool) Close() error {
 				lastErr = err
 			}
 		}
-		delete(p.sessions, id)
 		pooled.Lock.Unlock()
 	}
 
@@ -298,16 +442,18 @@ func (p *SessionPool) GetStats() PoolStats {
 	p.mu.RLock()
 	defer p.mu.RUnlock()
 
-	active := 0
+	active, turns := 0, 0
 	for _, pooled := range p.sessions {
 		if pooled.Active {
 			active++
 		}
+		turns += pooled.Turns
 	}
 
 	return PoolStats{
 		TotalSessions:  len(p.sessions),
 		ActiveSessions: active,
+		Turns:          turns,
 		BaseDir:        p.baseDir,
 	}
 }
@@ -316,9 +462,134 @@ func (p *SessionPool) GetStats() PoolStats {
 type PoolStats struct {
 	TotalSessions  int
 	ActiveSessions int
+	Turns          int // Completed executions across sessions
 	BaseDir        string
 }
 
+// List returns the sessions with completed executions, most recently used
+// first
+func (p *SessionPool) List() []SessionInfo {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.infosLocked()
+}
+
+// infosLocked implements List. Caller must hold p.mu.
+func (p *SessionPool) infosLocked() []SessionInfo {
+	infos := make([]SessionInfo, 0, len(p.sessions))
+	for _, pooled := range p.sessions {
+		if pooled.Turns == 0 {
+			continue
+		}
+		infos = append(infos, SessionInfo{
+			Key:       pooled.Key,
+			ID:        pooled.ID,
+			CreatedAt: pooled.CreatedAt,
+			LastUsed:  pooled.LastUsed,
+			Turns:     pooled.Turns,
+		})
+	}
+	sort.Slice(infos, func(i, j int) bool {
+		return infos[i].LastUsed.After(infos[j].LastUsed)
+	})
+	return infos
+}
+
+// load adds the unexpired sessions of the index to the pool. Their
+// processes are created when they are first used.
+func (p *SessionPool) load() error {
+	infos, err := p.readIndex()
+	if err != nil {
+		return err
+	}
+
+	for _, info := range infos {
+		if info.ID == "" || time.Since(info.LastUsed) > p.ttl {
+			continue
+		}
+		p.sessions[info.ID] = &PooledSession{
+			ID:        info.ID,
+			Key:       info.Key,
+			CreatedAt: info.CreatedAt,
+			LastUsed:  info.LastUsed,
+			Turns:     info.Turns,
+			Active:    true,
+		}
+	}
+	return nil
+}
+
+// readIndex reads the index of sessions to resume; a missing index is empty
+func (p *SessionPool) readIndex() ([]SessionInfo, error) {
+	data, err := os.ReadFile(filepath.Join(p.baseDir, SessionIndexFile))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var infos []SessionInfo
+	if err := json.Unmarshal(data, &infos); err != nil {
+		return nil, fmt.Errorf("invalid session index: %w", err)
+	}
+	return infos, nil
+}
+
+// saveLocked writes the index of sessions to resume. Other pools on the
+// same directory, in this or another process, write the index too: their
+// unexpired sessions are merged in from the index on disk, and each write
+// goes through its own temporary file. Caller must hold the p.mu write
+// lock.
+func (p *SessionPool) saveLocked() error {
+	infos := p.infosLocked()
+
+	onDisk, err := p.readIndex()
+	if err != nil {
+		log.Printf("[WARNING] failed to read session index, overwriting it: %v", err)
+	}
+	removed := make(map[string]bool)
+	for _, info := range onDisk {
+		if _, ok := p.sessions[info.ID]; ok || info.ID == "" || time.Since(info.LastUsed) > p.ttl {
+			continue
+		}
+		if p.removed[info.ID] {
+			removed[info.ID] = true
+			continue
+		}
+		infos = append(infos, info)
+	}
+	// Forget removals the index no longer lists
+	p.removed = removed
+	sort.Slice(infos, func(i, j int) bool {
+		return infos[i].LastUsed.After(infos[j].LastUsed)
+	})
+
+	data, err := json.MarshalIndent(infos, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(p.baseDir, SessionIndexFile+".*.tmp")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+	if err := os.Rename(tmp.Name(), filepath.Join(p.baseDir, SessionIndexFile)); err != nil {
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+	return nil
+}
+
 // ExecuteWithRetry executes a Claude command with retry logic
 // Implements the retry mechanism from docs/BEST_PRACTICE_CLI_AGENT.md
 func (p *PooledSession) ExecuteWithRetry(ctx context.Context, opts ExecuteOptions, maxRetries int) (*Output, error) {
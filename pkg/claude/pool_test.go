@@ -0,0 +1,211 @@
+// Package claude provides session pool tests
+package claude
+
+import (
+	"context"
+	"errors"
+	"io"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+// recordingSession records the options of its executions
+type recordingSession struct {
+	calls *[]ExecuteOptions
+	fail  bool
+}
+
+func (s *recordingSession) Execute(ctx context.Context, opts ExecuteOptions) (*Output, error) {
+	*s.calls = append(*s.calls, opts)
+	if s.fail {
+		return &Output{}, errors.New("claude exited with status 1")
+	}
+	return &Output{Raw: "ok", Result: "ok"}, nil
+}
+
+func (s *recordingSession) ExecuteWithStreams(ctx context.Context, opts ExecuteOptions, stdin io.Reader, stdout, stderr io.Writer) error {
+	return nil
+}
+
+func (s *recordingSession) Close() error { return nil }
+
+func newTestPool(t *testing.T, dir string, ttl time.Duration, calls *[]ExecuteOptions) *SessionPool {
+	t.Helper()
+	pool, err := NewSessionPool(PoolConfig{
+		BaseDir: dir,
+		TTL:     ttl,
+		NewSession: func(ctx context.Context) (Session, error) {
+			return &recordingSession{calls: calls}, nil
+		},
+	})
+	if err != nil {
+		t.Fatalf("NewSessionPool() error = %v", err)
+	}
+	t.Cleanup(func() { pool.Close() })
+	return pool
+}
+
+func TestSessionPoolResumesConversation(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	var calls []ExecuteOptions
+
+	pool := newTestPool(t, dir, time.Hour, &calls)
+	first, err := pool.ForKey(ctx, "acme/api#7/review")
+	if err != nil {
+		t.Fatalf("ForKey() error = %v", err)
+	}
+	for i := 0; i < 2; i++ {
+		if _, err := pool.Execute(ctx, first, ExecuteOptions{Prompt: "review"}, nil); err != nil {
+			t.Fatalf("Execute() error = %v", err)
+		}
+	}
+	if !calls[0].IsNewSession || calls[0].SessionID != first.ID {
+		t.Errorf("first execution = %+v, want new session %s", calls[0], first.ID)
+	}
+	if calls[1].IsNewSession || calls[1].SessionID != first.ID {
+		t.Errorf("second execution = %+v, want resumed session %s", calls[1], first.ID)
+	}
+
+	other, _ := pool.ForKey(ctx, "acme/api#8/review")
+	if other.ID == first.ID {
+		t.Error("conversations share a session")
+	}
+
+	// A new process resumes the session recorded in the index
+	restarted := newTestPool(t, dir, time.Hour, &calls)
+	again, err := restarted.ForKey(ctx, "acme/api#7/review")
+	if err != nil {
+		t.Fatalf("ForKey() after restart error = %v", err)
+	}
+	if again.ID != first.ID || again.Turns != 2 {
+		t.Fatalf("session after restart = %s with %d turns, want %s with 2", again.ID, again.Turns, first.ID)
+	}
+	if _, err := restarted.Execute(ctx, again, ExecuteOptions{Prompt: "re-check"}, nil); err != nil {
+		t.Fatal(err)
+	}
+	if last := calls[len(calls)-1]; last.IsNewSession || last.SessionID != first.ID {
+		t.Errorf("execution after restart = %+v, want resumed session", last)
+	}
+
+	// The unused session of PR 8 was not recorded
+	if infos := restarted.List(); len(infos) != 1 || infos[0].Turns != 3 {
+		t.Errorf("List() = %+v", infos)
+	}
+	if stats := restarted.GetStats(); stats.ActiveSessions != 1 || stats.Turns != 3 {
+		t.Errorf("GetStats() = %+v", stats)
+	}
+
+	// Removed sessions are not resumed
+	if err := restarted.Remove(first.ID); err != nil {
+		t.Fatal(err)
+	}
+	fresh, _ := newTestPool(t, dir, time.Hour, &calls).ForKey(ctx, "acme/api#7/review")
+	if fresh.ID == first.ID {
+		t.Error("removed session was resumed")
+	}
+}
+
+func TestSessionPoolExpiresSessions(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	var calls []ExecuteOptions
+
+	pool := newTestPool(t, dir, time.Hour, &calls)
+	old, _ := pool.ForKey(ctx, "acme/api#7/review")
+	if _, err := pool.Execute(ctx, old, ExecuteOptions{Prompt: "review"}, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	// Sessions idle for longer than the TTL are not loaded
+	time.Sleep(10 * time.Millisecond)
+	fresh, _ := newTestPool(t, dir, 5*time.Millisecond, &calls).ForKey(ctx, "acme/api#7/review")
+	if fresh.ID == old.ID {
+		t.Error("expired session was resumed")
+	}
+}
+
+func TestSessionPoolFailedExecution(t *testing.T) {
+	ctx := context.Background()
+	var calls []ExecuteOptions
+	pool, err := NewSessionPool(PoolConfig{
+		BaseDir: t.TempDir(),
+		NewSession: func(ctx context.Context) (Session, error) {
+			return &recordingSession{calls: &calls, fail: true}, nil
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pool.Close()
+
+	pooled, _ := pool.ForKey(ctx, "acme/api#7/review")
+	for i := 0; i < 2; i++ {
+		if _, err := pool.Execute(ctx, pooled, ExecuteOptions{Prompt: "review"}, nil); err == nil {
+			t.Fatal("Execute() should fail")
+		}
+	}
+	// The session was never created, so it is still started as new
+	if !calls[1].IsNewSession || pooled.Turns != 0 {
+		t.Errorf("after failures: IsNewSession = %v, turns = %d", calls[1].IsNewSession, pooled.Turns)
+	}
+	if len(pool.List()) != 0 {
+		t.Error("failed session recorded in the index")
+	}
+}
+
+func TestSessionPoolsShareIndex(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	var calls []ExecuteOptions
+
+	// Two pools on one directory each record their own conversation
+	a := newTestPool(t, dir, time.Hour, &calls)
+	b := newTestPool(t, dir, time.Hour, &calls)
+	first, _ := a.ForKey(ctx, "acme/api#7/review")
+	second, _ := b.ForKey(ctx, "acme/api#8/review")
+	if _, err := a.Execute(ctx, first, ExecuteOptions{Prompt: "review"}, nil); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := b.Execute(ctx, second, ExecuteOptions{Prompt: "review"}, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	restarted := newTestPool(t, dir, time.Hour, &calls)
+	if infos := restarted.List(); len(infos) != 2 {
+		t.Fatalf("List() = %+v, want both conversations", infos)
+	}
+	matches, _ := filepath.Glob(filepath.Join(dir, SessionIndexFile+".*"))
+	if len(matches) != 0 {
+		t.Errorf("temporary index files left: %v", matches)
+	}
+}
+
+func TestSessionPoolForKeyConcurrent(t *testing.T) {
+	ctx := context.Background()
+	var calls []ExecuteOptions
+	pool := newTestPool(t, t.TempDir(), time.Hour, &calls)
+
+	// Concurrent callers of a new conversation get one session
+	sessions := make([]*PooledSession, 8)
+	var wg sync.WaitGroup
+	for i := range sessions {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			sessions[i], _ = pool.ForKey(ctx, "acme/api#7/review")
+		}(i)
+	}
+	wg.Wait()
+
+	for _, s := range sessions {
+		if s == nil || s.ID != sessions[0].ID {
+			t.Fatalf("ForKey() returned different sessions: %v and %v", s, sessions[0])
+		}
+	}
+	if stats := pool.GetStats(); stats.TotalSessions != 1 {
+		t.Errorf("GetStats() = %+v, want 1 session", stats)
+	}
+}
@@ -127,4 +127,7 @@ type OutputParser interface {
 
 	// ExtractCodeChanges extracts code change suggestions from output
 	ExtractCodeChanges(output string) []CodeChange
+
+	// ExtractTokenUsage extracts token usage statistics, or nil if absent
+	ExtractTokenUsage(output string) *TokenUsage
 }
@@ -4,6 +4,8 @@ package claude
 
 import (
 	"bufio"
+	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -32,6 +34,9 @@ const (
 	EventTypeError StreamEventType = "error"
 	// EventTypeThinking is the thinking block
 	EventTypeThinking StreamEventType = "thinking"
+	// EventTypeAssistant is a complete assistant message of the CLI, whose
+	// content blocks hold text, tool calls and thinking
+	EventTypeAssistant StreamEventType = "assistant"
 )
 
 // StreamEvent represents a single event in the stream
@@ -41,6 +46,9 @@ type StreamEvent struct {
 	Data      json.RawMessage `json:"data,omitempty"`
 	Error     string          `json:"error,omitempty"`
 	Metadata  map[string]any  `json:"metadata,omitempty"`
+
+	// Raw is the undecoded event line
+	Raw json.RawMessage `json:"-"`
 }
 
 // StreamParser parses streaming JSON output from Claude CLI
@@ -139,6 +147,7 @@ func (p *StreamParser) Parse() error {
 		}
 
 		// Dispatch event based on type
+		event.Raw = json.RawMessage(append([]byte(nil), line...))
 		p.dispatch(event)
 	}
 
@@ -159,7 +168,15 @@ func (p *StreamParser) dispatch(event StreamEvent) {
 	case EventTypeToolUse:
 		p.handler.OnToolUse(event)
 	case EventTypeResult:
+		// The CLI reports result, usage and cost at the top level of the
+		// event rather than under data
+		if len(event.Data) == 0 {
+			event.Data = event.Raw
+		}
 		p.handler.OnResult(event)
+	case EventTypeAssistant:
+		p.handler.OnMessage(event)
+		p.dispatchContent(event)
 	case EventTypeError:
 		p.handler.OnError(event)
 	case EventTypeThinking:
@@ -170,6 +187,39 @@ func (p *StreamParser) dispatch(event StreamEvent) {
 	}
 }
 
+// dispatchContent passes the content blocks of an assistant message to the
+// handler as the events they correspond to: text as content deltas, tool
+// calls as tool use and thinking as thinking, each with the block as data
+func (p *StreamParser) dispatchContent(event StreamEvent) {
+	var msg struct {
+		Message struct {
+			Content []json.RawMessage `json:"content"`
+		} `json:"message"`
+	}
+	if err := json.Unmarshal(event.Raw, &msg); err != nil {
+		return
+	}
+
+	for _, raw := range msg.Message.Content {
+		var block struct {
+			Type     string `json:"type"`
+			Thinking string `json:"thinking"`
+		}
+		if err := json.Unmarshal(raw, &block); err != nil {
+			continue
+		}
+		switch block.Type {
+		case "text":
+			p.handler.OnContentDelta(StreamEvent{Type: EventTypeContentBlockDelta, Data: raw, Raw: event.Raw})
+		case "tool_use":
+			p.handler.OnToolUse(StreamEvent{Type: EventTypeToolUse, Data: raw, Raw: event.Raw})
+		case "thinking":
+			data, _ := json.Marshal(map[string]string{"text": block.Thinking})
+			p.handler.OnThinking(StreamEvent{Type: EventTypeThinking, Data: data, Raw: event.Raw})
+		}
+	}
+}
+
 // isErrorLine checks if a line appears to be an error message
 func (p *StreamParser) isErrorLine(line string) bool {
 	lower := strings.ToLower(line)
@@ -305,3 +355,171 @@ func ExtractTextFromContentDeltas(events []StreamEvent) string {
 
 	return sb.String()
 }
+
+// ExecuteStream runs Claude with stream-json output and passes each event to
+// handler as it arrives, so callers can report progress of long executions.
+// The returned output holds the final text of the result event as Result
+// (the streamed text when it has none, the raw output when nothing was
+// streamed), together with the issues and token usage of the result event.
+func ExecuteStream(ctx context.Context, session Session, opts ExecuteOptions, handler EventHandler) (*Output, error) {
+	if handler == nil {
+		handler = DefaultEventHandler
+	}
+	opts.OutputFormat = "stream-json"
+
+	buffered := NewBufferedEventHandler()
+	pr, pw := io.Pipe()
+	parsed := make(chan error, 1)
+	go func() {
+		err := NewStreamParser(pr, &teeEventHandler{handlers: []EventHandler{buffered, handler}}).Parse()
+		// Keep draining so the process never blocks on a failed parse
+		_, _ = io.Copy(io.Discard, pr)
+		parsed <- err
+	}()
+
+	var stdout, stderr bytes.Buffer
+	err := session.ExecuteWithStreams(ctx, opts,
+		strings.NewReader(opts.StdinContent),
+		io.MultiWriter(&stdout, pw),
+		&stderr,
+	)
+	_ = pw.Close()
+	parseErr := <-parsed
+
+	events := buffered.GetEvents()
+	output := &Output{
+		Raw:        stdout.String(),
+		Result:     streamResult(events, stdout.String()),
+		Issues:     CollectIssuesFromStream(events),
+		Thinking:   extractStreamThinking(events),
+		TokensUsed: extractStreamUsage(events),
+	}
+
+	if err != nil {
+		if output.Raw == "" {
+			output.Raw = stderr.String()
+		}
+		return output, fmt.Errorf("claude execution failed: %w", err)
+	}
+	if parseErr != nil {
+		return output, fmt.Errorf("failed to parse claude stream: %w", parseErr)
+	}
+
+	return output, nil
+}
+
+// teeEventHandler passes every event to several handlers in order
+type teeEventHandler struct {
+	handlers []EventHandler
+}
+
+func (h *teeEventHandler) OnMessage(event StreamEvent) {
+	for _, x := range h.handlers {
+		x.OnMessage(event)
+	}
+}
+
+func (h *teeEventHandler) OnContentDelta(event StreamEvent) {
+	for _, x := range h.handlers {
+		x.OnContentDelta(event)
+	}
+}
+
+func (h *teeEventHandler) OnToolUse(event StreamEvent) {
+	for _, x := range h.handlers {
+		x.OnToolUse(event)
+	}
+}
+
+func (h *teeEventHandler) OnResult(event StreamEvent) {
+	for _, x := range h.handlers {
+		x.OnResult(event)
+	}
+}
+
+func (h *teeEventHandler) OnError(event StreamEvent) {
+	for _, x := range h.handlers {
+		x.OnError(event)
+	}
+}
+
+func (h *teeEventHandler) OnThinking(event StreamEvent) {
+	for _, x := range h.handlers {
+		x.OnThinking(event)
+	}
+}
+
+// streamResult returns the final text of an execution: the result of the
+// result event, otherwise the streamed text. Without either, the output is
+// not a stream the parser understands and is returned as the plain
+// Execute would, so it is never mistaken for an empty answer.
+func streamResult(events []StreamEvent, raw string) string {
+	for i := len(events) - 1; i >= 0; i-- {
+		if events[i].Type != EventTypeResult {
+			continue
+		}
+		var result struct {
+			Result string `json:"result"`
+		}
+		if err := json.Unmarshal(events[i].Data, &result); err == nil && result.Result != "" {
+			return result.Result
+		}
+		break
+	}
+
+	if text := ExtractTextFromContentDeltas(events); text != "" {
+		return text
+	}
+	if jsonStr, err := extractJSONBlock(raw); err == nil {
+		return jsonStr
+	}
+	return raw
+}
+
+// extractStreamThinking joins the text of the thinking events
+func extractStreamThinking(events []StreamEvent) string {
+	var parts []string
+	for _, event := range events {
+		if event.Type != EventTypeThinking || len(event.Data) == 0 {
+			continue
+		}
+		var thinking struct {
+			Text     string `json:"text"`
+			Thinking string `json:"thinking"`
+		}
+		if err := json.Unmarshal(event.Data, &thinking); err == nil {
+			parts = append(parts, thinking.Text+thinking.Thinking)
+		}
+	}
+	return strings.TrimSpace(strings.Join(parts, ""))
+}
+
+// extractStreamUsage reads the token usage reported by the result event
+func extractStreamUsage(events []StreamEvent) *TokenUsage {
+	for i := len(events) - 1; i >= 0; i-- {
+		if events[i].Type != EventTypeResult || len(events[i].Data) == 0 {
+			continue
+		}
+		var result struct {
+			Usage *struct {
+				InputTokens              int `json:"input_tokens"`
+				CacheCreationInputTokens int `json:"cache_creation_input_tokens"`
+				CacheReadInputTokens     int `json:"cache_read_input_tokens"`
+				OutputTokens             int `json:"output_tokens"`
+			} `json:"usage"`
+			CostUSD float64 `json:"total_cost_usd"`
+		}
+		if err := json.Unmarshal(events[i].Data, &result); err != nil || result.Usage == nil {
+			return nil
+		}
+		// Cached prompt tokens are input too, billed at other rates
+		input := result.Usage.InputTokens + result.Usage.CacheCreationInputTokens + result.Usage.CacheReadInputTokens
+		return &TokenUsage{
+			InputTokens:  input,
+			OutputTokens: result.Usage.OutputTokens,
+			TotalTokens:  input + result.Usage.OutputTokens,
+			CostUSD:      result.CostUSD,
+		}
+	}
+	return nil
+}
@@ -2,19 +2,28 @@
 package config
 
 import (
+	"path/filepath"
 	"time"
 )
 
 // Config represents the complete configuration
 type Config struct {
-	Version   string         `yaml:"version"`
-	AIBackend string         `yaml:"ai_backend"` // "claude" or "crush"
-	Claude    ClaudeConfig   `yaml:"claude"`
-	Crush     CrushConfig    `yaml:"crush"`
-	Skills    []SkillConfig  `yaml:"skills"`
-	Platform  PlatformConfig `yaml:"platform"`
-	Global    GlobalConfig   `yaml:"global"`
-	Advanced  AdvancedConfig `yaml:"advanced,omitempty"`
+	Version     string         `yaml:"version"`
+	AIBackend   string         `yaml:"ai_backend"` // "claude", "crush", "api", "replay" or "chain"
+	Claude      ClaudeConfig   `yaml:"claude"`
+	Crush       CrushConfig    `yaml:"crush"`
+	API         APIConfig      `yaml:"api"`
+	Replay      ReplayConfig   `yaml:"replay,omitempty"`
+	Chain       ChainConfig    `yaml:"chain,omitempty"`
+	Skills      []SkillConfig  `yaml:"skills"`
+	Platform    PlatformConfig `yaml:"platform"`
+	Global      GlobalConfig   `yaml:"global"`
+	QualityGate QualityGate    `yaml:"quality_gate,omitempty"`
+	Budget      BudgetConfig   `yaml:"budget,omitempty"`
+	Retry       RetryConfig    `yaml:"retry,omitempty"`
+	Server      ServerConfig   `yaml:"server,omitempty"`
+	MCP         MCPConfig      `yaml:"mcp,omitempty"`
+	Advanced    AdvancedConfig `yaml:"advanced,omitempty"`
 }
 
 // ClaudeConfig contains Claude-specific settings
@@ -44,20 +53,59 @@ type CrushConfig struct {
 	OutputFormat string `yaml:"output_format"` // json, text
 }
 
+// APIConfig contains settings for calling a model HTTP API directly
+type APIConfig struct {
+	Provider          string  `yaml:"provider"`                       // anthropic, openai (any OpenAI-compatible endpoint)
+	Model             string  `yaml:"model"`                          // e.g., claude-sonnet-4-20250514, gpt-4o, llama3:70b
+	BaseURL           string  `yaml:"base_url,omitempty"`             // e.g., http://localhost:11434/v1 for Ollama
+	APIKeyEnv         string  `yaml:"api_key_env,omitempty"`          // Env var holding the API key (default: ANTHROPIC_API_KEY / OPENAI_API_KEY)
+	MaxTokens         int     `yaml:"max_tokens,omitempty"`           // Maximum output tokens per request (default: 4096)
+	MaxBudgetUSD      float64 `yaml:"max_budget_usd,omitempty"`       // Maximum spending per request
+	InputCostPerMTok  float64 `yaml:"input_cost_per_mtok,omitempty"`  // USD per million input tokens; overrides built-in prices
+	OutputCostPerMTok float64 `yaml:"output_cost_per_mtok,omitempty"` // USD per million output tokens; overrides built-in prices
+	Timeout           string  `yaml:"timeout,omitempty"`              // Go duration format
+}
+
+// ReplayConfig contains settings for the record/replay test backend
+type ReplayConfig struct {
+	Dir     string `yaml:"dir"`               // Fixture directory, relative to the repository root
+	Mode    string `yaml:"mode"`              // replay (default), record, auto
+	Backend string `yaml:"backend,omitempty"` // Backend recorded from: claude (default), crush, api
+}
+
+// ChainConfig contains settings for the fallback and routing chain
+type ChainConfig struct {
+	Backends []string      `yaml:"backends"`         // Backends tried in order: claude, crush, api, replay
+	Routes   []RouteConfig `yaml:"routes,omitempty"` // First matching route picks backend and model
+}
+
+// RouteConfig routes matching executions to a backend and model
+type RouteConfig struct {
+	Skills       []string `yaml:"skills,omitempty"`         // Match when any requested skill is listed
+	Operation    string   `yaml:"operation,omitempty"`      // Match review, analyze or test-gen
+	MaxDiffLines int      `yaml:"max_diff_lines,omitempty"` // Match diffs with at most this many changed lines
+	Backend      string   `yaml:"backend,omitempty"`        // Backend tried first (default: first in chain)
+	Model        string   `yaml:"model,omitempty"`          // Model for that backend
+}
+
 // SkillConfig defines a skill configuration
 type SkillConfig struct {
-	Name     string         `yaml:"name"`
-	Path     string         `yaml:"path"`
-	Enabled  bool           `yaml:"enabled"`
-	Priority int            `yaml:"priority,omitempty"`
-	Config   map[string]any `yaml:"config,omitempty"`
+	Name      string         `yaml:"name"`
+	Path      string         `yaml:"path"`
+	Enabled   bool           `yaml:"enabled"`
+	Priority  int            `yaml:"priority,omitempty"`   // Higher priority skills are listed first
+	Timeout   string         `yaml:"timeout,omitempty"`    // Per-skill execution timeout (Go duration)
+	BudgetUSD float64        `yaml:"budget_usd,omitempty"` // Per-skill budget; overrides claude.max_budget_usd
+	Config    map[string]any `yaml:"config,omitempty"`
 }
 
 // PlatformConfig contains platform-specific settings
 type PlatformConfig struct {
-	GitHub GitHubConfig `yaml:"github"`
-	Gitee  GiteeConfig  `yaml:"gitee"`
-	GitLab GitLabConfig `yaml:"gitlab"`
+	GitHub    GitHubConfig    `yaml:"github"`
+	Gitee     GiteeConfig     `yaml:"gitee"`
+	GitLab    GitLabConfig    `yaml:"gitlab"`
+	Bitbucket BitbucketConfig `yaml:"bitbucket"`
+	Jenkins   JenkinsConfig   `yaml:"jenkins,omitempty"`
 }
 
 // GitHubConfig contains GitHub-specific settings
@@ -88,17 +136,249 @@ type GitLabConfig struct {
 	APIURL                 string `yaml:"api_url,omitempty"` // For GitLab self-hosted
 }
 
+// BitbucketConfig contains Bitbucket Cloud / Data Center settings
+type BitbucketConfig struct {
+	Token       string `yaml:"token,omitempty"`    // Access token or app password (usually from env)
+	Username    string `yaml:"username,omitempty"` // Set to use basic auth with an app password
+	PostComment bool   `yaml:"post_comment"`
+	FailOnError bool   `yaml:"fail_on_error"`
+	APIURL      string `yaml:"api_url,omitempty"` // For Bitbucket Data Center
+}
+
+// JenkinsConfig contains Jenkins settings, used when builds are reviewed
+// from Jenkins webhooks
+type JenkinsConfig struct {
+	URL      string `yaml:"url,omitempty"`      // Jenkins base URL (usually from env)
+	Username string `yaml:"username,omitempty"` // User the API token belongs to
+	Token    string `yaml:"token,omitempty"`    // API token (usually from env)
+}
+
 // GlobalConfig contains global settings
 type GlobalConfig struct {
 	LogLevel       string            `yaml:"log_level"` // debug, info, warn, error
 	CacheDir       string            `yaml:"cache_dir"`
 	EnableCache    bool              `yaml:"enable_cache"`
+	CacheTTL       string            `yaml:"cache_ttl,omitempty"`         // e.g. "24h"
+	CacheMaxSizeMB int               `yaml:"cache_max_size_mb,omitempty"` // 0 = unbounded
 	ParallelSkills int               `yaml:"parallel_skills"`
 	DiffContext    int               `yaml:"diff_context"`
+	MaxChunkKB     int               `yaml:"max_chunk_kb,omitempty"` // Max diff size per AI call; 0 = default
 	Exclude        []string          `yaml:"exclude"`
 	Env            map[string]string `yaml:"env,omitempty"`
 }
 
+// QualityGate defines when review findings fail the CI job
+type QualityGate struct {
+	// MaxIssues maps a severity to the maximum number of issues allowed,
+	// e.g. {critical: 0} fails on any critical issue
+	MaxIssues map[string]int `yaml:"max_issues,omitempty"`
+	// FailOnCategories fails on any issue in these categories
+	FailOnCategories []string `yaml:"fail_on_categories,omitempty"`
+}
+
+// Enabled returns true if any gate rule is configured
+func (q *QualityGate) Enabled() bool {
+	return len(q.MaxIssues) > 0 || len(q.FailOnCategories) > 0
+}
+
+// BudgetConfig defines spending limits enforced across AI executions.
+// Spend is recorded in a ledger in the cache directory, so the per-PR and
+// per-day limits hold across runs.
+type BudgetConfig struct {
+	PerRunUSD float64 `yaml:"per_run_usd,omitempty"` // Limit for one review, analysis or test generation
+	PerPRUSD  float64 `yaml:"per_pr_usd,omitempty"`  // Limit across all runs on a pull request
+	PerDayUSD float64 `yaml:"per_day_usd,omitempty"` // Limit per repository per UTC day
+	// DowngradeModel is used instead of the configured model of
+	// DowngradeBackend once DowngradeAt of any limit is spent
+	DowngradeModel   string  `yaml:"downgrade_model,omitempty"`
+	DowngradeBackend string  `yaml:"downgrade_backend,omitempty"` // Backend of the downgrade model (default: see Config.GetDowngradeBackend)
+	DowngradeAt      float64 `yaml:"downgrade_at,omitempty"`      // Fraction of a limit (default 0.8)
+}
+
+// Enabled returns true if any spending limit is configured
+func (b *BudgetConfig) Enabled() bool {
+	return b.PerRunUSD > 0 || b.PerPRUSD > 0 || b.PerDayUSD > 0
+}
+
+// RetryConfig defines how transient AI and platform failures are retried:
+// exponential backoff with jitter, or the delay the server asks for
+type RetryConfig struct {
+	// MaxRetries is the number of retries after the first attempt
+	// (default: claude.max_retries); negative disables retries
+	MaxRetries int    `yaml:"max_retries,omitempty"`
+	BaseDelay  string `yaml:"base_delay,omitempty"` // Delay before the first retry (default: 1s)
+	MaxDelay   string `yaml:"max_delay,omitempty"`  // Longest delay between attempts (default: 2m)
+}
+
+// GetBaseDelay returns the delay before the first retry
+// Default: 1 second
+func (r *RetryConfig) GetBaseDelay() time.Duration {
+	if d, err := time.ParseDuration(r.BaseDelay); err == nil && d > 0 {
+		return d
+	}
+	return time.Second
+}
+
+// GetMaxDelay returns the longest delay between attempts
+// Default: 2 minutes
+func (r *RetryConfig) GetMaxDelay() time.Duration {
+	if d, err := time.ParseDuration(r.MaxDelay); err == nil && d > 0 {
+		return d
+	}
+	return 2 * time.Minute
+}
+
+// ServerConfig configures the webhook server of the serve command
+type ServerConfig struct {
+	Address         string   `yaml:"address,omitempty"`          // Listen address (default: :8080)
+	Operations      []string `yaml:"operations,omitempty"`       // Run on PR events: review, analyze (default: review)
+	MaxConcurrent   int      `yaml:"max_concurrent,omitempty"`   // Jobs run at once (default: 4)
+	JobTimeout      string   `yaml:"job_timeout,omitempty"`      // Limit for one job attempt (default: 30m)
+	ShutdownTimeout string   `yaml:"shutdown_timeout,omitempty"` // Wait for running jobs on shutdown (default: 30s)
+	QueueDir        string   `yaml:"queue_dir,omitempty"`        // Job store (default: <cache_dir>/queue)
+	JobRetries      int      `yaml:"job_retries,omitempty"`      // Retries of a failed job (default: 2, negative: none)
+	JobRetryDelay   string   `yaml:"job_retry_delay,omitempty"`  // Delay before the first job retry (default: 2m)
+	// APIToken is the bearer token of the job API (/jobs); without one the
+	// API is disabled. Prefer CICD_SERVER_TOKEN.
+	APIToken string `yaml:"api_token,omitempty"`
+	// Secrets verify webhook deliveries. Platforms without a secret are
+	// not accepted.
+	Secrets WebhookSecrets `yaml:"secrets,omitempty"`
+	ChatOps ChatOpsConfig  `yaml:"chatops,omitempty"`
+}
+
+// ChatOpsConfig configures /ai commands in pull request comments
+type ChatOpsConfig struct {
+	Enabled bool `yaml:"enabled,omitempty"`
+	// Users maps commenter logins, or "platform:login", to roles: viewer
+	// runs the AI commands, developer and admin may also /ai ignore.
+	Users       map[string][]string `yaml:"users,omitempty"`
+	DefaultRole string              `yaml:"default_role,omitempty"` // Role of unlisted commenters (default: none)
+	AuditLog    string              `yaml:"audit_log,omitempty"`    // File recording permission checks (default: none)
+}
+
+// WebhookSecrets are the per-platform webhook secrets (usually from env)
+type WebhookSecrets struct {
+	GitHub    string `yaml:"github,omitempty"`    // HMAC key of X-Hub-Signature-256
+	GitLab    string `yaml:"gitlab,omitempty"`    // Expected X-Gitlab-Token
+	Gitee     string `yaml:"gitee,omitempty"`     // HMAC key of X-Gitee-Token
+	Bitbucket string `yaml:"bitbucket,omitempty"` // HMAC key of X-Hub-Signature
+	Jenkins   string `yaml:"jenkins,omitempty"`   // Expected bearer token
+}
+
+// GetAddress returns the listen address
+// Default: :8080
+func (s *ServerConfig) GetAddress() string {
+	if s.Address != "" {
+		return s.Address
+	}
+	return ":8080"
+}
+
+// GetOperations returns the operations run on PR events
+// Default: review
+func (s *ServerConfig) GetOperations() []string {
+	if len(s.Operations) > 0 {
+		return s.Operations
+	}
+	return []string{"review"}
+}
+
+// GetMaxConcurrent returns the number of jobs run at once
+// Default: 4
+func (s *ServerConfig) GetMaxConcurrent() int {
+	if s.MaxConcurrent > 0 {
+		return s.MaxConcurrent
+	}
+	return 4
+}
+
+// GetJobTimeout returns the time limit of one job
+// Default: 30 minutes
+func (s *ServerConfig) GetJobTimeout() time.Duration {
+	if d, err := time.ParseDuration(s.JobTimeout); err == nil && d > 0 {
+		return d
+	}
+	return 30 * time.Minute
+}
+
+// GetShutdownTimeout returns how long shutdown waits for running jobs
+// Default: 30 seconds
+func (s *ServerConfig) GetShutdownTimeout() time.Duration {
+	if d, err := time.ParseDuration(s.ShutdownTimeout); err == nil && d > 0 {
+		return d
+	}
+	return 30 * time.Second
+}
+
+// GetQueueDir returns the directory of the job store
+// Default: queue under cacheDir
+func (s *ServerConfig) GetQueueDir(cacheDir string) string {
+	if s.QueueDir != "" {
+		return s.QueueDir
+	}
+	return filepath.Join(cacheDir, "queue")
+}
+
+// GetJobRetries returns how often a failed job is retried
+// Default: 2; a negative value disables retries
+func (s *ServerConfig) GetJobRetries() int {
+	switch {
+	case s.JobRetries < 0:
+		return 0
+	case s.JobRetries == 0:
+		return 2
+	default:
+		return s.JobRetries
+	}
+}
+
+// GetJobRetryDelay returns the delay before the first job retry
+// Default: 2 minutes
+func (s *ServerConfig) GetJobRetryDelay() time.Duration {
+	if d, err := time.ParseDuration(s.JobRetryDelay); err == nil && d > 0 {
+		return d
+	}
+	return 2 * time.Minute
+}
+
+// MCPConfig configures the HTTP transport of mcp-server (MCP_TRANSPORT=http).
+// Callers authenticate with a bearer token or a client certificate; at
+// least one must be configured.
+type MCPConfig struct {
+	Address string `yaml:"address,omitempty"` // Listen address (default: :8080); MCP_SERVER_ADDR overrides
+	// TokensFile holds "<identity> <token>" lines; MCP_TOKENS_FILE
+	// overrides
+	TokensFile   string `yaml:"tokens_file,omitempty"`
+	TLSCertFile  string `yaml:"tls_cert_file,omitempty"`  // Serve HTTPS with this certificate
+	TLSKeyFile   string `yaml:"tls_key_file,omitempty"`   // Key of tls_cert_file
+	ClientCAFile string `yaml:"client_ca_file,omitempty"` // Accept client certificates of this CA; the CN is the identity
+	// Users maps identities to roles: viewer calls the read tools,
+	// developer and admin may also post review comments
+	Users          map[string][]string `yaml:"users,omitempty"`
+	AuditLog       string              `yaml:"audit_log,omitempty"`       // File recording tool calls and sessions (default: none)
+	SessionTimeout string              `yaml:"session_timeout,omitempty"` // End idle sessions (default: 30m)
+	AllowedOrigins []string            `yaml:"allowed_origins,omitempty"` // Browser origins allowed to connect (default: none)
+}
+
+// GetAddress returns the listen address
+// Default: :8080
+func (m *MCPConfig) GetAddress() string {
+	if m.Address != "" {
+		return m.Address
+	}
+	return ":8080"
+}
+
+// GetSessionTimeout returns how long an idle session lives
+// Default: 30 minutes
+func (m *MCPConfig) GetSessionTimeout() time.Duration {
+	if d, err := time.ParseDuration(m.SessionTimeout); err == nil && d > 0 {
+		return d
+	}
+	return 30 * time.Minute
+}
+
 // AdvancedConfig contains advanced/experimental settings
 type AdvancedConfig struct {
 	MCPServers []MCPServer      `yaml:"mcp_servers,omitempty"`
@@ -155,6 +435,18 @@ func (c *ClaudeConfig) GetMaxRetries() int {
 	return 3
 }
 
+// GetCacheTTL returns the cache entry TTL as a time.Duration
+// Default: 24 hours
+func (g *GlobalConfig) GetCacheTTL() time.Duration {
+	if g.CacheTTL == "" {
+		return 24 * time.Hour
+	}
+	if ttl, err := time.ParseDuration(g.CacheTTL); err == nil && ttl > 0 {
+		return ttl
+	}
+	return 24 * time.Hour
+}
+
 // GetTimeout returns the timeout as a time.Duration for Crush
 func (c *CrushConfig) GetTimeout() (time.Duration, error) {
 	if c.Timeout == "" {
@@ -163,6 +455,35 @@ func (c *CrushConfig) GetTimeout() (time.Duration, error) {
 	return time.ParseDuration(c.Timeout)
 }
 
+// GetTimeout returns the timeout as a time.Duration for the model API
+func (c *APIConfig) GetTimeout() (time.Duration, error) {
+	if c.Timeout == "" {
+		return 5 * time.Minute, nil // Default timeout
+	}
+	return time.ParseDuration(c.Timeout)
+}
+
+// GetDowngradeBackend returns the backend budget.downgrade_model belongs
+// to: budget.downgrade_backend if set, otherwise the backend that runs
+// executions first (the first chain backend, or the backend recorded from
+// in replay mode)
+func (c *Config) GetDowngradeBackend() string {
+	if c.Budget.DowngradeBackend != "" {
+		return c.Budget.DowngradeBackend
+	}
+	backend := c.AIBackend
+	if backend == "chain" && len(c.Chain.Backends) > 0 {
+		backend = c.Chain.Backends[0]
+	}
+	if backend == "replay" {
+		backend = c.Replay.Backend
+	}
+	if backend == "" || backend == "replay" {
+		backend = "claude"
+	}
+	return backend
+}
+
 // IsEnabled returns true if a skill is enabled
 func (c *Config) IsEnabled(skillName string) bool {
 	for _, s := range c.Skills {
@@ -184,6 +505,16 @@ func (c *Config) GetEnabledSkills() []string {
 	return enabled
 }
 
+// GetSkill returns the configuration entry for a skill
+func (c *Config) GetSkill(skillName string) (SkillConfig, bool) {
+	for _, s := range c.Skills {
+		if s.Name == skillName {
+			return s, true
+		}
+	}
+	return SkillConfig{}, false
+}
+
 // GetSkillConfig returns configuration for a specific skill
 func (c *Config) GetSkillConfig(skillName string) (map[string]any, bool) {
 	for _, s := range c.Skills {
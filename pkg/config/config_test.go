@@ -170,6 +170,139 @@ func TestValidate(t *testing.T) {
 			},
 			wantErr: true,
 		},
+		{
+			name: "invalid session ttl",
+			cfg: &Config{
+				Version: "2.0",
+				Claude: ClaudeConfig{
+					Model:        "sonnet",
+					MaxBudgetUSD: 5.0,
+					MaxTurns:     50,
+					Timeout:      "30m",
+					SessionTTL:   "-1h",
+				},
+				Global: GlobalConfig{
+					LogLevel:       "info",
+					ParallelSkills: 1,
+					DiffContext:    3,
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "retry base delay above max delay",
+			cfg: &Config{
+				Version: "2.0",
+				Claude: ClaudeConfig{
+					Model:        "sonnet",
+					MaxBudgetUSD: 5.0,
+					MaxTurns:     50,
+					Timeout:      "30m",
+				},
+				Retry: RetryConfig{BaseDelay: "1m", MaxDelay: "10s"},
+				Global: GlobalConfig{
+					LogLevel:       "info",
+					ParallelSkills: 1,
+					DiffContext:    3,
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "invalid retry delay",
+			cfg: &Config{
+				Version: "2.0",
+				Claude: ClaudeConfig{
+					Model:        "sonnet",
+					MaxBudgetUSD: 5.0,
+					MaxTurns:     50,
+					Timeout:      "30m",
+				},
+				Retry: RetryConfig{BaseDelay: "soon"},
+				Global: GlobalConfig{
+					LogLevel:       "info",
+					ParallelSkills: 1,
+					DiffContext:    3,
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "invalid server operation",
+			cfg: &Config{
+				Version: "2.0",
+				Claude: ClaudeConfig{
+					Model:        "sonnet",
+					MaxBudgetUSD: 5.0,
+					MaxTurns:     50,
+					Timeout:      "30m",
+				},
+				Server: ServerConfig{Operations: []string{"review", "test-gen"}},
+				Global: GlobalConfig{
+					LogLevel:       "info",
+					ParallelSkills: 1,
+					DiffContext:    3,
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "invalid server job timeout",
+			cfg: &Config{
+				Version: "2.0",
+				Claude: ClaudeConfig{
+					Model:        "sonnet",
+					MaxBudgetUSD: 5.0,
+					MaxTurns:     50,
+					Timeout:      "30m",
+				},
+				Server: ServerConfig{JobTimeout: "-1m"},
+				Global: GlobalConfig{
+					LogLevel:       "info",
+					ParallelSkills: 1,
+					DiffContext:    3,
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "invalid server job retry delay",
+			cfg: &Config{
+				Version: "2.0",
+				Claude: ClaudeConfig{
+					Model:        "sonnet",
+					MaxBudgetUSD: 5.0,
+					MaxTurns:     50,
+					Timeout:      "30m",
+				},
+				Server: ServerConfig{JobRetryDelay: "soon"},
+				Global: GlobalConfig{
+					LogLevel:       "info",
+					ParallelSkills: 1,
+					DiffContext:    3,
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "invalid chatops role",
+			cfg: &Config{
+				Version: "2.0",
+				Claude: ClaudeConfig{
+					Model:        "sonnet",
+					MaxBudgetUSD: 5.0,
+					MaxTurns:     50,
+					Timeout:      "30m",
+				},
+				Server: ServerConfig{ChatOps: ChatOpsConfig{Users: map[string][]string{"alice": {"owner"}}}},
+				Global: GlobalConfig{
+					LogLevel:       "info",
+					ParallelSkills: 1,
+					DiffContext:    3,
+				},
+			},
+			wantErr: true,
+		},
 		{
 			name: "max turns too high",
 			cfg: &Config{
@@ -224,6 +357,305 @@ func TestValidate(t *testing.T) {
 			},
 			wantErr: true,
 		},
+		{
+			name: "invalid quality gate severity",
+			cfg: &Config{
+				Version: "2.0",
+				Claude: ClaudeConfig{
+					Model:        "sonnet",
+					MaxBudgetUSD: 5.0,
+					MaxTurns:     50,
+					Timeout:      "30m",
+				},
+				Global: GlobalConfig{
+					LogLevel:       "info",
+					ParallelSkills: 1,
+					DiffContext:    3,
+				},
+				QualityGate: QualityGate{
+					MaxIssues: map[string]int{"blocker": 0},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "negative quality gate limit",
+			cfg: &Config{
+				Version: "2.0",
+				Claude: ClaudeConfig{
+					Model:        "sonnet",
+					MaxBudgetUSD: 5.0,
+					MaxTurns:     50,
+					Timeout:      "30m",
+				},
+				Global: GlobalConfig{
+					LogLevel:       "info",
+					ParallelSkills: 1,
+					DiffContext:    3,
+				},
+				QualityGate: QualityGate{
+					MaxIssues: map[string]int{"high": -1},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "negative budget limit",
+			cfg: &Config{
+				Version: "2.0",
+				Claude: ClaudeConfig{
+					Model:        "sonnet",
+					MaxBudgetUSD: 5.0,
+					MaxTurns:     50,
+					Timeout:      "30m",
+				},
+				Global: GlobalConfig{
+					LogLevel:       "info",
+					ParallelSkills: 1,
+					DiffContext:    3,
+				},
+				Budget: BudgetConfig{PerPRUSD: -1},
+			},
+			wantErr: true,
+		},
+		{
+			name: "invalid budget downgrade threshold",
+			cfg: &Config{
+				Version: "2.0",
+				Claude: ClaudeConfig{
+					Model:        "sonnet",
+					MaxBudgetUSD: 5.0,
+					MaxTurns:     50,
+					Timeout:      "30m",
+				},
+				Global: GlobalConfig{
+					LogLevel:       "info",
+					ParallelSkills: 1,
+					DiffContext:    3,
+				},
+				Budget: BudgetConfig{PerDayUSD: 20, DowngradeModel: "haiku", DowngradeAt: 1.5},
+			},
+			wantErr: true,
+		},
+		{
+			name: "budget with crush backend",
+			cfg: &Config{
+				Version:   "2.0",
+				AIBackend: "crush",
+				Claude: ClaudeConfig{
+					Model:        "sonnet",
+					MaxBudgetUSD: 5.0,
+					MaxTurns:     50,
+					Timeout:      "30m",
+				},
+				Crush: CrushConfig{Provider: "anthropic", Model: "claude-sonnet-4-20250514"},
+				Global: GlobalConfig{
+					LogLevel:       "info",
+					ParallelSkills: 1,
+					DiffContext:    3,
+				},
+				Budget: BudgetConfig{PerPRUSD: 5},
+			},
+			wantErr: true,
+		},
+		{
+			name: "budget downgrade on crush",
+			cfg: &Config{
+				Version:   "2.0",
+				AIBackend: "chain",
+				Claude: ClaudeConfig{
+					Model:        "sonnet",
+					MaxBudgetUSD: 5.0,
+					MaxTurns:     50,
+					Timeout:      "30m",
+				},
+				Crush: CrushConfig{Provider: "anthropic", Model: "claude-sonnet-4-20250514"},
+				Chain: ChainConfig{Backends: []string{"crush", "claude"}},
+				Global: GlobalConfig{
+					LogLevel:       "info",
+					ParallelSkills: 1,
+					DiffContext:    3,
+				},
+				Budget: BudgetConfig{PerPRUSD: 5, DowngradeModel: "haiku"},
+			},
+			wantErr: true,
+		},
+		{
+			name: "invalid skill timeout",
+			cfg: &Config{
+				Version: "2.0",
+				Claude: ClaudeConfig{
+					Model:        "sonnet",
+					MaxBudgetUSD: 5.0,
+					MaxTurns:     50,
+					Timeout:      "30m",
+				},
+				Global: GlobalConfig{
+					LogLevel:       "info",
+					ParallelSkills: 1,
+					DiffContext:    3,
+				},
+				Skills: []SkillConfig{
+					{Name: "security-scanner", Path: "./skills/security-scanner", Timeout: "soon"},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "valid api backend",
+			cfg: &Config{
+				Version:   "2.0",
+				AIBackend: "api",
+				Claude: ClaudeConfig{
+					Model:        "sonnet",
+					MaxBudgetUSD: 5.0,
+					MaxTurns:     50,
+					Timeout:      "30m",
+				},
+				API: APIConfig{
+					Provider: "openai",
+					Model:    "llama3:70b",
+					BaseURL:  "http://localhost:11434/v1",
+				},
+				Global: GlobalConfig{
+					LogLevel:       "info",
+					ParallelSkills: 1,
+					DiffContext:    3,
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "invalid api provider",
+			cfg: &Config{
+				Version:   "2.0",
+				AIBackend: "api",
+				Claude: ClaudeConfig{
+					Model:        "sonnet",
+					MaxBudgetUSD: 5.0,
+					MaxTurns:     50,
+					Timeout:      "30m",
+				},
+				API: APIConfig{
+					Provider: "gemini",
+					Model:    "gemini-2.0-flash",
+				},
+				Global: GlobalConfig{
+					LogLevel:       "info",
+					ParallelSkills: 1,
+					DiffContext:    3,
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "invalid replay mode",
+			cfg: &Config{
+				Version:   "2.0",
+				AIBackend: "replay",
+				Claude: ClaudeConfig{
+					Model:        "sonnet",
+					MaxBudgetUSD: 5.0,
+					MaxTurns:     50,
+					Timeout:      "30m",
+				},
+				Replay: ReplayConfig{Mode: "rewind"},
+				Global: GlobalConfig{
+					LogLevel:       "info",
+					ParallelSkills: 1,
+					DiffContext:    3,
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "valid chain",
+			cfg: &Config{
+				Version:   "2.0",
+				AIBackend: "chain",
+				Claude: ClaudeConfig{
+					Model:        "sonnet",
+					MaxBudgetUSD: 5.0,
+					MaxTurns:     50,
+					Timeout:      "30m",
+				},
+				Chain: ChainConfig{
+					Backends: []string{"claude", "api"},
+					Routes: []RouteConfig{
+						{Skills: []string{"security-scanner"}, Model: "opus"},
+						{Operation: "analyze", Backend: "api"},
+					},
+				},
+				Global: GlobalConfig{
+					LogLevel:       "info",
+					ParallelSkills: 1,
+					DiffContext:    3,
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "chain without backends",
+			cfg: &Config{
+				Version:   "2.0",
+				AIBackend: "chain",
+				Claude: ClaudeConfig{
+					Model:        "sonnet",
+					MaxBudgetUSD: 5.0,
+					MaxTurns:     50,
+					Timeout:      "30m",
+				},
+				Global: GlobalConfig{
+					LogLevel:       "info",
+					ParallelSkills: 1,
+					DiffContext:    3,
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "chain route to backend outside the chain",
+			cfg: &Config{
+				Version:   "2.0",
+				AIBackend: "chain",
+				Claude: ClaudeConfig{
+					Model:        "sonnet",
+					MaxBudgetUSD: 5.0,
+					MaxTurns:     50,
+					Timeout:      "30m",
+				},
+				Chain: ChainConfig{
+					Backends: []string{"claude"},
+					Routes:   []RouteConfig{{Operation: "review", Backend: "crush"}},
+				},
+				Global: GlobalConfig{
+					LogLevel:       "info",
+					ParallelSkills: 1,
+					DiffContext:    3,
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "chain containing itself",
+			cfg: &Config{
+				Version:   "2.0",
+				AIBackend: "chain",
+				Claude: ClaudeConfig{
+					Model:        "sonnet",
+					MaxBudgetUSD: 5.0,
+					MaxTurns:     50,
+					Timeout:      "30m",
+				},
+				Chain: ChainConfig{Backends: []string{"claude", "chain"}},
+				Global: GlobalConfig{
+					LogLevel:       "info",
+					ParallelSkills: 1,
+					DiffContext:    3,
+				},
+			},
+			wantErr: true,
+		},
 	}
 
 	for _, tt := range tests {
@@ -248,6 +680,70 @@ func TestGetTimeout(t *testing.T) {
 	}
 }
 
+func TestGetDowngradeBackend(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  Config
+		want string
+	}{
+		{"default", Config{}, "claude"},
+		{"ai backend", Config{AIBackend: "api"}, "api"},
+		{"first chain backend", Config{AIBackend: "chain", Chain: ChainConfig{Backends: []string{"crush", "claude"}}}, "crush"},
+		{"recorded backend", Config{AIBackend: "replay", Replay: ReplayConfig{Backend: "api"}}, "api"},
+		{"configured", Config{AIBackend: "chain", Chain: ChainConfig{Backends: []string{"api", "claude"}}, Budget: BudgetConfig{DowngradeBackend: "claude"}}, "claude"},
+	}
+	for _, tt := range tests {
+		if got := tt.cfg.GetDowngradeBackend(); got != tt.want {
+			t.Errorf("%s: GetDowngradeBackend() = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestServerJobRetries(t *testing.T) {
+	tests := []struct {
+		retries int
+		want    int
+	}{
+		{retries: 0, want: 2},
+		{retries: 5, want: 5},
+		{retries: -1, want: 0},
+	}
+	for _, tt := range tests {
+		cfg := ServerConfig{JobRetries: tt.retries}
+		if got := cfg.GetJobRetries(); got != tt.want {
+			t.Errorf("GetJobRetries() with %d = %d, want %d", tt.retries, got, tt.want)
+		}
+	}
+}
+
+func TestMCPConfigValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     MCPConfig
+		wantErr bool
+	}{
+		{name: "empty", cfg: MCPConfig{}},
+		{
+			name: "tokens and mTLS",
+			cfg: MCPConfig{
+				TokensFile: "tokens", TLSCertFile: "cert.pem", TLSKeyFile: "key.pem", ClientCAFile: "ca.pem",
+				Users: map[string][]string{"ci": {"developer"}}, SessionTimeout: "1h",
+			},
+		},
+		{name: "invalid role", cfg: MCPConfig{Users: map[string][]string{"ci": {"owner"}}}, wantErr: true},
+		{name: "invalid session timeout", cfg: MCPConfig{SessionTimeout: "-5m"}, wantErr: true},
+		{name: "certificate without key", cfg: MCPConfig{TLSCertFile: "cert.pem"}, wantErr: true},
+		{name: "client CA without TLS", cfg: MCPConfig{ClientCAFile: "ca.pem"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.cfg.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
 func TestIsEnabled(t *testing.T) {
 	cfg := &Config{
 		Skills: []SkillConfig{
@@ -365,6 +861,7 @@ global:
 	t.Setenv("CICD_MODEL", "opus")
 	t.Setenv("CICD_MAX_BUDGET", "15.0")
 	t.Setenv("CICD_LOG_LEVEL", "debug")
+	t.Setenv("CICD_REPLAY_MODE", "record")
 	t.Setenv("GITHUB_TOKEN", "test-token")
 
 	cfg, err := LoadWithOverrides(configPath)
@@ -381,6 +878,9 @@ global:
 	if cfg.Global.LogLevel != "debug" {
 		t.Errorf("LogLevel = %s, want debug", cfg.Global.LogLevel)
 	}
+	if cfg.Replay.Mode != "record" {
+		t.Errorf("Replay.Mode = %s, want record", cfg.Replay.Mode)
+	}
 	if cfg.Platform.GitHub.Token != "test-token" {
 		t.Errorf("GitHub.Token = %s, want test-token", cfg.Platform.GitHub.Token)
 	}
@@ -134,6 +134,7 @@ func defaultConfig() *Config {
 			LogLevel:       "info",
 			CacheDir:       ".cicd-cache",
 			EnableCache:    true,
+			CacheMaxSizeMB: 100,
 			ParallelSkills: 1,
 			DiffContext:    3,
 		},
@@ -204,6 +205,9 @@ func LoadWithOverrides(path string) (*Config, error) {
 	if val := os.Getenv("CICD_TIMEOUT"); val != "" {
 		cfg.Claude.Timeout = val
 	}
+	if val := os.Getenv("CICD_REPLAY_MODE"); val != "" {
+		cfg.Replay.Mode = val
+	}
 	if val := os.Getenv("CICD_LOG_LEVEL"); val != "" {
 		cfg.Global.LogLevel = val
 	}
@@ -3,6 +3,7 @@ package config
 
 import (
 	"fmt"
+	"net/url"
 	"strings"
 	"time"
 )
@@ -44,6 +45,27 @@ func (c *Config) Validate() error {
 		}
 	}
 
+	// Validate API config if using the direct API backend
+	if strings.EqualFold(c.AIBackend, "api") {
+		if err := c.API.Validate(); err != nil {
+			return fmt.Errorf("api config: %w", err)
+		}
+	}
+
+	// Validate replay config if using the replay backend
+	if strings.EqualFold(c.AIBackend, "replay") {
+		if err := c.Replay.Validate(); err != nil {
+			return fmt.Errorf("replay config: %w", err)
+		}
+	}
+
+	// Validate chain config if using the fallback chain
+	if strings.EqualFold(c.AIBackend, "chain") {
+		if err := c.Chain.Validate(); err != nil {
+			return fmt.Errorf("chain config: %w", err)
+		}
+	}
+
 	// Validate skills
 	for i, skill := range c.Skills {
 		if err := skill.Validate(); err != nil {
@@ -56,6 +78,38 @@ func (c *Config) Validate() error {
 		return fmt.Errorf("global config: %w", err)
 	}
 
+	// Validate quality gate
+	if err := c.QualityGate.Validate(); err != nil {
+		return fmt.Errorf("quality_gate: %w", err)
+	}
+
+	// Validate budget limits
+	if err := c.Budget.Validate(); err != nil {
+		return fmt.Errorf("budget: %w", err)
+	}
+	// Crush cannot cap the spend of an execution
+	if c.Budget.Enabled() && c.AIBackend == "crush" {
+		return fmt.Errorf("budget: spending limits require the claude or api backend; crush cannot enforce them")
+	}
+	if c.Budget.Enabled() && c.Budget.DowngradeModel != "" && c.GetDowngradeBackend() == "crush" {
+		return fmt.Errorf("budget: downgrade_backend crush cannot enforce spending limits; set it to claude or api")
+	}
+
+	// Validate retry policy
+	if err := c.Retry.Validate(); err != nil {
+		return fmt.Errorf("retry: %w", err)
+	}
+
+	// Validate webhook server
+	if err := c.Server.Validate(); err != nil {
+		return fmt.Errorf("server: %w", err)
+	}
+
+	// Validate MCP HTTP transport
+	if err := c.MCP.Validate(); err != nil {
+		return fmt.Errorf("mcp: %w", err)
+	}
+
 	// Validate advanced config if present
 	if c.Advanced.Memory.Enabled {
 		if err := c.Advanced.Memory.Validate(); err != nil {
@@ -77,9 +131,12 @@ func (c *Config) ValidateAIBackend() error {
 	validBackends := map[string]bool{
 		"claude": true,
 		"crush":  true,
+		"api":    true,
+		"replay": true,
+		"chain":  true,
 	}
 	if !validBackends[strings.ToLower(c.AIBackend)] {
-		return fmt.Errorf("invalid ai_backend: %s (must be 'claude' or 'crush')", c.AIBackend)
+		return fmt.Errorf("invalid ai_backend: %s (must be 'claude', 'crush', 'api', 'replay' or 'chain')", c.AIBackend)
 	}
 
 	// Normalize to lowercase
@@ -128,6 +185,13 @@ func (c *ClaudeConfig) Validate() error {
 		return fmt.Errorf("invalid output_format: %s (must be text, json, or stream-json)", c.OutputFormat)
 	}
 
+	// Validate session TTL format if specified
+	if c.SessionTTL != "" {
+		if ttl, err := time.ParseDuration(c.SessionTTL); err != nil || ttl <= 0 {
+			return fmt.Errorf("invalid session_ttl: %q (must be a positive duration)", c.SessionTTL)
+		}
+	}
+
 	return nil
 }
 
@@ -164,6 +228,127 @@ func (c *CrushConfig) Validate() error {
 	return nil
 }
 
+// Validate validates the model API configuration
+func (c *APIConfig) Validate() error {
+	// Provider is optional (defaults to anthropic)
+	if c.Provider == "" {
+		c.Provider = "anthropic"
+	}
+	c.Provider = strings.ToLower(c.Provider)
+	if c.Provider != "anthropic" && c.Provider != "openai" {
+		return fmt.Errorf("invalid api provider: %s (must be anthropic or openai)", c.Provider)
+	}
+
+	// Model is required
+	if c.Model == "" {
+		return fmt.Errorf("api model is required")
+	}
+
+	if c.BaseURL != "" {
+		u, err := url.Parse(c.BaseURL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("invalid api base_url: %s", c.BaseURL)
+		}
+	}
+
+	if c.MaxTokens < 0 {
+		return fmt.Errorf("api max_tokens must be non-negative")
+	}
+	if c.MaxBudgetUSD < 0 || c.InputCostPerMTok < 0 || c.OutputCostPerMTok < 0 {
+		return fmt.Errorf("api budget and costs must be non-negative")
+	}
+
+	// Validate timeout format if specified
+	if c.Timeout != "" {
+		if _, err := time.ParseDuration(c.Timeout); err != nil {
+			return fmt.Errorf("invalid api timeout format: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// Validate validates the replay configuration
+func (c *ReplayConfig) Validate() error {
+	if c.Dir == "" {
+		c.Dir = "testdata/ai-fixtures"
+	}
+
+	if c.Mode == "" {
+		c.Mode = "replay"
+	}
+	validModes := map[string]bool{
+		"replay": true,
+		"record": true,
+		"auto":   true,
+	}
+	if !validModes[c.Mode] {
+		return fmt.Errorf("invalid replay mode: %s (must be replay, record, or auto)", c.Mode)
+	}
+
+	validBackends := map[string]bool{
+		"":       true,
+		"claude": true,
+		"crush":  true,
+		"api":    true,
+	}
+	if !validBackends[strings.ToLower(c.Backend)] {
+		return fmt.Errorf("invalid replay backend: %s (must be claude, crush, or api)", c.Backend)
+	}
+
+	return nil
+}
+
+// Validate validates the fallback chain configuration
+func (c *ChainConfig) Validate() error {
+	if len(c.Backends) == 0 {
+		return fmt.Errorf("chain backends are required")
+	}
+
+	validBackends := map[string]bool{
+		"claude": true,
+		"crush":  true,
+		"api":    true,
+		"replay": true,
+	}
+	seen := make(map[string]bool)
+	for i, b := range c.Backends {
+		b = strings.ToLower(b)
+		if !validBackends[b] {
+			return fmt.Errorf("invalid chain backend: %s (must be claude, crush, api, or replay)", c.Backends[i])
+		}
+		if seen[b] {
+			return fmt.Errorf("duplicate chain backend: %s", b)
+		}
+		seen[b] = true
+		c.Backends[i] = b
+	}
+
+	validOperations := map[string]bool{
+		"":         true,
+		"review":   true,
+		"analyze":  true,
+		"test-gen": true,
+		"explain":  true,
+	}
+	for i, r := range c.Routes {
+		if r.Backend == "" && r.Model == "" {
+			return fmt.Errorf("routes[%d]: backend or model is required", i)
+		}
+		if r.Backend != "" && !seen[strings.ToLower(r.Backend)] {
+			return fmt.Errorf("routes[%d]: backend %s is not in the chain", i, r.Backend)
+		}
+		if !validOperations[strings.ToLower(r.Operation)] {
+			return fmt.Errorf("routes[%d]: invalid operation: %s (must be review, analyze, test-gen, or explain)", i, r.Operation)
+		}
+		if r.MaxDiffLines < 0 {
+			return fmt.Errorf("routes[%d]: max_diff_lines must be non-negative", i)
+		}
+	}
+
+	return nil
+}
+
 // Validate validates the skill configuration
 func (s *SkillConfig) Validate() error {
 	if s.Name == "" {
@@ -175,6 +360,14 @@ func (s *SkillConfig) Validate() error {
 	if s.Priority < 0 {
 		return fmt.Errorf("skill priority must be non-negative")
 	}
+	if s.Timeout != "" {
+		if t, err := time.ParseDuration(s.Timeout); err != nil || t <= 0 {
+			return fmt.Errorf("invalid skill timeout: %s", s.Timeout)
+		}
+	}
+	if s.BudgetUSD < 0 {
+		return fmt.Errorf("skill budget_usd must be non-negative")
+	}
 	return nil
 }
 
@@ -199,6 +392,20 @@ func (g *GlobalConfig) Validate() error {
 		return fmt.Errorf("parallel_skills must not exceed %d", MaxParallelSkills)
 	}
 
+	// Validate cache settings
+	if g.CacheTTL != "" {
+		if ttl, err := time.ParseDuration(g.CacheTTL); err != nil || ttl <= 0 {
+			return fmt.Errorf("invalid cache_ttl: %s", g.CacheTTL)
+		}
+	}
+	if g.CacheMaxSizeMB < 0 {
+		return fmt.Errorf("cache_max_size_mb must be non-negative")
+	}
+
+	if g.MaxChunkKB < 0 {
+		return fmt.Errorf("max_chunk_kb must be non-negative")
+	}
+
 	// Validate diff context (lines of context around changes)
 	if g.DiffContext < 0 {
 		return fmt.Errorf("diff_context must be non-negative")
@@ -210,6 +417,144 @@ func (g *GlobalConfig) Validate() error {
 	return nil
 }
 
+// Validate validates the quality gate rules
+func (q *QualityGate) Validate() error {
+	validSeverities := map[string]bool{
+		"critical": true,
+		"high":     true,
+		"medium":   true,
+		"low":      true,
+	}
+	for severity, limit := range q.MaxIssues {
+		if !validSeverities[strings.ToLower(severity)] {
+			return fmt.Errorf("invalid severity in max_issues: %s (must be critical, high, medium, or low)", severity)
+		}
+		if limit < 0 {
+			return fmt.Errorf("max_issues.%s must be non-negative", severity)
+		}
+	}
+
+	for _, category := range q.FailOnCategories {
+		if strings.TrimSpace(category) == "" {
+			return fmt.Errorf("fail_on_categories must not contain empty entries")
+		}
+	}
+
+	return nil
+}
+
+// Validate validates the budget limits
+func (b *BudgetConfig) Validate() error {
+	if b.PerRunUSD < 0 || b.PerPRUSD < 0 || b.PerDayUSD < 0 {
+		return fmt.Errorf("budget limits must be non-negative")
+	}
+
+	if b.DowngradeAt == 0 {
+		b.DowngradeAt = 0.8
+	}
+	if b.DowngradeAt < 0 || b.DowngradeAt > 1 {
+		return fmt.Errorf("downgrade_at must be between 0 and 1, got %v", b.DowngradeAt)
+	}
+
+	if b.DowngradeBackend != "" {
+		b.DowngradeBackend = strings.ToLower(b.DowngradeBackend)
+		switch b.DowngradeBackend {
+		case "claude", "crush", "api":
+		default:
+			return fmt.Errorf("invalid downgrade_backend: %s (must be claude, crush or api)", b.DowngradeBackend)
+		}
+	}
+
+	return nil
+}
+
+// Validate validates the retry policy
+func (r *RetryConfig) Validate() error {
+	delays := []struct{ name, value string }{
+		{"base_delay", r.BaseDelay},
+		{"max_delay", r.MaxDelay},
+	}
+	for _, d := range delays {
+		if d.value == "" {
+			continue
+		}
+		if v, err := time.ParseDuration(d.value); err != nil || v <= 0 {
+			return fmt.Errorf("invalid %s: %q (must be a positive duration)", d.name, d.value)
+		}
+	}
+	if r.GetBaseDelay() > r.GetMaxDelay() {
+		return fmt.Errorf("base_delay %s exceeds max_delay %s", r.GetBaseDelay(), r.GetMaxDelay())
+	}
+	return nil
+}
+
+// Validate validates the webhook server configuration
+func (s *ServerConfig) Validate() error {
+	for _, op := range s.Operations {
+		if op != "review" && op != "analyze" {
+			return fmt.Errorf("invalid operation: %q (must be review or analyze)", op)
+		}
+	}
+	if s.MaxConcurrent < 0 {
+		return fmt.Errorf("max_concurrent must be non-negative, got %d", s.MaxConcurrent)
+	}
+	timeouts := []struct{ name, value string }{
+		{"job_timeout", s.JobTimeout},
+		{"shutdown_timeout", s.ShutdownTimeout},
+		{"job_retry_delay", s.JobRetryDelay},
+	}
+	for _, t := range timeouts {
+		if t.value == "" {
+			continue
+		}
+		if v, err := time.ParseDuration(t.value); err != nil || v <= 0 {
+			return fmt.Errorf("invalid %s: %q (must be a positive duration)", t.name, t.value)
+		}
+	}
+	return s.ChatOps.Validate()
+}
+
+// validRoles are the RBAC roles users can be given
+var validRoles = map[string]bool{"viewer": true, "developer": true, "admin": true}
+
+// Validate validates the ChatOps configuration
+func (c *ChatOpsConfig) Validate() error {
+	if c.DefaultRole != "" && !validRoles[c.DefaultRole] {
+		return fmt.Errorf("invalid chatops default_role: %s (must be viewer, developer, or admin)", c.DefaultRole)
+	}
+	for user, roles := range c.Users {
+		for _, role := range roles {
+			if !validRoles[role] {
+				return fmt.Errorf("invalid chatops role for %s: %s (must be viewer, developer, or admin)", user, role)
+			}
+		}
+	}
+	return nil
+}
+
+// Validate validates the MCP HTTP transport configuration
+func (m *MCPConfig) Validate() error {
+	for user, roles := range m.Users {
+		for _, role := range roles {
+			if !validRoles[role] {
+				return fmt.Errorf("invalid role for %s: %s (must be viewer, developer, or admin)", user, role)
+			}
+		}
+	}
+	if m.SessionTimeout != "" {
+		if v, err := time.ParseDuration(m.SessionTimeout); err != nil || v <= 0 {
+			return fmt.Errorf("invalid session_timeout: %q (must be a positive duration)", m.SessionTimeout)
+		}
+	}
+	if (m.TLSCertFile == "") != (m.TLSKeyFile == "") {
+		return fmt.Errorf("tls_cert_file and tls_key_file must be set together")
+	}
+	if m.ClientCAFile != "" && m.TLSCertFile == "" {
+		return fmt.Errorf("client_ca_file requires tls_cert_file and tls_key_file")
+	}
+	return nil
+}
+
 // Validate validates the memory configuration
 func (m *MemoryConfig) Validate() error {
 	if !m.Enabled {
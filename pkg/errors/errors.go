@@ -24,6 +24,9 @@ const (
 	ErrTimeout
 	// ErrBudget indicates budget limit exceeded
 	ErrBudget
+	// ErrUnavailable indicates an AI backend is temporarily unavailable
+	// (rate limited, overloaded, or unreachable)
+	ErrUnavailable
 )
 
 // CICDError is the base error type for all cicd-ai-toolkit errors
@@ -83,7 +86,7 @@ func IsRetryable(err error) bool {
 	}
 
 	switch cicdErr.Type {
-	case ErrPlatform, ErrTimeout:
+	case ErrPlatform, ErrTimeout, ErrUnavailable:
 		return true
 	case ErrClaude:
 		// Retry only for rate limits and timeouts
@@ -129,6 +132,8 @@ func errorTypeString(et ErrorType) string {
 		return "TIMEOUT"
 	case ErrBudget:
 		return "BUDGET"
+	case ErrUnavailable:
+		return "UNAVAILABLE"
 	default:
 		return "UNKNOWN"
 	}
@@ -170,3 +175,14 @@ func TimeoutError(message string, cause error) *CICDError {
 func BudgetError(message string, cause error) *CICDError {
 	return New(ErrBudget, message, cause)
 }
+
+// UnavailableError creates an AI backend unavailable error
+func UnavailableError(message string, cause error) *CICDError {
+	return New(ErrUnavailable, message, cause)
+}
+
+// IsClassified reports whether err or an error it wraps is a CICDError
+func IsClassified(err error) bool {
+	var cicdErr *CICDError
+	return errors.As(err, &cicdErr)
+}
@@ -0,0 +1,170 @@
+// Package mcp provides the authentication of HTTP callers and the
+// role-based authorization and auditing of their requests
+package mcp
+
+import (
+	"bufio"
+	"crypto/subtle"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/cicd-ai-toolkit/cicd-runner/pkg/observability"
+)
+
+// CodeForbidden is the error code of requests the caller's roles do not
+// permit (a JSON-RPC implementation-defined server error)
+const CodeForbidden = -32001
+
+// minTokenLength is the length below which a bearer token is rejected as
+// guessable
+const minTokenLength = 16
+
+// Identity is an authenticated caller
+type Identity struct {
+	ID     string // RBAC user ID: the token's identity or the certificate CN
+	Method string // How the caller was authenticated: "token" or "certificate"
+}
+
+// Authenticator identifies the caller of an HTTP request. It reports false
+// when the request carries no valid credentials.
+type Authenticator func(r *http.Request) (Identity, bool)
+
+// TokenAuth authenticates "Authorization: Bearer <token>" with tokens
+// mapped to identities
+func TokenAuth(tokens map[string]string) Authenticator {
+	return func(r *http.Request) (Identity, bool) {
+		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || token == "" {
+			return Identity{}, false
+		}
+		// Compare with every token so the time taken does not reveal which
+		// one shares a prefix
+		var id string
+		for t, identity := range tokens {
+			if subtle.ConstantTimeCompare([]byte(t), []byte(token)) == 1 {
+				id = identity
+			}
+		}
+		return Identity{ID: id, Method: "token"}, id != ""
+	}
+}
+
+// CertAuth authenticates callers by a client certificate verified by the
+// TLS server; the certificate's common name is the identity
+func CertAuth() Authenticator {
+	return func(r *http.Request) (Identity, bool) {
+		if r.TLS == nil || len(r.TLS.VerifiedChains) == 0 || len(r.TLS.VerifiedChains[0]) == 0 {
+			return Identity{}, false
+		}
+		cn := r.TLS.VerifiedChains[0][0].Subject.CommonName
+		return Identity{ID: cn, Method: "certificate"}, cn != ""
+	}
+}
+
+// AnyAuth returns the identity of the first authenticator that accepts a
+// request
+func AnyAuth(auths ...Authenticator) Authenticator {
+	return func(r *http.Request) (Identity, bool) {
+		for _, auth := range auths {
+			if id, ok := auth(r); ok {
+				return id, true
+			}
+		}
+		return Identity{}, false
+	}
+}
+
+// LoadTokens reads a tokens file of "<identity> <token>" lines; blank lines
+// and lines starting with # are skipped. It returns the tokens mapped to
+// their identities.
+func LoadTokens(path string) (map[string]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open tokens file: %w", err)
+	}
+	defer f.Close()
+
+	tokens := make(map[string]string)
+	scanner := bufio.NewScanner(f)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("%s:%d: want \"<identity> <token>\"", path, n)
+		}
+		identity, token := fields[0], fields[1]
+		if len(token) < minTokenLength {
+			return nil, fmt.Errorf("%s:%d: token of %s is shorter than %d characters", path, n, identity, minTokenLength)
+		}
+		if other, ok := tokens[token]; ok {
+			return nil, fmt.Errorf("%s:%d: %s has the same token as %s", path, n, identity, other)
+		}
+		tokens[token] = identity
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read tokens file: %w", err)
+	}
+	return tokens, nil
+}
+
+// SetAuthorization checks the requests of authenticated sessions against
+// rbac: tools/call needs the tool's permission, resources/read and
+// prompts/get need read. Tool calls are recorded in audit when it is not
+// nil. Sessions of local transports (stdio) have no identity and are not
+// checked.
+func (s *Server) SetAuthorization(rbac *observability.RBAC, audit *observability.AuditLogger) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.rbac = rbac
+	s.audit = audit
+}
+
+// authorize checks that the caller of a session has a permission
+func (s *Server) authorize(sess *Session, permission observability.Permission) error {
+	s.mu.RLock()
+	rbac := s.rbac
+	s.mu.RUnlock()
+	if rbac == nil || sess.identity == "" {
+		return nil
+	}
+	if err := rbac.CheckPermission(sess.identity, permission); err != nil {
+		return &rpcError{code: CodeForbidden, message: "Forbidden: " + err.Error()}
+	}
+	return nil
+}
+
+// auditToolCall records a tool call in the audit log
+func (s *Server) auditToolCall(sess *Session, tool string, duration time.Duration, err error) {
+	s.mu.RLock()
+	audit := s.audit
+	s.mu.RUnlock()
+	if audit == nil {
+		return
+	}
+
+	user := sess.identity
+	if user == "" {
+		user = "local"
+	}
+	level := "info"
+	details := map[string]interface{}{
+		"user":     user,
+		"tool":     tool,
+		"success":  err == nil,
+		"duration": duration.String(),
+	}
+	if sess.id != "" {
+		details["session"] = sess.id
+	}
+	if err != nil {
+		level = "warning"
+		details["error"] = err.Error()
+	}
+	audit.LogEvent(level, "mcp_tool_call", "call_"+tool, details)
+}
@@ -0,0 +1,702 @@
+// Package mcp provides the Streamable HTTP transport of the MCP server:
+// authenticated sessions on one endpoint, tool calls streamed as
+// server-sent events and streams resumable after a dropped connection
+package mcp
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// HTTP headers of the Streamable HTTP transport
+const (
+	headerSessionID       = "Mcp-Session-Id"
+	headerProtocolVersion = "Mcp-Protocol-Version"
+	headerLastEventID     = "Last-Event-ID"
+)
+
+const (
+	// getStream is the stream ID of the standalone GET stream, which carries
+	// the server's notifications
+	getStream = "g"
+
+	// maxMessageSize is the largest JSON-RPC message accepted in a POST
+	maxMessageSize = 4 << 20
+
+	// keepaliveInterval is how often an idle event stream gets a comment,
+	// so proxies do not close it
+	keepaliveInterval = 15 * time.Second
+)
+
+// HTTPOptions configures the Streamable HTTP transport
+type HTTPOptions struct {
+	// Authenticate identifies the caller of every request; requests it
+	// rejects get 401. Required.
+	Authenticate Authenticator
+
+	// SessionTimeout ends sessions without requests for this long
+	// (default: 30 minutes)
+	SessionTimeout time.Duration
+
+	// AllowedOrigins are the browser origins allowed to connect; requests
+	// with another Origin header get 403, against DNS rebinding
+	AllowedOrigins []string
+
+	// History is the number of events of a session kept for resumption
+	// (default: 256)
+	History int
+
+	// MaxSessions is the number of sessions a caller may hold at once;
+	// initialize beyond it gets 429 (default: 16)
+	MaxSessions int
+}
+
+// HTTPHandler serves the MCP Streamable HTTP transport on a single
+// endpoint. POST sends a JSON-RPC message; tool calls are answered as an
+// event stream when the client accepts one, other requests as JSON. GET
+// opens the session's notification stream, or resumes a dropped stream
+// from Last-Event-ID. DELETE ends the session.
+type HTTPHandler struct {
+	server *Server
+	opts   HTTPOptions
+
+	mu       sync.Mutex
+	sessions map[string]*httpSession
+	stop     chan struct{}
+	stopOnce sync.Once
+}
+
+// NewHTTPHandler creates a Streamable HTTP handler for the server. Close
+// it to end its sessions.
+func (s *Server) NewHTTPHandler(opts HTTPOptions) *HTTPHandler {
+	if opts.SessionTimeout <= 0 {
+		opts.SessionTimeout = 30 * time.Minute
+	}
+	if opts.History <= 0 {
+		opts.History = 256
+	}
+	if opts.MaxSessions <= 0 {
+		opts.MaxSessions = 16
+	}
+	h := &HTTPHandler{
+		server:   s,
+		opts:     opts,
+		sessions: make(map[string]*httpSession),
+		stop:     make(chan struct{}),
+	}
+	go h.expireSessions()
+	return h
+}
+
+// Close ends all sessions, cancelling their requests in flight
+func (h *HTTPHandler) Close() {
+	h.stopOnce.Do(func() { close(h.stop) })
+
+	h.mu.Lock()
+	sessions := h.sessions
+	h.sessions = make(map[string]*httpSession)
+	h.mu.Unlock()
+	for _, hs := range sessions {
+		hs.close()
+	}
+}
+
+// ServeHTTP implements http.Handler
+func (h *HTTPHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if origin := r.Header.Get("Origin"); origin != "" && !h.allowedOrigin(origin) {
+		http.Error(w, "Forbidden: origin not allowed", http.StatusForbidden)
+		return
+	}
+
+	identity, ok := h.opts.Authenticate(r)
+	if !ok {
+		h.auditAuthFailure(r)
+		w.Header().Set("WWW-Authenticate", `Bearer realm="mcp"`)
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	if version := r.Header.Get(headerProtocolVersion); version != "" && !supportedVersion(version) {
+		http.Error(w, "Bad Request: unsupported protocol version "+version, http.StatusBadRequest)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodPost:
+		h.handlePost(w, r, identity)
+	case http.MethodGet:
+		h.handleGet(w, r, identity)
+	case http.MethodDelete:
+		hs, ok := h.lookup(w, r, identity)
+		if !ok {
+			return
+		}
+		h.remove(hs, "deleted")
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.Header().Set("Allow", "GET, POST, DELETE")
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// handlePost handles a JSON-RPC message sent by the client
+func (h *HTTPHandler) handlePost(w http.ResponseWriter, r *http.Request, identity Identity) {
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxMessageSize))
+	if err != nil {
+		writeHTTPError(w, http.StatusRequestEntityTooLarge, CodeInvalidRequest, "Message too large")
+		return
+	}
+	if bytes.HasPrefix(bytes.TrimSpace(body), []byte("[")) {
+		writeHTTPError(w, http.StatusBadRequest, CodeInvalidRequest, "Batches are not supported")
+		return
+	}
+	var req MCPRequest
+	if err := json.Unmarshal(body, &req); err != nil {
+		writeHTTPError(w, http.StatusBadRequest, CodeParseError, "Parse error")
+		return
+	}
+
+	if req.Method == "initialize" {
+		h.initialize(w, r, identity, req)
+		return
+	}
+
+	hs, ok := h.lookup(w, r, identity)
+	if !ok {
+		return
+	}
+	hs.begin()
+	defer hs.end()
+
+	switch {
+	case req.Method == "":
+		// A response to a server request; the server sends none, so there
+		// is nothing waiting for it
+		w.WriteHeader(http.StatusAccepted)
+	case req.ID == nil:
+		hs.sess.Handle(r.Context(), req)
+		w.WriteHeader(http.StatusAccepted)
+	case req.Method == "tools/call" && acceptsEventStream(r):
+		h.streamCall(w, r, hs, req)
+	default:
+		resp, ok := hs.sess.Handle(r.Context(), req)
+		if !ok {
+			w.WriteHeader(http.StatusAccepted)
+			return
+		}
+		writeJSON(w, http.StatusOK, resp)
+	}
+}
+
+// initialize starts a session for an initialize request; its ID is returned
+// in the Mcp-Session-Id header
+func (h *HTTPHandler) initialize(w http.ResponseWriter, r *http.Request, identity Identity, req MCPRequest) {
+	if r.Header.Get(headerSessionID) != "" {
+		writeHTTPError(w, http.StatusBadRequest, CodeInvalidRequest, "Session already initialized")
+		return
+	}
+	if req.ID == nil {
+		writeHTTPError(w, http.StatusBadRequest, CodeInvalidRequest, "initialize must be a request")
+		return
+	}
+
+	hs, err := h.newSession(identity)
+	if errors.Is(err, errTooManySessions) {
+		writeHTTPError(w, http.StatusTooManyRequests, CodeInvalidRequest, "Too many sessions")
+		return
+	}
+	if err != nil {
+		writeHTTPError(w, http.StatusInternalServerError, CodeInternalError, "Internal error")
+		return
+	}
+	resp, _ := hs.sess.Handle(r.Context(), req)
+	if resp.Error != nil {
+		h.remove(hs, "failed")
+		writeJSON(w, http.StatusOK, resp)
+		return
+	}
+	w.Header().Set(headerSessionID, hs.id)
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// streamCall answers a tool call as an event stream. The call runs in the
+// session rather than the request context, so a dropped connection does not
+// cancel it and the client can resume the stream for the result.
+func (h *HTTPHandler) streamCall(w http.ResponseWriter, r *http.Request, hs *httpSession, req MCPRequest) {
+	stream := hs.openStream()
+	// A priming event gives the client an event ID to resume from before
+	// the result is ready
+	after := hs.append(stream, nil) - 1
+
+	hs.begin()
+	go func() {
+		defer hs.end()
+		defer hs.closeStream(stream)
+		resp, ok := hs.sess.Handle(hs.ctx, req)
+		if !ok {
+			return
+		}
+		data, err := json.Marshal(resp)
+		if err != nil {
+			h.server.logger.Error("failed to encode response", "error", err)
+			return
+		}
+		hs.append(stream, data)
+	}()
+
+	h.serveEvents(w, r, hs, stream, after, 0)
+}
+
+// handleGet opens the notification stream of a session, or resumes a
+// stream from the Last-Event-ID header
+func (h *HTTPHandler) handleGet(w http.ResponseWriter, r *http.Request, identity Identity) {
+	if !acceptsEventStream(r) {
+		http.Error(w, "Not Acceptable: text/event-stream required", http.StatusNotAcceptable)
+		return
+	}
+	hs, ok := h.lookup(w, r, identity)
+	if !ok {
+		return
+	}
+	hs.begin()
+	defer hs.end()
+
+	if last := r.Header.Get(headerLastEventID); last != "" {
+		stream, seq, ok := parseEventID(last)
+		if !ok || !hs.hasStream(stream) {
+			http.Error(w, "Bad Request: unknown event ID", http.StatusBadRequest)
+			return
+		}
+		var gen int
+		if stream == getStream {
+			gen = hs.claimGetStream()
+		}
+		h.serveEvents(w, r, hs, stream, seq, gen)
+		return
+	}
+
+	gen := hs.claimGetStream()
+	h.serveEvents(w, r, hs, getStream, hs.lastSeq(), gen)
+}
+
+// serveEvents writes the events of a stream after seq until the stream
+// closes, the client disconnects or the session ends. gen is the
+// generation of the GET stream being served; a newer GET stream replaces
+// it.
+func (h *HTTPHandler) serveEvents(w http.ResponseWriter, r *http.Request, hs *httpSession, stream string, seq int64, gen int) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "Streaming not supported", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	keepalive := time.NewTicker(keepaliveInterval)
+	defer keepalive.Stop()
+
+	for {
+		events, done, changed := hs.read(stream, seq, gen)
+		for _, e := range events {
+			if _, err := fmt.Fprintf(w, "id: %s-%d\ndata: %s\n\n", e.stream, e.seq, e.data); err != nil {
+				return
+			}
+			seq = e.seq
+		}
+		flusher.Flush()
+		if done {
+			return
+		}
+
+		select {
+		case <-changed:
+		case <-keepalive.C:
+			if _, err := io.WriteString(w, ": keepalive\n\n"); err != nil {
+				return
+			}
+			flusher.Flush()
+		case <-r.Context().Done():
+			return
+		case <-hs.ctx.Done():
+			return
+		}
+	}
+}
+
+// errTooManySessions rejects a session beyond the caller's MaxSessions
+var errTooManySessions = errors.New("too many sessions")
+
+// newSession starts a session for an authenticated caller, unless it
+// already holds MaxSessions
+func (h *HTTPHandler) newSession(identity Identity) (*httpSession, error) {
+	id, err := newSessionID()
+	if err != nil {
+		return nil, err
+	}
+
+	hs := &httpSession{
+		id:       id,
+		identity: identity,
+		history:  h.opts.History,
+		lastUsed: time.Now(),
+		open:     map[string]bool{getStream: true},
+		closed:   make(map[string]int64),
+		changed:  make(chan struct{}),
+	}
+	hs.ctx, hs.cancel = context.WithCancel(context.Background())
+	hs.sess = h.server.NewSession(func(n MCPNotification) error {
+		data, err := json.Marshal(n)
+		if err != nil {
+			return err
+		}
+		hs.append(getStream, data)
+		return nil
+	})
+	hs.sess.id = id
+	hs.sess.identity = identity.ID
+
+	h.mu.Lock()
+	held := 0
+	for _, other := range h.sessions {
+		if other.identity.ID == identity.ID {
+			held++
+		}
+	}
+	if held >= h.opts.MaxSessions {
+		h.mu.Unlock()
+		hs.close()
+		return nil, errTooManySessions
+	}
+	h.sessions[id] = hs
+	h.mu.Unlock()
+
+	h.auditSession(hs, "session_started")
+	return hs, nil
+}
+
+// lookup returns the session of a request, answering 400 when the request
+// names none and 404 when it is unknown, expired or another caller's
+func (h *HTTPHandler) lookup(w http.ResponseWriter, r *http.Request, identity Identity) (*httpSession, bool) {
+	id := r.Header.Get(headerSessionID)
+	if id == "" {
+		http.Error(w, "Bad Request: "+headerSessionID+" header required", http.StatusBadRequest)
+		return nil, false
+	}
+
+	h.mu.Lock()
+	hs, ok := h.sessions[id]
+	h.mu.Unlock()
+	// Another caller's session is reported as unknown, so session IDs
+	// cannot be probed
+	if !ok || hs.identity.ID != identity.ID {
+		http.Error(w, "Session not found", http.StatusNotFound)
+		return nil, false
+	}
+	return hs, true
+}
+
+// remove ends a session
+func (h *HTTPHandler) remove(hs *httpSession, reason string) {
+	h.mu.Lock()
+	_, ok := h.sessions[hs.id]
+	delete(h.sessions, hs.id)
+	h.mu.Unlock()
+	if !ok {
+		return
+	}
+	hs.close()
+	h.auditSession(hs, "session_"+reason)
+}
+
+// expireSessions ends idle sessions until the handler is closed
+func (h *HTTPHandler) expireSessions() {
+	interval := h.opts.SessionTimeout / 4
+	if interval > time.Minute {
+		interval = time.Minute
+	}
+	if interval <= 0 {
+		interval = h.opts.SessionTimeout
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-h.stop:
+			return
+		case now := <-ticker.C:
+			h.mu.Lock()
+			var expired []*httpSession
+			for _, hs := range h.sessions {
+				if hs.idle(now) > h.opts.SessionTimeout {
+					expired = append(expired, hs)
+				}
+			}
+			h.mu.Unlock()
+			for _, hs := range expired {
+				h.remove(hs, "expired")
+			}
+		}
+	}
+}
+
+func (h *HTTPHandler) allowedOrigin(origin string) bool {
+	for _, allowed := range h.opts.AllowedOrigins {
+		if strings.EqualFold(origin, allowed) {
+			return true
+		}
+	}
+	return false
+}
+
+// auditAuthFailure records a request with missing or invalid credentials
+func (h *HTTPHandler) auditAuthFailure(r *http.Request) {
+	h.server.mu.RLock()
+	audit := h.server.audit
+	h.server.mu.RUnlock()
+	if audit != nil {
+		audit.LogAuthEvent("mcp_authentication_failed", r.RemoteAddr, "mcp", false)
+	}
+}
+
+// auditSession records the start or end of a session
+func (h *HTTPHandler) auditSession(hs *httpSession, action string) {
+	h.server.mu.RLock()
+	audit := h.server.audit
+	h.server.mu.RUnlock()
+	if audit != nil {
+		audit.LogEvent("info", "mcp_session", action, map[string]interface{}{
+			"user":    hs.identity.ID,
+			"method":  hs.identity.Method,
+			"session": hs.id,
+		})
+	}
+}
+
+// httpSession is an MCP session of the HTTP transport with the log of the
+// events it sent, kept for resumption. Every response stream and the GET
+// stream have a stream ID; event IDs are "<stream>-<seq>" with seq
+// increasing across the session.
+type httpSession struct {
+	id       string
+	identity Identity
+	sess     *Session
+	history  int
+
+	// ctx runs the session's streamed tool calls; cancelled when it ends
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	mu         sync.Mutex
+	lastUsed   time.Time
+	active     int // Requests being served; a session in use never expires
+	seq        int64
+	events     []sseEvent
+	open       map[string]bool  // Streams that may get more events
+	closed     map[string]int64 // Finished streams with events in the log, by last seq
+	nextStream int
+	getGen     int           // Generation of the current GET stream
+	changed    chan struct{} // Closed and replaced when events are added
+}
+
+// sseEvent is an event sent on a stream; data is a JSON-RPC message, or
+// empty for priming events
+type sseEvent struct {
+	seq    int64
+	stream string
+	data   []byte
+}
+
+// begin marks a request of the session being served; pair with end
+func (hs *httpSession) begin() {
+	hs.mu.Lock()
+	defer hs.mu.Unlock()
+	hs.active++
+	hs.lastUsed = time.Now()
+}
+
+// end marks a request of the session finished
+func (hs *httpSession) end() {
+	hs.mu.Lock()
+	defer hs.mu.Unlock()
+	hs.active--
+	hs.lastUsed = time.Now()
+}
+
+// idle returns how long the session has not been used
+func (hs *httpSession) idle(now time.Time) time.Duration {
+	hs.mu.Lock()
+	defer hs.mu.Unlock()
+	if hs.active > 0 {
+		return 0
+	}
+	return now.Sub(hs.lastUsed)
+}
+
+// close ends the session, cancelling its requests and streams
+func (hs *httpSession) close() {
+	hs.sess.Close()
+	hs.cancel()
+}
+
+// openStream opens a response stream and returns its ID
+func (hs *httpSession) openStream() string {
+	hs.mu.Lock()
+	defer hs.mu.Unlock()
+	hs.nextStream++
+	stream := "p" + strconv.Itoa(hs.nextStream)
+	hs.open[stream] = true
+	return stream
+}
+
+// closeStream marks a response stream finished: its readers return once
+// they have written its events
+func (hs *httpSession) closeStream(stream string) {
+	hs.mu.Lock()
+	defer hs.mu.Unlock()
+	delete(hs.open, stream)
+	for i := len(hs.events) - 1; i >= 0; i-- {
+		if hs.events[i].stream == stream {
+			hs.closed[stream] = hs.events[i].seq
+			break
+		}
+	}
+	hs.notifyChanged()
+}
+
+// append adds an event to a stream and returns its seq. The oldest events
+// are dropped beyond the history size.
+func (hs *httpSession) append(stream string, data []byte) int64 {
+	hs.mu.Lock()
+	defer hs.mu.Unlock()
+	hs.seq++
+	hs.events = append(hs.events, sseEvent{seq: hs.seq, stream: stream, data: data})
+	for len(hs.events) > hs.history {
+		dropped := hs.events[0]
+		hs.events = hs.events[1:]
+		// Forget finished streams once none of their events are left
+		if last, ok := hs.closed[dropped.stream]; ok && last <= dropped.seq {
+			delete(hs.closed, dropped.stream)
+		}
+	}
+	hs.notifyChanged()
+	return hs.seq
+}
+
+// notifyChanged wakes the readers of the session's streams; hs.mu must be
+// held
+func (hs *httpSession) notifyChanged() {
+	close(hs.changed)
+	hs.changed = make(chan struct{})
+}
+
+// read returns the events of a stream after seq, whether the reader is
+// done, and a channel closed when the events change. A GET stream reader
+// of an older generation is done.
+func (hs *httpSession) read(stream string, seq int64, gen int) ([]sseEvent, bool, <-chan struct{}) {
+	hs.mu.Lock()
+	defer hs.mu.Unlock()
+
+	var events []sseEvent
+	for _, e := range hs.events {
+		if e.stream == stream && e.seq > seq {
+			events = append(events, e)
+		}
+	}
+	done := !hs.open[stream] || (stream == getStream && gen != hs.getGen)
+	return events, done, hs.changed
+}
+
+// hasStream reports whether a stream can be resumed
+func (hs *httpSession) hasStream(stream string) bool {
+	hs.mu.Lock()
+	defer hs.mu.Unlock()
+	_, closed := hs.closed[stream]
+	return hs.open[stream] || closed
+}
+
+// claimGetStream makes the caller the reader of the GET stream, ending the
+// previous reader so no notification is delivered twice, and returns its
+// generation
+func (hs *httpSession) claimGetStream() int {
+	hs.mu.Lock()
+	defer hs.mu.Unlock()
+	hs.getGen++
+	hs.notifyChanged()
+	return hs.getGen
+}
+
+// lastSeq returns the seq of the latest event
+func (hs *httpSession) lastSeq() int64 {
+	hs.mu.Lock()
+	defer hs.mu.Unlock()
+	return hs.seq
+}
+
+// newSessionID returns a random, unguessable session ID
+func newSessionID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate session ID: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// parseEventID splits an event ID into its stream and seq
+func parseEventID(id string) (string, int64, bool) {
+	i := strings.LastIndexByte(id, '-')
+	if i <= 0 {
+		return "", 0, false
+	}
+	seq, err := strconv.ParseInt(id[i+1:], 10, 64)
+	if err != nil {
+		return "", 0, false
+	}
+	return id[:i], seq, true
+}
+
+// acceptsEventStream reports whether the client accepts server-sent events
+func acceptsEventStream(r *http.Request) bool {
+	for _, accept := range r.Header.Values("Accept") {
+		if strings.Contains(accept, "text/event-stream") {
+			return true
+		}
+	}
+	return false
+}
+
+func supportedVersion(version string) bool {
+	for _, v := range SupportedProtocolVersions {
+		if v == version {
+			return true
+		}
+	}
+	return false
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	//nolint:errcheck // The client is gone when the write fails
+	json.NewEncoder(w).Encode(v)
+}
+
+// writeHTTPError answers a message that cannot be handled with a JSON-RPC
+// error without an ID
+func writeHTTPError(w http.ResponseWriter, status, code int, message string) {
+	writeJSON(w, status, MCPResponse{JSONRPC: "2.0", Error: &MCPError{Code: code, Message: message}})
+}
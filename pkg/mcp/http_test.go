@@ -0,0 +1,474 @@
+// Package mcp provides tests for the Streamable HTTP transport and its
+// authentication and authorization
+package mcp
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cicd-ai-toolkit/cicd-runner/pkg/observability"
+)
+
+const (
+	viewerToken = "viewer-token-0123456789"
+	devToken    = "developer-token-0123456789"
+)
+
+// httpClient drives an HTTPHandler as an authenticated caller
+type httpClient struct {
+	t       *testing.T
+	url     string
+	token   string
+	session string
+}
+
+func newHTTPServer(t *testing.T, server *Server) (*httptest.Server, *HTTPHandler) {
+	t.Helper()
+	handler := server.NewHTTPHandler(HTTPOptions{
+		Authenticate:   TokenAuth(map[string]string{viewerToken: "alice", devToken: "bob"}),
+		AllowedOrigins: []string{"https://ide.example.com"},
+	})
+	ts := httptest.NewServer(handler)
+	t.Cleanup(func() {
+		handler.Close()
+		ts.Close()
+	})
+	return ts, handler
+}
+
+func (c *httpClient) do(method, body string, header map[string]string) *http.Response {
+	c.t.Helper()
+	return c.doContext(context.Background(), method, body, header)
+}
+
+func (c *httpClient) doContext(ctx context.Context, method, body string, header map[string]string) *http.Response {
+	c.t.Helper()
+	req, err := http.NewRequestWithContext(ctx, method, c.url, strings.NewReader(body))
+	if err != nil {
+		c.t.Fatal(err)
+	}
+	req.Header.Set("Accept", "application/json, text/event-stream")
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+	if c.session != "" {
+		req.Header.Set(headerSessionID, c.session)
+	}
+	for k, v := range header {
+		req.Header.Set(k, v)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		c.t.Fatal(err)
+	}
+	c.t.Cleanup(func() { resp.Body.Close() })
+	return resp
+}
+
+// initialize starts a session
+func (c *httpClient) initialize() {
+	c.t.Helper()
+	resp := c.do(http.MethodPost, `{"jsonrpc": "2.0", "id": 1, "method": "initialize", "params": {"protocolVersion": "2025-06-18", "capabilities": {}, "clientInfo": {"name": "c"}}}`, nil)
+	if resp.StatusCode != http.StatusOK {
+		c.t.Fatalf("initialize status = %d", resp.StatusCode)
+	}
+	c.session = resp.Header.Get(headerSessionID)
+	if c.session == "" {
+		c.t.Fatal("initialize returned no session ID")
+	}
+	if resp := c.do(http.MethodPost, `{"jsonrpc": "2.0", "method": "notifications/initialized"}`, nil); resp.StatusCode != http.StatusAccepted {
+		c.t.Fatalf("notifications/initialized status = %d, want 202", resp.StatusCode)
+	}
+}
+
+// decode decodes a JSON response
+func decode(t *testing.T, resp *http.Response) MCPResponse {
+	t.Helper()
+	if ct := resp.Header.Get("Content-Type"); ct != "application/json" {
+		t.Fatalf("Content-Type = %q, want application/json", ct)
+	}
+	var msg MCPResponse
+	if err := json.NewDecoder(resp.Body).Decode(&msg); err != nil {
+		t.Fatal(err)
+	}
+	return msg
+}
+
+// sseEventOf is an event read from an event stream
+type sseEventOf struct {
+	id   string
+	data string
+}
+
+// readEvents reads the events of a stream as they arrive
+func readEvents(resp *http.Response) <-chan sseEventOf {
+	events := make(chan sseEventOf, 10)
+	go func() {
+		defer close(events)
+		scanner := bufio.NewScanner(resp.Body)
+		var e sseEventOf
+		for scanner.Scan() {
+			line := scanner.Text()
+			switch {
+			case line == "":
+				if e.id != "" || e.data != "" {
+					events <- e
+				}
+				e = sseEventOf{}
+			case strings.HasPrefix(line, "id: "):
+				e.id = strings.TrimPrefix(line, "id: ")
+			case strings.HasPrefix(line, "data:"):
+				e.data = strings.TrimSpace(strings.TrimPrefix(line, "data:"))
+			}
+		}
+	}()
+	return events
+}
+
+func nextEvent(t *testing.T, events <-chan sseEventOf) sseEventOf {
+	t.Helper()
+	select {
+	case e, ok := <-events:
+		if !ok {
+			t.Fatal("event stream closed")
+		}
+		return e
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for event")
+		return sseEventOf{}
+	}
+}
+
+func TestHTTPAuthentication(t *testing.T) {
+	ts, _ := newHTTPServer(t, newTestServer())
+	initialize := `{"jsonrpc": "2.0", "id": 1, "method": "initialize", "params": {}}`
+
+	tests := []struct {
+		name   string
+		token  string
+		header map[string]string
+		want   int
+	}{
+		{name: "no token", want: http.StatusUnauthorized},
+		{name: "wrong token", token: "not-a-valid-token-at-all", want: http.StatusUnauthorized},
+		{name: "valid token", token: viewerToken, want: http.StatusOK},
+		{name: "foreign origin", token: viewerToken, header: map[string]string{"Origin": "https://evil.example.com"}, want: http.StatusForbidden},
+		{name: "allowed origin", token: viewerToken, header: map[string]string{"Origin": "https://ide.example.com"}, want: http.StatusOK},
+		{name: "unsupported protocol", token: viewerToken, header: map[string]string{headerProtocolVersion: "1999-01-01"}, want: http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &httpClient{t: t, url: ts.URL, token: tt.token}
+			resp := c.do(http.MethodPost, initialize, tt.header)
+			if resp.StatusCode != tt.want {
+				t.Errorf("status = %d, want %d", resp.StatusCode, tt.want)
+			}
+			if tt.want == http.StatusUnauthorized && resp.Header.Get("WWW-Authenticate") == "" {
+				t.Error("401 without WWW-Authenticate")
+			}
+		})
+	}
+}
+
+func TestHTTPSessions(t *testing.T) {
+	ts, _ := newHTTPServer(t, newTestServer())
+	alice := &httpClient{t: t, url: ts.URL, token: viewerToken}
+	alice.initialize()
+
+	list := `{"jsonrpc": "2.0", "id": 2, "method": "tools/list"}`
+	msg := decode(t, alice.do(http.MethodPost, list, nil))
+	if msg.Error != nil || !strings.Contains(string(msg.Result), "get_pr_info") {
+		t.Fatalf("tools/list = %s, %+v", msg.Result, msg.Error)
+	}
+
+	if resp := (&httpClient{t: t, url: ts.URL, token: viewerToken}).do(http.MethodPost, list, nil); resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("request without session status = %d, want 400", resp.StatusCode)
+	}
+	if resp := (&httpClient{t: t, url: ts.URL, token: viewerToken, session: "unknown"}).do(http.MethodPost, list, nil); resp.StatusCode != http.StatusNotFound {
+		t.Errorf("unknown session status = %d, want 404", resp.StatusCode)
+	}
+	// A session is only usable by the caller who started it
+	if resp := (&httpClient{t: t, url: ts.URL, token: devToken, session: alice.session}).do(http.MethodPost, list, nil); resp.StatusCode != http.StatusNotFound {
+		t.Errorf("other caller's session status = %d, want 404", resp.StatusCode)
+	}
+
+	if resp := alice.do(http.MethodDelete, "", nil); resp.StatusCode != http.StatusNoContent {
+		t.Fatalf("DELETE status = %d, want 204", resp.StatusCode)
+	}
+	if resp := alice.do(http.MethodPost, list, nil); resp.StatusCode != http.StatusNotFound {
+		t.Errorf("deleted session status = %d, want 404", resp.StatusCode)
+	}
+}
+
+func TestHTTPSessionLimit(t *testing.T) {
+	server := newTestServer()
+	handler := server.NewHTTPHandler(HTTPOptions{
+		Authenticate: TokenAuth(map[string]string{viewerToken: "alice", devToken: "bob"}),
+		MaxSessions:  2,
+	})
+	ts := httptest.NewServer(handler)
+	defer ts.Close()
+	defer handler.Close()
+
+	first := &httpClient{t: t, url: ts.URL, token: viewerToken}
+	first.initialize()
+	(&httpClient{t: t, url: ts.URL, token: viewerToken}).initialize()
+
+	init := `{"jsonrpc": "2.0", "id": 1, "method": "initialize", "params": {"protocolVersion": "2025-06-18", "capabilities": {}, "clientInfo": {"name": "c"}}}`
+	if resp := (&httpClient{t: t, url: ts.URL, token: viewerToken}).do(http.MethodPost, init, nil); resp.StatusCode != http.StatusTooManyRequests {
+		t.Fatalf("session beyond limit status = %d, want 429", resp.StatusCode)
+	}
+	// The limit is per caller
+	(&httpClient{t: t, url: ts.URL, token: devToken}).initialize()
+
+	// Ending a session frees its slot
+	if resp := first.do(http.MethodDelete, "", nil); resp.StatusCode != http.StatusNoContent {
+		t.Fatalf("DELETE status = %d, want 204", resp.StatusCode)
+	}
+	(&httpClient{t: t, url: ts.URL, token: viewerToken}).initialize()
+}
+
+func TestHTTPSessionExpiry(t *testing.T) {
+	server := newTestServer()
+	handler := server.NewHTTPHandler(HTTPOptions{
+		Authenticate:   TokenAuth(map[string]string{viewerToken: "alice"}),
+		SessionTimeout: 50 * time.Millisecond,
+	})
+	ts := httptest.NewServer(handler)
+	defer ts.Close()
+	defer handler.Close()
+
+	c := &httpClient{t: t, url: ts.URL, token: viewerToken}
+	c.initialize()
+	time.Sleep(200 * time.Millisecond)
+	if resp := c.do(http.MethodPost, `{"jsonrpc": "2.0", "id": 2, "method": "ping"}`, nil); resp.StatusCode != http.StatusNotFound {
+		t.Errorf("expired session status = %d, want 404", resp.StatusCode)
+	}
+}
+
+func TestHTTPStreamedToolCall(t *testing.T) {
+	server := newTestServer()
+	release := make(chan struct{})
+	server.RegisterTool(Tool{
+		Name:        "slow",
+		InputSchema: map[string]any{"type": "object"},
+		Permission:  observability.PermissionRead,
+		Handler: func(ctx context.Context, args map[string]any) (map[string]any, error) {
+			<-release
+			return map[string]any{"done": true}, nil
+		},
+	})
+	ts, _ := newHTTPServer(t, server)
+	c := &httpClient{t: t, url: ts.URL, token: viewerToken}
+	c.initialize()
+
+	ctx, drop := context.WithCancel(context.Background())
+	resp := c.doContext(ctx, http.MethodPost, `{"jsonrpc": "2.0", "id": 3, "method": "tools/call", "params": {"name": "slow"}}`, nil)
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Fatalf("Content-Type = %q, want text/event-stream", ct)
+	}
+	priming := nextEvent(t, readEvents(resp))
+	if priming.id == "" || priming.data != "" {
+		t.Fatalf("priming event = %+v", priming)
+	}
+
+	// The connection drops before the result; the call goes on and the
+	// client resumes the stream for it
+	drop()
+	close(release)
+
+	resumed := c.do(http.MethodGet, "", map[string]string{headerLastEventID: priming.id})
+	if resumed.StatusCode != http.StatusOK {
+		t.Fatalf("resume status = %d", resumed.StatusCode)
+	}
+	events := readEvents(resumed)
+	result := nextEvent(t, events)
+	var msg MCPResponse
+	if err := json.Unmarshal([]byte(result.data), &msg); err != nil {
+		t.Fatalf("result event %q: %v", result.data, err)
+	}
+	if msg.ID != 3.0 || !strings.Contains(string(msg.Result), `"done":true`) {
+		t.Errorf("resumed result = %+v, %s", msg, msg.Result)
+	}
+	if _, ok := <-events; ok {
+		t.Error("resumed stream not closed after the result")
+	}
+
+	if resp := c.do(http.MethodGet, "", map[string]string{headerLastEventID: "p99-1"}); resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("unknown stream status = %d, want 400", resp.StatusCode)
+	}
+}
+
+func TestHTTPNotificationStream(t *testing.T) {
+	server := newTestServer()
+	ts, handler := newHTTPServer(t, server)
+	c := &httpClient{t: t, url: ts.URL, token: viewerToken}
+	c.initialize()
+
+	resp := c.do(http.MethodGet, "", nil)
+	if resp.StatusCode != http.StatusOK || resp.Header.Get("Content-Type") != "text/event-stream" {
+		t.Fatalf("GET = %d %q", resp.StatusCode, resp.Header.Get("Content-Type"))
+	}
+	events := readEvents(resp)
+
+	// Wait until the stream is registered before changing the tools
+	deadline := time.Now().Add(5 * time.Second)
+	for getStreamGen(handler, c.session) == 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	server.RegisterTool(Tool{Name: "extra", InputSchema: map[string]any{"type": "object"}})
+	e := nextEvent(t, events)
+	if !strings.HasPrefix(e.id, getStream+"-") || !strings.Contains(e.data, "notifications/tools/list_changed") {
+		t.Fatalf("event = %+v, want tools/list_changed", e)
+	}
+
+	// The notification can be replayed from before it
+	resumed := c.do(http.MethodGet, "", map[string]string{headerLastEventID: getStream + "-0"})
+	if replayed := nextEvent(t, readEvents(resumed)); replayed.id != e.id {
+		t.Errorf("replayed event = %+v, want %s", replayed, e.id)
+	}
+}
+
+// getStreamGen returns the generation of a session's GET stream, 0 until
+// one is opened
+func getStreamGen(handler *HTTPHandler, id string) int {
+	handler.mu.Lock()
+	hs := handler.sessions[id]
+	handler.mu.Unlock()
+	hs.mu.Lock()
+	defer hs.mu.Unlock()
+	return hs.getGen
+}
+
+func TestHTTPAuthorization(t *testing.T) {
+	auditFile := filepath.Join(t.TempDir(), "audit.log")
+	audit, err := observability.NewAuditLogger(auditFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer audit.Close()
+	rbac := observability.NewRBAC(audit)
+	for _, u := range []*observability.User{
+		{ID: "alice", Name: "alice", Roles: []string{"viewer"}},
+		{ID: "bob", Name: "bob", Roles: []string{"developer"}},
+	} {
+		if err := rbac.AddUser(u); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	server := newTestServer()
+	server.SetAuthorization(rbac, audit)
+	ts, _ := newHTTPServer(t, server)
+
+	post := `{"jsonrpc": "2.0", "id": 4, "method": "tools/call", "params": {"name": "post_review_comment", "arguments": {"pr_id": 1, "body": "hi"}}}`
+	read := `{"jsonrpc": "2.0", "id": 5, "method": "tools/call", "params": {"name": "get_pr_info", "arguments": {"pr_id": 1}}}`
+	tests := []struct {
+		token  string
+		body   string
+		forbid bool
+	}{
+		{token: viewerToken, body: read},
+		{token: viewerToken, body: post, forbid: true},
+		{token: devToken, body: post},
+	}
+	for _, tt := range tests {
+		c := &httpClient{t: t, url: ts.URL, token: tt.token}
+		c.initialize()
+		msg := decode(t, c.do(http.MethodPost, tt.body, map[string]string{"Accept": "application/json"}))
+		if forbidden := msg.Error != nil && msg.Error.Code == CodeForbidden; forbidden != tt.forbid {
+			t.Errorf("%s: error = %+v, want forbidden %v", tt.body, msg.Error, tt.forbid)
+		}
+	}
+
+	// Local sessions are trusted
+	resp := server.HandleRequest(context.Background(), MCPRequest{JSONRPC: "2.0", ID: 1.0, Method: "tools/call", Params: json.RawMessage(`{"name": "post_review_comment", "arguments": {"pr_id": 1, "body": "hi"}}`)})
+	if resp.Error != nil {
+		t.Errorf("local tool call error = %+v", resp.Error)
+	}
+
+	(&httpClient{t: t, url: ts.URL, token: "not-a-valid-token-at-all"}).do(http.MethodPost, "{}", nil)
+
+	data, err := os.ReadFile(auditFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	log := string(data)
+	for _, want := range []string{`"event":"mcp_tool_call"`, `"action":"call_post_review_comment"`, `"user":"alice"`, `"user":"bob"`, `"user":"local"`, `"event":"mcp_authentication_failed"`, `"event":"mcp_session"`} {
+		if !strings.Contains(log, want) {
+			t.Errorf("audit log lacks %s:\n%s", want, log)
+		}
+	}
+}
+
+func TestCertAuth(t *testing.T) {
+	cert := &x509.Certificate{Subject: pkix.Name{CommonName: "ci-agent"}}
+	tests := []struct {
+		name  string
+		state *tls.ConnectionState
+		want  string
+	}{
+		{name: "plain HTTP"},
+		{name: "no client certificate", state: &tls.ConnectionState{}},
+		{name: "verified", state: &tls.ConnectionState{PeerCertificates: []*x509.Certificate{cert}, VerifiedChains: [][]*x509.Certificate{{cert}}}, want: "ci-agent"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPost, "/mcp", nil)
+			r.TLS = tt.state
+			id, ok := AnyAuth(TokenAuth(nil), CertAuth())(r)
+			if ok != (tt.want != "") || id.ID != tt.want {
+				t.Errorf("identity = %+v, %v, want %q", id, ok, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadTokens(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    map[string]string
+		wantErr bool
+	}{
+		{
+			name:    "valid",
+			content: "# agents\nalice " + viewerToken + "\n\nbob " + devToken + "\n",
+			want:    map[string]string{viewerToken: "alice", devToken: "bob"},
+		},
+		{name: "missing token", content: "alice\n", wantErr: true},
+		{name: "short token", content: "alice secret\n", wantErr: true},
+		{name: "shared token", content: "alice " + viewerToken + "\nbob " + viewerToken + "\n", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "tokens")
+			if err := os.WriteFile(path, []byte(tt.content), 0o600); err != nil {
+				t.Fatal(err)
+			}
+			tokens, err := LoadTokens(path)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("LoadTokens() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if len(tokens) != len(tt.want) {
+				t.Fatalf("tokens = %v, want %v", tokens, tt.want)
+			}
+			for token, id := range tt.want {
+				if tokens[token] != id {
+					t.Errorf("tokens[%s] = %q, want %q", token, tokens[token], id)
+				}
+			}
+		})
+	}
+}
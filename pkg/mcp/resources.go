@@ -0,0 +1,308 @@
+// Package mcp provides the MCP resources and prompts: pull request data
+// as cicd:// resources and each skill as a resource and a prompt
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/cicd-ai-toolkit/cicd-runner/pkg/buildcontext"
+)
+
+// uriScheme prefixes the URIs of the server's resources
+const uriScheme = "cicd://"
+
+// Resource describes a readable resource (resources/list)
+type Resource struct {
+	URI         string `json:"uri"`
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	MimeType    string `json:"mimeType,omitempty"`
+}
+
+// ResourceTemplate describes resources addressed by a URI template
+// (resources/templates/list)
+type ResourceTemplate struct {
+	URITemplate string `json:"uriTemplate"`
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	MimeType    string `json:"mimeType,omitempty"`
+}
+
+// ResourceContents is the text of a resource
+type ResourceContents struct {
+	URI      string `json:"uri"`
+	MimeType string `json:"mimeType,omitempty"`
+	Text     string `json:"text"`
+}
+
+// Prompt describes a prompt template (prompts/list)
+type Prompt struct {
+	Name        string           `json:"name"`
+	Description string           `json:"description,omitempty"`
+	Arguments   []PromptArgument `json:"arguments,omitempty"`
+}
+
+// PromptArgument is an argument of a prompt
+type PromptArgument struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	Required    bool   `json:"required,omitempty"`
+}
+
+// PromptMessage is a message of a rendered prompt (prompts/get)
+type PromptMessage struct {
+	Role    string  `json:"role"`
+	Content Content `json:"content"`
+}
+
+// Content is a content block of a prompt message or tool result: text, or
+// an embedded resource
+type Content struct {
+	Type     string            `json:"type"`
+	Text     string            `json:"text,omitempty"`
+	Resource *ResourceContents `json:"resource,omitempty"`
+}
+
+// prResources are the resources of each pull request, cicd://pr/<id>/<kind>
+var prResources = []struct {
+	kind, description, mimeType string
+}{
+	{"diff", "Unified diff of the pull request", "text/x-diff"},
+	{"info", "Title, description, author, branches and labels of the pull request", "application/json"},
+	{"files", "Files changed by the pull request with their added and removed lines", "application/json"},
+}
+
+// changedFile is an entry of the cicd://pr/<id>/files resource
+type changedFile struct {
+	Path      string `json:"path"`
+	OldPath   string `json:"old_path,omitempty"` // Set for renames
+	Status    string `json:"status"`             // added, deleted, renamed or modified
+	Additions int    `json:"additions"`
+	Deletions int    `json:"deletions"`
+}
+
+// ListResources returns the resources of the current pull request, when
+// one is set, and of the skills
+func (s *Server) ListResources() ([]Resource, error) {
+	var resources []Resource
+	if prID := s.currentPR(); prID > 0 {
+		for _, r := range prResources {
+			resources = append(resources, Resource{
+				URI:         fmt.Sprintf("%spr/%d/%s", uriScheme, prID, r.kind),
+				Name:        fmt.Sprintf("PR #%d %s", prID, r.kind),
+				Description: r.description,
+				MimeType:    r.mimeType,
+			})
+		}
+	}
+
+	skills, err := s.loadSkills()
+	if err != nil {
+		return nil, err
+	}
+	for _, sk := range skills {
+		resources = append(resources, Resource{
+			URI:         uriScheme + "skills/" + sk.Name,
+			Name:        "Skill " + sk.Name,
+			Description: sk.Description,
+			MimeType:    "text/markdown",
+		})
+	}
+	return resources, nil
+}
+
+// ListResourceTemplates returns the templates of the pull request and
+// skill resources
+func (s *Server) ListResourceTemplates() []ResourceTemplate {
+	templates := make([]ResourceTemplate, 0, len(prResources)+1)
+	for _, r := range prResources {
+		templates = append(templates, ResourceTemplate{
+			URITemplate: uriScheme + "pr/{pr_id}/" + r.kind,
+			Name:        "PR " + r.kind,
+			Description: r.description,
+			MimeType:    r.mimeType,
+		})
+	}
+	return append(templates, ResourceTemplate{
+		URITemplate: uriScheme + "skills/{name}",
+		Name:        "Skill",
+		Description: "SKILL.md of a skill",
+		MimeType:    "text/markdown",
+	})
+}
+
+// ReadResource reads a resource by URI
+func (s *Server) ReadResource(ctx context.Context, uri string) (*ResourceContents, error) {
+	notFound := &rpcError{code: CodeResourceNotFound, message: "Resource not found: " + uri}
+
+	path, ok := strings.CutPrefix(uri, uriScheme)
+	if !ok {
+		return nil, notFound
+	}
+	parts := strings.Split(path, "/")
+	switch {
+	case len(parts) == 2 && parts[0] == "skills":
+		sk, err := s.loadSkill(parts[1])
+		if err != nil {
+			return nil, notFound
+		}
+		data, err := os.ReadFile(sk.Path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read skill: %w", err)
+		}
+		return &ResourceContents{URI: uri, MimeType: "text/markdown", Text: string(data)}, nil
+
+	case len(parts) == 3 && parts[0] == "pr":
+		prID, err := strconv.Atoi(parts[1])
+		if err != nil || prID <= 0 {
+			return nil, notFound
+		}
+		text, mimeType, err := s.readPR(ctx, prID, parts[2])
+		if err != nil {
+			return nil, err
+		}
+		if mimeType == "" {
+			return nil, notFound
+		}
+		return &ResourceContents{URI: uri, MimeType: mimeType, Text: text}, nil
+	}
+	return nil, notFound
+}
+
+// readPR returns a resource of a pull request and its MIME type, or no
+// MIME type for an unknown kind
+func (s *Server) readPR(ctx context.Context, prID int, kind string) (string, string, error) {
+	var v any
+	switch kind {
+	case "diff":
+		diff, err := s.platform.GetDiff(ctx, prID)
+		if err != nil {
+			return "", "", fmt.Errorf("failed to get PR diff: %w", err)
+		}
+		return diff, "text/x-diff", nil
+
+	case "info":
+		info, err := s.handleGetPRInfo(ctx, map[string]any{"pr_id": float64(prID)})
+		if err != nil {
+			return "", "", err
+		}
+		v = info
+
+	case "files":
+		diff, err := s.platform.GetDiff(ctx, prID)
+		if err != nil {
+			return "", "", fmt.Errorf("failed to get PR diff: %w", err)
+		}
+		files := []changedFile{}
+		for _, f := range buildcontext.ParseDiff(diff) {
+			files = append(files, newChangedFile(f))
+		}
+		v = files
+
+	default:
+		return "", "", nil
+	}
+
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return "", "", err
+	}
+	return string(data), "application/json", nil
+}
+
+// newChangedFile summarizes the diff of a file
+func newChangedFile(f buildcontext.FileDiff) changedFile {
+	file := changedFile{Path: f.Path(), Status: "modified"}
+	switch {
+	case f.OldPath == "":
+		file.Status = "added"
+	case f.NewPath == "":
+		file.Status = "deleted"
+	case f.OldPath != f.NewPath:
+		file.Status = "renamed"
+		file.OldPath = f.OldPath
+	}
+	for _, h := range f.Hunks {
+		for _, line := range h.Lines {
+			switch line.Kind {
+			case buildcontext.LineAdded:
+				file.Additions++
+			case buildcontext.LineRemoved:
+				file.Deletions++
+			}
+		}
+	}
+	return file
+}
+
+// ListPrompts returns a prompt for each skill. Besides the skill's inputs
+// every prompt takes an optional pr_id whose diff is attached.
+func (s *Server) ListPrompts() ([]Prompt, error) {
+	skills, err := s.loadSkills()
+	if err != nil {
+		return nil, err
+	}
+	prompts := make([]Prompt, 0, len(skills))
+	for _, sk := range skills {
+		p := Prompt{Name: sk.Name, Description: sk.Description}
+		hasPRID := false
+		for _, in := range sk.Inputs {
+			p.Arguments = append(p.Arguments, PromptArgument{Name: in.Name, Description: in.Description, Required: in.Required && in.Default == ""})
+			hasPRID = hasPRID || in.Name == "pr_id"
+		}
+		if !hasPRID {
+			p.Arguments = append(p.Arguments, PromptArgument{Name: "pr_id", Description: "Pull/Merge request whose diff is attached"})
+		}
+		prompts = append(prompts, p)
+	}
+	return prompts, nil
+}
+
+// GetPrompt renders the prompt of a skill: its instructions followed by
+// the given inputs and, with pr_id, the pull request diff as an embedded
+// resource
+func (s *Server) GetPrompt(ctx context.Context, name string, args map[string]string) (string, []PromptMessage, error) {
+	sk, err := s.loadSkill(name)
+	if err != nil {
+		return "", nil, &rpcError{code: CodeInvalidParams, message: "Unknown prompt: " + name}
+	}
+
+	var inputs strings.Builder
+	for _, in := range sk.Inputs {
+		value, ok := args[in.Name]
+		if !ok {
+			value = in.Default
+		}
+		if value == "" {
+			if in.Required {
+				return "", nil, &rpcError{code: CodeInvalidParams, message: "Missing required argument: " + in.Name}
+			}
+			continue
+		}
+		fmt.Fprintf(&inputs, "- %s: %s\n", in.Name, value)
+	}
+
+	text := strings.TrimSpace(sk.Content)
+	if inputs.Len() > 0 {
+		text += "\n\n## Inputs\n\n" + inputs.String()
+	}
+	messages := []PromptMessage{{Role: "user", Content: Content{Type: "text", Text: text}}}
+
+	if v := args["pr_id"]; v != "" {
+		prID, err := strconv.Atoi(v)
+		if err != nil || prID <= 0 {
+			return "", nil, &rpcError{code: CodeInvalidParams, message: "Invalid pr_id: " + v}
+		}
+		diff, err := s.ReadResource(ctx, fmt.Sprintf("%spr/%d/diff", uriScheme, prID))
+		if err != nil {
+			return "", nil, err
+		}
+		messages = append(messages, PromptMessage{Role: "user", Content: Content{Type: "resource", Resource: diff}})
+	}
+	return sk.Description, messages, nil
+}
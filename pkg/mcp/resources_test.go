@@ -0,0 +1,210 @@
+// Package mcp provides tests for resources and prompts
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// diffPlatform serves a diff that adds, changes and deletes files
+type diffPlatform struct {
+	mockPlatform
+}
+
+func (p *diffPlatform) GetDiff(ctx context.Context, prID int) (string, error) {
+	return `diff --git a/main.go b/main.go
+--- a/main.go
++++ b/main.go
+@@ -1,2 +1,3 @@
+ package main
+-func old() {}
++func f() {}
++func g() {}
+diff --git a/new.go b/new.go
+new file mode 100644
+--- /dev/null
++++ b/new.go
+@@ -0,0 +1,1 @@
++package main
+`, nil
+}
+
+const testSkill = `---
+name: reviewer
+description: Reviews code
+inputs:
+  - name: focus
+    type: string
+    description: Area to focus on
+    required: true
+---
+
+Review the changes.
+`
+
+func newResourceServer(t *testing.T) *Server {
+	t.Helper()
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "reviewer"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "reviewer", "SKILL.md"), []byte(testSkill), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	server := newTestServer()
+	server.platform = &diffPlatform{}
+	server.SetSkillsDir(dir)
+	return server
+}
+
+// call sends a request and decodes its result into v
+func call(t *testing.T, server *Server, method, params string, v any) *MCPError {
+	t.Helper()
+	req := MCPRequest{JSONRPC: "2.0", ID: 1.0, Method: method}
+	if params != "" {
+		req.Params = json.RawMessage(params)
+	}
+	resp := server.HandleRequest(context.Background(), req)
+	if resp.Error != nil {
+		return resp.Error
+	}
+	if err := json.Unmarshal(resp.Result, v); err != nil {
+		t.Fatalf("%s result: %v", method, err)
+	}
+	return nil
+}
+
+func TestResources(t *testing.T) {
+	server := newResourceServer(t)
+
+	var list struct {
+		Resources []Resource `json:"resources"`
+	}
+	if err := call(t, server, "resources/list", "", &list); err != nil {
+		t.Fatal(err)
+	}
+	if len(list.Resources) != 1 || list.Resources[0].URI != "cicd://skills/reviewer" {
+		t.Errorf("resources without a PR = %+v", list.Resources)
+	}
+
+	server.SetPRID(5)
+	if err := call(t, server, "resources/list", "", &list); err != nil {
+		t.Fatal(err)
+	}
+	if len(list.Resources) != 4 || list.Resources[0].URI != "cicd://pr/5/diff" {
+		t.Errorf("resources = %+v", list.Resources)
+	}
+
+	var templates struct {
+		ResourceTemplates []ResourceTemplate `json:"resourceTemplates"`
+	}
+	if err := call(t, server, "resources/templates/list", "", &templates); err != nil {
+		t.Fatal(err)
+	}
+	if len(templates.ResourceTemplates) != 4 || templates.ResourceTemplates[0].URITemplate != "cicd://pr/{pr_id}/diff" {
+		t.Errorf("templates = %+v", templates.ResourceTemplates)
+	}
+
+	tests := []struct {
+		uri      string
+		mimeType string
+		contains string
+	}{
+		{uri: "cicd://pr/9/diff", mimeType: "text/x-diff", contains: "+func g() {}"},
+		{uri: "cicd://pr/9/info", mimeType: "application/json", contains: `"number": 9`},
+		{uri: "cicd://skills/reviewer", mimeType: "text/markdown", contains: "description: Reviews code"},
+	}
+	for _, tt := range tests {
+		var read struct {
+			Contents []ResourceContents `json:"contents"`
+		}
+		if err := call(t, server, "resources/read", `{"uri": "`+tt.uri+`"}`, &read); err != nil {
+			t.Fatalf("read %s: %+v", tt.uri, err)
+		}
+		if len(read.Contents) != 1 || read.Contents[0].URI != tt.uri || read.Contents[0].MimeType != tt.mimeType ||
+			!strings.Contains(read.Contents[0].Text, tt.contains) {
+			t.Errorf("read %s = %+v", tt.uri, read.Contents)
+		}
+	}
+
+	for _, uri := range []string{"cicd://pr/9/commits", "cicd://pr/x/diff", "cicd://skills/missing", "cicd://skills/../etc", "file:///etc/passwd"} {
+		if err := call(t, server, "resources/read", `{"uri": "`+uri+`"}`, nil); err == nil || err.Code != CodeResourceNotFound {
+			t.Errorf("read %s error = %+v, want %d", uri, err, CodeResourceNotFound)
+		}
+	}
+}
+
+func TestChangedFilesResource(t *testing.T) {
+	server := newResourceServer(t)
+	contents, err := server.ReadResource(context.Background(), "cicd://pr/1/files")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var files []changedFile
+	if err := json.Unmarshal([]byte(contents.Text), &files); err != nil {
+		t.Fatal(err)
+	}
+	want := []changedFile{
+		{Path: "main.go", Status: "modified", Additions: 2, Deletions: 1},
+		{Path: "new.go", Status: "added", Additions: 1},
+	}
+	if len(files) != len(want) {
+		t.Fatalf("files = %+v, want %+v", files, want)
+	}
+	for i := range want {
+		if files[i] != want[i] {
+			t.Errorf("file %d = %+v, want %+v", i, files[i], want[i])
+		}
+	}
+}
+
+func TestPrompts(t *testing.T) {
+	server := newResourceServer(t)
+
+	var list struct {
+		Prompts []Prompt `json:"prompts"`
+	}
+	if err := call(t, server, "prompts/list", "", &list); err != nil {
+		t.Fatal(err)
+	}
+	if len(list.Prompts) != 1 {
+		t.Fatalf("prompts = %+v", list.Prompts)
+	}
+	prompt := list.Prompts[0]
+	if prompt.Name != "reviewer" || len(prompt.Arguments) != 2 || !prompt.Arguments[0].Required ||
+		prompt.Arguments[1].Name != "pr_id" || prompt.Arguments[1].Required {
+		t.Errorf("prompt = %+v", prompt)
+	}
+
+	var get struct {
+		Description string          `json:"description"`
+		Messages    []PromptMessage `json:"messages"`
+	}
+	if err := call(t, server, "prompts/get", `{"name": "reviewer", "arguments": {"focus": "security", "pr_id": "3"}}`, &get); err != nil {
+		t.Fatal(err)
+	}
+	if len(get.Messages) != 2 {
+		t.Fatalf("messages = %+v", get.Messages)
+	}
+	if text := get.Messages[0].Content.Text; !strings.Contains(text, "Review the changes.") || !strings.Contains(text, "- focus: security") {
+		t.Errorf("prompt text = %q", text)
+	}
+	if r := get.Messages[1].Content.Resource; get.Messages[1].Content.Type != "resource" || r == nil || r.URI != "cicd://pr/3/diff" {
+		t.Errorf("embedded diff = %+v", get.Messages[1].Content)
+	}
+
+	for _, params := range []string{
+		`{"name": "reviewer"}`,
+		`{"name": "missing"}`,
+		`{"name": "reviewer", "arguments": {"focus": "x", "pr_id": "abc"}}`,
+	} {
+		if err := call(t, server, "prompts/get", params, nil); err == nil || err.Code != CodeInvalidParams {
+			t.Errorf("prompts/get %s error = %+v, want %d", params, err, CodeInvalidParams)
+		}
+	}
+}
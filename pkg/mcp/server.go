@@ -5,13 +5,17 @@ package mcp
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log/slog"
 	"net/http"
 	"sync"
+	"time"
 
+	"github.com/cicd-ai-toolkit/cicd-runner/pkg/observability"
 	"github.com/cicd-ai-toolkit/cicd-runner/pkg/platform"
+	"github.com/cicd-ai-toolkit/cicd-runner/pkg/skill"
 )
 
 // Server implements an MCP server over stdio or HTTP
@@ -20,6 +24,17 @@ type Server struct {
 	tools    []Tool
 	mu       sync.RWMutex
 	logger   *slog.Logger
+
+	prID int // Current pull request listed as resources; 0 for none
+
+	skills   *skill.Loader // nil without a skills directory
+	skillsMu sync.Mutex    // The loader is not safe for concurrent use
+
+	sessions   map[*Session]struct{}
+	sessionsMu sync.Mutex
+
+	rbac  *observability.RBAC        // Authorizes authenticated sessions; nil allows all
+	audit *observability.AuditLogger // Records tool calls; nil for none
 }
 
 // Tool represents an MCP tool
@@ -28,6 +43,10 @@ type Tool struct {
 	Description string         `json:"description"`
 	InputSchema map[string]any `json:"inputSchema"`
 	Handler     ToolHandler    `json:"-"`
+
+	// Permission an authenticated caller needs to call the tool
+	// (default: write)
+	Permission observability.Permission `json:"-"`
 }
 
 // ToolHandler handles tool execution
@@ -38,11 +57,55 @@ func NewServer(p platform.Platform, logger *slog.Logger) *Server {
 	s := &Server{
 		platform: p,
 		logger:   logger,
+		sessions: make(map[*Session]struct{}),
 	}
 	s.registerDefaultTools()
 	return s
 }
 
+// SetSkillsDir sets the directory whose skills are served as resources
+// and prompts
+func (s *Server) SetSkillsDir(dir string) {
+	s.skillsMu.Lock()
+	defer s.skillsMu.Unlock()
+	s.skills = skill.NewLoader(dir)
+}
+
+// SetPRID sets the pull request whose resources resources/list includes.
+// Other pull requests can still be read by URI.
+func (s *Server) SetPRID(prID int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.prID = prID
+}
+
+// currentPR returns the pull request set with SetPRID
+func (s *Server) currentPR() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.prID
+}
+
+// loadSkills loads the skills of the skills directory
+func (s *Server) loadSkills() ([]*skill.Skill, error) {
+	s.skillsMu.Lock()
+	defer s.skillsMu.Unlock()
+	if s.skills == nil {
+		return nil, nil
+	}
+	return s.skills.LoadAll()
+}
+
+// loadSkill loads a skill by name
+func (s *Server) loadSkill(name string) (*skill.Skill, error) {
+	s.skillsMu.Lock()
+	defer s.skillsMu.Unlock()
+	if s.skills == nil {
+		return nil, fmt.Errorf("no skills directory")
+	}
+	return s.skills.Load(name)
+}
+
 // registerDefaultTools registers platform-specific tools
 func (s *Server) registerDefaultTools() {
 	s.tools = []Tool{
@@ -59,7 +122,8 @@ func (s *Server) registerDefaultTools() {
 				},
 				"required": []string{"pr_id"},
 			},
-			Handler: s.handleGetPRInfo,
+			Handler:    s.handleGetPRInfo,
+			Permission: observability.PermissionRead,
 		},
 		{
 			Name:        "get_pr_diff",
@@ -74,7 +138,8 @@ func (s *Server) registerDefaultTools() {
 				},
 				"required": []string{"pr_id"},
 			},
-			Handler: s.handleGetPRDiff,
+			Handler:    s.handleGetPRDiff,
+			Permission: observability.PermissionRead,
 		},
 		{
 			Name:        "get_file_content",
@@ -93,7 +158,8 @@ func (s *Server) registerDefaultTools() {
 				},
 				"required": []string{"path", "ref"},
 			},
-			Handler: s.handleGetFileContent,
+			Handler:    s.handleGetFileContent,
+			Permission: observability.PermissionRead,
 		},
 		{
 			Name:        "post_review_comment",
@@ -116,7 +182,8 @@ func (s *Server) registerDefaultTools() {
 				},
 				"required": []string{"pr_id", "body"},
 			},
-			Handler: s.handlePostReviewComment,
+			Handler:    s.handlePostReviewComment,
+			Permission: observability.PermissionWrite,
 		},
 		{
 			Name:        "list_files",
@@ -135,23 +202,35 @@ func (s *Server) registerDefaultTools() {
 				},
 				"required": []string{"path", "ref"},
 			},
-			Handler: s.handleListFiles,
+			Handler:    s.handleListFiles,
+			Permission: observability.PermissionRead,
 		},
 	}
 }
 
-// RegisterTool registers a custom tool
+// RegisterTool registers a custom tool and notifies initialized clients
+// that the tool list changed
 func (s *Server) RegisterTool(tool Tool) {
 	s.mu.Lock()
-	defer s.mu.Unlock()
 	s.tools = append(s.tools, tool)
+	s.mu.Unlock()
+
+	s.sessionsMu.Lock()
+	sessions := make([]*Session, 0, len(s.sessions))
+	for sess := range s.sessions {
+		sessions = append(sessions, sess)
+	}
+	s.sessionsMu.Unlock()
+	for _, sess := range sessions {
+		sess.send("notifications/tools/list_changed", nil)
+	}
 }
 
 // ListTools returns all available tools (for MCP tools/list response)
 func (s *Server) ListTools() []Tool {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
-	return s.tools
+	return append([]Tool(nil), s.tools...)
 }
 
 // CallTool executes a tool by name
@@ -171,7 +250,7 @@ func (s *Server) CallTool(ctx context.Context, name string, args map[string]any)
 		}
 	}
 
-	return nil, fmt.Errorf("tool not found: %s", name)
+	return nil, &rpcError{code: CodeInvalidParams, message: "Unknown tool: " + name}
 }
 
 // Tool handlers
@@ -320,8 +399,28 @@ type InitializeResult struct {
 	Meta            map[string]interface{} `json:"meta,omitempty"`
 }
 
-// HandleRequest handles an incoming MCP JSON-RPC request
+// rpcError is an error answered with a specific JSON-RPC error code
+type rpcError struct {
+	code    int
+	message string
+}
+
+func (e *rpcError) Error() string {
+	return e.message
+}
+
+// HandleRequest handles an incoming MCP JSON-RPC request outside of a
+// session, as the stateless HTTP transport does. Notifications get an
+// empty response that must not be sent.
 func (s *Server) HandleRequest(ctx context.Context, req MCPRequest) MCPResponse {
+	sess := s.NewSession(nil)
+	defer sess.Close()
+	resp, _ := sess.Handle(ctx, req)
+	return resp
+}
+
+// dispatch runs the method of a request in a session
+func (s *Server) dispatch(ctx context.Context, sess *Session, req MCPRequest) MCPResponse {
 	var result any
 	var err error
 
@@ -329,45 +428,107 @@ func (s *Server) HandleRequest(ctx context.Context, req MCPRequest) MCPResponse
 	case "initialize":
 		var params InitializeParams
 		if err := json.Unmarshal(req.Params, &params); err != nil {
-			return s.errorResponse(req.ID, -32700, "Parse error", nil)
+			return s.errorResponse(req.ID, CodeInvalidParams, "Invalid params", nil)
 		}
-		result = s.handleInitialize(params)
+		result = s.handleInitialize(sess, params)
+
+	case "ping":
+		result = map[string]any{}
 
 	case "tools/list":
 		result = s.handleListTools()
 
 	case "tools/call":
-		result, err = s.handleToolsCall(ctx, req.Params)
-		if err != nil {
-			return s.errorResponse(req.ID, -32603, "Internal error", []byte(err.Error()))
+		result, err = s.handleToolsCall(ctx, sess, req.Params)
+
+	case "resources/list":
+		var resources []Resource
+		resources, err = s.ListResources()
+		result = map[string]any{"resources": nonNil(resources)}
+
+	case "resources/templates/list":
+		result = map[string]any{"resourceTemplates": s.ListResourceTemplates()}
+
+	case "resources/read":
+		var p struct {
+			URI string `json:"uri"`
+		}
+		if err := json.Unmarshal(req.Params, &p); err != nil || p.URI == "" {
+			return s.errorResponse(req.ID, CodeInvalidParams, "Invalid params: uri is required", nil)
+		}
+		if err := s.authorize(sess, observability.PermissionRead); err != nil {
+			return s.errorResponse(req.ID, CodeForbidden, err.Error(), nil)
+		}
+		var contents *ResourceContents
+		if contents, err = s.ReadResource(ctx, p.URI); err == nil {
+			result = map[string]any{"contents": []*ResourceContents{contents}}
+		}
+
+	case "prompts/list":
+		var prompts []Prompt
+		prompts, err = s.ListPrompts()
+		result = map[string]any{"prompts": nonNil(prompts)}
+
+	case "prompts/get":
+		var p struct {
+			Name      string            `json:"name"`
+			Arguments map[string]string `json:"arguments,omitempty"`
+		}
+		if err := json.Unmarshal(req.Params, &p); err != nil || p.Name == "" {
+			return s.errorResponse(req.ID, CodeInvalidParams, "Invalid params: name is required", nil)
+		}
+		if err := s.authorize(sess, observability.PermissionRead); err != nil {
+			return s.errorResponse(req.ID, CodeForbidden, err.Error(), nil)
+		}
+		var description string
+		var messages []PromptMessage
+		if description, messages, err = s.GetPrompt(ctx, p.Name, p.Arguments); err == nil {
+			result = map[string]any{"description": description, "messages": messages}
 		}
 
 	default:
-		return s.errorResponse(req.ID, -32601, "Method not found", nil)
+		return s.errorResponse(req.ID, CodeMethodNotFound, "Method not found", nil)
 	}
 
-	if result != nil {
-		data, _ := json.Marshal(result)
-		return MCPResponse{
-			JSONRPC: "2.0",
-			ID:      req.ID,
-			Result:  json.RawMessage(data),
+	if err != nil {
+		var rpcErr *rpcError
+		if errors.As(err, &rpcErr) {
+			return s.errorResponse(req.ID, rpcErr.code, rpcErr.message, nil)
 		}
+		s.logger.Error("request failed", "method", req.Method, "error", err)
+		return s.errorResponse(req.ID, CodeInternalError, err.Error(), nil)
 	}
 
+	data, err := json.Marshal(result)
+	if err != nil {
+		return s.errorResponse(req.ID, CodeInternalError, "Internal error", nil)
+	}
 	return MCPResponse{
 		JSONRPC: "2.0",
 		ID:      req.ID,
+		Result:  json.RawMessage(data),
 	}
 }
 
-func (s *Server) handleInitialize(params InitializeParams) InitializeResult {
-	s.logger.Info("MCP server initialized", "client", params.ClientInfo["name"])
+// nonNil returns an empty list for nil, so lists are encoded as []
+func nonNil[T any](list []T) []T {
+	if list == nil {
+		return []T{}
+	}
+	return list
+}
+
+func (s *Server) handleInitialize(sess *Session, params InitializeParams) InitializeResult {
+	version := sess.initialize(params)
+	s.logger.Info("MCP server initialized", "client", params.ClientInfo["name"],
+		"requested_protocol", params.ProtocolVersion, "protocol", version)
 
 	return InitializeResult{
-		ProtocolVersion: "2024-11-05",
+		ProtocolVersion: version,
 		Capabilities: map[string]any{
-			"tools": map[string]any{},
+			"tools":     map[string]any{"listChanged": true},
+			"resources": map[string]any{},
+			"prompts":   map[string]any{},
 		},
 		ServerInfo: map[string]string{
 			"name":    "cicd-toolkit",
@@ -377,8 +538,9 @@ func (s *Server) handleInitialize(params InitializeParams) InitializeResult {
 }
 
 func (s *Server) handleListTools() map[string]any {
-	tools := make([]map[string]any, len(s.tools))
-	for i, tool := range s.tools {
+	list := s.ListTools()
+	tools := make([]map[string]any, len(list))
+	for i, tool := range list {
 		tools[i] = map[string]any{
 			"name":        tool.Name,
 			"description": tool.Description,
@@ -390,16 +552,67 @@ func (s *Server) handleListTools() map[string]any {
 	}
 }
 
-func (s *Server) handleToolsCall(ctx context.Context, params json.RawMessage) (map[string]any, error) {
+// handleToolsCall calls a tool the session's caller is permitted to call.
+// The result is returned as JSON text content and as structured content; a
+// failing tool is a result with isError set, so the model sees the error,
+// while an unknown or forbidden tool is a protocol error.
+func (s *Server) handleToolsCall(ctx context.Context, sess *Session, params json.RawMessage) (map[string]any, error) {
 	var p struct {
 		Name      string         `json:"name"`
 		Arguments map[string]any `json:"arguments,omitempty"`
 	}
 	if err := json.Unmarshal(params, &p); err != nil {
-		return nil, fmt.Errorf("invalid params: %w", err)
+		return nil, &rpcError{code: CodeInvalidParams, message: fmt.Sprintf("Invalid params: %v", err)}
+	}
+
+	permission, ok := s.toolPermission(p.Name)
+	if !ok {
+		return nil, &rpcError{code: CodeInvalidParams, message: "Unknown tool: " + p.Name}
+	}
+	if err := s.authorize(sess, permission); err != nil {
+		s.auditToolCall(sess, p.Name, 0, err)
+		return nil, err
+	}
+
+	start := time.Now()
+	result, err := s.CallTool(ctx, p.Name, p.Arguments)
+	s.auditToolCall(sess, p.Name, time.Since(start), err)
+	var rpcErr *rpcError
+	if errors.As(err, &rpcErr) {
+		return nil, err
+	}
+	if err != nil {
+		return map[string]any{
+			"content": []Content{{Type: "text", Text: err.Error()}},
+			"isError": true,
+		}, nil
+	}
+
+	text, err := json.Marshal(result)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode tool result: %w", err)
 	}
+	return map[string]any{
+		"content":           []Content{{Type: "text", Text: string(text)}},
+		"structuredContent": result,
+		"isError":           false,
+	}, nil
+}
 
-	return s.CallTool(ctx, p.Name, p.Arguments)
+// toolPermission returns the permission needed to call a tool, and false
+// for unknown tools
+func (s *Server) toolPermission(name string) (observability.Permission, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for _, tool := range s.tools {
+		if tool.Name == name {
+			if tool.Permission == "" {
+				return observability.PermissionWrite, true
+			}
+			return tool.Permission, true
+		}
+	}
+	return "", false
 }
 
 func (s *Server) errorResponse(id any, code int, message string, data json.RawMessage) MCPResponse {
@@ -414,7 +627,9 @@ func (s *Server) errorResponse(id any, code int, message string, data json.RawMe
 	}
 }
 
-// ServeHTTP handles HTTP requests (for SSE/HTTP transport)
+// ServeHTTP handles single JSON-RPC POST requests without a session or
+// authentication; notifications are answered with 202. Shared deployments
+// use the Streamable HTTP transport of NewHTTPHandler instead.
 func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 
@@ -425,32 +640,77 @@ func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 
 	var req MCPRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		s.writeError(w, nil, -32700, "Parse error", nil)
+		s.writeError(w, nil, CodeParseError, "Parse error", nil)
 		return
 	}
 
-	resp := s.HandleRequest(r.Context(), req)
+	sess := s.NewSession(nil)
+	defer sess.Close()
+	resp, ok := sess.Handle(r.Context(), req)
+	if !ok {
+		w.WriteHeader(http.StatusAccepted)
+		return
+	}
 	json.NewEncoder(w).Encode(resp)
 }
 
-// ServeStdio handles stdio transport (for direct Claude Code integration)
+// ServeStdio handles stdio transport (for direct Claude Code integration).
+// Requests other than initialize run concurrently so that a client can
+// cancel or ping while a tool call is running; it returns when the input
+// ends and the requests in flight have been answered.
 func (s *Server) ServeStdio(ctx context.Context, in io.Reader, out io.Writer) error {
 	decoder := json.NewDecoder(in)
 	encoder := json.NewEncoder(out)
 
+	var writeMu sync.Mutex
+	write := func(v any) error {
+		writeMu.Lock()
+		defer writeMu.Unlock()
+		return encoder.Encode(v)
+	}
+
+	sess := s.NewSession(func(n MCPNotification) error { return write(n) })
+	defer sess.Close()
+
+	var wg sync.WaitGroup
+	defer wg.Wait()
+	var writeErr error
+	var errOnce sync.Once
+
 	for {
 		var req MCPRequest
 		if err := decoder.Decode(&req); err != nil {
 			if err == io.EOF {
-				return nil
+				wg.Wait()
+				return writeErr
 			}
 			return fmt.Errorf("decode error: %w", err)
 		}
 
-		resp := s.HandleRequest(ctx, req)
-		if err := encoder.Encode(resp); err != nil {
-			return fmt.Errorf("encode error: %w", err)
+		switch {
+		case req.ID == nil:
+			sess.Handle(ctx, req)
+			continue
+		case req.Method == "initialize":
+			// Initialize before reading on, so the lifecycle stays in order
+			if resp, ok := sess.Handle(ctx, req); ok {
+				if err := write(resp); err != nil {
+					return fmt.Errorf("encode error: %w", err)
+				}
+			}
+			continue
 		}
+		wg.Add(1)
+		go func(req MCPRequest) {
+			defer wg.Done()
+			resp, ok := sess.Handle(ctx, req)
+			if !ok {
+				return
+			}
+			if err := write(resp); err != nil {
+				errOnce.Do(func() { writeErr = fmt.Errorf("encode error: %w", err) })
+			}
+		}(req)
 	}
 }
 
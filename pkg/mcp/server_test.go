@@ -44,6 +44,10 @@ func (m *mockPlatform) GetPRInfo(ctx context.Context, prID int) (*platform.PRInf
 	}, nil
 }
 
+func (m *mockPlatform) SetStatus(ctx context.Context, status platform.CommitStatus) error {
+	return nil
+}
+
 func (m *mockPlatform) Health(ctx context.Context) error {
 	return nil
 }
@@ -309,9 +313,18 @@ func TestHandleRequestToolsCall(t *testing.T) {
 		t.Fatalf("Failed to unmarshal result: %v", err)
 	}
 
+	if result["isError"] != false {
+		t.Errorf("Expected isError false, got %v", result["isError"])
+	}
+	content, ok := result["content"].([]any)
+	if !ok || len(content) != 1 || content[0].(map[string]any)["type"] != "text" {
+		t.Errorf("Expected one text content block, got %v", result["content"])
+	}
+
 	// JSON numbers are unmarshaled as float64
-	if int(result["number"].(float64)) != 123 {
-		t.Errorf("Expected PR number 123, got %v", result["number"])
+	structured, _ := result["structuredContent"].(map[string]any)
+	if number, _ := structured["number"].(float64); int(number) != 123 {
+		t.Errorf("Expected PR number 123, got %v", structured["number"])
 	}
 }
 
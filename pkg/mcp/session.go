@@ -0,0 +1,196 @@
+// Package mcp provides the per-client session state of the MCP server:
+// protocol version negotiation, lifecycle and request cancellation
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// SupportedProtocolVersions are the MCP protocol revisions the server
+// speaks, newest first
+var SupportedProtocolVersions = []string{"2025-06-18", "2025-03-26", "2024-11-05"}
+
+// JSON-RPC and MCP error codes
+const (
+	CodeParseError       = -32700
+	CodeInvalidRequest   = -32600
+	CodeMethodNotFound   = -32601
+	CodeInvalidParams    = -32602
+	CodeInternalError    = -32603
+	CodeResourceNotFound = -32002
+)
+
+// errCancelled is the cause of a request context cancelled by the client
+var errCancelled = errors.New("request cancelled by client")
+
+// MCPNotification is a JSON-RPC notification sent by the server
+type MCPNotification struct {
+	JSONRPC string `json:"jsonrpc"`
+	Method  string `json:"method"`
+	Params  any    `json:"params,omitempty"`
+}
+
+// NotifyFunc delivers a server notification to the client of a session
+type NotifyFunc func(MCPNotification) error
+
+// Session is the state of one client connection: the negotiated protocol
+// version, whether the client finished initialization and its requests in
+// flight, which the client may cancel.
+type Session struct {
+	server *Server
+	notify NotifyFunc
+
+	// Set by authenticating transports before the session is used
+	id       string // Transport session ID, recorded in the audit log
+	identity string // Authenticated caller; "" for local transports
+
+	mu              sync.Mutex
+	protocolVersion string
+	initialized     bool
+	inflight        map[string]context.CancelCauseFunc
+	closed          bool
+}
+
+// NewSession starts a session. notify delivers server notifications such
+// as notifications/tools/list_changed; with nil they are dropped. Close
+// the session when the connection ends.
+func (s *Server) NewSession(notify NotifyFunc) *Session {
+	sess := &Session{
+		server:   s,
+		notify:   notify,
+		inflight: make(map[string]context.CancelCauseFunc),
+	}
+	s.sessionsMu.Lock()
+	s.sessions[sess] = struct{}{}
+	s.sessionsMu.Unlock()
+	return sess
+}
+
+// Close ends the session and cancels its requests in flight
+func (sess *Session) Close() {
+	sess.server.sessionsMu.Lock()
+	delete(sess.server.sessions, sess)
+	sess.server.sessionsMu.Unlock()
+
+	sess.mu.Lock()
+	defer sess.mu.Unlock()
+	sess.closed = true
+	for _, cancel := range sess.inflight {
+		cancel(context.Canceled)
+	}
+}
+
+// ProtocolVersion returns the negotiated protocol version, or "" before
+// initialize
+func (sess *Session) ProtocolVersion() string {
+	sess.mu.Lock()
+	defer sess.mu.Unlock()
+	return sess.protocolVersion
+}
+
+// Initialized reports whether the client sent notifications/initialized
+func (sess *Session) Initialized() bool {
+	sess.mu.Lock()
+	defer sess.mu.Unlock()
+	return sess.initialized
+}
+
+// Handle handles a request or notification. It reports false when no
+// response must be sent: for notifications and for requests the client
+// cancelled.
+func (sess *Session) Handle(ctx context.Context, req MCPRequest) (MCPResponse, bool) {
+	if req.ID == nil {
+		sess.handleNotification(req)
+		return MCPResponse{}, false
+	}
+
+	key := requestKey(req.ID)
+	ctx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+	sess.mu.Lock()
+	if sess.closed {
+		sess.mu.Unlock()
+		return MCPResponse{}, false
+	}
+	sess.inflight[key] = cancel
+	sess.mu.Unlock()
+	defer func() {
+		sess.mu.Lock()
+		delete(sess.inflight, key)
+		sess.mu.Unlock()
+	}()
+
+	resp := sess.server.dispatch(ctx, sess, req)
+	if errors.Is(context.Cause(ctx), errCancelled) {
+		sess.server.logger.Info("request cancelled", "method", req.Method, "id", key)
+		return MCPResponse{}, false
+	}
+	return resp, true
+}
+
+// handleNotification handles a notification from the client. Unknown
+// notifications are ignored, as the protocol requires.
+func (sess *Session) handleNotification(req MCPRequest) {
+	switch req.Method {
+	case "notifications/initialized":
+		sess.mu.Lock()
+		sess.initialized = true
+		sess.mu.Unlock()
+		sess.server.logger.Info("MCP client ready", "protocol", sess.ProtocolVersion())
+
+	case "notifications/cancelled":
+		var p struct {
+			RequestID any    `json:"requestId"`
+			Reason    string `json:"reason,omitempty"`
+		}
+		if err := json.Unmarshal(req.Params, &p); err != nil || p.RequestID == nil {
+			return
+		}
+		sess.mu.Lock()
+		cancel, ok := sess.inflight[requestKey(p.RequestID)]
+		sess.mu.Unlock()
+		if ok {
+			cancel(errCancelled)
+		}
+
+	default:
+		sess.server.logger.Debug("ignoring notification", "method", req.Method)
+	}
+}
+
+// initialize negotiates the protocol version: the client's version when
+// the server supports it, otherwise the newest the server speaks
+func (sess *Session) initialize(params InitializeParams) string {
+	version := SupportedProtocolVersions[0]
+	for _, v := range SupportedProtocolVersions {
+		if v == params.ProtocolVersion {
+			version = v
+			break
+		}
+	}
+
+	sess.mu.Lock()
+	sess.protocolVersion = version
+	sess.mu.Unlock()
+	return version
+}
+
+// send delivers a notification once the client is initialized
+func (sess *Session) send(method string, params any) {
+	if sess.notify == nil || !sess.Initialized() {
+		return
+	}
+	if err := sess.notify(MCPNotification{JSONRPC: "2.0", Method: method, Params: params}); err != nil {
+		sess.server.logger.Warn("failed to send notification", "method", method, "error", err)
+	}
+}
+
+// requestKey identifies a request ID, a JSON string or number; "1" and 1
+// are different IDs
+func requestKey(id any) string {
+	return fmt.Sprintf("%T:%v", id, id)
+}
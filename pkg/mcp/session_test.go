@@ -0,0 +1,211 @@
+// Package mcp provides tests for sessions and the stdio transport
+package mcp
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+)
+
+func newTestServer() *Server {
+	return NewServer(&mockPlatform{}, slog.New(slog.NewTextHandler(io.Discard, nil)))
+}
+
+func TestProtocolVersionNegotiation(t *testing.T) {
+	tests := []struct {
+		requested string
+		want      string
+	}{
+		{requested: "2025-06-18", want: "2025-06-18"},
+		{requested: "2025-03-26", want: "2025-03-26"},
+		{requested: "2024-11-05", want: "2024-11-05"},
+		{requested: "2099-01-01", want: SupportedProtocolVersions[0]},
+		{requested: "", want: SupportedProtocolVersions[0]},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.requested, func(t *testing.T) {
+			sess := newTestServer().NewSession(nil)
+			defer sess.Close()
+
+			params := `{"protocolVersion": "` + tt.requested + `", "capabilities": {}, "clientInfo": {"name": "c"}}`
+			resp, ok := sess.Handle(context.Background(), MCPRequest{JSONRPC: "2.0", ID: 1.0, Method: "initialize", Params: json.RawMessage(params)})
+			if !ok || resp.Error != nil {
+				t.Fatalf("initialize = %+v, %v", resp.Error, ok)
+			}
+			var result InitializeResult
+			if err := json.Unmarshal(resp.Result, &result); err != nil {
+				t.Fatal(err)
+			}
+			if result.ProtocolVersion != tt.want || sess.ProtocolVersion() != tt.want {
+				t.Errorf("protocol version = %s, session %s, want %s", result.ProtocolVersion, sess.ProtocolVersion(), tt.want)
+			}
+			for _, capability := range []string{"tools", "resources", "prompts"} {
+				if _, ok := result.Capabilities[capability]; !ok {
+					t.Errorf("capability %s not advertised", capability)
+				}
+			}
+		})
+	}
+}
+
+func TestSessionNotificationsAndPing(t *testing.T) {
+	sess := newTestServer().NewSession(nil)
+	defer sess.Close()
+	ctx := context.Background()
+
+	for _, method := range []string{"notifications/initialized", "notifications/unknown", "tools/list"} {
+		if _, ok := sess.Handle(ctx, MCPRequest{JSONRPC: "2.0", Method: method}); ok {
+			t.Errorf("notification %s got a response", method)
+		}
+	}
+	if !sess.Initialized() {
+		t.Error("session not initialized after notifications/initialized")
+	}
+
+	resp, ok := sess.Handle(ctx, MCPRequest{JSONRPC: "2.0", ID: "p", Method: "ping"})
+	if !ok || resp.Error != nil || string(resp.Result) != "{}" {
+		t.Errorf("ping = %s, %+v, %v", resp.Result, resp.Error, ok)
+	}
+}
+
+func TestToolsCallErrors(t *testing.T) {
+	server := newTestServer()
+	ctx := context.Background()
+
+	resp := server.HandleRequest(ctx, MCPRequest{JSONRPC: "2.0", ID: 1.0, Method: "tools/call", Params: json.RawMessage(`{"name": "nope"}`)})
+	if resp.Error == nil || resp.Error.Code != CodeInvalidParams {
+		t.Errorf("unknown tool error = %+v, want %d", resp.Error, CodeInvalidParams)
+	}
+
+	// A failing tool is a result the model can see, not a protocol error
+	resp = server.HandleRequest(ctx, MCPRequest{JSONRPC: "2.0", ID: 2.0, Method: "tools/call", Params: json.RawMessage(`{"name": "get_pr_info", "arguments": {}}`)})
+	if resp.Error != nil {
+		t.Fatalf("tool error returned as protocol error: %+v", resp.Error)
+	}
+	var result struct {
+		Content []Content `json:"content"`
+		IsError bool      `json:"isError"`
+	}
+	if err := json.Unmarshal(resp.Result, &result); err != nil {
+		t.Fatal(err)
+	}
+	if !result.IsError || len(result.Content) != 1 || result.Content[0].Text == "" {
+		t.Errorf("tool error result = %+v", result)
+	}
+}
+
+// stdioClient drives ServeStdio through pipes
+type stdioClient struct {
+	t     *testing.T
+	in    *io.PipeWriter
+	lines chan map[string]any
+	done  chan error
+}
+
+func newStdioClient(t *testing.T, server *Server) *stdioClient {
+	inR, inW := io.Pipe()
+	outR, outW := io.Pipe()
+	c := &stdioClient{t: t, in: inW, lines: make(chan map[string]any, 10), done: make(chan error, 1)}
+	go func() {
+		c.done <- server.ServeStdio(context.Background(), inR, outW)
+		outW.Close()
+	}()
+	go func() {
+		scanner := bufio.NewScanner(outR)
+		for scanner.Scan() {
+			var msg map[string]any
+			if err := json.Unmarshal(scanner.Bytes(), &msg); err != nil {
+				t.Errorf("invalid output %q: %v", scanner.Text(), err)
+				continue
+			}
+			c.lines <- msg
+		}
+		close(c.lines)
+	}()
+	return c
+}
+
+func (c *stdioClient) send(msg string) {
+	c.t.Helper()
+	if _, err := io.WriteString(c.in, msg+"\n"); err != nil {
+		c.t.Fatal(err)
+	}
+}
+
+func (c *stdioClient) next() map[string]any {
+	c.t.Helper()
+	select {
+	case msg, ok := <-c.lines:
+		if !ok {
+			c.t.Fatal("output closed")
+		}
+		return msg
+	case <-time.After(5 * time.Second):
+		c.t.Fatal("timed out waiting for output")
+		return nil
+	}
+}
+
+func (c *stdioClient) close() {
+	c.t.Helper()
+	c.in.Close()
+	if err := <-c.done; err != nil {
+		c.t.Errorf("ServeStdio() error = %v", err)
+	}
+	for msg := range c.lines {
+		c.t.Errorf("unexpected output %v", msg)
+	}
+}
+
+func TestServeStdioCancellation(t *testing.T) {
+	server := newTestServer()
+	started := make(chan struct{})
+	server.RegisterTool(Tool{
+		Name:        "wait",
+		InputSchema: map[string]any{"type": "object"},
+		Handler: func(ctx context.Context, args map[string]any) (map[string]any, error) {
+			close(started)
+			<-ctx.Done()
+			return nil, ctx.Err()
+		},
+	})
+	c := newStdioClient(t, server)
+
+	c.send(`{"jsonrpc": "2.0", "id": 7, "method": "tools/call", "params": {"name": "wait"}}`)
+	<-started
+
+	// The server answers pings while the tool runs, and never answers the
+	// cancelled call
+	c.send(`{"jsonrpc": "2.0", "id": "ping-1", "method": "ping"}`)
+	if msg := c.next(); msg["id"] != "ping-1" {
+		t.Fatalf("got %v, want ping response", msg)
+	}
+	c.send(`{"jsonrpc": "2.0", "method": "notifications/cancelled", "params": {"requestId": 7, "reason": "user"}}`)
+	c.close()
+}
+
+func TestServeStdioListChanged(t *testing.T) {
+	server := newTestServer()
+	c := newStdioClient(t, server)
+
+	c.send(`{"jsonrpc": "2.0", "id": 1, "method": "initialize", "params": {"protocolVersion": "2025-06-18", "capabilities": {}, "clientInfo": {"name": "c"}}}`)
+	if msg := c.next(); msg["id"] != 1.0 || msg["error"] != nil {
+		t.Fatalf("initialize = %v", msg)
+	}
+	c.send(`{"jsonrpc": "2.0", "method": "notifications/initialized"}`)
+	c.send(`{"jsonrpc": "2.0", "id": 2, "method": "ping"}`)
+	if msg := c.next(); msg["id"] != 2.0 {
+		t.Fatalf("got %v, want ping response", msg)
+	}
+
+	server.RegisterTool(Tool{Name: "extra", InputSchema: map[string]any{"type": "object"}})
+	if msg := c.next(); msg["method"] != "notifications/tools/list_changed" || msg["id"] != nil {
+		t.Errorf("got %v, want tools/list_changed", msg)
+	}
+	c.close()
+}
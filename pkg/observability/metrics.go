@@ -226,6 +226,38 @@ func (m *MetricsCollector) RecordCacheOperation(hit bool, operation string) {
 	m.Counter("cache.operations", 1, labels)
 }
 
+// RecordRetryAttempt records one attempt of a retried call. outcome is
+// success, retry (another attempt follows after delay) or failure.
+func (m *MetricsCollector) RecordRetryAttempt(target, operation string, attempt int, outcome string, delay time.Duration) {
+	labels := map[string]string{
+		"target":    target,
+		"operation": operation,
+		"attempt":   fmt.Sprintf("%d", attempt),
+		"outcome":   outcome,
+	}
+
+	m.Counter("retry.attempts", 1, labels)
+	if outcome == "retry" {
+		m.Histogram("retry.delay_ms", float64(delay.Milliseconds()), map[string]string{"target": target})
+	}
+}
+
+// RetryCount returns the number of retries recorded by RecordRetryAttempt
+func (m *MetricsCollector) RetryCount() int {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	count := 0
+	for key, val := range m.metrics {
+		if strings.HasPrefix(key, "counter.retry.attempts.") && strings.Contains(key, "outcome:retry") {
+			if f, ok := val.(float64); ok {
+				count += int(f)
+			}
+		}
+	}
+	return count
+}
+
 // GetSnapshot returns a snapshot of current metrics
 func (m *MetricsCollector) GetSnapshot() map[string]interface{} {
 	m.mu.RLock()
@@ -0,0 +1,598 @@
+// Package platform provides Bitbucket Cloud and Data Center implementation
+package platform
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/cicd-ai-toolkit/cicd-runner/pkg/retry"
+)
+
+// bitbucketCloudAPIURL is the Bitbucket Cloud REST API root
+const bitbucketCloudAPIURL = "https://api.bitbucket.org/2.0"
+
+// bitbucketServerAPIPath is the REST API path of Bitbucket Data Center
+const bitbucketServerAPIPath = "/rest/api/1.0"
+
+// BitbucketClient implements Platform for Bitbucket Cloud and Bitbucket
+// Data Center (Server). The flavor is chosen by the API URL: Cloud uses
+// api.bitbucket.org, any other host is treated as Data Center.
+type BitbucketClient struct {
+	token    string // Access token, or app password when username is set
+	username string // Optional: enables basic auth with an app password
+	baseURL  string // Cloud: https://api.bitbucket.org/2.0; Data Center: https://host/rest/api/1.0
+	repo     string // workspace/repo_slug (Cloud) or PROJECT/repo_slug (Data Center)
+	server   bool   // true for Bitbucket Data Center
+	client   *http.Client
+}
+
+// BitbucketCloudPR represents a Bitbucket Cloud pull request
+type BitbucketCloudPR struct {
+	ID          int    `json:"id"`
+	Title       string `json:"title"`
+	Description string `json:"description"`
+	State       string `json:"state"`
+	Author      struct {
+		DisplayName string `json:"display_name"`
+		Nickname    string `json:"nickname"`
+	} `json:"author"`
+	Source      BitbucketCloudRef `json:"source"`
+	Destination BitbucketCloudRef `json:"destination"`
+}
+
+// BitbucketCloudRef is a branch endpoint of a Bitbucket Cloud pull request
+type BitbucketCloudRef struct {
+	Branch struct {
+		Name string `json:"name"`
+	} `json:"branch"`
+	Commit struct {
+		Hash string `json:"hash"`
+	} `json:"commit"`
+	Repository struct {
+		FullName string `json:"full_name"`
+	} `json:"repository"`
+}
+
+// BitbucketServerPR represents a Bitbucket Data Center pull request
+type BitbucketServerPR struct {
+	ID          int    `json:"id"`
+	Title       string `json:"title"`
+	Description string `json:"description"`
+	State       string `json:"state"`
+	Author      struct {
+		User struct {
+			Name        string `json:"name"`
+			DisplayName string `json:"displayName"`
+		} `json:"user"`
+	} `json:"author"`
+	FromRef BitbucketServerRef `json:"fromRef"`
+	ToRef   BitbucketServerRef `json:"toRef"`
+}
+
+// BitbucketServerRef is a branch endpoint of a Bitbucket Data Center pull request
+type BitbucketServerRef struct {
+	DisplayID    string `json:"displayId"`
+	LatestCommit string `json:"latestCommit"`
+	Repository   struct {
+		Slug    string `json:"slug"`
+		Project struct {
+			Key string `json:"key"`
+		} `json:"project"`
+	} `json:"repository"`
+}
+
+// BitbucketCloudComment is the request body for a Bitbucket Cloud PR comment
+type BitbucketCloudComment struct {
+	Content struct {
+		Raw string `json:"raw"`
+	} `json:"content"`
+	Inline *BitbucketCloudInline `json:"inline,omitempty"`
+}
+
+// BitbucketCloudInline anchors a Bitbucket Cloud comment to a file line
+type BitbucketCloudInline struct {
+	Path string `json:"path"`
+	To   int    `json:"to,omitempty"`   // Line in the new version
+	From int    `json:"from,omitempty"` // Line in the old version
+}
+
+// BitbucketServerComment is the request body for a Bitbucket Data Center PR comment
+type BitbucketServerComment struct {
+	Text   string                 `json:"text"`
+	Anchor *BitbucketServerAnchor `json:"anchor,omitempty"`
+}
+
+// BitbucketServerAnchor anchors a Bitbucket Data Center comment to a diff line
+type BitbucketServerAnchor struct {
+	Path     string `json:"path"`
+	Line     int    `json:"line"`
+	LineType string `json:"lineType"` // ADDED, REMOVED or CONTEXT
+	FileType string `json:"fileType"` // FROM or TO
+	DiffType string `json:"diffType"` // EFFECTIVE
+}
+
+// BitbucketBuildStatus is the request body for a commit build status
+type BitbucketBuildStatus struct {
+	State       string `json:"state"` // INPROGRESS, SUCCESSFUL, FAILED, STOPPED
+	Key         string `json:"key"`
+	Name        string `json:"name,omitempty"`
+	URL         string `json:"url"`
+	Description string `json:"description,omitempty"`
+}
+
+// bitbucketMaxStatusDescription is the longest build status description kept
+const bitbucketMaxStatusDescription = 255
+
+// NewBitbucketClient creates a new Bitbucket platform client. The API URL
+// defaults to Bitbucket Cloud and can be overridden with BITBUCKET_API_URL.
+func NewBitbucketClient(token, repo string) *BitbucketClient {
+	c := &BitbucketClient{
+		token:   token,
+		baseURL: bitbucketCloudAPIURL,
+		repo:    repo,
+		client: newAPIClient("bitbucket", &http.Transport{
+			MaxIdleConns:        100,
+			MaxIdleConnsPerHost: 10,
+			IdleConnTimeout:     90 * time.Second,
+		}),
+	}
+
+	if apiURL := os.Getenv("BITBUCKET_API_URL"); apiURL != "" {
+		// If validation fails, keep the default URL
+		_ = c.SetBaseURL(apiURL)
+	}
+
+	return c
+}
+
+// Name returns the platform name
+func (b *BitbucketClient) Name() string {
+	return "bitbucket"
+}
+
+// Repository returns the repository the client acts on
+func (b *BitbucketClient) Repository() string {
+	return b.repo
+}
+
+// SetBaseURL sets the API URL. Bitbucket Data Center URLs may be given with
+// or without the /rest/api/1.0 suffix.
+func (b *BitbucketClient) SetBaseURL(apiURL string) error {
+	// SECURITY: Validate baseURL to prevent SSRF attacks
+	if err := validateBaseURL(apiURL); err != nil {
+		return err
+	}
+
+	parsed, err := url.Parse(apiURL)
+	if err != nil {
+		return fmt.Errorf("invalid URL: %w", err)
+	}
+
+	apiURL = strings.TrimSuffix(apiURL, "/")
+	b.server = parsed.Hostname() != "api.bitbucket.org"
+	if b.server && !strings.HasSuffix(apiURL, bitbucketServerAPIPath) {
+		apiURL += bitbucketServerAPIPath
+	}
+	b.baseURL = apiURL
+	return nil
+}
+
+// SetBasicAuth authenticates with a username and app password (Cloud) or
+// personal access token (Data Center) instead of a bearer token
+func (b *BitbucketClient) SetBasicAuth(username, password string) {
+	b.username = username
+	b.token = password
+}
+
+// repoURL returns the API URL of the repository
+func (b *BitbucketClient) repoURL() (string, error) {
+	parts := strings.Split(b.repo, "/")
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return "", fmt.Errorf("invalid repository %q (expected owner/repo_slug)", b.repo)
+	}
+	for _, part := range parts {
+		if err := validatePath(part); err != nil {
+			return "", fmt.Errorf("invalid repository: %w", err)
+		}
+	}
+
+	if b.server {
+		return fmt.Sprintf("%s/projects/%s/repos/%s", b.baseURL, url.PathEscape(parts[0]), url.PathEscape(parts[1])), nil
+	}
+	return fmt.Sprintf("%s/repositories/%s/%s", b.baseURL, url.PathEscape(parts[0]), url.PathEscape(parts[1])), nil
+}
+
+// pullRequestURL returns the API URL of a pull request
+func (b *BitbucketClient) pullRequestURL(prID int) (string, error) {
+	if prID <= 0 {
+		return "", fmt.Errorf("PR ID is required")
+	}
+	repoURL, err := b.repoURL()
+	if err != nil {
+		return "", err
+	}
+	if b.server {
+		return fmt.Sprintf("%s/pull-requests/%d", repoURL, prID), nil
+	}
+	return fmt.Sprintf("%s/pullrequests/%d", repoURL, prID), nil
+}
+
+// doRequest performs an HTTP request with auth and common headers
+func (b *BitbucketClient) doRequest(ctx context.Context, method, url string, body interface{}) (*http.Response, error) {
+	var reqBody io.Reader
+	if body != nil {
+		jsonBody, err := json.Marshal(body)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal request body: %w", err)
+		}
+		reqBody = bytes.NewReader(jsonBody)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, url, reqBody)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	if b.username != "" {
+		req.SetBasicAuth(b.username, b.token)
+	} else if b.token != "" {
+		req.Header.Set("Authorization", "Bearer "+b.token)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	req.Header.Set("User-Agent", userAgent)
+
+	return b.client.Do(req)
+}
+
+// getJSON performs a GET request and decodes the JSON response into result
+func (b *BitbucketClient) getJSON(ctx context.Context, url, what string, result interface{}) error {
+	resp, err := b.doRequest(ctx, "GET", url, nil)
+	if err != nil {
+		return fmt.Errorf("failed to get %s: %w", what, err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to get %s (status %d)", what, resp.StatusCode)
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(result); err != nil {
+		return fmt.Errorf("failed to decode %s response: %w", what, err)
+	}
+	return nil
+}
+
+// getText performs a GET request and returns the raw response body
+func (b *BitbucketClient) getText(ctx context.Context, url, what string) (string, error) {
+	resp, err := b.doRequest(ctx, "GET", url, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to get %s: %w", what, err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("failed to get %s (status %d)", what, resp.StatusCode)
+	}
+
+	content, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("failed to read %s: %w", what, err)
+	}
+	return string(content), nil
+}
+
+// PostComment posts a comment to a Bitbucket pull request, anchored to a
+// file line when a position is given
+func (b *BitbucketClient) PostComment(ctx context.Context, opts CommentOptions) error {
+	prURL, err := b.pullRequestURL(opts.PRID)
+	if err != nil {
+		return err
+	}
+
+	if opts.Position != nil {
+		if err := validatePath(opts.Position.Path); err != nil {
+			return fmt.Errorf("invalid comment path: %w", err)
+		}
+		if opts.Position.Line <= 0 {
+			return fmt.Errorf("line must be positive, got %d", opts.Position.Line)
+		}
+	}
+
+	var payload interface{}
+	if b.server {
+		comment := BitbucketServerComment{Text: opts.Body}
+		if opts.Position != nil {
+			lineType := "ADDED"
+			if opts.Position.OldLine > 0 {
+				lineType = "CONTEXT"
+			}
+			comment.Anchor = &BitbucketServerAnchor{
+				Path:     opts.Position.Path,
+				Line:     opts.Position.Line,
+				LineType: lineType,
+				FileType: "TO",
+				DiffType: "EFFECTIVE",
+			}
+		}
+		payload = comment
+	} else {
+		var comment BitbucketCloudComment
+		comment.Content.Raw = opts.Body
+		if opts.Position != nil {
+			comment.Inline = &BitbucketCloudInline{Path: opts.Position.Path, To: opts.Position.Line}
+		}
+		payload = comment
+	}
+
+	resp, err := b.doRequest(ctx, "POST", prURL+"/comments", payload)
+	if err != nil {
+		return fmt.Errorf("failed to post comment: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusCreated {
+		respBody, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("failed to post comment (status %d): %s", resp.StatusCode, string(respBody))
+	}
+
+	return nil
+}
+
+// SetStatus reports a build status on a commit
+func (b *BitbucketClient) SetStatus(ctx context.Context, status CommitStatus) error {
+	if status.SHA == "" {
+		return fmt.Errorf("commit SHA cannot be empty")
+	}
+
+	repoURL, err := b.repoURL()
+	if err != nil {
+		return err
+	}
+
+	payload := BitbucketBuildStatus{
+		State:       bitbucketStatusState(status.State, b.server),
+		Key:         status.statusName(),
+		Name:        status.statusName(),
+		URL:         status.TargetURL,
+		Description: truncateStatus(status.Description, bitbucketMaxStatusDescription),
+	}
+	// Both APIs require a URL; fall back to the repository
+	if payload.URL == "" {
+		payload.URL = b.repoWebURL()
+	}
+
+	var statusURL string
+	if b.server {
+		// Build statuses live in a separate REST API next to /rest/api/1.0
+		root := strings.TrimSuffix(b.baseURL, bitbucketServerAPIPath)
+		statusURL = fmt.Sprintf("%s/rest/build-status/1.0/commits/%s", root, url.PathEscape(status.SHA))
+	} else {
+		statusURL = fmt.Sprintf("%s/commit/%s/statuses/build", repoURL, url.PathEscape(status.SHA))
+	}
+
+	resp, err := b.doRequest(ctx, "POST", statusURL, payload)
+	if err != nil {
+		return fmt.Errorf("failed to set status: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated && resp.StatusCode != http.StatusNoContent {
+		respBody, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("failed to set status (status %d): %s", resp.StatusCode, string(respBody))
+	}
+
+	return nil
+}
+
+// repoWebURL returns the web URL of the repository
+func (b *BitbucketClient) repoWebURL() string {
+	if b.server {
+		return strings.TrimSuffix(b.baseURL, bitbucketServerAPIPath)
+	}
+	return "https://bitbucket.org/" + b.repo
+}
+
+// bitbucketStatusState maps a status state to a Bitbucket build state.
+// Data Center has no STOPPED state.
+func bitbucketStatusState(state StatusState, server bool) string {
+	switch state {
+	case StatusPending, StatusRunning:
+		return "INPROGRESS"
+	case StatusSuccess:
+		return "SUCCESSFUL"
+	case StatusCancelled:
+		if !server {
+			return "STOPPED"
+		}
+		return "FAILED"
+	default:
+		return "FAILED"
+	}
+}
+
+// GetDiff retrieves the unified diff of a Bitbucket pull request
+func (b *BitbucketClient) GetDiff(ctx context.Context, prID int) (string, error) {
+	prURL, err := b.pullRequestURL(prID)
+	if err != nil {
+		return "", err
+	}
+
+	// Cloud redirects /diff to the diff of the source and merge base commits;
+	// Data Center streams the raw diff from <pr>.diff
+	diffURL := prURL + "/diff"
+	if b.server {
+		diffURL = prURL + ".diff"
+	}
+
+	return b.getText(ctx, diffURL, "diff")
+}
+
+// GetFile retrieves a file from the Bitbucket repository at a ref. An empty
+// ref reads the default branch.
+func (b *BitbucketClient) GetFile(ctx context.Context, path, ref string) (string, error) {
+	if err := validatePath(path); err != nil {
+		return "", fmt.Errorf("invalid path: %w", err)
+	}
+
+	repoURL, err := b.repoURL()
+	if err != nil {
+		return "", err
+	}
+
+	segments := strings.Split(path, "/")
+	for i, s := range segments {
+		segments[i] = url.PathEscape(s)
+	}
+	escapedPath := strings.Join(segments, "/")
+
+	var fileURL string
+	if b.server {
+		fileURL = fmt.Sprintf("%s/raw/%s", repoURL, escapedPath)
+		if ref != "" {
+			fileURL += "?at=" + url.QueryEscape(ref)
+		}
+	} else {
+		if ref == "" {
+			if ref, err = b.defaultBranch(ctx); err != nil {
+				return "", err
+			}
+		}
+		fileURL = fmt.Sprintf("%s/src/%s/%s", repoURL, url.PathEscape(ref), escapedPath)
+	}
+
+	return b.getText(ctx, fileURL, "file")
+}
+
+// defaultBranch returns the main branch of a Bitbucket Cloud repository
+func (b *BitbucketClient) defaultBranch(ctx context.Context) (string, error) {
+	repoURL, err := b.repoURL()
+	if err != nil {
+		return "", err
+	}
+
+	var repo struct {
+		MainBranch struct {
+			Name string `json:"name"`
+		} `json:"mainbranch"`
+	}
+	if err := b.getJSON(ctx, repoURL, "repository", &repo); err != nil {
+		return "", err
+	}
+	if repo.MainBranch.Name == "" {
+		return "", fmt.Errorf("repository %s has no main branch", b.repo)
+	}
+	return repo.MainBranch.Name, nil
+}
+
+// GetPRInfo retrieves pull request information from Bitbucket
+func (b *BitbucketClient) GetPRInfo(ctx context.Context, prID int) (*PRInfo, error) {
+	prURL, err := b.pullRequestURL(prID)
+	if err != nil {
+		return nil, err
+	}
+
+	if b.server {
+		var pr BitbucketServerPR
+		if err := b.getJSON(ctx, prURL, "PR info", &pr); err != nil {
+			return nil, err
+		}
+		sourceRepo := ""
+		if pr.FromRef.Repository.Slug != "" {
+			sourceRepo = pr.FromRef.Repository.Project.Key + "/" + pr.FromRef.Repository.Slug
+		}
+		return &PRInfo{
+			Number:      pr.ID,
+			Title:       pr.Title,
+			Description: pr.Description,
+			Author:      pr.Author.User.Name,
+			SHA:         pr.FromRef.LatestCommit,
+			BaseBranch:  pr.ToRef.DisplayID,
+			HeadBranch:  pr.FromRef.DisplayID,
+			SourceRepo:  sourceRepo,
+		}, nil
+	}
+
+	var pr BitbucketCloudPR
+	if err := b.getJSON(ctx, prURL, "PR info", &pr); err != nil {
+		return nil, err
+	}
+	author := pr.Author.Nickname
+	if author == "" {
+		author = pr.Author.DisplayName
+	}
+	return &PRInfo{
+		Number:      pr.ID,
+		Title:       pr.Title,
+		Description: pr.Description,
+		Author:      author,
+		SHA:         pr.Source.Commit.Hash,
+		BaseBranch:  pr.Destination.Branch.Name,
+		HeadBranch:  pr.Source.Branch.Name,
+		SourceRepo:  pr.Source.Repository.FullName,
+	}, nil
+}
+
+// Health checks if the Bitbucket API and repository are accessible
+func (b *BitbucketClient) Health(ctx context.Context) error {
+	ctx = retry.NoRetry(ctx)
+
+	repoURL, err := b.repoURL()
+	if err != nil {
+		return err
+	}
+
+	resp, err := b.doRequest(ctx, "GET", repoURL, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("Bitbucket API returned status %d", resp.StatusCode)
+	}
+
+	return nil
+}
+
+// IsBitbucketEnv checks if running in Bitbucket Pipelines
+func IsBitbucketEnv() bool {
+	return os.Getenv("BITBUCKET_BUILD_NUMBER") != ""
+}
+
+// ParseRepoFromBitbucketEnv parses workspace/repo_slug from Bitbucket Pipelines environment variables
+func ParseRepoFromBitbucketEnv() (string, error) {
+	if repo := os.Getenv("BITBUCKET_REPO_FULL_NAME"); repo != "" {
+		return repo, nil
+	}
+
+	workspace := os.Getenv("BITBUCKET_WORKSPACE")
+	slug := os.Getenv("BITBUCKET_REPO_SLUG")
+	if workspace != "" && slug != "" {
+		return workspace + "/" + slug, nil
+	}
+
+	return "", fmt.Errorf("could not parse repo from Bitbucket environment")
+}
+
+// ParsePRIDFromBitbucketEnv parses the PR ID from Bitbucket Pipelines environment
+func ParsePRIDFromBitbucketEnv() (int, error) {
+	// Set for pull-requests pipelines only
+	if pr := os.Getenv("BITBUCKET_PR_ID"); pr != "" {
+		var id int
+		if _, err := fmt.Sscanf(pr, "%d", &id); err == nil && id > 0 {
+			return id, nil
+		}
+	}
+
+	return 0, fmt.Errorf("could not parse PR ID from Bitbucket environment")
+}
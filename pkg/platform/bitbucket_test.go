@@ -0,0 +1,228 @@
+// Package platform tests for Bitbucket client
+package platform
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// bitbucketStandIn serves the Bitbucket Cloud and Data Center endpoints used
+// by BitbucketClient and records the last request body per path
+func bitbucketStandIn(t *testing.T) (*httptest.Server, map[string]json.RawMessage) {
+	t.Helper()
+	bodies := make(map[string]json.RawMessage)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer test-token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		if r.Method == http.MethodPost {
+			var body json.RawMessage
+			if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			bodies[r.URL.Path] = body
+			w.WriteHeader(http.StatusCreated)
+			_, _ = w.Write([]byte(`{}`))
+			return
+		}
+
+		switch r.URL.Path {
+		// Bitbucket Cloud
+		case "/repositories/team/repo":
+			_, _ = w.Write([]byte(`{"full_name": "team/repo", "mainbranch": {"name": "main"}}`))
+		case "/repositories/team/repo/pullrequests/5":
+			_, _ = w.Write([]byte(`{
+				"id": 5, "title": "Cloud PR", "description": "desc",
+				"author": {"display_name": "Dev", "nickname": "dev"},
+				"source": {"branch": {"name": "feature"}, "commit": {"hash": "abc123"}, "repository": {"full_name": "fork/repo"}},
+				"destination": {"branch": {"name": "main"}}
+			}`))
+		case "/repositories/team/repo/pullrequests/5/diff":
+			_, _ = w.Write([]byte("diff --git a/a.go b/a.go\n"))
+		case "/repositories/team/repo/src/main/pkg/a.go":
+			_, _ = w.Write([]byte("package pkg\n"))
+
+		// Bitbucket Data Center
+		case "/rest/api/1.0/projects/PROJ/repos/repo":
+			_, _ = w.Write([]byte(`{"slug": "repo"}`))
+		case "/rest/api/1.0/projects/PROJ/repos/repo/pull-requests/9":
+			_, _ = w.Write([]byte(`{
+				"id": 9, "title": "DC PR",
+				"author": {"user": {"name": "dev", "displayName": "Dev"}},
+				"fromRef": {"displayId": "feature", "latestCommit": "def456", "repository": {"slug": "repo", "project": {"key": "PROJ"}}},
+				"toRef": {"displayId": "main"}
+			}`))
+		case "/rest/api/1.0/projects/PROJ/repos/repo/pull-requests/9.diff":
+			_, _ = w.Write([]byte("diff --git a/b.go b/b.go\n"))
+		case "/rest/api/1.0/projects/PROJ/repos/repo/raw/pkg/b.go":
+			if r.URL.Query().Get("at") != "feature" {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			_, _ = w.Write([]byte("package pkg\n"))
+
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	return server, bodies
+}
+
+func TestNewBitbucketClient(t *testing.T) {
+	t.Setenv("BITBUCKET_API_URL", "")
+	client := NewBitbucketClient("test-token", "team/repo")
+
+	if client.baseURL != bitbucketCloudAPIURL || client.server {
+		t.Errorf("expected Bitbucket Cloud defaults, got %s (server=%v)", client.baseURL, client.server)
+	}
+
+	if err := client.SetBaseURL("https://bitbucket.example.com/"); err != nil {
+		t.Fatalf("SetBaseURL failed: %v", err)
+	}
+	if client.baseURL != "https://bitbucket.example.com/rest/api/1.0" || !client.server {
+		t.Errorf("expected Data Center API URL, got %s (server=%v)", client.baseURL, client.server)
+	}
+}
+
+func TestBitbucketCloudClient(t *testing.T) {
+	server, bodies := bitbucketStandIn(t)
+	client := NewBitbucketClient("test-token", "team/repo")
+	client.baseURL = server.URL
+	ctx := context.Background()
+
+	if err := client.Health(ctx); err != nil {
+		t.Errorf("Health failed: %v", err)
+	}
+
+	diff, err := client.GetDiff(ctx, 5)
+	if err != nil || !strings.HasPrefix(diff, "diff --git a/a.go") {
+		t.Errorf("GetDiff = %q, %v", diff, err)
+	}
+
+	// An empty ref reads the main branch
+	content, err := client.GetFile(ctx, "pkg/a.go", "")
+	if err != nil || content != "package pkg\n" {
+		t.Errorf("GetFile = %q, %v", content, err)
+	}
+
+	info, err := client.GetPRInfo(ctx, 5)
+	if err != nil {
+		t.Fatalf("GetPRInfo failed: %v", err)
+	}
+	if info.Number != 5 || info.Author != "dev" || info.SHA != "abc123" || info.BaseBranch != "main" || info.HeadBranch != "feature" || info.SourceRepo != "fork/repo" {
+		t.Errorf("unexpected PR info: %+v", info)
+	}
+
+	err = client.PostComment(ctx, CommentOptions{PRID: 5, Body: "Inline", Position: &Position{Path: "pkg/a.go", Line: 3}})
+	if err != nil {
+		t.Fatalf("PostComment failed: %v", err)
+	}
+	var comment BitbucketCloudComment
+	_ = json.Unmarshal(bodies["/repositories/team/repo/pullrequests/5/comments"], &comment)
+	if comment.Content.Raw != "Inline" || comment.Inline == nil || comment.Inline.Path != "pkg/a.go" || comment.Inline.To != 3 {
+		t.Errorf("unexpected comment: %+v", comment)
+	}
+
+	if err := client.SetStatus(ctx, CommitStatus{SHA: "abc123", State: StatusCancelled}); err != nil {
+		t.Fatalf("SetStatus failed: %v", err)
+	}
+	var status BitbucketBuildStatus
+	_ = json.Unmarshal(bodies["/repositories/team/repo/commit/abc123/statuses/build"], &status)
+	if status.State != "STOPPED" || status.Key != DefaultStatusName || status.URL != "https://bitbucket.org/team/repo" {
+		t.Errorf("unexpected status: %+v", status)
+	}
+}
+
+func TestBitbucketServerClient(t *testing.T) {
+	server, bodies := bitbucketStandIn(t)
+	client := NewBitbucketClient("test-token", "PROJ/repo")
+	client.baseURL = server.URL + bitbucketServerAPIPath
+	client.server = true
+	ctx := context.Background()
+
+	if err := client.Health(ctx); err != nil {
+		t.Errorf("Health failed: %v", err)
+	}
+
+	diff, err := client.GetDiff(ctx, 9)
+	if err != nil || !strings.HasPrefix(diff, "diff --git a/b.go") {
+		t.Errorf("GetDiff = %q, %v", diff, err)
+	}
+
+	content, err := client.GetFile(ctx, "pkg/b.go", "feature")
+	if err != nil || content != "package pkg\n" {
+		t.Errorf("GetFile = %q, %v", content, err)
+	}
+
+	info, err := client.GetPRInfo(ctx, 9)
+	if err != nil {
+		t.Fatalf("GetPRInfo failed: %v", err)
+	}
+	if info.Number != 9 || info.Author != "dev" || info.SHA != "def456" || info.BaseBranch != "main" || info.SourceRepo != "PROJ/repo" {
+		t.Errorf("unexpected PR info: %+v", info)
+	}
+
+	// Unchanged lines are anchored as context lines
+	err = client.PostComment(ctx, CommentOptions{PRID: 9, Body: "Inline", Position: &Position{Path: "pkg/b.go", Line: 8, OldLine: 6}})
+	if err != nil {
+		t.Fatalf("PostComment failed: %v", err)
+	}
+	var comment BitbucketServerComment
+	_ = json.Unmarshal(bodies["/rest/api/1.0/projects/PROJ/repos/repo/pull-requests/9/comments"], &comment)
+	if comment.Text != "Inline" || comment.Anchor == nil || comment.Anchor.Line != 8 || comment.Anchor.LineType != "CONTEXT" || comment.Anchor.FileType != "TO" {
+		t.Errorf("unexpected comment: %+v", comment)
+	}
+
+	if err := client.SetStatus(ctx, CommitStatus{SHA: "def456", State: StatusRunning, TargetURL: "https://ci.example.com/1"}); err != nil {
+		t.Fatalf("SetStatus failed: %v", err)
+	}
+	var status BitbucketBuildStatus
+	_ = json.Unmarshal(bodies["/rest/build-status/1.0/commits/def456"], &status)
+	if status.State != "INPROGRESS" || status.URL != "https://ci.example.com/1" {
+		t.Errorf("unexpected status: %+v", status)
+	}
+}
+
+func TestBitbucketClientValidation(t *testing.T) {
+	client := NewBitbucketClient("test-token", "not-a-repo")
+	ctx := context.Background()
+
+	if _, err := client.GetDiff(ctx, 1); err == nil {
+		t.Error("expected error for invalid repository")
+	}
+
+	client = NewBitbucketClient("test-token", "team/repo")
+	if err := client.PostComment(ctx, CommentOptions{Body: "x"}); err == nil || !strings.Contains(err.Error(), "PR ID is required") {
+		t.Errorf("expected missing PR ID error, got %v", err)
+	}
+	if _, err := client.GetFile(ctx, "../etc/passwd", "main"); err == nil {
+		t.Error("expected error for path traversal")
+	}
+}
+
+func TestParseRepoFromBitbucketEnv(t *testing.T) {
+	t.Setenv("BITBUCKET_REPO_FULL_NAME", "")
+	t.Setenv("BITBUCKET_WORKSPACE", "team")
+	t.Setenv("BITBUCKET_REPO_SLUG", "repo")
+	t.Setenv("BITBUCKET_PR_ID", "12")
+
+	repo, err := ParseRepoFromBitbucketEnv()
+	if err != nil || repo != "team/repo" {
+		t.Errorf("ParseRepoFromBitbucketEnv = %q, %v", repo, err)
+	}
+
+	prID, err := ParsePRIDFromBitbucketEnv()
+	if err != nil || prID != 12 {
+		t.Errorf("ParsePRIDFromBitbucketEnv = %d, %v", prID, err)
+	}
+}
@@ -20,6 +20,9 @@ type Platform interface {
 	// GetPRInfo retrieves pull/merge request metadata
 	GetPRInfo(ctx context.Context, prID int) (*PRInfo, error)
 
+	// SetStatus reports a status on a commit (check run or commit status)
+	SetStatus(ctx context.Context, status CommitStatus) error
+
 	// Health checks if the platform API is accessible
 	Health(ctx context.Context) error
 }
@@ -35,8 +38,16 @@ type CommentOptions struct {
 // Position specifies a location in a file for comments
 type Position struct {
 	Path string
-	Line int
+	Line int    // Line number in the new version of the file
 	SHA  string // Commit SHA for line tracking
+
+	// OldLine is the line number in the old version for unchanged context
+	// lines (required by GitLab discussions); zero for added lines
+	OldLine int
+
+	// DiffPosition is the 1-based offset of the line from the file's first
+	// hunk header (required by Gitee review comments)
+	DiffPosition int
 }
 
 // PRInfo contains pull/merge request metadata
@@ -61,16 +72,25 @@ const (
 	EventPRReopened    EventType = "reopened"
 	EventPRClosed      EventType = "closed"
 	EventPRMerged      EventType = "merged"
+	EventPRComment     EventType = "comment"
 )
 
 // WebhookEvent represents a normalized webhook event
 type WebhookEvent struct {
-	Type      EventType
-	Platform  string
-	PRID      int
-	Repo      string
-	SHA       string
-	BaseRef   string
-	HeadRef   string
-	Timestamp int64
+	Type      EventType       `json:"type"`
+	Platform  string          `json:"platform"`
+	PRID      int             `json:"pr_id"`
+	Repo      string          `json:"repo"`
+	SHA       string          `json:"sha,omitempty"`
+	BaseRef   string          `json:"base_ref,omitempty"`
+	HeadRef   string          `json:"head_ref,omitempty"`
+	Comment   *WebhookComment `json:"comment,omitempty"` // Set for EventPRComment
+	Timestamp int64           `json:"timestamp"`
+}
+
+// WebhookComment is the pull request comment of a comment event
+type WebhookComment struct {
+	ID     int64  `json:"id"`
+	Author string `json:"author"`
+	Body   string `json:"body"`
 }
@@ -13,6 +13,8 @@ import (
 	"os"
 	"strings"
 	"time"
+
+	"github.com/cicd-ai-toolkit/cicd-runner/pkg/retry"
 )
 
 const (
@@ -87,6 +89,11 @@ func (g *GiteeClient) Name() string {
 	return "gitee"
 }
 
+// Repository returns the repository the client acts on
+func (g *GiteeClient) Repository() string {
+	return g.repo
+}
+
 // NewGiteeClient creates a new Gitee platform client
 func NewGiteeClient(token, repo string) *GiteeClient {
 	baseURL := os.Getenv("GITEE_API_URL")
@@ -104,14 +111,11 @@ func NewGiteeClient(token, repo string) *GiteeClient {
 		token:   token,
 		baseURL: baseURL,
 		repo:    repo,
-		client: &http.Client{
-			Timeout: 30 * time.Second,
-			Transport: &http.Transport{
-				MaxIdleConns:        100,
-				MaxIdleConnsPerHost: 10,
-				IdleConnTimeout:     90 * time.Second,
-			},
-		},
+		client: newAPIClient("gitee", &http.Transport{
+			MaxIdleConns:        100,
+			MaxIdleConnsPerHost: 10,
+			IdleConnTimeout:     90 * time.Second,
+		}),
 	}
 }
 
@@ -178,12 +182,24 @@ func validatePath(path string) error {
 	return nil
 }
 
-// PostComment posts a comment to a Gitee pull request
+// PostComment posts a comment to a Gitee pull request.
+// Comments with a diff position are posted as line-level review comments.
 func (g *GiteeClient) PostComment(ctx context.Context, opts CommentOptions) error {
 	if opts.PRID == 0 {
 		return fmt.Errorf("PR ID is required")
 	}
 
+	if opts.Position != nil {
+		_, err := g.PostReviewComment(ctx, opts.PRID, ReviewComment{
+			Path:     opts.Position.Path,
+			Position: opts.Position.DiffPosition,
+			Side:     "RIGHT",
+			Body:     opts.Body,
+			CommitID: opts.Position.SHA,
+		})
+		return err
+	}
+
 	comment := GiteeComment{
 		Body: opts.Body,
 	}
@@ -310,6 +326,8 @@ func (g *GiteeClient) GetPRInfo(ctx context.Context, prID int) (*PRInfo, error)
 
 // Health checks if the Gitee API is accessible
 func (g *GiteeClient) Health(ctx context.Context) error {
+	ctx = retry.NoRetry(ctx)
+
 	url := fmt.Sprintf("%s/repos/%s", g.baseURL, url.QueryEscape(g.repo))
 
 	resp, err := g.doRequest(ctx, "GET", url, nil)
@@ -11,29 +11,6 @@ import (
 	"time"
 )
 
-// StatusState represents the state of a status check
-type StatusState string
-
-const (
-	// StatusPending indicates the check is pending
-	StatusPending StatusState = "pending"
-	// StatusRunning indicates the check is running
-	StatusRunning StatusState = "running"
-	// StatusSuccess indicates the check passed
-	StatusSuccess StatusState = "success"
-	// StatusFailed indicates the check failed
-	StatusFailed StatusState = "fail"
-	// StatusError indicates an error occurred
-	StatusError StatusState = "error"
-	// StatusCancelled indicates the check was cancelled
-	StatusCancelled StatusState = "cancelled"
-)
-
-// String returns the string representation of the status state
-func (s StatusState) String() string {
-	return string(s)
-}
-
 // StatusOptions represents options for creating a status check
 type StatusOptions struct {
 	// State is the status state
@@ -76,7 +53,7 @@ func (g *GiteeClient) CreateStatus(ctx context.Context, sha string, opts StatusO
 	}
 
 	if opts.Context == "" {
-		opts.Context = "cicd-ai-toolkit"
+		opts.Context = DefaultStatusName
 	}
 
 	payload := map[string]interface{}{
@@ -116,6 +93,17 @@ func (g *GiteeClient) CreateStatus(ctx context.Context, sha string, opts StatusO
 	return &status, nil
 }
 
+// SetStatus reports a commit status on Gitee
+func (g *GiteeClient) SetStatus(ctx context.Context, status CommitStatus) error {
+	_, err := g.CreateStatus(ctx, status.SHA, StatusOptions{
+		State:       status.State,
+		TargetURL:   status.TargetURL,
+		Description: status.Description,
+		Context:     status.statusName(),
+	})
+	return err
+}
+
 // GetStatuses retrieves all statuses for a commit
 func (g *GiteeClient) GetStatuses(ctx context.Context, sha string) ([]GiteeStatus, error) {
 	if sha == "" {
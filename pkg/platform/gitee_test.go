@@ -88,6 +88,26 @@ func TestGiteePostCommentValidation(t *testing.T) {
 			wantErr: true,
 			errMsg:  "PR ID is required",
 		},
+		{
+			name: "inline comment without diff position",
+			opts: CommentOptions{
+				PRID:     123,
+				Body:     "Test comment",
+				Position: &Position{Path: "main.go", Line: 10},
+			},
+			wantErr: true,
+			errMsg:  "position must be positive",
+		},
+		{
+			name: "inline comment with traversal path",
+			opts: CommentOptions{
+				PRID:     123,
+				Body:     "Test comment",
+				Position: &Position{Path: "../secret", Line: 10, DiffPosition: 3},
+			},
+			wantErr: true,
+			errMsg:  "invalid path",
+		},
 	}
 
 	for _, tt := range tests {
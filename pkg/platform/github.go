@@ -11,14 +11,21 @@ import (
 	"net/http"
 	"os"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/cicd-ai-toolkit/cicd-runner/pkg/errors"
+	"github.com/cicd-ai-toolkit/cicd-runner/pkg/retry"
 )
 
 const (
-	// DefaultHTTPTimeout is the default timeout for HTTP requests
+	// DefaultHTTPTimeout bounds each attempt of a platform API request
 	DefaultHTTPTimeout = 30 * time.Second
+
+	// GitHub check run API limits
+	gitHubMaxCheckTitle   = 255
+	gitHubMaxCheckSummary = 65535
+	gitHubMaxAnnotations  = 50 // Per request
 )
 
 // GitHubClient implements Platform for GitHub
@@ -27,6 +34,9 @@ type GitHubClient struct {
 	baseURL string // For GitHub Enterprise
 	repo    string // owner/repo format
 	client  *http.Client
+
+	checkMu   sync.Mutex
+	checkRuns map[string]int64 // name@sha -> check run ID, so status updates reuse one run
 }
 
 // GitHubAPIResponse represents common GitHub API response structure
@@ -72,20 +82,50 @@ type GitHubUser struct {
 // GitHubReviewComment represents a review comment
 type GitHubReviewComment struct {
 	Body     string `json:"body"`
+	CommitID string `json:"commit_id,omitempty"`
 	Path     string `json:"path,omitempty"`
 	Position *int   `json:"position,omitempty"`
 	Line     *int   `json:"line,omitempty"`
+	Side     string `json:"side,omitempty"` // LEFT or RIGHT
+}
+
+// GitHubCheckRun is the request and response body of the check runs API
+type GitHubCheckRun struct {
+	ID          int64                 `json:"id,omitempty"`
+	Name        string                `json:"name,omitempty"`
+	HeadSHA     string                `json:"head_sha,omitempty"`
+	Status      string                `json:"status,omitempty"`     // queued, in_progress, completed
+	Conclusion  string                `json:"conclusion,omitempty"` // success, failure, neutral, cancelled
+	DetailsURL  string                `json:"details_url,omitempty"`
+	CompletedAt string                `json:"completed_at,omitempty"`
+	Output      *GitHubCheckRunOutput `json:"output,omitempty"`
+}
+
+// GitHubCheckRunOutput is the summary shown on a check run
+type GitHubCheckRunOutput struct {
+	Title       string                  `json:"title"`
+	Summary     string                  `json:"summary"`
+	Annotations []GitHubCheckAnnotation `json:"annotations,omitempty"`
+}
+
+// GitHubCheckAnnotation marks a file line in a check run
+type GitHubCheckAnnotation struct {
+	Path            string `json:"path"`
+	StartLine       int    `json:"start_line"`
+	EndLine         int    `json:"end_line"`
+	AnnotationLevel string `json:"annotation_level"` // notice, warning, failure
+	Title           string `json:"title,omitempty"`
+	Message         string `json:"message"`
 }
 
 // NewGitHubClient creates a new GitHub platform client
 func NewGitHubClient(token, repo string) *GitHubClient {
 	return &GitHubClient{
-		token:   token,
-		baseURL: "https://api.github.com",
-		repo:    repo,
-		client: &http.Client{
-			Timeout: DefaultHTTPTimeout,
-		},
+		token:     token,
+		baseURL:   "https://api.github.com",
+		repo:      repo,
+		client:    newAPIClient("github", nil),
+		checkRuns: make(map[string]int64),
 	}
 }
 
@@ -104,8 +144,16 @@ func (c *GitHubClient) Name() string {
 	return "github"
 }
 
+// Repository returns the repository the client acts on
+func (c *GitHubClient) Repository() string {
+	return c.repo
+}
+
 // PostComment posts a review comment to a pull request
 func (c *GitHubClient) PostComment(ctx context.Context, opts CommentOptions) error {
+	if opts.Position != nil {
+		return c.postLineComment(ctx, opts)
+	}
 	if opts.AsReview {
 		return c.postReviewComment(ctx, opts)
 	}
@@ -141,18 +189,128 @@ func (c *GitHubClient) postReviewComment(ctx context.Context, opts CommentOption
 		"comments":  []GitHubReviewComment{},
 	}
 
-	// If position is specified, add line-specific comment
-	if opts.Position != nil {
-		payload["comments"] = []GitHubReviewComment{
-			{
-				Body: opts.Body,
-				Path: opts.Position.Path,
-				Line: &opts.Position.Line,
-			},
+	return c.doRequest(ctx, "POST", url, payload, nil)
+}
+
+// postLineComment posts a review comment anchored to a line of the PR diff
+func (c *GitHubClient) postLineComment(ctx context.Context, opts CommentOptions) error {
+	if err := validateFilePath(opts.Position.Path); err != nil {
+		return fmt.Errorf("invalid comment path: %w", err)
+	}
+	if opts.Position.Line <= 0 {
+		return fmt.Errorf("line must be positive, got %d", opts.Position.Line)
+	}
+
+	// Comments must reference a commit; default to the PR head
+	commitID := opts.Position.SHA
+	if commitID == "" {
+		pr, err := c.getPR(ctx, opts.PRID)
+		if err != nil {
+			return fmt.Errorf("failed to get PR info: %w", err)
 		}
+		commitID = pr.Head.SHA
 	}
 
-	return c.doRequest(ctx, "POST", url, payload, nil)
+	url := fmt.Sprintf("%s/repos/%s/pulls/%d/comments", c.baseURL, c.repo, opts.PRID)
+
+	line := opts.Position.Line
+	comment := GitHubReviewComment{
+		Body:     opts.Body,
+		CommitID: commitID,
+		Path:     opts.Position.Path,
+		Line:     &line,
+		Side:     "RIGHT",
+	}
+
+	return c.doRequest(ctx, "POST", url, comment, nil)
+}
+
+// SetStatus reports a commit status as a check run with annotations.
+// The first call for a name and commit creates the check run; later calls update it.
+func (c *GitHubClient) SetStatus(ctx context.Context, status CommitStatus) error {
+	if status.SHA == "" {
+		return fmt.Errorf("commit SHA cannot be empty")
+	}
+
+	run := gitHubCheckRunRequest(status)
+	key := status.statusName() + "@" + status.SHA
+
+	c.checkMu.Lock()
+	defer c.checkMu.Unlock()
+
+	if id, ok := c.checkRuns[key]; ok {
+		url := fmt.Sprintf("%s/repos/%s/check-runs/%d", c.baseURL, c.repo, id)
+		return c.doRequest(ctx, "PATCH", url, run, nil)
+	}
+
+	run.HeadSHA = status.SHA
+	var created GitHubCheckRun
+	url := fmt.Sprintf("%s/repos/%s/check-runs", c.baseURL, c.repo)
+	if err := c.doRequest(ctx, "POST", url, run, &created); err != nil {
+		return err
+	}
+	c.checkRuns[key] = created.ID
+
+	return nil
+}
+
+// gitHubCheckRunRequest converts a commit status into a check run request
+func gitHubCheckRunRequest(status CommitStatus) GitHubCheckRun {
+	run := GitHubCheckRun{
+		Name:       status.statusName(),
+		DetailsURL: status.TargetURL,
+	}
+
+	switch status.State {
+	case StatusPending:
+		run.Status = "queued"
+	case StatusRunning:
+		run.Status = "in_progress"
+	default:
+		run.Status = "completed"
+		run.CompletedAt = time.Now().UTC().Format(time.RFC3339)
+		switch status.State {
+		case StatusSuccess:
+			run.Conclusion = "success"
+		case StatusFailed:
+			run.Conclusion = "failure"
+		case StatusCancelled:
+			run.Conclusion = "cancelled"
+		default:
+			// Infrastructure errors are reported without blocking the merge
+			run.Conclusion = "neutral"
+		}
+	}
+
+	if status.Description != "" || status.Summary != "" || len(status.Annotations) > 0 {
+		title := status.Description
+		if title == "" {
+			title = run.Name
+		}
+		summary := status.Summary
+		if summary == "" {
+			summary = title
+		}
+		run.Output = &GitHubCheckRunOutput{
+			Title:   truncateStatus(title, gitHubMaxCheckTitle),
+			Summary: truncateStatus(summary, gitHubMaxCheckSummary),
+		}
+		for i, a := range status.Annotations {
+			if i == gitHubMaxAnnotations {
+				break
+			}
+			run.Output.Annotations = append(run.Output.Annotations, GitHubCheckAnnotation{
+				Path:            a.Path,
+				StartLine:       a.Line,
+				EndLine:         a.Line,
+				AnnotationLevel: string(a.Level),
+				Title:           a.Title,
+				Message:         a.Message,
+			})
+		}
+	}
+
+	return run
 }
 
 // GetDiff retrieves the diff for a pull request
@@ -266,6 +424,8 @@ func (c *GitHubClient) getPR(ctx context.Context, prID int) (*GitHubPR, error) {
 
 // Health checks if the GitHub API is accessible
 func (c *GitHubClient) Health(ctx context.Context) error {
+	ctx = retry.NoRetry(ctx)
+
 	url := fmt.Sprintf("%s/", c.baseURL)
 
 	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
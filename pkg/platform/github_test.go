@@ -3,6 +3,7 @@ package platform
 
 import (
 	"context"
+	"encoding/json"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -322,6 +323,7 @@ func TestIsGitHubEnv(t *testing.T) {
 
 // Test that Position comments work
 func TestGitHubClient_PostCommentWithPosition(t *testing.T) {
+	var got GitHubReviewComment
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		// Get PR first
 		if r.URL.Path == "/repos/owner/repo/pulls/123" {
@@ -333,10 +335,13 @@ func TestGitHubClient_PostCommentWithPosition(t *testing.T) {
 			return
 		}
 
-		// Post review
-		if r.URL.Path == "/repos/owner/repo/pulls/123/reviews" {
-			// Verify body contains position
-			w.WriteHeader(http.StatusOK)
+		// Post line comment
+		if r.URL.Path == "/repos/owner/repo/pulls/123/comments" && r.Method == "POST" {
+			if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			w.WriteHeader(http.StatusCreated)
 			_, _ = w.Write([]byte(`{"id": 1}`))
 			return
 		}
@@ -361,7 +366,79 @@ func TestGitHubClient_PostCommentWithPosition(t *testing.T) {
 	})
 
 	if err != nil {
-		t.Errorf("PostComment(Position) error = %v", err)
+		t.Fatalf("PostComment(Position) error = %v", err)
+	}
+	if got.CommitID != "abc123" || got.Path != "path/to/file.go" || got.Side != "RIGHT" {
+		t.Errorf("unexpected comment payload: %+v", got)
+	}
+	if got.Line == nil || *got.Line != line {
+		t.Errorf("expected line %d, got %v", line, got.Line)
+	}
+}
+
+func TestGitHubClient_PostCommentWithPosition_InvalidPath(t *testing.T) {
+	client := NewGitHubClient("test-token", "owner/repo")
+
+	err := client.PostComment(context.Background(), CommentOptions{
+		PRID:     1,
+		Body:     "x",
+		Position: &Position{Path: "../etc/passwd", Line: 1, SHA: "abc"},
+	})
+	if err == nil {
+		t.Error("expected error for traversal path")
+	}
+}
+
+func TestGitHubClient_SetStatus(t *testing.T) {
+	var requests []GitHubCheckRun
+	var paths []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var run GitHubCheckRun
+		if err := json.NewDecoder(r.Body).Decode(&run); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		requests = append(requests, run)
+		paths = append(paths, r.Method+" "+r.URL.Path)
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte(`{"id": 42}`))
+	}))
+	defer server.Close()
+
+	client := NewGitHubClient("token", "owner/repo")
+	client.baseURL = server.URL
+	ctx := context.Background()
+
+	if err := client.SetStatus(ctx, CommitStatus{SHA: "abc123", State: StatusPending}); err != nil {
+		t.Fatalf("SetStatus(pending) error = %v", err)
+	}
+	err := client.SetStatus(ctx, CommitStatus{
+		SHA:         "abc123",
+		State:       StatusFailed,
+		Description: "Quality gate failed",
+		Annotations: []StatusAnnotation{{Path: "main.go", Line: 3, Level: AnnotationFailure, Message: "bug"}},
+	})
+	if err != nil {
+		t.Fatalf("SetStatus(failed) error = %v", err)
+	}
+
+	wantPaths := []string{"POST /repos/owner/repo/check-runs", "PATCH /repos/owner/repo/check-runs/42"}
+	if len(paths) != 2 || paths[0] != wantPaths[0] || paths[1] != wantPaths[1] {
+		t.Fatalf("requests = %v, want %v", paths, wantPaths)
+	}
+	if requests[0].HeadSHA != "abc123" || requests[0].Status != "queued" || requests[0].Name != DefaultStatusName {
+		t.Errorf("unexpected create request: %+v", requests[0])
+	}
+	update := requests[1]
+	if update.Status != "completed" || update.Conclusion != "failure" || update.Output == nil {
+		t.Fatalf("unexpected update request: %+v", update)
+	}
+	if len(update.Output.Annotations) != 1 || update.Output.Annotations[0].AnnotationLevel != "failure" {
+		t.Errorf("unexpected annotations: %+v", update.Output.Annotations)
+	}
+
+	if err := client.SetStatus(ctx, CommitStatus{State: StatusPending}); err == nil {
+		t.Error("expected error for empty SHA")
 	}
 }
 
@@ -13,6 +13,8 @@ import (
 	"os"
 	"strings"
 	"time"
+
+	"github.com/cicd-ai-toolkit/cicd-runner/pkg/retry"
 )
 
 // GitLabClient implements Platform for GitLab
@@ -31,21 +33,59 @@ type GitLabAPIResponse struct {
 
 // GitLabMR represents GitLab merge request response
 type GitLabMR struct {
-	ID            int           `json:"id"`
-	IID           int           `json:"iid"` // Merge Request IID (user-facing number)
-	Title         string        `json:"title"`
-	Description   string        `json:"description"`
-	Head          GitLabMRRef   `json:"source_branch"`
-	Base          GitLabMRRef   `json:"target_branch"`
-	Author        GitLabUser    `json:"author"`
-	WebURL        string        `json:"web_url"`
-	State         string        `json:"state"`
-	MergedAt      *time.Time    `json:"merged_at"`
-	CreatedAt     time.Time     `json:"created_at"`
-	UpdatedAt     time.Time     `json:"updated_at"`
-	SourceProject GitLabProject `json:"source_project"`
+	ID            int            `json:"id"`
+	IID           int            `json:"iid"` // Merge Request IID (user-facing number)
+	Title         string         `json:"title"`
+	Description   string         `json:"description"`
+	Head          GitLabMRRef    `json:"source_branch"`
+	Base          GitLabMRRef    `json:"target_branch"`
+	Author        GitLabUser     `json:"author"`
+	WebURL        string         `json:"web_url"`
+	State         string         `json:"state"`
+	MergedAt      *time.Time     `json:"merged_at"`
+	CreatedAt     time.Time      `json:"created_at"`
+	UpdatedAt     time.Time      `json:"updated_at"`
+	SourceProject GitLabProject  `json:"source_project"`
+	DiffRefs      GitLabDiffRefs `json:"diff_refs"`
+}
+
+// GitLabDiffRefs holds the SHAs that identify a merge request diff version
+type GitLabDiffRefs struct {
+	BaseSHA  string `json:"base_sha"`
+	HeadSHA  string `json:"head_sha"`
+	StartSHA string `json:"start_sha"`
+}
+
+// GitLabDiscussion is the request body for a merge request discussion
+type GitLabDiscussion struct {
+	Body     string                    `json:"body"`
+	CommitID string                    `json:"commit_id,omitempty"`
+	Position *GitLabDiscussionPosition `json:"position,omitempty"`
+}
+
+// GitLabDiscussionPosition anchors a discussion to a line of the MR diff
+type GitLabDiscussionPosition struct {
+	PositionType string `json:"position_type"` // always "text"
+	BaseSHA      string `json:"base_sha"`
+	StartSHA     string `json:"start_sha"`
+	HeadSHA      string `json:"head_sha"`
+	OldPath      string `json:"old_path"`
+	NewPath      string `json:"new_path"`
+	NewLine      int    `json:"new_line,omitempty"`
+	OldLine      int    `json:"old_line,omitempty"`
+}
+
+// GitLabCommitStatus is the request body for setting a commit status
+type GitLabCommitStatus struct {
+	State       string `json:"state"` // pending, running, success, failed, canceled
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	TargetURL   string `json:"target_url,omitempty"`
 }
 
+// gitLabMaxStatusDescription is the longest commit status description GitLab accepts
+const gitLabMaxStatusDescription = 255
+
 // GitLabMRRef represents a branch reference in a MR
 type GitLabMRRef string
 
@@ -97,14 +137,11 @@ func NewGitLabClient(token, repo string) *GitLabClient {
 		token:   token,
 		baseURL: baseURL,
 		repo:    repo,
-		client: &http.Client{
-			Timeout: 30 * time.Second,
-			Transport: &http.Transport{
-				MaxIdleConns:        100,
-				MaxIdleConnsPerHost: 10,
-				IdleConnTimeout:     90 * time.Second,
-			},
-		},
+		client: newAPIClient("gitlab", &http.Transport{
+			MaxIdleConns:        100,
+			MaxIdleConnsPerHost: 10,
+			IdleConnTimeout:     90 * time.Second,
+		}),
 	}
 }
 
@@ -113,6 +150,11 @@ func (g *GitLabClient) Name() string {
 	return "gitlab"
 }
 
+// Repository returns the repository the client acts on
+func (g *GitLabClient) Repository() string {
+	return g.repo
+}
+
 // SetBaseURL sets a custom base URL for GitLab self-hosted
 func (g *GitLabClient) SetBaseURL(url string) error {
 	// SECURITY: Validate baseURL to prevent SSRF attacks
@@ -123,11 +165,15 @@ func (g *GitLabClient) SetBaseURL(url string) error {
 	return nil
 }
 
-// PostComment posts a comment to a GitLab merge request
+// PostComment posts a comment to a GitLab merge request.
+// Review and line-anchored comments are posted as resolvable discussions.
 func (g *GitLabClient) PostComment(ctx context.Context, opts CommentOptions) error {
 	if opts.PRID == 0 {
 		return fmt.Errorf("MR IID is required")
 	}
+	if opts.AsReview || opts.Position != nil {
+		return g.postDiscussion(ctx, opts)
+	}
 
 	comment := GitLabComment{
 		Body: opts.Body,
@@ -167,6 +213,162 @@ func (g *GitLabClient) PostComment(ctx context.Context, opts CommentOptions) err
 	return nil
 }
 
+// postDiscussion starts a merge request discussion, anchored to a diff line when a position is given
+func (g *GitLabClient) postDiscussion(ctx context.Context, opts CommentOptions) error {
+	discussion := GitLabDiscussion{Body: opts.Body}
+
+	if opts.Position != nil {
+		if err := validatePath(opts.Position.Path); err != nil {
+			return fmt.Errorf("invalid comment path: %w", err)
+		}
+		if opts.Position.Line <= 0 {
+			return fmt.Errorf("line must be positive, got %d", opts.Position.Line)
+		}
+
+		// Positions must reference the MR's current diff version
+		mr, err := g.getMR(ctx, opts.PRID)
+		if err != nil {
+			return err
+		}
+		discussion.Position = &GitLabDiscussionPosition{
+			PositionType: "text",
+			BaseSHA:      mr.DiffRefs.BaseSHA,
+			StartSHA:     mr.DiffRefs.StartSHA,
+			HeadSHA:      mr.DiffRefs.HeadSHA,
+			OldPath:      opts.Position.Path,
+			NewPath:      opts.Position.Path,
+			NewLine:      opts.Position.Line,
+			// Unchanged lines must carry both old and new line numbers
+			OldLine: opts.Position.OldLine,
+		}
+	}
+
+	body, err := json.Marshal(discussion)
+	if err != nil {
+		return fmt.Errorf("failed to marshal discussion: %w", err)
+	}
+
+	encodedRepo, err := urlPathEncode(g.repo)
+	if err != nil {
+		return fmt.Errorf("invalid repo path: %w", err)
+	}
+	url := fmt.Sprintf("%s/projects/%s/merge_requests/%d/discussions", g.baseURL, encodedRepo, opts.PRID)
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("PRIVATE-TOKEN", g.token)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := g.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to post discussion: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusCreated {
+		respBody, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("failed to post discussion (status %d): %s", resp.StatusCode, string(respBody))
+	}
+
+	return nil
+}
+
+// SetStatus reports a commit status on the project's commit
+func (g *GitLabClient) SetStatus(ctx context.Context, status CommitStatus) error {
+	if status.SHA == "" {
+		return fmt.Errorf("commit SHA cannot be empty")
+	}
+
+	payload := GitLabCommitStatus{
+		State:       gitLabStatusState(status.State),
+		Name:        status.statusName(),
+		Description: truncateStatus(status.Description, gitLabMaxStatusDescription),
+		TargetURL:   status.TargetURL,
+	}
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to marshal status: %w", err)
+	}
+
+	encodedRepo, err := urlPathEncode(g.repo)
+	if err != nil {
+		return fmt.Errorf("invalid repo path: %w", err)
+	}
+	url := fmt.Sprintf("%s/projects/%s/statuses/%s", g.baseURL, encodedRepo, url.PathEscape(status.SHA))
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("PRIVATE-TOKEN", g.token)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := g.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to set status: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusCreated && resp.StatusCode != http.StatusOK {
+		respBody, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("failed to set status (status %d): %s", resp.StatusCode, string(respBody))
+	}
+
+	return nil
+}
+
+// gitLabStatusState maps a status state to a GitLab commit status state
+func gitLabStatusState(state StatusState) string {
+	switch state {
+	case StatusPending:
+		return "pending"
+	case StatusRunning:
+		return "running"
+	case StatusSuccess:
+		return "success"
+	case StatusCancelled:
+		return "canceled"
+	default:
+		return "failed"
+	}
+}
+
+// getMR fetches a merge request by IID
+func (g *GitLabClient) getMR(ctx context.Context, mrID int) (*GitLabMR, error) {
+	encodedRepo, err := urlPathEncode(g.repo)
+	if err != nil {
+		return nil, fmt.Errorf("invalid repo path: %w", err)
+	}
+	url := fmt.Sprintf("%s/projects/%s/merge_requests/%d", g.baseURL, encodedRepo, mrID)
+
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("PRIVATE-TOKEN", g.token)
+
+	resp, err := g.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get MR info: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to get MR info (status %d)", resp.StatusCode)
+	}
+
+	var gitlabMR GitLabMR
+	if err := json.NewDecoder(resp.Body).Decode(&gitlabMR); err != nil {
+		return nil, fmt.Errorf("failed to decode MR response: %w", err)
+	}
+
+	return &gitlabMR, nil
+}
+
 // GetDiff retrieves the diff for a GitLab merge request
 func (g *GitLabClient) GetDiff(ctx context.Context, mrID int) (string, error) {
 	encodedRepo, err := urlPathEncode(g.repo)
@@ -258,32 +460,9 @@ func (g *GitLabClient) GetFile(ctx context.Context, path, ref string) (string, e
 
 // GetPRInfo retrieves merge request information from GitLab
 func (g *GitLabClient) GetPRInfo(ctx context.Context, mrID int) (*PRInfo, error) {
-	encodedRepo, err := urlPathEncode(g.repo)
-	if err != nil {
-		return nil, fmt.Errorf("invalid repo path: %w", err)
-	}
-	url := fmt.Sprintf("%s/projects/%s/merge_requests/%d", g.baseURL, encodedRepo, mrID)
-
-	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
-	}
-
-	req.Header.Set("PRIVATE-TOKEN", g.token)
-
-	resp, err := g.client.Do(req)
+	gitlabMR, err := g.getMR(ctx, mrID)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get MR info: %w", err)
-	}
-	defer func() { _ = resp.Body.Close() }()
-
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("failed to get MR info (status %d)", resp.StatusCode)
-	}
-
-	var gitlabMR GitLabMR
-	if err := json.NewDecoder(resp.Body).Decode(&gitlabMR); err != nil {
-		return nil, fmt.Errorf("failed to decode MR response: %w", err)
+		return nil, err
 	}
 
 	// Get latest SHA for source branch
@@ -324,6 +503,8 @@ func (g *GitLabClient) GetPRInfo(ctx context.Context, mrID int) (*PRInfo, error)
 
 // Health checks if the GitLab API is accessible
 func (g *GitLabClient) Health(ctx context.Context) error {
+	ctx = retry.NoRetry(ctx)
+
 	encodedRepo, err := urlPathEncode(g.repo)
 	if err != nil {
 		return fmt.Errorf("invalid repo path: %w", err)
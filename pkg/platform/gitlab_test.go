@@ -3,6 +3,9 @@ package platform
 
 import (
 	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
 	"testing"
 )
 
@@ -116,3 +119,89 @@ func TestGitLabHealthCheck(t *testing.T) {
 		t.Log("Health check passed (unexpected in test environment)")
 	}
 }
+
+func TestGitLabPostCommentAsDiscussion(t *testing.T) {
+	var got GitLabDiscussion
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.EscapedPath() {
+		case "/projects/owner%2Frepo/merge_requests/7":
+			_, _ = w.Write([]byte(`{"iid": 7, "diff_refs": {"base_sha": "base", "head_sha": "head", "start_sha": "start"}}`))
+		case "/projects/owner%2Frepo/merge_requests/7/discussions":
+			if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			w.WriteHeader(http.StatusCreated)
+			_, _ = w.Write([]byte(`{"id": "abc"}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	client := NewGitLabClient("test-token", "owner/repo")
+	client.baseURL = server.URL
+
+	err := client.PostComment(context.Background(), CommentOptions{
+		PRID:     7,
+		Body:     "Inline finding",
+		Position: &Position{Path: "pkg/a.go", Line: 12, OldLine: 10},
+	})
+	if err != nil {
+		t.Fatalf("PostComment(Position) error = %v", err)
+	}
+
+	if got.Position == nil {
+		t.Fatal("expected discussion position")
+	}
+	pos := got.Position
+	if pos.PositionType != "text" || pos.BaseSHA != "base" || pos.HeadSHA != "head" || pos.StartSHA != "start" {
+		t.Errorf("unexpected diff refs: %+v", pos)
+	}
+	if pos.NewPath != "pkg/a.go" || pos.NewLine != 12 || pos.OldLine != 10 {
+		t.Errorf("unexpected line anchor: %+v", pos)
+	}
+
+	// AsReview without a position starts an unanchored discussion
+	got = GitLabDiscussion{}
+	if err := client.PostComment(context.Background(), CommentOptions{PRID: 7, Body: "Summary", AsReview: true}); err != nil {
+		t.Fatalf("PostComment(AsReview) error = %v", err)
+	}
+	if got.Body != "Summary" || got.Position != nil {
+		t.Errorf("unexpected discussion: %+v", got)
+	}
+}
+
+func TestGitLabSetStatus(t *testing.T) {
+	var got GitLabCommitStatus
+	var path string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.EscapedPath()
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte(`{"id": 1}`))
+	}))
+	defer server.Close()
+
+	client := NewGitLabClient("test-token", "owner/repo")
+	client.baseURL = server.URL
+
+	err := client.SetStatus(context.Background(), CommitStatus{
+		SHA:         "abc123",
+		State:       StatusFailed,
+		Description: "Quality gate failed",
+	})
+	if err != nil {
+		t.Fatalf("SetStatus() error = %v", err)
+	}
+
+	if path != "/projects/owner%2Frepo/statuses/abc123" {
+		t.Errorf("unexpected path %q", path)
+	}
+	if got.State != "failed" || got.Name != DefaultStatusName || got.Description != "Quality gate failed" {
+		t.Errorf("unexpected status: %+v", got)
+	}
+}
@@ -11,10 +11,14 @@ import (
 	"io"
 	"net/http"
 	"net/url"
+	"os"
 	"path/filepath"
 	"regexp"
+	"strconv"
 	"strings"
-	"time"
+
+	"github.com/cicd-ai-toolkit/cicd-runner/pkg/buildcontext"
+	"github.com/cicd-ai-toolkit/cicd-runner/pkg/retry"
 )
 
 // validJobNamePattern matches safe job names: alphanumeric, hyphen, underscore, dot
@@ -107,6 +111,7 @@ type JenkinsClient struct {
 	apiToken   string
 	jobName    string
 	httpClient *http.Client
+	workspace  *buildcontext.Builder // Git checkout used to compute diffs
 }
 
 // JenkinsBuildInfo represents information about a Jenkins build
@@ -170,14 +175,19 @@ func NewJenkinsClient(baseURL, username, apiToken, jobName string) (*JenkinsClie
 		return nil, fmt.Errorf("invalid job name: %w", err)
 	}
 
+	// Jenkins checks the repository out into the build workspace
+	workspace := os.Getenv("WORKSPACE")
+	if workspace == "" {
+		workspace = "."
+	}
+
 	return &JenkinsClient{
-		baseURL:  strings.TrimSuffix(baseURL, "/"),
-		username: username,
-		apiToken: apiToken,
-		jobName:  cleanJobName,
-		httpClient: &http.Client{
-			Timeout: 30 * time.Second,
-		},
+		baseURL:    strings.TrimSuffix(baseURL, "/"),
+		username:   username,
+		apiToken:   apiToken,
+		jobName:    cleanJobName,
+		httpClient: newAPIClient("jenkins", nil),
+		workspace:  buildcontext.NewBuilder(workspace, 0, nil),
 	}, nil
 }
 
@@ -230,60 +240,124 @@ func (j *JenkinsClient) PostComment(ctx context.Context, opts CommentOptions) er
 	return nil
 }
 
-// GetDiff retrieves the diff for a Jenkins build
-// For Jenkins, we need to get the SCM change information
+// SetStatus is a no-op for Jenkins.
+// The build result, driven by the runner's exit code, is the status.
+func (j *JenkinsClient) SetStatus(ctx context.Context, status CommitStatus) error {
+	return nil
+}
+
+// GetDiff computes the unified diff for a Jenkins build from the git checkout
+// in the build workspace. For the running build the range comes from the
+// change-request variables set by the GitHub/Bitbucket branch source and
+// pull request builder plugins (merge base with the target branch), or from
+// GIT_PREVIOUS_SUCCESSFUL_COMMIT..GIT_COMMIT. Other builds use the SCM
+// revision and change set recorded by Jenkins.
 func (j *JenkinsClient) GetDiff(ctx context.Context, prID int) (string, error) {
-	// Get the change set for this build
-	url := fmt.Sprintf("%s/job/%s/%d/changeset", j.baseURL, j.jobName, prID)
+	if !j.workspace.IsGitRepo() {
+		return "", fmt.Errorf("jenkins workspace is not a git checkout: cannot compute diff for build %d", prID)
+	}
 
-	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	base, head, err := j.diffRange(ctx, prID)
 	if err != nil {
-		return "", fmt.Errorf("failed to create request: %w", err)
+		return "", err
 	}
 
-	req.SetBasicAuth(j.username, j.apiToken)
-
-	resp, err := j.httpClient.Do(req)
+	diff, err := j.workspace.BuildDiff(ctx, buildcontext.DiffOptions{TargetRef: base, SourceRef: head})
 	if err != nil {
-		return "", fmt.Errorf("failed to get changeset: %w", err)
+		return "", fmt.Errorf("failed to diff %s..%s: %w", base, head, err)
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode == http.StatusNotFound {
-		return "", fmt.Errorf("no changeset found for build %d", prID)
+	return diff, nil
+}
+
+// SetWorkspace sets the directory of the git checkout diffs are computed in
+func (j *JenkinsClient) SetWorkspace(dir string) {
+	j.workspace = buildcontext.NewBuilder(dir, 0, nil)
+}
+
+// jenkinsTargetBranchVars are the change-request variables naming the target
+// branch: GitHub/Bitbucket branch source, GitHub and Bitbucket PR builders
+var jenkinsTargetBranchVars = []string{"CHANGE_TARGET", "ghprbTargetBranch", "bitbucketTargetBranch"}
+
+// diffRange returns the base and head commits of a build's changes
+func (j *JenkinsClient) diffRange(ctx context.Context, buildNumber int) (string, string, error) {
+	if j.isCurrentBuild(buildNumber) {
+		if base, head, ok := j.envDiffRange(ctx); ok {
+			return base, head, nil
+		}
 	}
 
-	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	if buildNumber <= 0 {
+		return "", "", fmt.Errorf("no diff range: set a build number or run inside a Jenkins build with GIT_COMMIT")
 	}
+	return j.buildDiffRange(ctx, buildNumber)
+}
 
-	// Jenkins changeset API returns HTML, we need to parse or use the API
-	// For simplicity, return the change information
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return "", fmt.Errorf("failed to read response: %w", err)
+// isCurrentBuild reports whether a build number refers to the running build
+func (j *JenkinsClient) isCurrentBuild(buildNumber int) bool {
+	if buildNumber <= 0 {
+		return true
 	}
+	n := strconv.Itoa(buildNumber)
+	return os.Getenv("BUILD_NUMBER") == n || os.Getenv("CHANGE_ID") == n
+}
 
-	// Try to parse as JSON (some Jenkins APIs return JSON)
-	var changeset struct {
-		Changes []struct {
-			Commit struct {
-				Msg string `json:"msg"`
-			} `json:"commit"`
-		} `json:"changes"`
+// envDiffRange derives the diff range of the running build from the
+// environment set by the Jenkins git and branch source plugins
+func (j *JenkinsClient) envDiffRange(ctx context.Context) (string, string, bool) {
+	head := os.Getenv("GIT_COMMIT")
+	if head == "" {
+		head = os.Getenv("ghprbActualCommit")
+	}
+	if head == "" {
+		head = "HEAD"
 	}
 
-	if err := json.Unmarshal(body, &changeset); err == nil {
-		var result strings.Builder
-		for _, change := range changeset.Changes {
-			result.WriteString(change.Commit.Msg)
-			result.WriteString("\n\n")
+	for _, name := range jenkinsTargetBranchVars {
+		target := os.Getenv(name)
+		if target == "" {
+			continue
+		}
+		// Multibranch checkouts only fetch remote refs for the target branch
+		for _, ref := range []string{"origin/" + target, target} {
+			if base, err := j.workspace.MergeBase(ctx, ref, head); err == nil {
+				return base, head, true
+			}
 		}
-		return result.String(), nil
 	}
 
-	// Return raw HTML as fallback
-	return string(body), nil
+	if prev := os.Getenv("GIT_PREVIOUS_SUCCESSFUL_COMMIT"); prev != "" && prev != head {
+		return prev, head, true
+	}
+
+	return "", "", false
+}
+
+// buildDiffRange derives the diff range of a build from its recorded SCM
+// data: the parent of the oldest change up to the built revision
+func (j *JenkinsClient) buildDiffRange(ctx context.Context, buildNumber int) (string, string, error) {
+	buildInfo, err := j.getBuildInfo(ctx, buildNumber)
+	if err != nil {
+		return "", "", err
+	}
+
+	head := j.getBuildSHA(buildInfo)
+	if head == "" {
+		return "", "", fmt.Errorf("build %d has no recorded git revision", buildNumber)
+	}
+
+	changes := buildInfo.changes()
+	if len(changes) == 0 {
+		return "", "", fmt.Errorf("build %d has no SCM changes to diff", buildNumber)
+	}
+
+	// Jenkins lists changes oldest first
+	base, err := j.workspace.ResolveParent(ctx, changes[0].CommitID)
+	if err != nil {
+		return "", "", fmt.Errorf("commit %s of build %d is not in the workspace checkout: %w", changes[0].CommitID, buildNumber, err)
+	}
+
+	return base, head, nil
 }
 
 // GetFile retrieves a file's content from the workspace of a build
@@ -402,6 +476,8 @@ func (j *JenkinsClient) GetPRInfo(ctx context.Context, prID int) (*PRInfo, error
 
 // Health checks if the Jenkins API is accessible
 func (j *JenkinsClient) Health(ctx context.Context) error {
+	ctx = retry.NoRetry(ctx)
+
 	url := fmt.Sprintf("%s/api/json", j.baseURL)
 
 	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
@@ -472,6 +548,22 @@ type jenkinsBuildInfoFull struct {
 			ShortDescription string `json:"shortDescription"`
 		} `json:"causes"`
 	} `json:"actions"`
+	ChangeSets []jenkinsChangeSet `json:"changeSets"` // Pipeline jobs
+	ChangeSet  jenkinsChangeSet   `json:"changeSet"`  // Freestyle jobs
+}
+
+// jenkinsChangeSet is the SCM change set of a build
+type jenkinsChangeSet struct {
+	Items []JenkinsChange `json:"items"`
+}
+
+// changes returns the SCM changes of a build, oldest first
+func (b *jenkinsBuildInfoFull) changes() []JenkinsChange {
+	var changes []JenkinsChange
+	for _, cs := range b.ChangeSets {
+		changes = append(changes, cs.Items...)
+	}
+	return append(changes, b.ChangeSet.Items...)
 }
 
 // getBuildSHA extracts the commit SHA from build info
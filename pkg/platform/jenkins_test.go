@@ -3,8 +3,12 @@ package platform
 
 import (
 	"context"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
 	"strings"
 	"testing"
 )
@@ -387,3 +391,153 @@ func TestJenkinsCreateCrumbDisabled(t *testing.T) {
 		t.Fatalf("CreateCrumb with CSRF disabled should not error: %v", err)
 	}
 }
+
+// jenkinsWorkspace creates a git checkout where "feature" (commits feature1,
+// feature2) branches off "main" before a further main commit, and returns
+// the directory and commit SHAs by name
+func jenkinsWorkspace(t *testing.T) (string, map[string]string) {
+	t.Helper()
+	dir := t.TempDir()
+	commits := make(map[string]string)
+
+	git := func(args ...string) string {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		out, err := cmd.CombinedOutput()
+		if err != nil {
+			t.Skipf("git not available: %v: %s", err, out)
+		}
+		return strings.TrimSpace(string(out))
+	}
+	commit := func(name, file string) {
+		if err := os.WriteFile(filepath.Join(dir, file), []byte(name+"\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		git("add", ".")
+		git("commit", "-q", "-m", name)
+		commits[name] = git("rev-parse", "HEAD")
+	}
+
+	git("init", "-q")
+	git("config", "user.email", "test@example.com")
+	git("config", "user.name", "Test")
+	git("checkout", "-q", "-b", "main")
+	commit("base", "base.go")
+	git("checkout", "-q", "-b", "feature")
+	commit("feature1", "feature1.go")
+	commit("feature2", "feature2.go")
+	git("checkout", "-q", "main")
+	commit("main", "main.go")
+	git("checkout", "-q", "feature")
+
+	return dir, commits
+}
+
+// setJenkinsEnv clears the Jenkins build variables and sets the given ones
+func setJenkinsEnv(t *testing.T, env map[string]string) {
+	vars := append([]string{"BUILD_NUMBER", "CHANGE_ID", "GIT_COMMIT", "GIT_PREVIOUS_SUCCESSFUL_COMMIT", "ghprbActualCommit"}, jenkinsTargetBranchVars...)
+	for _, name := range vars {
+		t.Setenv(name, env[name])
+	}
+}
+
+func TestJenkinsGetDiffFromEnv(t *testing.T) {
+	dir, commits := jenkinsWorkspace(t)
+
+	tests := []struct {
+		name    string
+		prID    int
+		env     map[string]string
+		want    []string
+		notWant []string
+	}{
+		{
+			name: "previous successful commit",
+			prID: 7,
+			env: map[string]string{
+				"BUILD_NUMBER":                   "7",
+				"GIT_COMMIT":                     commits["feature2"],
+				"GIT_PREVIOUS_SUCCESSFUL_COMMIT": commits["feature1"],
+			},
+			want:    []string{"+++ b/feature2.go"},
+			notWant: []string{"feature1.go", "main.go"},
+		},
+		{
+			name: "change request target branch",
+			prID: 12,
+			env: map[string]string{
+				"CHANGE_ID":     "12",
+				"CHANGE_TARGET": "main",
+				"GIT_COMMIT":    commits["feature2"],
+			},
+			want:    []string{"+++ b/feature1.go", "+++ b/feature2.go"},
+			notWant: []string{"main.go"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setJenkinsEnv(t, tt.env)
+			client, _ := NewJenkinsClient("http://jenkins.example.com", "user", "token", "test-job")
+			client.SetWorkspace(dir)
+
+			diff, err := client.GetDiff(context.Background(), tt.prID)
+			if err != nil {
+				t.Fatalf("GetDiff failed: %v", err)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(diff, want) {
+					t.Errorf("diff missing %q:\n%s", want, diff)
+				}
+			}
+			for _, notWant := range tt.notWant {
+				if strings.Contains(diff, notWant) {
+					t.Errorf("diff should not contain %q:\n%s", notWant, diff)
+				}
+			}
+		})
+	}
+}
+
+func TestJenkinsGetDiffFromBuildChangeSet(t *testing.T) {
+	dir, commits := jenkinsWorkspace(t)
+	setJenkinsEnv(t, nil)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/job/test-job/5/api/json" {
+			fmt.Fprintf(w, `{
+				"number": 5,
+				"actions": [{"lastBuiltRevision": {"SHA1": %q}}],
+				"changeSets": [{"items": [{"commitId": %q}, {"commitId": %q}]}]
+			}`, commits["feature2"], commits["feature1"], commits["feature2"])
+			return
+		}
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	client, _ := NewJenkinsClient(server.URL, "user", "token", "test-job")
+	client.SetWorkspace(dir)
+
+	diff, err := client.GetDiff(context.Background(), 5)
+	if err != nil {
+		t.Fatalf("GetDiff failed: %v", err)
+	}
+	if !strings.Contains(diff, "+++ b/feature1.go") || !strings.Contains(diff, "+++ b/feature2.go") {
+		t.Errorf("diff missing build changes:\n%s", diff)
+	}
+	if strings.Contains(diff, "main.go") {
+		t.Errorf("diff contains changes outside the build:\n%s", diff)
+	}
+}
+
+func TestJenkinsGetDiffNoCheckout(t *testing.T) {
+	setJenkinsEnv(t, nil)
+
+	client, _ := NewJenkinsClient("http://jenkins.example.com", "user", "token", "test-job")
+	client.SetWorkspace(t.TempDir())
+
+	if _, err := client.GetDiff(context.Background(), 1); err == nil {
+		t.Error("expected error outside a git checkout")
+	}
+}
@@ -0,0 +1,50 @@
+// Package platform provides the retry policy of platform API clients
+package platform
+
+import (
+	"net/http"
+
+	"github.com/cicd-ai-toolkit/cicd-runner/pkg/retry"
+)
+
+// retrier is implemented by clients that send requests through a retrying
+// transport
+type retrier interface {
+	retryTransport() *retry.Transport
+}
+
+// SetRetryPolicy sets the policy under which p retries rate-limited and
+// failed API requests. Platforms without a retrying transport are left
+// unchanged.
+func SetRetryPolicy(p Platform, policy retry.Policy) {
+	if r, ok := p.(retrier); ok {
+		if t := r.retryTransport(); t != nil {
+			t.Policy = policy
+		}
+	}
+}
+
+// newAPIClient returns an HTTP client for a platform API that retries
+// through base. Each attempt is bounded by DefaultHTTPTimeout; the overall
+// time, including rate-limit waits, is bounded by the caller's context.
+func newAPIClient(target string, base http.RoundTripper) *http.Client {
+	t := retry.NewTransport(target, base)
+	t.AttemptTimeout = DefaultHTTPTimeout
+	return &http.Client{Transport: t}
+}
+
+// transportOf returns the retrying transport of client, or nil if it was
+// replaced (e.g. by a test server's client)
+func transportOf(client *http.Client) *retry.Transport {
+	if client == nil {
+		return nil
+	}
+	t, _ := client.Transport.(*retry.Transport)
+	return t
+}
+
+func (c *GitHubClient) retryTransport() *retry.Transport    { return transportOf(c.client) }
+func (g *GitLabClient) retryTransport() *retry.Transport    { return transportOf(g.client) }
+func (g *GiteeClient) retryTransport() *retry.Transport     { return transportOf(g.client) }
+func (b *BitbucketClient) retryTransport() *retry.Transport { return transportOf(b.client) }
+func (j *JenkinsClient) retryTransport() *retry.Transport   { return transportOf(j.httpClient) }
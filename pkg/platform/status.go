@@ -0,0 +1,89 @@
+// Package platform provides platform-neutral commit status reporting
+package platform
+
+import "unicode/utf8"
+
+// DefaultStatusName is the status context / check name used when none is given
+const DefaultStatusName = "cicd-ai-toolkit"
+
+// StatusState represents the state of a status check
+type StatusState string
+
+const (
+	// StatusPending indicates the check is pending
+	StatusPending StatusState = "pending"
+	// StatusRunning indicates the check is running
+	StatusRunning StatusState = "running"
+	// StatusSuccess indicates the check passed
+	StatusSuccess StatusState = "success"
+	// StatusFailed indicates the check failed
+	StatusFailed StatusState = "fail"
+	// StatusError indicates an error occurred
+	StatusError StatusState = "error"
+	// StatusCancelled indicates the check was cancelled
+	StatusCancelled StatusState = "cancelled"
+)
+
+// String returns the string representation of the status state
+func (s StatusState) String() string {
+	return string(s)
+}
+
+// AnnotationLevel is the severity of a status annotation
+type AnnotationLevel string
+
+const (
+	// AnnotationNotice is an informational annotation
+	AnnotationNotice AnnotationLevel = "notice"
+	// AnnotationWarning is a warning annotation
+	AnnotationWarning AnnotationLevel = "warning"
+	// AnnotationFailure is a failure annotation
+	AnnotationFailure AnnotationLevel = "failure"
+)
+
+// CommitStatus is a status report on a commit
+type CommitStatus struct {
+	// SHA is the commit the status applies to
+	SHA string
+	// State is the status state
+	State StatusState
+	// Name differentiates this status from others; defaults to DefaultStatusName
+	Name string
+	// Description is a short one-line summary
+	Description string
+	// Summary is a detailed markdown summary (GitHub check runs only)
+	Summary string
+	// TargetURL is a URL to associate with this status
+	TargetURL string
+	// Annotations mark file lines (GitHub check runs only)
+	Annotations []StatusAnnotation
+}
+
+// StatusAnnotation marks a line of a file in a status report
+type StatusAnnotation struct {
+	Path    string
+	Line    int
+	Level   AnnotationLevel
+	Title   string
+	Message string
+}
+
+// statusName returns the status name, falling back to DefaultStatusName
+func (s CommitStatus) statusName() string {
+	if s.Name == "" {
+		return DefaultStatusName
+	}
+	return s.Name
+}
+
+// truncateStatus shortens s to at most max bytes without splitting a rune
+func truncateStatus(s string, max int) string {
+	if len(s) <= max {
+		return s
+	}
+	cut := max - len("...")
+	for cut > 0 && !utf8.RuneStart(s[cut]) {
+		cut--
+	}
+	return s[:cut] + "..."
+}
@@ -0,0 +1,123 @@
+// Package queue provides the HTTP API to list, inspect and cancel jobs
+package queue
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+)
+
+// NewAPI returns the job API, authenticated with a bearer token:
+//
+//	GET  /jobs[?state=<state>]  list jobs, newest first, with stats
+//	GET  /jobs/<id>             inspect a job
+//	POST /jobs/<id>/cancel      cancel a queued or running job
+//
+// Without a token every request is answered 404, so the API is off unless
+// configured.
+func NewAPI(q *Queue, token string) http.Handler {
+	return &api{queue: q, token: token}
+}
+
+type api struct {
+	queue *Queue
+	token string
+}
+
+func (a *api) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if a.token == "" {
+		http.NotFound(w, r)
+		return
+	}
+	if !a.authorized(r) {
+		w.Header().Set("WWW-Authenticate", `Bearer realm="jobs"`)
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "unauthorized"})
+		return
+	}
+
+	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	if parts[0] != "jobs" || len(parts) > 3 {
+		http.NotFound(w, r)
+		return
+	}
+
+	switch {
+	case len(parts) == 1:
+		a.list(w, r)
+	case len(parts) == 2:
+		a.get(w, r, parts[1])
+	case parts[2] == "cancel":
+		a.cancel(w, r, parts[1])
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// authorized checks the bearer token in constant time
+func (a *api) authorized(r *http.Request) bool {
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	return ok && subtle.ConstantTimeCompare([]byte(token), []byte(a.token)) == 1
+}
+
+func (a *api) list(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", http.MethodGet)
+		writeJSON(w, http.StatusMethodNotAllowed, map[string]string{"error": "method not allowed"})
+		return
+	}
+
+	state := State(r.URL.Query().Get("state"))
+	switch state {
+	case "", StateQueued, StateRunning, StateSucceeded, StateFailed, StateCancelled, StateSuperseded:
+	default:
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "unknown state: " + string(state)})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, struct {
+		Jobs  []Job `json:"jobs"`
+		Stats Stats `json:"stats"`
+	}{Jobs: a.queue.List(state), Stats: a.queue.Stats()})
+}
+
+func (a *api) get(w http.ResponseWriter, r *http.Request, id string) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", http.MethodGet)
+		writeJSON(w, http.StatusMethodNotAllowed, map[string]string{"error": "method not allowed"})
+		return
+	}
+
+	job, ok := a.queue.Get(id)
+	if !ok {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": ErrNotFound.Error()})
+		return
+	}
+	writeJSON(w, http.StatusOK, job)
+}
+
+func (a *api) cancel(w http.ResponseWriter, r *http.Request, id string) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		writeJSON(w, http.StatusMethodNotAllowed, map[string]string{"error": "method not allowed"})
+		return
+	}
+
+	job, err := a.queue.Cancel(id)
+	switch {
+	case errors.Is(err, ErrNotFound):
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": err.Error()})
+	case errors.Is(err, ErrFinished):
+		writeJSON(w, http.StatusConflict, map[string]interface{}{"error": err.Error(), "job": job})
+	default:
+		writeJSON(w, http.StatusOK, job)
+	}
+}
+
+// writeJSON writes a JSON response
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
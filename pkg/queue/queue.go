@@ -0,0 +1,618 @@
+// Package queue provides a durable job queue between webhook receivers and
+// the runner. Jobs are coalesced per pull request: a newer event for the
+// same PR supersedes queued and running jobs, and at most one job per PR,
+// including comment commands, runs at a time. Failed jobs are retried with
+// backoff unless the error is permanent, and jobs are kept on disk so
+// queued and interrupted work resumes after a restart.
+package queue
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	cicderrors "github.com/cicd-ai-toolkit/cicd-runner/pkg/errors"
+	"github.com/cicd-ai-toolkit/cicd-runner/pkg/perf"
+	"github.com/cicd-ai-toolkit/cicd-runner/pkg/platform"
+	"github.com/cicd-ai-toolkit/cicd-runner/pkg/retry"
+)
+
+// State is the lifecycle state of a job
+type State string
+
+const (
+	// StateQueued jobs wait for a worker, or for their next attempt
+	StateQueued State = "queued"
+	// StateRunning jobs are being run
+	StateRunning State = "running"
+	// StateSucceeded jobs completed
+	StateSucceeded State = "succeeded"
+	// StateFailed jobs failed on every attempt
+	StateFailed State = "failed"
+	// StateCancelled jobs were cancelled through the API
+	StateCancelled State = "cancelled"
+	// StateSuperseded jobs were replaced by a newer event on the same PR
+	StateSuperseded State = "superseded"
+)
+
+// Finished reports whether a job in this state will not run again
+func (s State) Finished() bool {
+	switch s {
+	case StateSucceeded, StateFailed, StateCancelled, StateSuperseded:
+		return true
+	default:
+		return false
+	}
+}
+
+var (
+	// ErrNotFound is returned for an unknown job ID
+	ErrNotFound = errors.New("job not found")
+	// ErrFinished is returned when cancelling a job that already finished
+	ErrFinished = errors.New("job already finished")
+	// ErrStopped is returned when enqueueing on a stopped queue
+	ErrStopped = errors.New("queue is stopped")
+)
+
+// Job is the work triggered by one pull request event
+type Job struct {
+	ID string `json:"id"`
+	// Key identifies the PR; jobs with the same key are coalesced
+	Key          string                `json:"key"`
+	Event        platform.WebhookEvent `json:"event"`
+	State        State                 `json:"state"`
+	Attempts     int                   `json:"attempts"`
+	Error        string                `json:"error,omitempty"` // Error of the last failed attempt
+	SupersededBy string                `json:"superseded_by,omitempty"`
+	CreatedAt    time.Time             `json:"created_at"`
+	UpdatedAt    time.Time             `json:"updated_at"`
+	RunAfter     *time.Time            `json:"run_after,omitempty"` // Earliest start of a retry
+	StartedAt    *time.Time            `json:"started_at,omitempty"`
+	FinishedAt   *time.Time            `json:"finished_at,omitempty"`
+}
+
+// JobKey returns the coalescing key of an event: platform, repository and
+// PR. Comment events are keyed by their comment, so commands neither
+// supersede nor are superseded by other jobs of the PR; they still wait
+// for them (see prKey).
+func JobKey(event platform.WebhookEvent) string {
+	key := prKey(event)
+	if event.Comment != nil {
+		key += fmt.Sprintf("/comment/%d", event.Comment.ID)
+	}
+	return key
+}
+
+// prKey identifies the PR of an event. Jobs of the same PR share its
+// cache, ignored rules and conversation, so only one of them runs at a
+// time.
+func prKey(event platform.WebhookEvent) string {
+	return fmt.Sprintf("%s/%s#%d", event.Platform, event.Repo, event.PRID)
+}
+
+// permanent reports whether a failed attempt would fail again: invalid
+// configuration or input, or an exhausted budget
+func permanent(err error) bool {
+	return cicderrors.ShouldBlockCI(err) || cicderrors.IsType(err, cicderrors.ErrBudget)
+}
+
+// Handler runs a job. ctx is cancelled when the job is superseded,
+// cancelled, times out or the queue stops.
+type Handler func(ctx context.Context, job Job) error
+
+// Options configures a queue
+type Options struct {
+	// Dir is the directory jobs are stored in
+	Dir string
+	// Workers is the number of jobs run at once (default: 4)
+	Workers int
+	// JobTimeout limits one attempt (default: 30m)
+	JobTimeout time.Duration
+	// Retry sets how often a failed job is retried (MaxRetries) and the
+	// backoff between attempts (default: DefaultRetryPolicy)
+	Retry *retry.Policy
+	// Retention is how long finished jobs are kept (default: 7 days)
+	Retention time.Duration
+	// Logger receives one line per job state change (default: discard)
+	Logger func(format string, args ...interface{})
+}
+
+// DefaultRetryPolicy retries a failed job twice, one to two minutes after
+// the first failure and two to four minutes after the second. Transient AI and API failures are already retried within an
+// attempt, so job retries cover longer outages.
+func DefaultRetryPolicy() retry.Policy {
+	return retry.Policy{MaxRetries: 2, BaseDelay: 2 * time.Minute, MaxDelay: 15 * time.Minute}
+}
+
+// Stats counts jobs by state
+type Stats struct {
+	Queued     int `json:"queued"`
+	Running    int `json:"running"`
+	Succeeded  int `json:"succeeded"`
+	Failed     int `json:"failed"`
+	Cancelled  int `json:"cancelled"`
+	Superseded int `json:"superseded"`
+}
+
+// pruneInterval is how often finished jobs past retention are removed
+const pruneInterval = time.Hour
+
+// Queue is a durable job queue run by a perf.WorkerPool
+type Queue struct {
+	opts    Options
+	handler Handler
+	store   *store
+	pool    *perf.WorkerPool
+
+	// ctx is the parent of job contexts, cancelled when Stop stops waiting
+	ctx    context.Context
+	cancel context.CancelFunc
+	wake   chan struct{}
+	quit   chan struct{}
+	done   chan struct{} // Closed when the dispatcher exits
+	jobs   sync.WaitGroup
+
+	mu        sync.Mutex
+	all       map[string]*Job
+	running   map[string]context.CancelFunc // Job ID -> cancels its attempt
+	started   bool
+	stopping  bool
+	lastPrune time.Time
+}
+
+// Open loads the jobs stored in opts.Dir and returns a queue that runs
+// them with handler once started. Jobs that were running when the process
+// exited are queued again; a job interrupted on its last attempt fails.
+func Open(opts Options, handler Handler) (*Queue, error) {
+	if opts.Workers <= 0 {
+		opts.Workers = 4
+	}
+	if opts.JobTimeout <= 0 {
+		opts.JobTimeout = 30 * time.Minute
+	}
+	if opts.Retry == nil {
+		policy := DefaultRetryPolicy()
+		opts.Retry = &policy
+	}
+	if opts.Retention <= 0 {
+		opts.Retention = 7 * 24 * time.Hour
+	}
+	if opts.Logger == nil {
+		opts.Logger = func(format string, args ...interface{}) {}
+	}
+
+	st, err := newStore(opts.Dir)
+	if err != nil {
+		return nil, err
+	}
+	jobs, err := st.load()
+	if err != nil {
+		return nil, err
+	}
+	pool, err := perf.NewWorkerPool(opts.Workers)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	q := &Queue{
+		opts:    opts,
+		handler: handler,
+		store:   st,
+		pool:    pool,
+		ctx:     ctx,
+		cancel:  cancel,
+		wake:    make(chan struct{}, 1),
+		quit:    make(chan struct{}),
+		done:    make(chan struct{}),
+		all:     make(map[string]*Job),
+		running: make(map[string]context.CancelFunc),
+	}
+
+	now := time.Now()
+	for _, job := range jobs {
+		if job.State == StateRunning {
+			if job.Attempts > opts.Retry.MaxRetries {
+				job.State = StateFailed
+				job.Error = "interrupted by a restart on the last attempt"
+				job.FinishedAt = &now
+			} else {
+				job.State = StateQueued
+			}
+			job.UpdatedAt = now
+			q.save(job)
+		}
+		q.all[job.ID] = job
+	}
+	q.prune(now)
+	return q, nil
+}
+
+// Start starts the workers and the dispatcher
+func (q *Queue) Start() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.started || q.stopping {
+		return
+	}
+	q.started = true
+	q.pool.Start()
+	go q.dispatch()
+}
+
+// Enqueue adds a job for an event. Queued and running jobs for the same PR
+// are superseded; running ones are cancelled. A delivery of the same head
+// commit or comment as an unfinished job is a duplicate: the existing job
+// is returned with false.
+func (q *Queue) Enqueue(event platform.WebhookEvent) (Job, bool, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.stopping {
+		return Job{}, false, ErrStopped
+	}
+
+	key := JobKey(event)
+	var pending []*Job
+	for _, j := range q.all {
+		if j.Key != key || j.State.Finished() {
+			continue
+		}
+		if event.Comment != nil || (event.SHA != "" && j.Event.SHA == event.SHA) {
+			return *j, false, nil
+		}
+		pending = append(pending, j)
+	}
+
+	now := time.Now()
+	job := &Job{
+		ID:        newJobID(),
+		Key:       key,
+		Event:     event,
+		State:     StateQueued,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if err := q.store.save(job); err != nil {
+		return Job{}, false, err
+	}
+	q.all[job.ID] = job
+
+	for _, j := range pending {
+		q.finishLocked(j, StateSuperseded, now)
+		j.SupersededBy = job.ID
+		q.save(j)
+		q.opts.Logger("job %s (%s) superseded by %s", j.ID, key, job.ID)
+	}
+
+	q.opts.Logger("job %s (%s at %s) queued", job.ID, key, shortSHA(event.SHA))
+	q.signal()
+	return *job, true, nil
+}
+
+// Get returns a job
+func (q *Queue) Get(id string) (Job, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	j, ok := q.all[id]
+	if !ok {
+		return Job{}, false
+	}
+	return *j, true
+}
+
+// List returns the jobs in a state, or all jobs when state is empty,
+// newest first
+func (q *Queue) List(state State) []Job {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	jobs := make([]Job, 0, len(q.all))
+	for _, j := range q.all {
+		if state == "" || j.State == state {
+			jobs = append(jobs, *j)
+		}
+	}
+	sort.Slice(jobs, func(i, k int) bool { return jobs[i].ID > jobs[k].ID })
+	return jobs
+}
+
+// Cancel cancels a queued or running job
+func (q *Queue) Cancel(id string) (Job, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	j, ok := q.all[id]
+	if !ok {
+		return Job{}, ErrNotFound
+	}
+	if j.State.Finished() {
+		return *j, ErrFinished
+	}
+
+	q.finishLocked(j, StateCancelled, time.Now())
+	q.save(j)
+	q.opts.Logger("job %s (%s) cancelled", j.ID, j.Key)
+	return *j, nil
+}
+
+// Stats counts the jobs by state
+func (q *Queue) Stats() Stats {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	var s Stats
+	for _, j := range q.all {
+		switch j.State {
+		case StateQueued:
+			s.Queued++
+		case StateRunning:
+			s.Running++
+		case StateSucceeded:
+			s.Succeeded++
+		case StateFailed:
+			s.Failed++
+		case StateCancelled:
+			s.Cancelled++
+		case StateSuperseded:
+			s.Superseded++
+		}
+	}
+	return s
+}
+
+// Stop stops starting jobs and waits for running ones. When ctx is done
+// first, they are cancelled. Jobs cut short by the stop are queued again
+// without counting the attempt, so they run after a restart.
+func (q *Queue) Stop(ctx context.Context) error {
+	q.mu.Lock()
+	if q.stopping {
+		q.mu.Unlock()
+		return nil
+	}
+	q.stopping = true
+	started := q.started
+	q.mu.Unlock()
+
+	close(q.quit)
+	if started {
+		<-q.done
+	}
+
+	waited := make(chan struct{})
+	go func() {
+		q.jobs.Wait()
+		close(waited)
+	}()
+
+	var err error
+	select {
+	case <-waited:
+	case <-ctx.Done():
+		q.opts.Logger("stop timed out, cancelling %d running jobs", q.Stats().Running)
+		q.cancel()
+		<-waited
+		err = ctx.Err()
+	}
+
+	q.cancel()
+	q.pool.Stop()
+	return err
+}
+
+// dispatch starts ready jobs whenever a job is queued or finishes, a retry
+// becomes due, and prunes finished jobs periodically
+func (q *Queue) dispatch() {
+	defer close(q.done)
+
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+	for {
+		wait := q.startReady(time.Now())
+		if !timer.Stop() {
+			select {
+			case <-timer.C:
+			default:
+			}
+		}
+		timer.Reset(wait)
+
+		select {
+		case <-q.quit:
+			return
+		case <-q.wake:
+		case <-timer.C:
+		}
+	}
+}
+
+// startReady starts queued jobs while workers are free, at most one per
+// PR, oldest first. It returns how long to wait for the next due retry.
+func (q *Queue) startReady(now time.Time) time.Duration {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if now.Sub(q.lastPrune) >= pruneInterval {
+		q.prune(now)
+	}
+
+	busy := make(map[string]bool)
+	for id := range q.running {
+		busy[prKey(q.all[id].Event)] = true
+	}
+
+	var ready []*Job
+	for _, j := range q.all {
+		if j.State == StateQueued {
+			ready = append(ready, j)
+		}
+	}
+	sort.Slice(ready, func(i, k int) bool { return ready[i].ID < ready[k].ID })
+
+	wait := pruneInterval
+	for _, j := range ready {
+		if len(q.running) >= q.opts.Workers {
+			break
+		}
+		if busy[prKey(j.Event)] {
+			continue
+		}
+		if j.RunAfter != nil && j.RunAfter.After(now) {
+			if d := j.RunAfter.Sub(now); d < wait {
+				wait = d
+			}
+			continue
+		}
+		if q.startLocked(j, now) {
+			busy[prKey(j.Event)] = true
+		}
+	}
+	return wait
+}
+
+// startLocked submits a job to the worker pool. Caller must hold q.mu.
+func (q *Queue) startLocked(j *Job, now time.Time) bool {
+	ctx, cancel := context.WithTimeout(q.ctx, q.opts.JobTimeout)
+	job := *j
+	job.State = StateRunning
+	job.Attempts++
+	job.StartedAt = &now
+	job.RunAfter = nil
+	job.UpdatedAt = now
+
+	q.jobs.Add(1)
+	if !q.pool.Submit(func() { q.run(ctx, cancel, job) }) {
+		q.jobs.Done()
+		cancel()
+		return false
+	}
+
+	*j = job
+	q.running[j.ID] = cancel
+	q.save(j)
+	q.opts.Logger("job %s (%s) started, attempt %d", j.ID, j.Key, j.Attempts)
+	return true
+}
+
+// run runs one attempt of a job
+func (q *Queue) run(ctx context.Context, cancel context.CancelFunc, job Job) {
+	defer q.jobs.Done()
+	defer cancel()
+
+	err := func() (err error) {
+		defer func() {
+			if v := recover(); v != nil {
+				err = fmt.Errorf("panic: %v", v)
+			}
+		}()
+		return q.handler(ctx, job)
+	}()
+
+	q.finish(job.ID, err)
+	q.signal()
+}
+
+// finish records the outcome of an attempt
+func (q *Queue) finish(id string, err error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	delete(q.running, id)
+	j, ok := q.all[id]
+	if !ok || j.State != StateRunning {
+		// Superseded or cancelled while running
+		return
+	}
+
+	now := time.Now()
+	switch {
+	case err == nil:
+		q.finishLocked(j, StateSucceeded, now)
+		j.Error = ""
+		q.opts.Logger("job %s (%s) succeeded in %s", j.ID, j.Key, now.Sub(*j.StartedAt).Round(time.Millisecond))
+	case q.ctx.Err() != nil:
+		j.State = StateQueued
+		j.Attempts--
+		q.opts.Logger("job %s (%s) interrupted by shutdown, queued for restart", j.ID, j.Key)
+	case j.Attempts <= q.opts.Retry.MaxRetries && !permanent(err):
+		j.State = StateQueued
+		j.Error = err.Error()
+		runAfter := now.Add(q.opts.Retry.Backoff(j.Attempts))
+		j.RunAfter = &runAfter
+		q.opts.Logger("job %s (%s) attempt %d failed, retrying at %s: %v", j.ID, j.Key, j.Attempts, runAfter.Format(time.RFC3339), err)
+	default:
+		q.finishLocked(j, StateFailed, now)
+		j.Error = err.Error()
+		q.opts.Logger("job %s (%s) failed after %d attempts: %v", j.ID, j.Key, j.Attempts, err)
+	}
+	j.UpdatedAt = now
+	q.save(j)
+}
+
+// finishLocked moves a job to a final state, cancelling its running
+// attempt. Caller must hold q.mu and save the job.
+func (q *Queue) finishLocked(j *Job, state State, now time.Time) {
+	if cancel, ok := q.running[j.ID]; ok {
+		cancel()
+	}
+	j.State = state
+	j.RunAfter = nil
+	j.FinishedAt = &now
+	j.UpdatedAt = now
+}
+
+// prune removes finished jobs older than the retention. Caller must hold
+// q.mu or own the queue exclusively.
+func (q *Queue) prune(now time.Time) {
+	q.lastPrune = now
+	for id, j := range q.all {
+		if j.State.Finished() && j.FinishedAt != nil && now.Sub(*j.FinishedAt) > q.opts.Retention {
+			if err := q.store.remove(id); err != nil {
+				q.opts.Logger("[WARNING] %v", err)
+				continue
+			}
+			delete(q.all, id)
+		}
+	}
+}
+
+// save persists a job, logging failures: the in-memory state stays
+// authoritative until the next successful write
+func (q *Queue) save(j *Job) {
+	if err := q.store.save(j); err != nil {
+		q.opts.Logger("[WARNING] %v", err)
+	}
+}
+
+// signal wakes the dispatcher
+func (q *Queue) signal() {
+	select {
+	case q.wake <- struct{}{}:
+	default:
+	}
+}
+
+// newJobID returns a unique, time-ordered job ID
+func newJobID() string {
+	suffix := make([]byte, 4)
+	_, _ = rand.Read(suffix)
+	return time.Now().UTC().Format("20060102T150405.000000") + "-" + hex.EncodeToString(suffix)
+}
+
+// shortSHA abbreviates a commit SHA for logs
+func shortSHA(sha string) string {
+	if len(sha) > 12 {
+		return sha[:12]
+	}
+	if sha == "" {
+		return "unknown head"
+	}
+	return sha
+}
@@ -0,0 +1,442 @@
+// Package queue provides job queue tests
+package queue
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	cicderrors "github.com/cicd-ai-toolkit/cicd-runner/pkg/errors"
+	"github.com/cicd-ai-toolkit/cicd-runner/pkg/platform"
+	"github.com/cicd-ai-toolkit/cicd-runner/pkg/retry"
+)
+
+func prEvent(pr int, sha string) platform.WebhookEvent {
+	return platform.WebhookEvent{Type: platform.EventPRSynchronize, Platform: "github", PRID: pr, Repo: "owner/repo", SHA: sha}
+}
+
+// openQueue opens and starts a queue in a temporary directory
+func openQueue(t *testing.T, dir string, opts Options, handler Handler) *Queue {
+	t.Helper()
+	opts.Dir = dir
+	q, err := Open(opts, handler)
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	q.Start()
+	t.Cleanup(func() { _ = q.Stop(context.Background()) })
+	return q
+}
+
+// waitState waits until a job reaches a state
+func waitState(t *testing.T, q *Queue, id string, state State) Job {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		job, ok := q.Get(id)
+		if ok && job.State == state {
+			return job
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("job %s state = %s, want %s", id, job.State, state)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+// blocker is a handler whose jobs run until released or cancelled
+type blocker struct {
+	started chan string
+	release chan struct{}
+	mu      sync.Mutex
+	runs    map[string]int
+}
+
+func newBlocker() *blocker {
+	return &blocker{started: make(chan string, 10), release: make(chan struct{}), runs: make(map[string]int)}
+}
+
+func (b *blocker) handle(ctx context.Context, job Job) error {
+	b.mu.Lock()
+	b.runs[job.Event.SHA]++
+	b.mu.Unlock()
+	b.started <- job.ID
+	select {
+	case <-b.release:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func TestJobKey(t *testing.T) {
+	if got := JobKey(prEvent(42, "a")); got != "github/owner/repo#42" {
+		t.Errorf("JobKey() = %q", got)
+	}
+
+	comment := prEvent(42, "")
+	comment.Comment = &platform.WebhookComment{ID: 7, Body: "/ai review"}
+	if got := JobKey(comment); got != "github/owner/repo#42/comment/7" {
+		t.Errorf("JobKey(comment) = %q", got)
+	}
+}
+
+func TestEnqueueCoalesces(t *testing.T) {
+	b := newBlocker()
+	// Cancelled jobs exit only once exit is closed, so the PR stays busy
+	// until all events are queued
+	exit := make(chan struct{})
+	q := openQueue(t, t.TempDir(), Options{Workers: 2}, func(ctx context.Context, job Job) error {
+		err := b.handle(ctx, job)
+		if err != nil {
+			<-exit
+		}
+		return err
+	})
+
+	first, created, err := q.Enqueue(prEvent(1, "a"))
+	if err != nil || !created {
+		t.Fatalf("Enqueue() = %v, %v", created, err)
+	}
+	<-b.started
+
+	// A redelivery of the same head commit is a duplicate
+	dup, created, err := q.Enqueue(prEvent(1, "a"))
+	if err != nil || created || dup.ID != first.ID {
+		t.Errorf("duplicate Enqueue() = %s, %v, %v", dup.ID, created, err)
+	}
+
+	// A new push supersedes the running job, and the next one supersedes
+	// that while the cancelled job is still exiting
+	second, _, _ := q.Enqueue(prEvent(1, "b"))
+	third, _, _ := q.Enqueue(prEvent(1, "c"))
+	if job, _ := q.Get(third.ID); job.State != StateQueued {
+		t.Errorf("third = %s, want queued while the PR is busy", job.State)
+	}
+	close(exit)
+	<-b.started
+
+	if job := waitState(t, q, first.ID, StateSuperseded); job.SupersededBy != second.ID {
+		t.Errorf("first superseded by %s, want %s", job.SupersededBy, second.ID)
+	}
+	if job, _ := q.Get(second.ID); job.State != StateSuperseded || job.Attempts != 0 {
+		t.Errorf("second = %s after %d attempts, want superseded before running", job.State, job.Attempts)
+	}
+	waitState(t, q, third.ID, StateRunning)
+
+	// Other PRs are not affected
+	other, _, _ := q.Enqueue(prEvent(2, "c"))
+	<-b.started
+	waitState(t, q, other.ID, StateRunning)
+
+	close(b.release)
+	waitState(t, q, third.ID, StateSucceeded)
+	waitState(t, q, other.ID, StateSucceeded)
+
+	if b.runs["b"] != 0 || b.runs["c"] != 2 {
+		t.Errorf("runs = %v", b.runs)
+	}
+	if stats := q.Stats(); stats.Succeeded != 2 || stats.Superseded != 2 {
+		t.Errorf("stats = %+v", stats)
+	}
+}
+
+func TestEnqueueComment(t *testing.T) {
+	b := newBlocker()
+	q := openQueue(t, t.TempDir(), Options{Workers: 3}, b.handle)
+
+	review, _, _ := q.Enqueue(prEvent(1, "a"))
+	<-b.started
+
+	// A command waits for the PR's job and is deduplicated by comment
+	comment := prEvent(1, "")
+	comment.Comment = &platform.WebhookComment{ID: 9, Body: "/ai analyze"}
+	command, created, err := q.Enqueue(comment)
+	if err != nil || !created {
+		t.Fatalf("Enqueue(comment) = %v, %v", created, err)
+	}
+	if dup, created, _ := q.Enqueue(comment); created || dup.ID != command.ID {
+		t.Errorf("redelivered comment created job %s", dup.ID)
+	}
+
+	// A command on another PR runs beside it
+	other := prEvent(2, "")
+	other.Comment = &platform.WebhookComment{ID: 10, Body: "/ai analyze"}
+	otherCommand, _, _ := q.Enqueue(other)
+	<-b.started
+	waitState(t, q, otherCommand.ID, StateRunning)
+
+	time.Sleep(20 * time.Millisecond)
+	if job, _ := q.Get(command.ID); job.State != StateQueued {
+		t.Errorf("command = %s while the PR's review runs, want queued", job.State)
+	}
+	waitState(t, q, review.ID, StateRunning)
+
+	close(b.release)
+	waitState(t, q, review.ID, StateSucceeded)
+	waitState(t, q, command.ID, StateSucceeded)
+}
+
+func TestRetry(t *testing.T) {
+	tests := []struct {
+		name      string
+		failures  int
+		err       error // Returned by failing attempts; default "boom"
+		want      State
+		wantTries int
+	}{
+		{name: "succeeds on retry", failures: 2, want: StateSucceeded, wantTries: 3},
+		{name: "fails after max retries", failures: 5, want: StateFailed, wantTries: 3},
+		{name: "config error is not retried", failures: 5, err: cicderrors.ConfigError("boom", nil), want: StateFailed, wantTries: 1},
+		{name: "budget error is not retried", failures: 5, err: cicderrors.BudgetError("boom", nil), want: StateFailed, wantTries: 1},
+		{name: "platform error is retried", failures: 5, err: cicderrors.PlatformError("boom", nil), want: StateFailed, wantTries: 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var mu sync.Mutex
+			calls := 0
+			handler := func(ctx context.Context, job Job) error {
+				mu.Lock()
+				defer mu.Unlock()
+				calls++
+				if calls <= tt.failures {
+					if tt.err != nil {
+						return tt.err
+					}
+					return errors.New("boom")
+				}
+				return nil
+			}
+			q := openQueue(t, t.TempDir(), Options{Retry: &retry.Policy{MaxRetries: 2, BaseDelay: time.Millisecond}}, handler)
+
+			job, _, _ := q.Enqueue(prEvent(1, "a"))
+			got := waitState(t, q, job.ID, tt.want)
+			if got.Attempts != tt.wantTries {
+				t.Errorf("attempts = %d, want %d", got.Attempts, tt.wantTries)
+			}
+			if tt.want == StateFailed && !strings.Contains(got.Error, "boom") {
+				t.Errorf("error = %q", got.Error)
+			}
+		})
+	}
+}
+
+func TestRetryWaitsForBackoff(t *testing.T) {
+	handler := func(ctx context.Context, job Job) error { return errors.New("boom") }
+	q := openQueue(t, t.TempDir(), Options{Retry: &retry.Policy{MaxRetries: 1, BaseDelay: time.Hour}}, handler)
+
+	job, _, _ := q.Enqueue(prEvent(1, "a"))
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		got, _ := q.Get(job.ID)
+		if got.Error != "" {
+			if got.State != StateQueued || got.RunAfter == nil || time.Until(*got.RunAfter) < 29*time.Minute {
+				t.Errorf("job = %s, run after %v", got.State, got.RunAfter)
+			}
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("job did not fail")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestCancel(t *testing.T) {
+	b := newBlocker()
+	q := openQueue(t, t.TempDir(), Options{Workers: 1}, b.handle)
+
+	running, _, _ := q.Enqueue(prEvent(1, "a"))
+	<-b.started
+	queued, _, _ := q.Enqueue(prEvent(2, "a"))
+
+	if _, err := q.Cancel(queued.ID); err != nil {
+		t.Fatalf("Cancel(queued) error = %v", err)
+	}
+	if _, err := q.Cancel(running.ID); err != nil {
+		t.Fatalf("Cancel(running) error = %v", err)
+	}
+	waitState(t, q, running.ID, StateCancelled)
+
+	if _, err := q.Cancel(running.ID); !errors.Is(err, ErrFinished) {
+		t.Errorf("Cancel(finished) error = %v, want ErrFinished", err)
+	}
+	if _, err := q.Cancel("missing"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Cancel(missing) error = %v, want ErrNotFound", err)
+	}
+
+	// The cancelled job's handler must have returned before stats settle
+	if err := q.Stop(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if b.runs["a"] != 1 {
+		t.Errorf("runs = %v, want only the first job run", b.runs)
+	}
+	if stats := q.Stats(); stats.Cancelled != 2 {
+		t.Errorf("stats = %+v", stats)
+	}
+}
+
+func TestRestart(t *testing.T) {
+	dir := t.TempDir()
+	b := newBlocker()
+	q, err := Open(Options{Dir: dir, Workers: 1}, b.handle)
+	if err != nil {
+		t.Fatal(err)
+	}
+	q.Start()
+
+	running, _, _ := q.Enqueue(prEvent(1, "a"))
+	<-b.started
+	queued, _, _ := q.Enqueue(prEvent(2, "b"))
+
+	// The stop times out, cancelling the running job
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := q.Stop(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Stop() error = %v, want deadline exceeded", err)
+	}
+	if _, _, err := q.Enqueue(prEvent(3, "c")); !errors.Is(err, ErrStopped) {
+		t.Errorf("Enqueue() after Stop error = %v, want ErrStopped", err)
+	}
+
+	// A corrupt file does not stop the queue from loading
+	if err := os.WriteFile(filepath.Join(dir, "corrupt.json"), []byte("{"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	var mu sync.Mutex
+	var ran []string
+	q = openQueue(t, dir, Options{Workers: 1}, func(ctx context.Context, job Job) error {
+		mu.Lock()
+		ran = append(ran, job.ID)
+		mu.Unlock()
+		return nil
+	})
+
+	if job := waitState(t, q, running.ID, StateSucceeded); job.Attempts != 1 {
+		t.Errorf("interrupted job attempts = %d, want 1", job.Attempts)
+	}
+	waitState(t, q, queued.ID, StateSucceeded)
+	mu.Lock()
+	defer mu.Unlock()
+	if len(ran) != 2 || ran[0] != running.ID {
+		t.Errorf("ran = %v, want both jobs in order", ran)
+	}
+}
+
+func TestOpenFailsInterruptedLastAttempt(t *testing.T) {
+	dir := t.TempDir()
+	st, err := newStore(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	old := time.Now().Add(-30 * 24 * time.Hour)
+	jobs := []*Job{
+		{ID: "1", Key: "k1", State: StateRunning, Attempts: 1},
+		{ID: "2", Key: "k2", State: StateRunning, Attempts: 3},
+		{ID: "3", Key: "k3", State: StateSucceeded, FinishedAt: &old},
+	}
+	for _, j := range jobs {
+		if err := st.save(j); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	q, err := Open(Options{Dir: dir}, func(ctx context.Context, job Job) error { return nil })
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if job, _ := q.Get("1"); job.State != StateQueued {
+		t.Errorf("job 1 = %s, want queued", job.State)
+	}
+	if job, _ := q.Get("2"); job.State != StateFailed || job.Error == "" {
+		t.Errorf("job 2 = %s %q, want failed", job.State, job.Error)
+	}
+	if _, ok := q.Get("3"); ok {
+		t.Error("job 3 past retention was not pruned")
+	}
+	if _, err := os.Stat(st.path("3")); !os.IsNotExist(err) {
+		t.Errorf("job 3 file not removed: %v", err)
+	}
+}
+
+func TestAPI(t *testing.T) {
+	b := newBlocker()
+	q := openQueue(t, t.TempDir(), Options{Workers: 1}, b.handle)
+	running, _, _ := q.Enqueue(prEvent(1, "a"))
+	<-b.started
+	queued, _, _ := q.Enqueue(prEvent(2, "b"))
+
+	handler := NewAPI(q, "secret")
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		token      string
+		wantStatus int
+		wantBody   string
+	}{
+		{name: "no token", method: http.MethodGet, path: "/jobs", wantStatus: http.StatusUnauthorized},
+		{name: "wrong token", method: http.MethodGet, path: "/jobs", token: "nope", wantStatus: http.StatusUnauthorized},
+		{name: "list", method: http.MethodGet, path: "/jobs", token: "secret", wantStatus: http.StatusOK, wantBody: `"running":1`},
+		{name: "list by state", method: http.MethodGet, path: "/jobs?state=queued", token: "secret", wantStatus: http.StatusOK, wantBody: queued.ID},
+		{name: "unknown state", method: http.MethodGet, path: "/jobs?state=done", token: "secret", wantStatus: http.StatusBadRequest},
+		{name: "get", method: http.MethodGet, path: "/jobs/" + running.ID, token: "secret", wantStatus: http.StatusOK, wantBody: `"state":"running"`},
+		{name: "get missing", method: http.MethodGet, path: "/jobs/missing", token: "secret", wantStatus: http.StatusNotFound},
+		{name: "cancel with GET", method: http.MethodGet, path: "/jobs/" + running.ID + "/cancel", token: "secret", wantStatus: http.StatusMethodNotAllowed},
+		{name: "cancel", method: http.MethodPost, path: "/jobs/" + queued.ID + "/cancel", token: "secret", wantStatus: http.StatusOK, wantBody: `"state":"cancelled"`},
+		{name: "cancel finished", method: http.MethodPost, path: "/jobs/" + queued.ID + "/cancel", token: "secret", wantStatus: http.StatusConflict},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			if tt.token != "" {
+				req.Header.Set("Authorization", "Bearer "+tt.token)
+			}
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body.String())
+			}
+			if !strings.Contains(w.Body.String(), tt.wantBody) {
+				t.Errorf("body = %s, want %s", w.Body.String(), tt.wantBody)
+			}
+		})
+	}
+
+	var list struct {
+		Jobs []Job `json:"jobs"`
+	}
+	req := httptest.NewRequest(http.MethodGet, "/jobs", nil)
+	req.Header.Set("Authorization", "Bearer secret")
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	if err := json.Unmarshal(w.Body.Bytes(), &list); err != nil {
+		t.Fatal(err)
+	}
+	if len(list.Jobs) != 2 || list.Jobs[0].ID != queued.ID {
+		t.Errorf("jobs not listed newest first: %+v", list.Jobs)
+	}
+
+	// Without a token the API is disabled
+	w = httptest.NewRecorder()
+	NewAPI(q, "").ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/jobs", nil))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("disabled API status = %d, want 404", w.Code)
+	}
+
+	close(b.release)
+}
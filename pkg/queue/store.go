@@ -0,0 +1,87 @@
+// Package queue provides the file store that keeps jobs across restarts
+package queue
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// store keeps one JSON file per job in a directory. Files are replaced
+// atomically, so a crash leaves either the old or the new state.
+type store struct {
+	dir string
+}
+
+// newStore opens the store in dir, creating it if needed
+func newStore(dir string) (*store, error) {
+	if dir == "" {
+		return nil, fmt.Errorf("queue directory is required")
+	}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, fmt.Errorf("failed to create queue directory: %w", err)
+	}
+	return &store{dir: dir}, nil
+}
+
+// path returns the file of a job
+func (s *store) path(id string) string {
+	return filepath.Join(s.dir, id+".json")
+}
+
+// save writes a job
+func (s *store) save(job *Job) error {
+	data, err := json.Marshal(job)
+	if err != nil {
+		return fmt.Errorf("failed to marshal job %s: %w", job.ID, err)
+	}
+
+	tmp := s.path(job.ID) + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return fmt.Errorf("failed to write job %s: %w", job.ID, err)
+	}
+	if err := os.Rename(tmp, s.path(job.ID)); err != nil {
+		_ = os.Remove(tmp)
+		return fmt.Errorf("failed to write job %s: %w", job.ID, err)
+	}
+	return nil
+}
+
+// remove deletes a job
+func (s *store) remove(id string) error {
+	if err := os.Remove(s.path(id)); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove job %s: %w", id, err)
+	}
+	return nil
+}
+
+// load reads all jobs. Unreadable files are skipped with a warning so one
+// corrupt job does not stop the queue.
+func (s *store) load() ([]*Job, error) {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read queue directory: %w", err)
+	}
+
+	var jobs []*Job
+	for _, e := range entries {
+		if e.IsDir() || !strings.HasSuffix(e.Name(), ".json") {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(s.dir, e.Name()))
+		if err != nil {
+			log.Printf("[WARNING] skipping queued job %s: %v", e.Name(), err)
+			continue
+		}
+		var job Job
+		if err := json.Unmarshal(data, &job); err != nil || job.ID == "" {
+			log.Printf("[WARNING] skipping corrupt queued job %s", e.Name())
+			continue
+		}
+		jobs = append(jobs, &job)
+	}
+	return jobs, nil
+}
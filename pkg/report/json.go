@@ -0,0 +1,328 @@
+// Package report provides the versioned JSON result document of the
+// review, analyze and test-gen commands
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/cicd-ai-toolkit/cicd-runner/pkg/ai"
+	"github.com/cicd-ai-toolkit/cicd-runner/pkg/runner"
+)
+
+// JSONSchemaVersion is the version of the JSON result document. It changes
+// when fields are removed or change meaning; fields may be added within a
+// version, so consumers should ignore unknown fields.
+const JSONSchemaVersion = "1"
+
+// JSONOptions configures JSON result generation
+type JSONOptions struct {
+	// ToolName overrides the tool name (default: cicd-ai-toolkit)
+	ToolName string
+	// ToolVersion is the cicd-runner version
+	ToolVersion string
+}
+
+// JSONResult is the top-level JSON result document. Exactly one of
+// Review, Analysis and TestGen is set, matching Command.
+type JSONResult struct {
+	SchemaVersion string        `json:"schema_version"`
+	Command       string        `json:"command"` // review, analyze or test-gen
+	Tool          JSONTool      `json:"tool"`
+	DurationMS    int64         `json:"duration_ms"`
+	Cached        bool          `json:"cached"`
+	Usage         *JSONUsage    `json:"usage"` // null when no AI execution ran
+	Review        *JSONReview   `json:"review,omitempty"`
+	Analysis      *JSONAnalysis `json:"analysis,omitempty"`
+	TestGen       *JSONTestGen  `json:"test_gen,omitempty"`
+}
+
+// JSONTool identifies the tool that produced the document
+type JSONTool struct {
+	Name    string `json:"name"`
+	Version string `json:"version,omitempty"`
+}
+
+// JSONUsage is the AI usage and spend of the run
+type JSONUsage struct {
+	Executions      int      `json:"executions"`
+	Backends        []string `json:"backends"` // In order of first use; more than one after a fallback
+	Models          []string `json:"models"`   // In order of first use; more than one after a budget downgrade
+	InputTokens     int      `json:"input_tokens"`
+	OutputTokens    int      `json:"output_tokens"`
+	TotalTokens     int      `json:"total_tokens"`
+	CostUSD         float64  `json:"cost_usd"`
+	PRCostUSD       float64  `json:"pr_cost_usd,omitempty"` // Spent on the PR across runs
+	DowngradedModel string   `json:"downgraded_model,omitempty"`
+}
+
+// JSONReview is the result of a review
+type JSONReview struct {
+	Summary      JSONReviewSummary  `json:"summary"`
+	Issues       []ai.Issue         `json:"issues"`
+	Resolved     []ai.Issue         `json:"resolved"`
+	HeadSHA      string             `json:"head_sha,omitempty"`
+	PreviousSHA  string             `json:"previous_sha,omitempty"` // Set when only changes since this head were reviewed
+	Chunks       int                `json:"chunks"`
+	FailedChunks []JSONChunkFailure `json:"failed_chunks"`
+	Skills       []JSONSkill        `json:"skills"`
+	Comment      string             `json:"comment"` // Markdown comment posted with --post
+}
+
+// JSONReviewSummary counts the issues of a review by severity
+type JSONReviewSummary struct {
+	FilesChanged int `json:"files_changed"`
+	TotalIssues  int `json:"total_issues"`
+	Critical     int `json:"critical"`
+	High         int `json:"high"`
+	Medium       int `json:"medium"`
+	Low          int `json:"low"`
+}
+
+// JSONChunkFailure is a failed skill execution on a diff chunk
+type JSONChunkFailure struct {
+	Chunk int      `json:"chunk"`
+	Skill string   `json:"skill,omitempty"`
+	Files []string `json:"files"`
+	Error string   `json:"error"`
+}
+
+// JSONSkill is the outcome of one review skill
+type JSONSkill struct {
+	Name       string `json:"name"`
+	Priority   int    `json:"priority"`
+	Issues     int    `json:"issues"`
+	DurationMS int64  `json:"duration_ms"`
+	Failed     int    `json:"failed"`
+	Error      string `json:"error,omitempty"`
+}
+
+// JSONAnalysis is the result of a change analysis
+type JSONAnalysis struct {
+	Summary     JSONChangeSummary `json:"summary"`
+	Impact      JSONImpact        `json:"impact"`
+	Risk        JSONRisk          `json:"risk"`
+	Changelog   JSONChangelog     `json:"changelog"`
+	Suggestions []string          `json:"suggestions"`
+}
+
+// JSONChangeSummary describes the analyzed changes
+type JSONChangeSummary struct {
+	Title        string `json:"title,omitempty"`
+	Description  string `json:"description,omitempty"`
+	FilesChanged int    `json:"files_changed"`
+	LinesAdded   int    `json:"lines_added"`
+	LinesRemoved int    `json:"lines_removed"`
+}
+
+// JSONImpact is the impact of the analyzed changes
+type JSONImpact struct {
+	BreakingChanges    []string `json:"breaking_changes"`
+	APIChanges         []string `json:"api_changes"`
+	DatabaseMigrations bool     `json:"database_migrations"`
+	ConfigChanges      []string `json:"config_changes"`
+	AffectedModules    []string `json:"affected_modules"`
+}
+
+// JSONRisk is the risk assessment of the analyzed changes
+type JSONRisk struct {
+	Score              int      `json:"score"` // 1-10
+	Factors            []string `json:"factors"`
+	TestingLevel       string   `json:"testing_level,omitempty"`
+	RollbackComplexity string   `json:"rollback_complexity,omitempty"`
+}
+
+// JSONChangelog is the suggested changelog entry
+type JSONChangelog struct {
+	Added      []string `json:"added"`
+	Changed    []string `json:"changed"`
+	Deprecated []string `json:"deprecated"`
+	Removed    []string `json:"removed"`
+	Fixed      []string `json:"fixed"`
+}
+
+// JSONTestGen is the result of test generation
+type JSONTestGen struct {
+	Summary   JSONTestGenSummary `json:"summary"`
+	TestFiles []JSONTestFile     `json:"test_files"`
+}
+
+// JSONTestGenSummary counts the generated tests
+type JSONTestGenSummary struct {
+	FilesCreated     int    `json:"files_created"`
+	TotalTests       int    `json:"total_tests"`
+	CoverageEstimate string `json:"coverage_estimate,omitempty"`
+}
+
+// JSONTestFile is a generated test file
+type JSONTestFile struct {
+	Path     string `json:"path"`
+	Language string `json:"language,omitempty"`
+	Tests    int    `json:"tests"`
+	Content  string `json:"content"`
+}
+
+// BuildReviewJSON converts a review result into a JSON result document
+func BuildReviewJSON(result *runner.ReviewResult, opts JSONOptions) *JSONResult {
+	doc := newJSONResult("review", opts, result.Duration, result.Usage, result.Cost)
+	doc.Cached = result.Cached
+
+	review := &JSONReview{
+		Summary: JSONReviewSummary{
+			FilesChanged: result.Summary.FilesChanged,
+			TotalIssues:  result.Summary.TotalIssues,
+			Critical:     result.Summary.Critical,
+			High:         result.Summary.High,
+			Medium:       result.Summary.Medium,
+			Low:          result.Summary.Low,
+		},
+		Issues:       nonNilIssues(result.Issues),
+		Resolved:     nonNilIssues(result.Resolved),
+		HeadSHA:      result.HeadSHA,
+		PreviousSHA:  result.PreviousSHA,
+		Chunks:       result.Chunks,
+		FailedChunks: make([]JSONChunkFailure, 0, len(result.FailedChunks)),
+		Skills:       make([]JSONSkill, 0, len(result.Skills)),
+		Comment:      result.PlatformComment,
+	}
+	for _, f := range result.FailedChunks {
+		review.FailedChunks = append(review.FailedChunks, JSONChunkFailure{
+			Chunk: f.Index,
+			Skill: f.Skill,
+			Files: nonNil(f.Files),
+			Error: f.Err,
+		})
+	}
+	for _, s := range result.Skills {
+		review.Skills = append(review.Skills, JSONSkill{
+			Name:       s.Name,
+			Priority:   s.Priority,
+			Issues:     s.Issues,
+			DurationMS: s.Duration.Milliseconds(),
+			Failed:     s.Failed,
+			Error:      s.Err,
+		})
+	}
+
+	doc.Review = review
+	return doc
+}
+
+// BuildAnalysisJSON converts an analysis result into a JSON result document
+func BuildAnalysisJSON(result *runner.AnalyzeResult, opts JSONOptions) *JSONResult {
+	doc := newJSONResult("analyze", opts, result.Duration, result.Usage, result.Cost)
+	doc.Analysis = &JSONAnalysis{
+		Summary: JSONChangeSummary{
+			Title:        result.Summary.Title,
+			Description:  result.Summary.Description,
+			FilesChanged: result.Summary.FilesChanged,
+			LinesAdded:   result.Summary.LinesAdded,
+			LinesRemoved: result.Summary.LinesRemoved,
+		},
+		Impact: JSONImpact{
+			BreakingChanges:    nonNil(result.Impact.BreakingChanges),
+			APIChanges:         nonNil(result.Impact.APIChanges),
+			DatabaseMigrations: result.Impact.DatabaseMigrations,
+			ConfigChanges:      nonNil(result.Impact.ConfigChanges),
+			AffectedModules:    nonNil(result.Impact.AffectedModules),
+		},
+		Risk: JSONRisk{
+			Score:              result.Risk.Score,
+			Factors:            nonNil(result.Risk.Factors),
+			TestingLevel:       result.Risk.TestingLevel,
+			RollbackComplexity: result.Risk.RollbackComplexity,
+		},
+		Changelog: JSONChangelog{
+			Added:      nonNil(result.Changelog.Added),
+			Changed:    nonNil(result.Changelog.Changed),
+			Deprecated: nonNil(result.Changelog.Deprecated),
+			Removed:    nonNil(result.Changelog.Removed),
+			Fixed:      nonNil(result.Changelog.Fixed),
+		},
+		Suggestions: nonNil(result.Suggestions),
+	}
+	return doc
+}
+
+// BuildTestGenJSON converts a test generation result into a JSON result
+// document
+func BuildTestGenJSON(result *runner.TestGenResult, opts JSONOptions) *JSONResult {
+	doc := newJSONResult("test-gen", opts, result.Duration, result.Usage, result.Cost)
+	testGen := &JSONTestGen{
+		Summary: JSONTestGenSummary{
+			FilesCreated:     result.Summary.FilesCreated,
+			TotalTests:       result.Summary.TotalTests,
+			CoverageEstimate: result.Summary.CoverageEst,
+		},
+		TestFiles: make([]JSONTestFile, 0, len(result.TestFiles)),
+	}
+	for _, f := range result.TestFiles {
+		testGen.TestFiles = append(testGen.TestFiles, JSONTestFile{
+			Path:     f.Path,
+			Language: f.Language,
+			Tests:    f.Tests,
+			Content:  f.Content,
+		})
+	}
+
+	doc.TestGen = testGen
+	return doc
+}
+
+// WriteJSON writes an indented JSON result document to w
+func WriteJSON(w io.Writer, doc *JSONResult) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		return fmt.Errorf("failed to write JSON result: %w", err)
+	}
+	return nil
+}
+
+// newJSONResult creates a document with the fields shared by all commands
+func newJSONResult(command string, opts JSONOptions, duration time.Duration, usage *runner.RunUsage, cost *runner.RunCost) *JSONResult {
+	doc := &JSONResult{
+		SchemaVersion: JSONSchemaVersion,
+		Command:       command,
+		Tool: JSONTool{
+			Name:    nonEmpty(opts.ToolName, DefaultToolName),
+			Version: opts.ToolVersion,
+		},
+		DurationMS: duration.Milliseconds(),
+	}
+
+	if usage != nil {
+		doc.Usage = &JSONUsage{
+			Executions:   usage.Executions,
+			Backends:     nonNil(usage.Backends),
+			Models:       nonNil(usage.Models),
+			InputTokens:  usage.InputTokens,
+			OutputTokens: usage.OutputTokens,
+			TotalTokens:  usage.InputTokens + usage.OutputTokens,
+			CostUSD:      usage.CostUSD,
+		}
+		if cost != nil {
+			doc.Usage.PRCostUSD = cost.PRUSD
+			doc.Usage.DowngradedModel = cost.Downgraded
+		}
+	}
+	return doc
+}
+
+// nonNil returns s, or an empty slice so it is encoded as [] rather than null
+func nonNil(s []string) []string {
+	if s == nil {
+		return []string{}
+	}
+	return s
+}
+
+// nonNilIssues returns issues, or an empty slice so it is encoded as []
+func nonNilIssues(issues []ai.Issue) []ai.Issue {
+	if issues == nil {
+		return []ai.Issue{}
+	}
+	return issues
+}
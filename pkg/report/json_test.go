@@ -0,0 +1,125 @@
+// Package report provides JSON result document tests
+package report
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/cicd-ai-toolkit/cicd-runner/pkg/ai"
+	"github.com/cicd-ai-toolkit/cicd-runner/pkg/runner"
+)
+
+func TestBuildReviewJSON(t *testing.T) {
+	result := &runner.ReviewResult{
+		Summary: runner.ReviewSummary{TotalIssues: 1, High: 1},
+		Issues: []ai.Issue{
+			{Severity: "high", Category: "security", File: "db.go", Line: 7, Message: "SQL injection", Skill: "security-scanner"},
+		},
+		PlatformComment: "## Review",
+		HeadSHA:         "abc123",
+		Chunks:          2,
+		FailedChunks:    []runner.ChunkFailure{{Index: 2, Skill: "code-reviewer", Files: []string{"big.go"}, Err: "timeout"}},
+		Skills:          []runner.SkillResult{{Name: "security-scanner", Priority: 90, Issues: 1, Duration: 1500 * time.Millisecond}},
+		Cost:            &runner.RunCost{RunUSD: 0.05, PRUSD: 0.15, Downgraded: "haiku"},
+		Usage: &runner.RunUsage{
+			Executions:   3,
+			Backends:     []string{"claude", "api"},
+			Models:       []string{"sonnet", "haiku"},
+			InputTokens:  1000,
+			OutputTokens: 200,
+			CostUSD:      0.05,
+		},
+		Duration: 2 * time.Second,
+	}
+
+	var buf bytes.Buffer
+	if err := WriteJSON(&buf, BuildReviewJSON(result, JSONOptions{ToolVersion: "1.2.3"})); err != nil {
+		t.Fatalf("WriteJSON() error = %v", err)
+	}
+
+	var doc JSONResult
+	if err := json.Unmarshal(buf.Bytes(), &doc); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, buf.String())
+	}
+	if doc.SchemaVersion != JSONSchemaVersion || doc.Command != "review" || doc.Tool.Version != "1.2.3" || doc.Tool.Name != DefaultToolName {
+		t.Errorf("header = %+v", doc)
+	}
+	if doc.DurationMS != 2000 || doc.Cached {
+		t.Errorf("duration_ms = %d, cached = %v", doc.DurationMS, doc.Cached)
+	}
+	if doc.Usage == nil || doc.Usage.TotalTokens != 1200 || doc.Usage.Backends[1] != "api" ||
+		doc.Usage.PRCostUSD != 0.15 || doc.Usage.DowngradedModel != "haiku" {
+		t.Errorf("usage = %+v", doc.Usage)
+	}
+	if doc.Analysis != nil || doc.TestGen != nil {
+		t.Error("review document has analysis or test_gen set")
+	}
+
+	review := doc.Review
+	if review == nil || len(review.Issues) != 1 || review.Issues[0].Skill != "security-scanner" {
+		t.Fatalf("review = %+v", review)
+	}
+	if review.Summary.High != 1 || review.HeadSHA != "abc123" || review.Comment != "## Review" {
+		t.Errorf("review = %+v", review)
+	}
+	if len(review.FailedChunks) != 1 || review.FailedChunks[0].Chunk != 2 || review.FailedChunks[0].Error != "timeout" {
+		t.Errorf("failed_chunks = %+v", review.FailedChunks)
+	}
+	if len(review.Skills) != 1 || review.Skills[0].DurationMS != 1500 {
+		t.Errorf("skills = %+v", review.Skills)
+	}
+}
+
+func TestBuildReviewJSONCached(t *testing.T) {
+	doc := BuildReviewJSON(&runner.ReviewResult{Cached: true}, JSONOptions{})
+
+	var buf bytes.Buffer
+	if err := WriteJSON(&buf, doc); err != nil {
+		t.Fatal(err)
+	}
+
+	// Empty lists are encoded as [] and a run without AI executions has null usage
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(buf.Bytes(), &raw); err != nil {
+		t.Fatal(err)
+	}
+	if string(raw["usage"]) != "null" || string(raw["cached"]) != "true" {
+		t.Errorf("usage = %s, cached = %s", raw["usage"], raw["cached"])
+	}
+	var review map[string]json.RawMessage
+	if err := json.Unmarshal(raw["review"], &review); err != nil {
+		t.Fatal(err)
+	}
+	for _, field := range []string{"issues", "resolved", "failed_chunks", "skills"} {
+		if string(review[field]) != "[]" {
+			t.Errorf("%s = %s, want []", field, review[field])
+		}
+	}
+}
+
+func TestBuildAnalysisAndTestGenJSON(t *testing.T) {
+	analysis := BuildAnalysisJSON(&runner.AnalyzeResult{
+		Summary:  runner.ChangeSummary{FilesChanged: 3, LinesAdded: 10},
+		Risk:     runner.RiskAssessment{Score: 7, Factors: []string{"touches auth"}},
+		Duration: time.Second,
+	}, JSONOptions{})
+	if analysis.Command != "analyze" || analysis.Analysis == nil || analysis.Review != nil {
+		t.Fatalf("analysis document = %+v", analysis)
+	}
+	if analysis.Analysis.Risk.Score != 7 || analysis.Analysis.Summary.FilesChanged != 3 || analysis.Analysis.Impact.BreakingChanges == nil {
+		t.Errorf("analysis = %+v", analysis.Analysis)
+	}
+
+	testGen := BuildTestGenJSON(&runner.TestGenResult{
+		TestFiles: []runner.GeneratedTest{{Path: "a_test.go", Language: "go", Content: "package a", Tests: 2}},
+		Summary:   runner.TestGenSummary{FilesCreated: 1, TotalTests: 2, CoverageEst: "~70%"},
+	}, JSONOptions{})
+	if testGen.Command != "test-gen" || testGen.TestGen == nil {
+		t.Fatalf("test-gen document = %+v", testGen)
+	}
+	if len(testGen.TestGen.TestFiles) != 1 || testGen.TestGen.TestFiles[0].Tests != 2 || testGen.TestGen.Summary.CoverageEstimate != "~70%" {
+		t.Errorf("test_gen = %+v", testGen.TestGen)
+	}
+}
@@ -0,0 +1,242 @@
+// Package report provides JUnit XML rendering of review findings, so CI
+// systems show them in their test report views
+package report
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/cicd-ai-toolkit/cicd-runner/pkg/ai"
+	"github.com/cicd-ai-toolkit/cicd-runner/pkg/runner"
+)
+
+// JUnitOptions configures JUnit XML generation
+type JUnitOptions struct {
+	// ToolName overrides the name of the root testsuites element
+	// (default: cicd-ai-toolkit)
+	ToolName string
+	// Files are the changed files. Each becomes a testcase in every suite;
+	// files with issues are added when missing.
+	Files []string
+}
+
+// JUnitTestSuites is the root element of a JUnit XML report
+type JUnitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Name     string           `xml:"name,attr"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Skipped  int              `xml:"skipped,attr"`
+	Time     string           `xml:"time,attr"`
+	Suites   []JUnitTestSuite `xml:"testsuite"`
+}
+
+// JUnitTestSuite groups the testcases of one skill or issue category
+type JUnitTestSuite struct {
+	Name     string          `xml:"name,attr"`
+	Tests    int             `xml:"tests,attr"`
+	Failures int             `xml:"failures,attr"`
+	Errors   int             `xml:"errors,attr"`
+	Skipped  int             `xml:"skipped,attr"`
+	Time     string          `xml:"time,attr"`
+	Cases    []JUnitTestCase `xml:"testcase"`
+}
+
+// JUnitTestCase is the review of one file by one skill
+type JUnitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *JUnitFailure `xml:"failure,omitempty"`
+	Skipped   *JUnitSkipped `xml:"skipped,omitempty"`
+	SystemOut string        `xml:"system-out,omitempty"`
+}
+
+// JUnitFailure carries the blocking issues of a file
+type JUnitFailure struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Text    string `xml:",chardata"`
+}
+
+// JUnitSkipped marks a file the skill could not review
+type JUnitSkipped struct {
+	Message string `xml:"message,attr"`
+}
+
+// BuildJUnit converts a review result into a JUnit XML report. Issues are
+// grouped into one testsuite per skill, or per category for issues without
+// a skill, with one testcase per file. Files with critical or high issues
+// fail; lower severity issues are listed in the testcase output. Files in
+// chunks a skill failed to review are skipped.
+func BuildJUnit(result *runner.ReviewResult, opts JUnitOptions) *JUnitTestSuites {
+	type suite struct {
+		name    string
+		seconds float64
+		issues  map[string][]ai.Issue
+		failed  map[string]string // File -> reason it was not reviewed
+	}
+	var suites []*suite
+	byName := make(map[string]*suite)
+	get := func(name string) *suite {
+		s, ok := byName[name]
+		if !ok {
+			s = &suite{name: name, issues: make(map[string][]ai.Issue), failed: make(map[string]string)}
+			byName[name] = s
+			suites = append(suites, s)
+		}
+		return s
+	}
+
+	// Skills that ran come first, in priority order, even without issues;
+	// then the other suites by name
+	for _, sk := range result.Skills {
+		get(sk.Name).seconds = sk.Duration.Seconds()
+	}
+	ran := len(suites)
+	for _, issue := range result.Issues {
+		get(junitSuiteName(issue))
+	}
+	others := suites[ran:]
+	sort.Slice(others, func(i, j int) bool { return others[i].name < others[j].name })
+
+	files := newChangedFiles(opts.Files)
+	changed := newChangedFiles(opts.Files)
+	for _, issue := range result.Issues {
+		issue.File = changed.uri(issue.File)
+		file := nonEmpty(issue.File, "(general)")
+		files[file] = true
+		s := byName[junitSuiteName(issue)]
+		s.issues[file] = append(s.issues[file], issue)
+	}
+	for _, f := range result.FailedChunks {
+		if s, ok := byName[f.Skill]; ok {
+			for _, file := range f.Files {
+				s.failed[artifactURI(file)] = f.Err
+			}
+		}
+	}
+
+	paths := make([]string, 0, len(files))
+	for f := range files {
+		paths = append(paths, f)
+	}
+	sort.Strings(paths)
+
+	report := &JUnitTestSuites{
+		Name:   nonEmpty(opts.ToolName, DefaultToolName),
+		Time:   junitSeconds(result.Duration.Seconds()),
+		Suites: make([]JUnitTestSuite, 0, len(suites)),
+	}
+	for _, s := range suites {
+		ts := JUnitTestSuite{Name: s.name, Time: junitSeconds(s.seconds)}
+		for _, path := range paths {
+			tc := JUnitTestCase{Name: path, ClassName: s.name, Time: "0"}
+			issues := s.issues[path]
+			if reason, ok := s.failed[path]; ok && len(issues) == 0 {
+				tc.Skipped = &JUnitSkipped{Message: "not reviewed: " + reason}
+				ts.Skipped++
+			}
+			tc.Failure, tc.SystemOut = junitFindings(issues)
+			if tc.Failure != nil {
+				ts.Failures++
+			}
+			ts.Cases = append(ts.Cases, tc)
+		}
+		ts.Tests = len(ts.Cases)
+
+		report.Tests += ts.Tests
+		report.Failures += ts.Failures
+		report.Skipped += ts.Skipped
+		report.Suites = append(report.Suites, ts)
+	}
+
+	return report
+}
+
+// WriteJUnit writes a review result as an indented JUnit XML report
+func WriteJUnit(w io.Writer, result *runner.ReviewResult, opts JUnitOptions) error {
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return fmt.Errorf("failed to write JUnit report: %w", err)
+	}
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(BuildJUnit(result, opts)); err != nil {
+		return fmt.Errorf("failed to encode JUnit report: %w", err)
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+// junitSuiteName returns the suite an issue is reported in
+func junitSuiteName(issue ai.Issue) string {
+	if issue.Skill != "" {
+		return issue.Skill
+	}
+	if issue.Category != "" {
+		return strings.ToLower(issue.Category)
+	}
+	return "general"
+}
+
+// junitFindings splits the issues of a file into a failure for critical and
+// high issues and output text for the others
+func junitFindings(issues []ai.Issue) (*JUnitFailure, string) {
+	var blocking, other []string
+	worst := ""
+	for _, issue := range issues {
+		text := junitIssueText(issue)
+		if severityRank(issue.Severity) >= severityRank("high") {
+			blocking = append(blocking, text)
+			if severityRank(issue.Severity) > severityRank(worst) {
+				worst = strings.ToLower(issue.Severity)
+			}
+		} else {
+			other = append(other, text)
+		}
+	}
+
+	var failure *JUnitFailure
+	if len(blocking) > 0 {
+		message := firstMessage(issues, worst)
+		if len(blocking) > 1 {
+			message = fmt.Sprintf("%d critical or high issues: %s", len(blocking), message)
+		}
+		failure = &JUnitFailure{Message: message, Type: worst, Text: strings.Join(blocking, "\n\n")}
+	}
+	return failure, strings.Join(other, "\n\n")
+}
+
+// firstMessage returns the message of the first issue of the given severity
+func firstMessage(issues []ai.Issue, severity string) string {
+	for _, issue := range issues {
+		if strings.EqualFold(issue.Severity, severity) {
+			return issue.Message
+		}
+	}
+	return ""
+}
+
+// junitIssueText describes an issue with its location and suggestion
+func junitIssueText(issue ai.Issue) string {
+	location := nonEmpty(artifactURI(issue.File), "(general)")
+	if issue.Line > 0 {
+		location = fmt.Sprintf("%s:%d", location, issue.Line)
+	}
+	text := fmt.Sprintf("%s [%s] %s", location, strings.ToLower(nonEmpty(issue.Severity, "unknown")), issue.Message)
+	if issue.Category != "" {
+		text += fmt.Sprintf(" (%s)", strings.ToLower(issue.Category))
+	}
+	if issue.Suggestion != "" {
+		text += "\nSuggestion: " + issue.Suggestion
+	}
+	return text
+}
+
+// junitSeconds formats a duration in seconds as JUnit expects
+func junitSeconds(seconds float64) string {
+	return fmt.Sprintf("%.3f", seconds)
+}
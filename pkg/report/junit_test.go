@@ -0,0 +1,113 @@
+// Package report provides JUnit XML rendering tests
+package report
+
+import (
+	"bytes"
+	"encoding/xml"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cicd-ai-toolkit/cicd-runner/pkg/ai"
+	"github.com/cicd-ai-toolkit/cicd-runner/pkg/runner"
+)
+
+func TestBuildJUnit(t *testing.T) {
+	result := &runner.ReviewResult{
+		Issues: []ai.Issue{
+			{Severity: "high", Category: "security", File: "./db.go", Line: 7, Message: "SQL injection", Suggestion: "Use placeholders", Skill: "security-scanner"},
+			{Severity: "critical", Category: "security", File: "db.go", Line: 9, Message: "Hardcoded password", Skill: "security-scanner"},
+			{Severity: "low", Category: "style", File: "api.go", Line: 3, Message: "Long function", Skill: "code-reviewer"},
+			{Severity: "medium", Category: "Performance", File: "cache.go", Message: "Unbounded map"},
+		},
+		Skills: []runner.SkillResult{
+			{Name: "security-scanner", Priority: 90, Duration: 2 * time.Second},
+			{Name: "code-reviewer", Priority: 10, Duration: time.Second},
+		},
+		FailedChunks: []runner.ChunkFailure{{Index: 2, Skill: "code-reviewer", Files: []string{"big.go"}, Err: "timeout"}},
+		Duration:     3 * time.Second,
+	}
+
+	report := BuildJUnit(result, JUnitOptions{Files: []string{"api.go", "big.go", "db.go"}})
+
+	// Skills in priority order, then suites of issues without a skill
+	var names []string
+	for _, s := range report.Suites {
+		names = append(names, s.Name)
+	}
+	if got := strings.Join(names, ","); got != "security-scanner,code-reviewer,performance" {
+		t.Fatalf("suites = %s", got)
+	}
+
+	// Every suite has one testcase per file, including cache.go from the issues
+	for _, s := range report.Suites {
+		if s.Tests != 4 || len(s.Cases) != 4 {
+			t.Errorf("suite %s has %d tests, want 4", s.Name, s.Tests)
+		}
+	}
+	if report.Tests != 12 || report.Failures != 1 || report.Skipped != 1 || report.Time != "3.000" {
+		t.Errorf("totals = tests %d, failures %d, skipped %d, time %s", report.Tests, report.Failures, report.Skipped, report.Time)
+	}
+
+	security := report.Suites[0]
+	if security.Failures != 1 || security.Time != "2.000" {
+		t.Errorf("security suite = %+v", security)
+	}
+	db := findCase(t, security, "db.go")
+	if db.Failure == nil || db.Failure.Type != "critical" || db.Failure.Message != "2 critical or high issues: Hardcoded password" {
+		t.Fatalf("db.go failure = %+v", db.Failure)
+	}
+	if !strings.Contains(db.Failure.Text, "db.go:7 [high] SQL injection (security)\nSuggestion: Use placeholders") {
+		t.Errorf("failure text = %q", db.Failure.Text)
+	}
+
+	// Low and medium issues do not fail
+	reviewer := report.Suites[1]
+	api := findCase(t, reviewer, "api.go")
+	if api.Failure != nil || !strings.Contains(api.SystemOut, "api.go:3 [low] Long function") {
+		t.Errorf("api.go = %+v", api)
+	}
+	if big := findCase(t, reviewer, "big.go"); big.Skipped == nil || big.Skipped.Message != "not reviewed: timeout" {
+		t.Errorf("big.go skipped = %+v", big.Skipped)
+	}
+	if reviewer.Failures != 0 || reviewer.Skipped != 1 {
+		t.Errorf("code-reviewer suite = failures %d, skipped %d", reviewer.Failures, reviewer.Skipped)
+	}
+}
+
+func TestWriteJUnit(t *testing.T) {
+	result := &runner.ReviewResult{
+		Issues: []ai.Issue{{Severity: "high", Category: "logic", File: "a.go", Line: 1, Message: "x < y && z > w"}},
+	}
+
+	var buf bytes.Buffer
+	if err := WriteJUnit(&buf, result, JUnitOptions{}); err != nil {
+		t.Fatalf("WriteJUnit() error = %v", err)
+	}
+	if !strings.HasPrefix(buf.String(), xml.Header) {
+		t.Errorf("missing XML header:\n%s", buf.String())
+	}
+
+	var parsed JUnitTestSuites
+	if err := xml.Unmarshal(buf.Bytes(), &parsed); err != nil {
+		t.Fatalf("invalid XML: %v\n%s", err, buf.String())
+	}
+	if len(parsed.Suites) != 1 || parsed.Suites[0].Name != "logic" || parsed.Suites[0].Cases[0].Failure == nil {
+		t.Fatalf("parsed = %+v", parsed)
+	}
+	if got := parsed.Suites[0].Cases[0].Failure.Message; got != "x < y && z > w" {
+		t.Errorf("failure message = %q", got)
+	}
+}
+
+// findCase returns the testcase of a file in a suite
+func findCase(t *testing.T, suite JUnitTestSuite, name string) JUnitTestCase {
+	t.Helper()
+	for _, c := range suite.Cases {
+		if c.Name == name {
+			return c
+		}
+	}
+	t.Fatalf("suite %s has no testcase %s", suite.Name, name)
+	return JUnitTestCase{}
+}
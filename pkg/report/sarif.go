@@ -0,0 +1,434 @@
+// Package report renders review findings in machine-readable formats
+package report
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/cicd-ai-toolkit/cicd-runner/pkg/ai"
+)
+
+const (
+	// SARIFVersion is the SARIF specification version emitted
+	SARIFVersion = "2.1.0"
+
+	// SARIFSchema is the JSON schema URI for SARIF 2.1.0
+	SARIFSchema = "https://json.schemastore.org/sarif-2.1.0.json"
+
+	// DefaultToolName is the tool driver name reported in SARIF runs
+	DefaultToolName = "cicd-ai-toolkit"
+
+	// DefaultToolURI is the informationUri reported for the tool driver
+	DefaultToolURI = "https://github.com/cicd-ai-toolkit/cicd-runner"
+
+	// srcRootBaseID is the uriBaseId used for repository-relative paths
+	srcRootBaseID = "%SRCROOT%"
+)
+
+// SARIFOptions configures SARIF generation
+type SARIFOptions struct {
+	// ToolName overrides the driver name (default: cicd-ai-toolkit)
+	ToolName string
+	// ToolVersion is the cicd-runner version
+	ToolVersion string
+	// InformationURI overrides the driver information URI
+	InformationURI string
+	// Files are the changed files of the reviewed diff. An issue path with
+	// an a/ or b/ prefix echoed from a diff header is matched against them.
+	Files []string
+}
+
+// SARIFLog is the top-level SARIF document
+type SARIFLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []SARIFRun `json:"runs"`
+}
+
+// SARIFRun is a single analysis run
+type SARIFRun struct {
+	Tool               SARIFTool                     `json:"tool"`
+	Results            []SARIFResult                 `json:"results"`
+	OriginalURIBaseIDs map[string]SARIFArtifactLocal `json:"originalUriBaseIds,omitempty"`
+}
+
+// SARIFArtifactLocal describes a uriBaseId root
+type SARIFArtifactLocal struct {
+	URI         string        `json:"uri,omitempty"`
+	Description *SARIFMessage `json:"description,omitempty"`
+}
+
+// SARIFTool describes the analysis tool
+type SARIFTool struct {
+	Driver SARIFDriver `json:"driver"`
+}
+
+// SARIFDriver is the tool component that produced the results
+type SARIFDriver struct {
+	Name           string      `json:"name"`
+	Version        string      `json:"version,omitempty"`
+	InformationURI string      `json:"informationUri,omitempty"`
+	Rules          []SARIFRule `json:"rules"`
+}
+
+// SARIFRule is a reporting descriptor for a rule
+type SARIFRule struct {
+	ID                   string              `json:"id"`
+	Name                 string              `json:"name,omitempty"`
+	ShortDescription     SARIFMessage        `json:"shortDescription"`
+	FullDescription      *SARIFMessage       `json:"fullDescription,omitempty"`
+	Help                 *SARIFMessage       `json:"help,omitempty"`
+	DefaultConfiguration SARIFRuleConfig     `json:"defaultConfiguration"`
+	Properties           SARIFRuleProperties `json:"properties"`
+}
+
+// SARIFRuleConfig holds the default configuration of a rule
+type SARIFRuleConfig struct {
+	Level string `json:"level"`
+}
+
+// SARIFRuleProperties holds rule property bag values
+type SARIFRuleProperties struct {
+	Tags []string `json:"tags,omitempty"`
+	// SecuritySeverity is read by GitHub code scanning to rank security alerts
+	SecuritySeverity string `json:"security-severity,omitempty"`
+	Precision        string `json:"precision,omitempty"`
+}
+
+// SARIFMessage is a SARIF message object
+type SARIFMessage struct {
+	Text     string `json:"text"`
+	Markdown string `json:"markdown,omitempty"`
+}
+
+// SARIFResult is a single finding
+type SARIFResult struct {
+	RuleID              string                `json:"ruleId"`
+	RuleIndex           int                   `json:"ruleIndex"`
+	Level               string                `json:"level"`
+	Message             SARIFMessage          `json:"message"`
+	Locations           []SARIFLocation       `json:"locations,omitempty"`
+	PartialFingerprints map[string]string     `json:"partialFingerprints,omitempty"`
+	Properties          SARIFResultProperties `json:"properties"`
+}
+
+// SARIFResultProperties holds result property bag values
+type SARIFResultProperties struct {
+	Severity   string `json:"severity,omitempty"`
+	Category   string `json:"category,omitempty"`
+	Suggestion string `json:"suggestion,omitempty"`
+}
+
+// SARIFLocation is a result location
+type SARIFLocation struct {
+	PhysicalLocation SARIFPhysicalLocation `json:"physicalLocation"`
+}
+
+// SARIFPhysicalLocation identifies a file and region
+type SARIFPhysicalLocation struct {
+	ArtifactLocation SARIFArtifactLocation `json:"artifactLocation"`
+	Region           *SARIFRegion          `json:"region,omitempty"`
+}
+
+// SARIFArtifactLocation identifies a file
+type SARIFArtifactLocation struct {
+	URI       string `json:"uri"`
+	URIBaseID string `json:"uriBaseId,omitempty"`
+}
+
+// SARIFRegion identifies a region within a file
+type SARIFRegion struct {
+	StartLine int           `json:"startLine"`
+	Snippet   *SARIFMessage `json:"snippet,omitempty"`
+}
+
+// BuildSARIF converts review issues into a SARIF 2.1.0 log.
+// Each distinct rule (Issue.Rule, falling back to Issue.Category) becomes a
+// reporting descriptor whose default level is the highest severity seen.
+// Free-text suggestions are carried in the result markdown and property bag
+// rather than as SARIF fix objects, which require concrete text replacements.
+func BuildSARIF(issues []ai.Issue, opts SARIFOptions) *SARIFLog {
+	if opts.ToolName == "" {
+		opts.ToolName = DefaultToolName
+	}
+	if opts.InformationURI == "" {
+		opts.InformationURI = DefaultToolURI
+	}
+
+	rules := make([]SARIFRule, 0)
+	ruleIndex := make(map[string]int)
+	results := make([]SARIFResult, 0, len(issues))
+	changed := newChangedFiles(opts.Files)
+
+	for _, issue := range issues {
+		issue.File = changed.uri(issue.File)
+		id := ruleID(issue)
+		idx, ok := ruleIndex[id]
+		if !ok {
+			idx = len(rules)
+			ruleIndex[id] = idx
+			rules = append(rules, newSARIFRule(id, issue))
+		} else if severityRank(issue.Severity) > severityRank(levelSeverity(rules[idx])) {
+			rules[idx].DefaultConfiguration.Level = SARIFLevel(issue.Severity)
+			rules[idx].Properties.SecuritySeverity = securitySeverity(issue)
+		}
+
+		results = append(results, newSARIFResult(id, idx, issue))
+	}
+
+	return &SARIFLog{
+		Schema:  SARIFSchema,
+		Version: SARIFVersion,
+		Runs: []SARIFRun{
+			{
+				Tool: SARIFTool{
+					Driver: SARIFDriver{
+						Name:           opts.ToolName,
+						Version:        opts.ToolVersion,
+						InformationURI: opts.InformationURI,
+						Rules:          rules,
+					},
+				},
+				Results: results,
+				OriginalURIBaseIDs: map[string]SARIFArtifactLocal{
+					srcRootBaseID: {
+						Description: &SARIFMessage{Text: "Repository root"},
+					},
+				},
+			},
+		},
+	}
+}
+
+// WriteSARIF writes issues as an indented SARIF 2.1.0 document
+func WriteSARIF(w io.Writer, issues []ai.Issue, opts SARIFOptions) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(BuildSARIF(issues, opts)); err != nil {
+		return fmt.Errorf("failed to encode SARIF: %w", err)
+	}
+	return nil
+}
+
+// SARIFLevel maps an issue severity to a SARIF result level
+func SARIFLevel(severity string) string {
+	switch strings.ToLower(severity) {
+	case "critical", "high":
+		return "error"
+	case "medium":
+		return "warning"
+	case "low":
+		return "note"
+	default:
+		return "note"
+	}
+}
+
+// newSARIFRule creates a reporting descriptor for the first issue seen with a rule ID
+func newSARIFRule(id string, issue ai.Issue) SARIFRule {
+	category := strings.ToLower(issue.Category)
+
+	rule := SARIFRule{
+		ID:               id,
+		Name:             ruleName(id),
+		ShortDescription: SARIFMessage{Text: ruleDescription(id, issue)},
+		DefaultConfiguration: SARIFRuleConfig{
+			Level: SARIFLevel(issue.Severity),
+		},
+		Properties: SARIFRuleProperties{
+			SecuritySeverity: securitySeverity(issue),
+			Precision:        "medium",
+		},
+	}
+
+	if category != "" {
+		rule.Properties.Tags = append(rule.Properties.Tags, category)
+	}
+	if issue.Suggestion != "" {
+		rule.Help = &SARIFMessage{Text: issue.Suggestion}
+	}
+
+	return rule
+}
+
+// newSARIFResult converts a single issue into a SARIF result
+func newSARIFResult(id string, idx int, issue ai.Issue) SARIFResult {
+	message := SARIFMessage{Text: issue.Message}
+	if message.Text == "" {
+		message.Text = fmt.Sprintf("%s issue", nonEmpty(issue.Category, "review"))
+	}
+	if issue.Suggestion != "" {
+		message.Markdown = fmt.Sprintf("%s\n\n**Suggestion**: %s", message.Text, issue.Suggestion)
+	}
+
+	result := SARIFResult{
+		RuleID:    id,
+		RuleIndex: idx,
+		Level:     SARIFLevel(issue.Severity),
+		Message:   message,
+		PartialFingerprints: map[string]string{
+			"cicdIssueHash/v1": issueFingerprint(id, issue),
+		},
+		Properties: SARIFResultProperties{
+			Severity:   strings.ToLower(issue.Severity),
+			Category:   strings.ToLower(issue.Category),
+			Suggestion: issue.Suggestion,
+		},
+	}
+
+	if uri := artifactURI(issue.File); uri != "" {
+		location := SARIFLocation{
+			PhysicalLocation: SARIFPhysicalLocation{
+				ArtifactLocation: SARIFArtifactLocation{
+					URI:       uri,
+					URIBaseID: srcRootBaseID,
+				},
+			},
+		}
+		// SARIF regions are 1-based; omit the region when the line is unknown
+		if issue.Line > 0 {
+			location.PhysicalLocation.Region = &SARIFRegion{StartLine: issue.Line}
+			if issue.CodeSnippet != "" {
+				location.PhysicalLocation.Region.Snippet = &SARIFMessage{Text: issue.CodeSnippet}
+			}
+		}
+		result.Locations = []SARIFLocation{location}
+	}
+
+	return result
+}
+
+// ruleID returns a stable rule identifier for an issue
+func ruleID(issue ai.Issue) string {
+	if issue.Rule != "" {
+		return issue.Rule
+	}
+	if issue.Category != "" {
+		return strings.ToLower(issue.Category)
+	}
+	return "general"
+}
+
+// ruleName converts a rule ID into a PascalCase rule name
+func ruleName(id string) string {
+	parts := strings.FieldsFunc(id, func(r rune) bool {
+		return r == '-' || r == '_' || r == '/' || r == '.' || r == ' '
+	})
+	var b strings.Builder
+	for _, p := range parts {
+		b.WriteString(strings.ToUpper(p[:1]))
+		b.WriteString(p[1:])
+	}
+	return b.String()
+}
+
+// ruleDescription builds the short description for a rule
+func ruleDescription(id string, issue ai.Issue) string {
+	if issue.Rule != "" && issue.Category != "" {
+		return fmt.Sprintf("%s (%s)", issue.Rule, strings.ToLower(issue.Category))
+	}
+	return fmt.Sprintf("AI review finding: %s", id)
+}
+
+// securitySeverity returns a CVSS-like score for security findings.
+// GitHub code scanning only honors this property on security-tagged rules.
+func securitySeverity(issue ai.Issue) string {
+	if !strings.EqualFold(issue.Category, "security") {
+		return ""
+	}
+	switch strings.ToLower(issue.Severity) {
+	case "critical":
+		return "9.5"
+	case "high":
+		return "8.0"
+	case "medium":
+		return "5.5"
+	case "low":
+		return "2.0"
+	default:
+		return ""
+	}
+}
+
+// severityRank orders severities for comparison
+func severityRank(severity string) int {
+	switch strings.ToLower(severity) {
+	case "critical":
+		return 4
+	case "high":
+		return 3
+	case "medium":
+		return 2
+	case "low":
+		return 1
+	default:
+		return 0
+	}
+}
+
+// levelSeverity returns the lowest severity that maps to a rule's current level
+func levelSeverity(rule SARIFRule) string {
+	switch rule.DefaultConfiguration.Level {
+	case "error":
+		return "high"
+	case "warning":
+		return "medium"
+	default:
+		return "low"
+	}
+}
+
+// artifactURI normalizes a file path into a repository-relative URI
+func artifactURI(file string) string {
+	if file == "" {
+		return ""
+	}
+	uri := filepath.ToSlash(file)
+	uri = strings.TrimPrefix(uri, "./")
+	return strings.TrimPrefix(uri, "/")
+}
+
+// changedFiles is the set of URIs of the files changed in a diff
+type changedFiles map[string]bool
+
+// newChangedFiles returns the set of URIs of files
+func newChangedFiles(files []string) changedFiles {
+	changed := make(changedFiles, len(files))
+	for _, f := range files {
+		if uri := artifactURI(f); uri != "" {
+			changed[uri] = true
+		}
+	}
+	return changed
+}
+
+// uri returns the URI of an issue's file. Models sometimes echo the a/ or
+// b/ prefix of diff headers; it is stripped only when the path without it
+// is a changed file and the path as given is not, so a real a/ or b/
+// directory is kept.
+func (c changedFiles) uri(file string) string {
+	uri := artifactURI(file)
+	if (strings.HasPrefix(uri, "a/") || strings.HasPrefix(uri, "b/")) && !c[uri] && c[uri[2:]] {
+		return uri[2:]
+	}
+	return uri
+}
+
+// issueFingerprint returns a stable hash used to track a finding across runs
+func issueFingerprint(id string, issue ai.Issue) string {
+	h := sha256.New()
+	fmt.Fprintf(h, "%s\x00%s\x00%s", id, artifactURI(issue.File), strings.TrimSpace(issue.Message))
+	return fmt.Sprintf("%x", h.Sum(nil))[:32]
+}
+
+// nonEmpty returns s if non-empty, otherwise def
+func nonEmpty(s, def string) string {
+	if s == "" {
+		return def
+	}
+	return s
+}
@@ -0,0 +1,178 @@
+// Package report provides SARIF rendering tests
+package report
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/cicd-ai-toolkit/cicd-runner/pkg/ai"
+)
+
+func TestSARIFLevel(t *testing.T) {
+	tests := []struct {
+		severity string
+		want     string
+	}{
+		{"critical", "error"},
+		{"HIGH", "error"},
+		{"medium", "warning"},
+		{"low", "note"},
+		{"", "note"},
+		{"unknown", "note"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.severity, func(t *testing.T) {
+			if got := SARIFLevel(tt.severity); got != tt.want {
+				t.Errorf("SARIFLevel(%q) = %q, want %q", tt.severity, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBuildSARIF(t *testing.T) {
+	issues := []ai.Issue{
+		{
+			Severity:   "high",
+			Category:   "security",
+			File:       "./pkg/auth/login.go",
+			Line:       42,
+			Rule:       "sql-injection",
+			Message:    "User input concatenated into SQL query",
+			Suggestion: "Use parameterized queries",
+		},
+		{
+			Severity: "critical",
+			Category: "security",
+			File:     "b/pkg/db/query.go",
+			Line:     7,
+			Rule:     "sql-injection",
+			Message:  "Raw query built from request body",
+		},
+		{
+			Severity: "low",
+			Category: "style",
+			Message:  "Consider splitting this package",
+		},
+	}
+
+	log := BuildSARIF(issues, SARIFOptions{ToolVersion: "1.2.3", Files: []string{"pkg/db/query.go"}})
+
+	if log.Version != SARIFVersion || log.Schema != SARIFSchema {
+		t.Fatalf("unexpected header: version=%q schema=%q", log.Version, log.Schema)
+	}
+	if len(log.Runs) != 1 {
+		t.Fatalf("expected 1 run, got %d", len(log.Runs))
+	}
+
+	run := log.Runs[0]
+	if run.Tool.Driver.Name != DefaultToolName || run.Tool.Driver.Version != "1.2.3" {
+		t.Errorf("unexpected driver: %+v", run.Tool.Driver)
+	}
+
+	// Two issues share a rule, so only two rules are expected
+	if len(run.Tool.Driver.Rules) != 2 {
+		t.Fatalf("expected 2 rules, got %d", len(run.Tool.Driver.Rules))
+	}
+	rule := run.Tool.Driver.Rules[0]
+	if rule.ID != "sql-injection" || rule.Name != "SqlInjection" {
+		t.Errorf("unexpected rule: id=%q name=%q", rule.ID, rule.Name)
+	}
+	if rule.Properties.SecuritySeverity != "9.5" {
+		t.Errorf("expected rule security-severity raised to 9.5, got %q", rule.Properties.SecuritySeverity)
+	}
+	if run.Tool.Driver.Rules[1].ID != "style" {
+		t.Errorf("expected category fallback rule ID, got %q", run.Tool.Driver.Rules[1].ID)
+	}
+
+	if len(run.Results) != 3 {
+		t.Fatalf("expected 3 results, got %d", len(run.Results))
+	}
+
+	first := run.Results[0]
+	if first.Level != "error" || first.RuleIndex != 0 {
+		t.Errorf("unexpected first result: level=%q ruleIndex=%d", first.Level, first.RuleIndex)
+	}
+	loc := first.Locations[0].PhysicalLocation
+	if loc.ArtifactLocation.URI != "pkg/auth/login.go" {
+		t.Errorf("expected normalized URI, got %q", loc.ArtifactLocation.URI)
+	}
+	if loc.Region == nil || loc.Region.StartLine != 42 {
+		t.Errorf("expected region startLine 42, got %+v", loc.Region)
+	}
+	if first.Properties.Suggestion != "Use parameterized queries" || first.Message.Markdown == "" {
+		t.Errorf("expected suggestion to be carried in result: %+v", first)
+	}
+
+	if got := run.Results[1].Locations[0].PhysicalLocation.ArtifactLocation.URI; got != "pkg/db/query.go" {
+		t.Errorf("expected diff prefix stripped, got %q", got)
+	}
+
+	last := run.Results[2]
+	if len(last.Locations) != 0 {
+		t.Errorf("expected no locations for issue without file, got %d", len(last.Locations))
+	}
+	if last.Level != "note" || last.RuleIndex != 1 {
+		t.Errorf("unexpected last result: level=%q ruleIndex=%d", last.Level, last.RuleIndex)
+	}
+}
+
+func TestBuildSARIF_DiffPrefix(t *testing.T) {
+	tests := []struct {
+		name  string
+		file  string
+		files []string
+		want  string
+	}{
+		{"echoed prefix", "b/main.go", []string{"main.go"}, "main.go"},
+		{"real directory", "b/main.go", []string{"b/main.go", "main.go"}, "b/main.go"},
+		{"not in diff", "a/docs/index.md", []string{"main.go"}, "a/docs/index.md"},
+		{"diff unknown", "b/main.go", nil, "b/main.go"},
+		{"relative", "./pkg/a.go", nil, "pkg/a.go"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			log := BuildSARIF([]ai.Issue{{File: tt.file, Message: "m"}}, SARIFOptions{Files: tt.files})
+			if got := log.Runs[0].Results[0].Locations[0].PhysicalLocation.ArtifactLocation.URI; got != tt.want {
+				t.Errorf("URI = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBuildSARIF_Fingerprint(t *testing.T) {
+	issue := ai.Issue{Severity: "medium", File: "main.go", Line: 3, Message: "unused variable"}
+
+	a := BuildSARIF([]ai.Issue{issue}, SARIFOptions{}).Runs[0].Results[0]
+	issue.Line = 10
+	b := BuildSARIF([]ai.Issue{issue}, SARIFOptions{}).Runs[0].Results[0]
+
+	// Fingerprints must survive line shifts so alerts are tracked across pushes
+	if a.PartialFingerprints["cicdIssueHash/v1"] != b.PartialFingerprints["cicdIssueHash/v1"] {
+		t.Error("fingerprint should not depend on line number")
+	}
+}
+
+func TestWriteSARIF_Empty(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteSARIF(&buf, nil, SARIFOptions{}); err != nil {
+		t.Fatalf("WriteSARIF() error = %v", err)
+	}
+
+	var doc map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &doc); err != nil {
+		t.Fatalf("output is not valid JSON: %v", err)
+	}
+
+	runs := doc["runs"].([]any)
+	run := runs[0].(map[string]any)
+	// results and rules must be present (as empty arrays) for SARIF consumers
+	if results, ok := run["results"].([]any); !ok || len(results) != 0 {
+		t.Errorf("expected empty results array, got %v", run["results"])
+	}
+	driver := run["tool"].(map[string]any)["driver"].(map[string]any)
+	if rules, ok := driver["rules"].([]any); !ok || len(rules) != 0 {
+		t.Errorf("expected empty rules array, got %v", driver["rules"])
+	}
+}
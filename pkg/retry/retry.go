@@ -0,0 +1,197 @@
+// Package retry provides the retry policy shared by AI backends and
+// platform clients: exponential backoff with jitter, server-requested
+// delays and a stop at the context deadline
+package retry
+
+import (
+	"context"
+	"errors"
+	"log"
+	"math/rand"
+	"time"
+
+	"github.com/cicd-ai-toolkit/cicd-runner/pkg/config"
+	cicderrors "github.com/cicd-ai-toolkit/cicd-runner/pkg/errors"
+	"github.com/cicd-ai-toolkit/cicd-runner/pkg/observability"
+)
+
+// Attempt outcomes recorded in metrics
+const (
+	OutcomeSuccess = "success"
+	OutcomeRetry   = "retry"
+	OutcomeFailure = "failure"
+)
+
+// Policy decides whether and when a failed call is retried. The zero
+// Policy makes a single attempt.
+type Policy struct {
+	MaxRetries int           // Retries after the first attempt
+	BaseDelay  time.Duration // Delay before the first retry; doubled for each further retry
+	MaxDelay   time.Duration // Longest delay; longer server-requested delays are not waited for
+
+	// Metrics records every attempt, or nil
+	Metrics *observability.MetricsCollector
+
+	// OnRetry is called before waiting to retry a failed call, or nil
+	OnRetry func(attempt int, delay time.Duration, err error)
+
+	// sleep waits between attempts; replaced in tests
+	sleep func(ctx context.Context, d time.Duration) error
+}
+
+// DefaultPolicy returns the policy used when none is configured
+func DefaultPolicy() Policy {
+	return Policy{
+		MaxRetries: 3,
+		BaseDelay:  time.Second,
+		MaxDelay:   2 * time.Minute, // GitHub asks for 60s after secondary rate limits
+	}
+}
+
+// FromConfig returns the policy configured in cfg. retry.max_retries
+// defaults to claude.max_retries.
+func FromConfig(cfg *config.Config) Policy {
+	p := Policy{
+		MaxRetries: cfg.Retry.MaxRetries,
+		BaseDelay:  cfg.Retry.GetBaseDelay(),
+		MaxDelay:   cfg.Retry.GetMaxDelay(),
+	}
+	if p.MaxRetries == 0 {
+		p.MaxRetries = cfg.Claude.GetMaxRetries()
+	}
+	if p.MaxRetries < 0 {
+		p.MaxRetries = 0
+	}
+	return p
+}
+
+// hinted is implemented by errors that carry a server-requested delay
+type hinted interface {
+	RetryAfter() time.Duration
+}
+
+// delayError attaches a server-requested delay to an error
+type delayError struct {
+	err   error
+	delay time.Duration
+}
+
+func (e *delayError) Error() string             { return e.err.Error() }
+func (e *delayError) Unwrap() error             { return e.err }
+func (e *delayError) RetryAfter() time.Duration { return e.delay }
+
+// WithDelay attaches the delay a server asked for (e.g. Retry-After) to err
+func WithDelay(err error, delay time.Duration) error {
+	if err == nil || delay <= 0 {
+		return err
+	}
+	return &delayError{err: err, delay: delay}
+}
+
+// After returns the server-requested delay carried by err, if any
+func After(err error) (time.Duration, bool) {
+	var h hinted
+	if errors.As(err, &h) && h.RetryAfter() > 0 {
+		return h.RetryAfter(), true
+	}
+	return 0, false
+}
+
+// Do calls fn until it succeeds or fails with an error that
+// errors.IsRetryable rejects, retries are exhausted, or the next attempt
+// would start after the context deadline. The last error is returned.
+// target and operation label the attempts in metrics and logs.
+func (p Policy) Do(ctx context.Context, target, operation string, fn func() error) error {
+	for attempt := 1; ; attempt++ {
+		err := fn()
+		if err == nil {
+			p.record(target, operation, attempt, OutcomeSuccess, 0)
+			return nil
+		}
+
+		ok := false
+		var delay time.Duration
+		if cicderrors.IsRetryable(err) {
+			hint, _ := After(err)
+			delay, ok = p.next(ctx, attempt, hint)
+		}
+		if !ok {
+			p.record(target, operation, attempt, OutcomeFailure, 0)
+			return err
+		}
+
+		p.record(target, operation, attempt, OutcomeRetry, delay)
+		if p.OnRetry != nil {
+			p.OnRetry(attempt, delay, err)
+		}
+		log.Printf("[WARNING] %s %s failed (attempt %d of %d), retrying in %s: %v",
+			target, operation, attempt, p.MaxRetries+1, delay.Round(time.Millisecond), err)
+		if p.wait(ctx, delay) != nil {
+			return err
+		}
+	}
+}
+
+// next returns the delay before retrying a failed attempt, or false if
+// retries are exhausted or the delay is too long. hint is the delay the
+// server asked for, or 0 to back off.
+func (p Policy) next(ctx context.Context, attempt int, hint time.Duration) (time.Duration, bool) {
+	if attempt > p.MaxRetries || ctx.Err() != nil {
+		return 0, false
+	}
+
+	delay := hint
+	if hint > 0 {
+		if p.MaxDelay > 0 && hint > p.MaxDelay {
+			return 0, false
+		}
+	} else {
+		delay = p.Backoff(attempt)
+	}
+
+	if deadline, ok := ctx.Deadline(); ok && time.Now().Add(delay).After(deadline) {
+		return 0, false
+	}
+	return delay, true
+}
+
+// Backoff returns the jittered delay after the given failed attempt:
+// BaseDelay doubled per attempt, capped at MaxDelay, of which a random
+// half is waited
+func (p Policy) Backoff(attempt int) time.Duration {
+	delay := p.BaseDelay
+	for i := 1; i < attempt && (p.MaxDelay <= 0 || delay < p.MaxDelay); i++ {
+		delay *= 2
+	}
+	if p.MaxDelay > 0 && delay > p.MaxDelay {
+		delay = p.MaxDelay
+	}
+	if delay <= 1 {
+		return delay
+	}
+	half := delay / 2
+	//nolint:gosec // Jitter does not need a secure source
+	return half + time.Duration(rand.Int63n(int64(half)+1))
+}
+
+// wait sleeps for d or until ctx is done
+func (p Policy) wait(ctx context.Context, d time.Duration) error {
+	if p.sleep != nil {
+		return p.sleep(ctx, d)
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// record reports an attempt to the metrics collector
+func (p Policy) record(target, operation string, attempt int, outcome string, delay time.Duration) {
+	if p.Metrics != nil {
+		p.Metrics.RecordRetryAttempt(target, operation, attempt, outcome, delay)
+	}
+}
@@ -0,0 +1,211 @@
+// Package retry provides retry policy tests
+package retry
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/cicd-ai-toolkit/cicd-runner/pkg/config"
+	cicderrors "github.com/cicd-ai-toolkit/cicd-runner/pkg/errors"
+	"github.com/cicd-ai-toolkit/cicd-runner/pkg/observability"
+)
+
+// instantPolicy returns a policy recording its delays instead of sleeping
+func instantPolicy(maxRetries int, delays *[]time.Duration) Policy {
+	p := DefaultPolicy()
+	p.MaxRetries = maxRetries
+	p.sleep = func(ctx context.Context, d time.Duration) error {
+		*delays = append(*delays, d)
+		return ctx.Err()
+	}
+	return p
+}
+
+func TestDo(t *testing.T) {
+	unavailable := cicderrors.UnavailableError("backend unavailable", nil)
+	invalid := cicderrors.ValidationError("bad prompt", nil)
+
+	tests := []struct {
+		name      string
+		errs      []error // Errors of consecutive attempts; nil succeeds
+		wantCalls int
+		wantErr   bool
+	}{
+		{"succeeds first time", []error{nil}, 1, false},
+		{"retries transient failures", []error{unavailable, unavailable, nil}, 3, false},
+		{"gives up after max retries", []error{unavailable, unavailable, unavailable, unavailable, unavailable}, 4, true},
+		{"does not retry permanent failures", []error{invalid, nil}, 1, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var delays []time.Duration
+			calls := 0
+			err := instantPolicy(3, &delays).Do(context.Background(), "claude", "review", func() error {
+				err := tt.errs[calls]
+				calls++
+				return err
+			})
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Do() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if calls != tt.wantCalls {
+				t.Errorf("calls = %d, want %d", calls, tt.wantCalls)
+			}
+			if len(delays) != calls-1 && !tt.wantErr {
+				t.Errorf("waited %d times for %d calls", len(delays), calls)
+			}
+		})
+	}
+}
+
+func TestDoHonoursServerDelay(t *testing.T) {
+	var delays []time.Duration
+	p := instantPolicy(2, &delays)
+	rateLimited := WithDelay(cicderrors.UnavailableError("rate limited", nil), 5*time.Second)
+
+	var retried []int
+	p.OnRetry = func(attempt int, delay time.Duration, err error) { retried = append(retried, attempt) }
+
+	calls := 0
+	err := p.Do(context.Background(), "api", "review", func() error {
+		calls++
+		if calls == 1 {
+			return rateLimited
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Do() error = %v", err)
+	}
+	if len(delays) != 1 || delays[0] != 5*time.Second {
+		t.Errorf("delays = %v, want [5s]", delays)
+	}
+	if len(retried) != 1 || retried[0] != 1 {
+		t.Errorf("OnRetry attempts = %v, want [1]", retried)
+	}
+
+	// A delay longer than MaxDelay is not waited for
+	calls = 0
+	err = p.Do(context.Background(), "api", "review", func() error {
+		calls++
+		return WithDelay(cicderrors.UnavailableError("rate limited", nil), time.Hour)
+	})
+	if err == nil || calls != 1 {
+		t.Errorf("Do() = %v after %d calls, want failure after 1", err, calls)
+	}
+}
+
+func TestDoStopsAtDeadline(t *testing.T) {
+	p := DefaultPolicy()
+	p.BaseDelay = time.Minute
+	p.MaxDelay = time.Minute
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	calls := 0
+	start := time.Now()
+	err := p.Do(ctx, "claude", "review", func() error {
+		calls++
+		return cicderrors.TimeoutError("execution timed out", nil)
+	})
+	if err == nil || calls != 1 {
+		t.Errorf("Do() = %v after %d calls, want failure after 1", err, calls)
+	}
+	if time.Since(start) > time.Second {
+		t.Errorf("Do() waited %v past the deadline", time.Since(start))
+	}
+}
+
+func TestDoRecordsMetrics(t *testing.T) {
+	metrics := observability.NewMetricsCollector(observability.MetricConfig{Enabled: true})
+	defer metrics.Close()
+
+	var delays []time.Duration
+	p := instantPolicy(3, &delays)
+	p.Metrics = metrics
+
+	calls := 0
+	_ = p.Do(context.Background(), "github", "GET", func() error {
+		calls++
+		if calls < 3 {
+			return cicderrors.PlatformError("bad gateway", nil)
+		}
+		return nil
+	})
+
+	if got := metrics.RetryCount(); got != 2 {
+		t.Errorf("RetryCount() = %d, want 2", got)
+	}
+	if got := metrics.CounterGet("retry.attempts", 0); got != 3 {
+		t.Errorf("retry.attempts = %v, want 3", got)
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	p := Policy{BaseDelay: time.Second, MaxDelay: 10 * time.Second}
+
+	tests := []struct {
+		attempt int
+		max     time.Duration
+	}{
+		{1, time.Second},
+		{2, 2 * time.Second},
+		{3, 4 * time.Second},
+		{4, 8 * time.Second},
+		{5, 10 * time.Second},
+		{30, 10 * time.Second},
+	}
+	for _, tt := range tests {
+		for i := 0; i < 20; i++ {
+			d := p.Backoff(tt.attempt)
+			if d < tt.max/2 || d > tt.max {
+				t.Errorf("Backoff(%d) = %v, want between %v and %v", tt.attempt, d, tt.max/2, tt.max)
+			}
+		}
+	}
+}
+
+func TestAfter(t *testing.T) {
+	base := errors.New("429")
+	if _, ok := After(base); ok {
+		t.Error("After() found a delay on a plain error")
+	}
+	if WithDelay(base, 0) != base {
+		t.Error("WithDelay() with no delay should return the error unchanged")
+	}
+
+	wrapped := cicderrors.UnavailableError("rate limited", WithDelay(base, 3*time.Second))
+	if d, ok := After(wrapped); !ok || d != 3*time.Second {
+		t.Errorf("After() = %v, %v, want 3s", d, ok)
+	}
+	if !errors.Is(wrapped, base) {
+		t.Error("WithDelay() should keep the cause reachable")
+	}
+}
+
+func TestFromConfig(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  config.Config
+		want int
+	}{
+		{"defaults to claude max retries", config.Config{Claude: config.ClaudeConfig{MaxRetries: 5}}, 5},
+		{"retry section wins", config.Config{Claude: config.ClaudeConfig{MaxRetries: 5}, Retry: config.RetryConfig{MaxRetries: 1}}, 1},
+		{"negative disables retries", config.Config{Retry: config.RetryConfig{MaxRetries: -1}}, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := FromConfig(&tt.cfg)
+			if p.MaxRetries != tt.want {
+				t.Errorf("MaxRetries = %d, want %d", p.MaxRetries, tt.want)
+			}
+			if p.BaseDelay != time.Second || p.MaxDelay != 2*time.Minute {
+				t.Errorf("delays = %v, %v, want 1s, 2m", p.BaseDelay, p.MaxDelay)
+			}
+		})
+	}
+}
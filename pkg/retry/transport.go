@@ -0,0 +1,215 @@
+// Package retry provides an HTTP transport retrying rate-limited and
+// failed platform API requests
+package retry
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// noRetryKey marks contexts whose requests are sent once
+type noRetryKey struct{}
+
+// NoRetry returns a context whose HTTP requests through a Transport are
+// sent once. The platform clients' Health checks use it: they report the
+// current state, and retrying would only delay the answer.
+func NoRetry(ctx context.Context) context.Context {
+	return context.WithValue(ctx, noRetryKey{}, true)
+}
+
+// Transport is an http.RoundTripper retrying requests under a Policy.
+// Rate-limited requests (429, or 403 with no remaining rate limit) are
+// retried after the delay the server asks for via Retry-After or the
+// X-RateLimit-Reset / RateLimit-Reset headers. Network errors and server
+// errors (5xx) are retried with backoff for idempotent methods only, since
+// the server may have processed the request; unknown hosts and certificate
+// errors are not retried. Requests with a body are
+// retried only if it can be replayed (http.NewRequest does this for
+// in-memory bodies).
+//
+// AttemptTimeout bounds each attempt, including reading its response
+// body, but not the waits between attempts. Use it instead of
+// http.Client.Timeout, which would cover all attempts and cut rate-limit
+// waits short; the overall time is left to the request's context.
+type Transport struct {
+	Base           http.RoundTripper // nil for http.DefaultTransport
+	Target         string            // Labels attempts in metrics and logs (e.g. github)
+	Policy         Policy
+	AttemptTimeout time.Duration // 0 = attempts are bounded by the request's context only
+}
+
+// NewTransport creates a transport retrying requests sent through base
+// under the default policy
+func NewTransport(target string, base http.RoundTripper) *Transport {
+	return &Transport{Base: base, Target: target, Policy: DefaultPolicy()}
+}
+
+// RoundTrip sends the request, retrying it as the policy allows
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	ctx := req.Context()
+	replayable := req.Body == nil || req.Body == http.NoBody || req.GetBody != nil
+	once, _ := ctx.Value(noRetryKey{}).(bool)
+	operation := req.Method
+
+	for attempt := 1; ; attempt++ {
+		if attempt > 1 && req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			req = req.Clone(ctx)
+			req.Body = body
+		}
+
+		resp, err := t.attempt(base, req)
+
+		retryable, hint := false, time.Duration(0)
+		switch {
+		case err != nil:
+			retryable = idempotent(req.Method) && !permanent(err)
+		case rateLimited(resp):
+			retryable, hint = true, rateLimitDelay(resp, time.Now())
+		case resp.StatusCode >= 500 && resp.StatusCode != http.StatusNotImplemented:
+			retryable, hint = idempotent(req.Method), retryAfter(resp, time.Now())
+		default:
+			t.Policy.record(t.Target, operation, attempt, OutcomeSuccess, 0)
+			return resp, nil
+		}
+
+		var delay time.Duration
+		ok := false
+		if retryable && replayable && !once {
+			delay, ok = t.Policy.next(ctx, attempt, hint)
+		}
+		if !ok {
+			t.Policy.record(t.Target, operation, attempt, OutcomeFailure, 0)
+			return resp, err
+		}
+
+		t.Policy.record(t.Target, operation, attempt, OutcomeRetry, delay)
+		if resp != nil {
+			_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64*1024))
+			_ = resp.Body.Close()
+		}
+		if t.Policy.wait(ctx, delay) != nil {
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// attempt sends one attempt of the request, bounded by AttemptTimeout. The
+// attempt's context is released when the response body is closed.
+func (t *Transport) attempt(base http.RoundTripper, req *http.Request) (*http.Response, error) {
+	if t.AttemptTimeout <= 0 {
+		return base.RoundTrip(req)
+	}
+	ctx, cancel := context.WithTimeout(req.Context(), t.AttemptTimeout)
+	resp, err := base.RoundTrip(req.WithContext(ctx))
+	if err != nil {
+		cancel()
+		return resp, err
+	}
+	resp.Body = &cancelBody{ReadCloser: resp.Body, cancel: cancel}
+	return resp, nil
+}
+
+// cancelBody releases the context of an attempt when its body is closed
+type cancelBody struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+// Close closes the body and releases the attempt's context
+func (b *cancelBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
+}
+
+// idempotent reports whether a request with the method can safely be sent
+// twice
+func idempotent(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete:
+		return true
+	default:
+		return false
+	}
+}
+
+// permanent reports whether a network error will recur on every attempt:
+// an unknown host or an untrusted certificate
+func permanent(err error) bool {
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) && dnsErr.IsNotFound {
+		return true
+	}
+	var certErr *tls.CertificateVerificationError
+	var unknownAuthority x509.UnknownAuthorityError
+	var hostname x509.HostnameError
+	return errors.As(err, &certErr) || errors.As(err, &unknownAuthority) || errors.As(err, &hostname)
+}
+
+// rateLimited reports whether the server refused the request for exceeding
+// a rate limit. GitHub answers 403 when the primary limit is exhausted.
+func rateLimited(resp *http.Response) bool {
+	if resp.StatusCode == http.StatusTooManyRequests {
+		return true
+	}
+	return resp.StatusCode == http.StatusForbidden && rateLimitHeader(resp, "Remaining") == "0"
+}
+
+// rateLimitDelay returns how long the server asks to wait: Retry-After,
+// else the time until the rate limit resets
+func rateLimitDelay(resp *http.Response, now time.Time) time.Duration {
+	if d := retryAfter(resp, now); d > 0 {
+		return d
+	}
+	if reset, err := strconv.ParseInt(rateLimitHeader(resp, "Reset"), 10, 64); err == nil {
+		if d := time.Unix(reset, 0).Sub(now); d > 0 {
+			return d
+		}
+	}
+	return 0
+}
+
+// retryAfter returns the delay requested by the response's Retry-After
+// header
+func retryAfter(resp *http.Response, now time.Time) time.Duration {
+	return ParseRetryAfter(resp.Header.Get("Retry-After"), now)
+}
+
+// ParseRetryAfter parses a Retry-After header value in seconds or as an
+// HTTP date, returning 0 if it is missing, malformed or in the past
+func ParseRetryAfter(value string, now time.Time) time.Duration {
+	if value == "" {
+		return 0
+	}
+	if secs, err := strconv.Atoi(value); err == nil && secs > 0 {
+		return time.Duration(secs) * time.Second
+	}
+	if at, err := http.ParseTime(value); err == nil && at.After(now) {
+		return at.Sub(now)
+	}
+	return 0
+}
+
+// rateLimitHeader reads X-RateLimit-<name> (GitHub, Gitee) or
+// RateLimit-<name> (GitLab)
+func rateLimitHeader(resp *http.Response, name string) string {
+	if v := resp.Header.Get("X-RateLimit-" + name); v != "" {
+		return v
+	}
+	return resp.Header.Get("RateLimit-" + name)
+}
@@ -0,0 +1,261 @@
+// Package retry provides retrying transport tests
+package retry
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// scriptedServer answers requests with the given status codes in turn,
+// then 200, and counts the requests it received
+func scriptedServer(t *testing.T, header http.Header, codes ...int) (*httptest.Server, *int32) {
+	t.Helper()
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := int(atomic.AddInt32(&calls, 1))
+		if body, _ := io.ReadAll(r.Body); r.Method == http.MethodPost && string(body) != "payload" {
+			t.Errorf("attempt %d body = %q, want replayed payload", n, body)
+		}
+		if n <= len(codes) {
+			for k, v := range header {
+				w.Header()[k] = v
+			}
+			w.WriteHeader(codes[n-1])
+			return
+		}
+		_, _ = w.Write([]byte("ok"))
+	}))
+	t.Cleanup(server.Close)
+	return server, &calls
+}
+
+// instantTransport returns a transport whose waits are recorded, not slept
+func instantTransport(delays *[]time.Duration) *Transport {
+	tr := NewTransport("github", nil)
+	tr.Policy.sleep = func(ctx context.Context, d time.Duration) error {
+		*delays = append(*delays, d)
+		return ctx.Err()
+	}
+	return tr
+}
+
+func TestTransportRetries(t *testing.T) {
+	tests := []struct {
+		name       string
+		method     string
+		header     http.Header
+		codes      []int
+		wantCalls  int32
+		wantStatus int
+		wantDelay  time.Duration // First delay, if exact
+	}{
+		{
+			name:       "retries 5xx on GET",
+			method:     http.MethodGet,
+			codes:      []int{502, 503},
+			wantCalls:  3,
+			wantStatus: 200,
+		},
+		{
+			name:       "does not retry 5xx on POST",
+			method:     http.MethodPost,
+			codes:      []int{500},
+			wantCalls:  1,
+			wantStatus: 500,
+		},
+		{
+			name:       "retries 429 on POST after Retry-After",
+			method:     http.MethodPost,
+			header:     http.Header{"Retry-After": {"2"}},
+			codes:      []int{429},
+			wantCalls:  2,
+			wantStatus: 200,
+			wantDelay:  2 * time.Second,
+		},
+		{
+			name:       "does not retry plain 403",
+			method:     http.MethodGet,
+			codes:      []int{403},
+			wantCalls:  1,
+			wantStatus: 403,
+		},
+		{
+			name:       "does not retry 501",
+			method:     http.MethodGet,
+			codes:      []int{501},
+			wantCalls:  1,
+			wantStatus: 501,
+		},
+		{
+			name:       "gives up after max retries",
+			method:     http.MethodGet,
+			codes:      []int{503, 503, 503, 503, 503},
+			wantCalls:  4,
+			wantStatus: 503,
+		},
+		{
+			name:       "does not wait longer than max delay",
+			method:     http.MethodGet,
+			header:     http.Header{"Retry-After": {"3600"}},
+			codes:      []int{429},
+			wantCalls:  1,
+			wantStatus: 429,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, calls := scriptedServer(t, tt.header, tt.codes...)
+			var delays []time.Duration
+			client := &http.Client{Transport: instantTransport(&delays)}
+
+			req, err := http.NewRequest(tt.method, server.URL, strings.NewReader("payload"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp, err := client.Do(req)
+			if err != nil {
+				t.Fatalf("Do() error = %v", err)
+			}
+			resp.Body.Close()
+
+			if resp.StatusCode != tt.wantStatus {
+				t.Errorf("status = %d, want %d", resp.StatusCode, tt.wantStatus)
+			}
+			if got := atomic.LoadInt32(calls); got != tt.wantCalls {
+				t.Errorf("requests = %d, want %d", got, tt.wantCalls)
+			}
+			if tt.wantDelay > 0 && (len(delays) == 0 || delays[0] != tt.wantDelay) {
+				t.Errorf("delays = %v, want first %v", delays, tt.wantDelay)
+			}
+		})
+	}
+}
+
+func TestTransportRateLimitReset(t *testing.T) {
+	reset := time.Now().Add(10 * time.Second).Unix()
+	header := http.Header{
+		"X-Ratelimit-Remaining": {"0"},
+		"X-Ratelimit-Reset":     {strconv.FormatInt(reset, 10)},
+	}
+	server, calls := scriptedServer(t, header, http.StatusForbidden)
+
+	var delays []time.Duration
+	client := &http.Client{Transport: instantTransport(&delays)}
+	resp, err := client.Get(server.URL)
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != 200 || atomic.LoadInt32(calls) != 2 {
+		t.Errorf("status = %d after %d requests, want 200 after 2", resp.StatusCode, atomic.LoadInt32(calls))
+	}
+	if len(delays) != 1 || delays[0] < 8*time.Second || delays[0] > 11*time.Second {
+		t.Errorf("delays = %v, want about 10s until the reset", delays)
+	}
+}
+
+func TestTransportNoRetry(t *testing.T) {
+	server, calls := scriptedServer(t, nil, 503)
+
+	var delays []time.Duration
+	client := &http.Client{Transport: instantTransport(&delays)}
+	req, _ := http.NewRequestWithContext(NoRetry(context.Background()), http.MethodGet, server.URL, nil)
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatalf("Do() error = %v", err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != 503 || atomic.LoadInt32(calls) != 1 {
+		t.Errorf("status = %d after %d requests, want 503 after 1", resp.StatusCode, atomic.LoadInt32(calls))
+	}
+}
+
+func TestTransportStopsAtDeadline(t *testing.T) {
+	server, calls := scriptedServer(t, nil, 503, 503, 503)
+
+	tr := NewTransport("gitlab", nil)
+	tr.Policy.BaseDelay = time.Minute
+	tr.Policy.MaxDelay = time.Minute
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, server.URL, nil)
+	resp, err := (&http.Client{Transport: tr}).Do(req)
+	if err != nil {
+		t.Fatalf("Do() error = %v", err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != 503 || atomic.LoadInt32(calls) != 1 {
+		t.Errorf("status = %d after %d requests, want 503 after 1", resp.StatusCode, atomic.LoadInt32(calls))
+	}
+}
+
+func TestTransportAttemptTimeout(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch atomic.AddInt32(&calls, 1) {
+		case 1:
+			// Hangs past the attempt timeout
+			select {
+			case <-r.Context().Done():
+			case <-time.After(time.Second):
+			}
+		case 2:
+			w.Header().Set("Retry-After", "60")
+			w.WriteHeader(http.StatusTooManyRequests)
+		default:
+			_, _ = w.Write([]byte("ok"))
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	var delays []time.Duration
+	tr := instantTransport(&delays)
+	tr.AttemptTimeout = 100 * time.Millisecond
+	resp, err := (&http.Client{Transport: tr}).Get(server.URL)
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+
+	// The hung attempt is retried, and the 60s rate-limit wait is not cut
+	// short by the attempt timeout
+	if err != nil || string(body) != "ok" || atomic.LoadInt32(&calls) != 3 {
+		t.Errorf("body = %q, %v after %d requests, want ok after 3", body, err, atomic.LoadInt32(&calls))
+	}
+	if len(delays) != 2 || delays[1] != time.Minute {
+		t.Errorf("delays = %v, want the requested 1m wait last", delays)
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		value string
+		want  time.Duration
+	}{
+		{"", 0},
+		{"30", 30 * time.Second},
+		{"-5", 0},
+		{"soon", 0},
+		{now.Add(time.Minute).Format(http.TimeFormat), time.Minute},
+		{now.Add(-time.Minute).Format(http.TimeFormat), 0},
+	}
+	for _, tt := range tests {
+		if got := ParseRetryAfter(tt.value, now); got != tt.want {
+			t.Errorf("ParseRetryAfter(%q) = %v, want %v", tt.value, got, tt.want)
+		}
+	}
+}
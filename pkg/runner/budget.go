@@ -0,0 +1,357 @@
+// Package runner provides enforcement of AI spending limits
+package runner
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/cicd-ai-toolkit/cicd-runner/pkg/ai"
+	"github.com/cicd-ai-toolkit/cicd-runner/pkg/config"
+	"github.com/cicd-ai-toolkit/cicd-runner/pkg/errors"
+	"github.com/cicd-ai-toolkit/cicd-runner/pkg/platform"
+)
+
+// RunCost is the AI spend of a run as recorded in the budget ledger
+type RunCost struct {
+	RunUSD     float64 // Spent by this run
+	PRUSD      float64 // Spent on the PR across runs, including this one
+	PRLimitUSD float64 // Per-PR limit; 0 = unlimited
+	Downgraded string  // Downgrade model used for some executions, if any
+}
+
+// RunUsage is the AI usage of a run across its executions
+type RunUsage struct {
+	Executions   int
+	Backends     []string // Backends that ran executions, in order of first use
+	Models       []string // Models that ran executions, in order of first use
+	InputTokens  int
+	OutputTokens int
+	CostUSD      float64
+}
+
+// budgetRun tracks the spend of one review, analysis or test generation
+// against the configured limits. Spend of earlier runs is read from the
+// ledger when the run starts; spend of concurrent runs is seen by the next
+// run only. The cap of each running execution is held back from the
+// remaining budget until it finishes, so parallel executions of the run
+// cannot together spend more than the remainder.
+type budgetRun struct {
+	id        string
+	repo      string
+	prID      int
+	operation string
+	limits    config.BudgetConfig
+	ledger    *Ledger
+
+	// downgradeBackend is the backend the downgrade model belongs to
+	downgradeBackend ai.BackendType
+
+	mu         sync.Mutex
+	spent      float64 // Spent by this run
+	prBase     float64 // Spent on the PR by earlier runs
+	dayBase    float64 // Spent in the repository today by earlier runs
+	held       float64 // Caps of the running executions
+	running    int     // Executions admitted and not yet finished
+	slots      int     // Executions the run starts at once; 0 or 1 = sequential
+	executions int
+	downgraded bool
+	used       RunUsage // Tokens, backends and models of the executions
+}
+
+// budgetRunKey is the context key of the current budget run
+type budgetRunKey struct{}
+
+// repositoryNamer is implemented by platforms that know their repository
+type repositoryNamer interface {
+	Repository() string
+}
+
+// repositoryName identifies the repository in the ledger: the platform's
+// repository when known, otherwise the name of the base directory
+func repositoryName(p platform.Platform, baseDir string) string {
+	if rn, ok := p.(repositoryNamer); ok && rn.Repository() != "" {
+		return rn.Repository()
+	}
+	return filepath.Base(baseDir)
+}
+
+// startRun begins tracking the spend of an operation, returning a context
+// that carries the run to the AI executions it makes
+func (r *DefaultRunner) startRun(ctx context.Context, operation string, prID int) (context.Context, *budgetRun) {
+	run := &budgetRun{
+		id:        newRunID(),
+		repo:      r.repo,
+		prID:      prID,
+		operation: operation,
+		ledger:    r.ledger,
+	}
+	if r.cfg != nil {
+		run.limits = r.cfg.Budget
+		run.downgradeBackend = ai.BackendType(r.cfg.GetDowngradeBackend())
+	}
+
+	if run.ledger != nil {
+		pr, day, err := run.ledger.Spend(run.repo, prID, time.Now().UTC().Format("2006-01-02"))
+		if err != nil {
+			log.Printf("[WARNING] failed to read budget ledger, earlier spend is not counted: %v", err)
+		}
+		run.prBase, run.dayBase = pr, day
+	}
+
+	return context.WithValue(ctx, budgetRunKey{}, run), run
+}
+
+// budgetRunFrom returns the budget run carried by ctx, or nil
+func budgetRunFrom(ctx context.Context) *budgetRun {
+	run, _ := ctx.Value(budgetRunKey{}).(*budgetRun)
+	return run
+}
+
+// parallel sets the number of executions the run starts at once. The
+// remaining budget is split between them rather than handed in full to
+// the first one.
+func (b *budgetRun) parallel(n int) {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	b.slots = n
+	b.mu.Unlock()
+}
+
+// budgetLimit is a configured limit and the spend counted against it
+type budgetLimit struct {
+	name  string
+	limit float64
+	spent float64
+}
+
+// limitsLocked returns the configured limits. Caller must hold b.mu.
+func (b *budgetRun) limitsLocked() []budgetLimit {
+	var limits []budgetLimit
+	if b.limits.PerRunUSD > 0 {
+		limits = append(limits, budgetLimit{"per-run", b.limits.PerRunUSD, b.spent})
+	}
+	if b.limits.PerPRUSD > 0 && b.prID > 0 {
+		limits = append(limits, budgetLimit{"per-PR", b.limits.PerPRUSD, b.prBase + b.spent})
+	}
+	if b.limits.PerDayUSD > 0 {
+		limits = append(limits, budgetLimit{"per-day", b.limits.PerDayUSD, b.dayBase + b.spent})
+	}
+	return limits
+}
+
+// check returns a budget error if any limit is exhausted
+func (b *budgetRun) check() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.checkLocked()
+}
+
+// checkLocked implements check. Caller must hold b.mu.
+func (b *budgetRun) checkLocked() error {
+	for _, l := range b.limitsLocked() {
+		if l.spent >= l.limit {
+			return errors.BudgetError(fmt.Sprintf("%s budget of $%.2f exhausted ($%.4f spent)", l.name, l.limit, l.spent), nil)
+		}
+	}
+	return nil
+}
+
+// admit refuses an execution when a limit is exhausted. Otherwise it caps
+// the execution's budget to its share of the smallest remaining allowance
+// not held by running executions, holds that cap back until release or
+// record, and switches to the downgrade model once the downgrade threshold
+// of a limit is spent. The downgrade model only replaces the model of the
+// backend it belongs to; other backends, such as later members of a chain,
+// keep theirs. It returns the amount held.
+func (b *budgetRun) admit(opts *ai.ExecuteOptions) (float64, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if err := b.checkLocked(); err != nil {
+		return 0, err
+	}
+
+	threshold := b.limits.DowngradeAt
+	if threshold <= 0 {
+		threshold = 0.8
+	}
+	downgrade := false
+	limits := b.limitsLocked()
+	share := -1.0
+	for _, l := range limits {
+		available := l.limit - l.spent - b.held
+		if available <= 0 {
+			return 0, errors.BudgetError(fmt.Sprintf("%s budget of $%.2f is held by running executions", l.name, l.limit), nil)
+		}
+		if share < 0 || available < share {
+			share = available
+		}
+		if l.spent >= threshold*l.limit {
+			downgrade = true
+		}
+	}
+
+	held := 0.0
+	if len(limits) > 0 {
+		// Leave the other slots of the run their part of the remainder
+		if free := b.slots - b.running; free > 1 {
+			share /= float64(free)
+		}
+		if opts.MaxBudgetUSD <= 0 || opts.MaxBudgetUSD > share {
+			opts.MaxBudgetUSD = share
+		}
+		held = opts.MaxBudgetUSD
+		b.held += held
+	}
+	b.running++
+
+	if downgrade && b.limits.DowngradeModel != "" {
+		if !b.downgraded {
+			log.Printf("[WARNING] budget nearly spent, using %s for the remaining executions", b.limits.DowngradeModel)
+		}
+		b.downgraded = true
+		opts.Model = b.limits.DowngradeModel
+		opts.ModelBackend = b.downgradeBackend
+	}
+
+	return held, nil
+}
+
+// release returns the budget held for an execution that failed
+func (b *budgetRun) release(held float64) {
+	b.mu.Lock()
+	b.releaseLocked(held)
+	b.mu.Unlock()
+}
+
+// releaseLocked implements release. Caller must hold b.mu.
+func (b *budgetRun) releaseLocked(held float64) {
+	b.held -= held
+	b.running--
+}
+
+// record adds an execution's cost to the run, releases the budget held for
+// it and appends it to the ledger
+func (b *budgetRun) record(opts ai.ExecuteOptions, output *ai.Output, held float64) {
+	entry := LedgerEntry{
+		Time:      time.Now().UTC(),
+		RunID:     b.id,
+		Repo:      b.repo,
+		PRID:      b.prID,
+		Operation: b.operation,
+		Skills:    opts.Skills,
+		Backend:   string(output.Backend),
+		Model:     output.Model,
+	}
+	if entry.Model == "" {
+		entry.Model = opts.Model
+	}
+	if output.TokensUsed != nil {
+		entry.InputTokens = output.TokensUsed.InputTokens
+		entry.OutputTokens = output.TokensUsed.OutputTokens
+		entry.CostUSD = output.TokensUsed.CostUSD
+	}
+
+	b.mu.Lock()
+	b.releaseLocked(held)
+	b.spent += entry.CostUSD
+	b.executions++
+	b.used.InputTokens += entry.InputTokens
+	b.used.OutputTokens += entry.OutputTokens
+	b.used.CostUSD += entry.CostUSD
+	b.used.Backends = appendUnique(b.used.Backends, entry.Backend)
+	b.used.Models = appendUnique(b.used.Models, entry.Model)
+	b.mu.Unlock()
+
+	if b.ledger != nil {
+		if err := b.ledger.Append(entry); err != nil {
+			log.Printf("[WARNING] failed to record spend in budget ledger: %v", err)
+		}
+	}
+}
+
+// cost returns the spend of the run, or nil if it made no AI executions
+func (b *budgetRun) cost() *RunCost {
+	if b == nil {
+		return nil
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.executions == 0 {
+		return nil
+	}
+	cost := &RunCost{RunUSD: b.spent}
+	if b.prID > 0 {
+		cost.PRUSD = b.prBase + b.spent
+		cost.PRLimitUSD = b.limits.PerPRUSD
+	}
+	if b.downgraded {
+		cost.Downgraded = b.limits.DowngradeModel
+	}
+	return cost
+}
+
+// usage returns the AI usage of the run, or nil if it made no AI executions
+func (b *budgetRun) usage() *RunUsage {
+	if b == nil {
+		return nil
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.executions == 0 {
+		return nil
+	}
+	usage := b.used
+	usage.Executions = b.executions
+	usage.Backends = append([]string(nil), b.used.Backends...)
+	usage.Models = append([]string(nil), b.used.Models...)
+	return &usage
+}
+
+// appendUnique appends s to list unless it is empty or already listed
+func appendUnique(list []string, s string) []string {
+	if s == "" {
+		return list
+	}
+	for _, v := range list {
+		if v == s {
+			return list
+		}
+	}
+	return append(list, s)
+}
+
+// newRunID returns a unique, time-ordered run identifier
+func newRunID() string {
+	suffix := make([]byte, 4)
+	_, _ = rand.Read(suffix)
+	return time.Now().UTC().Format("20060102T150405") + "-" + hex.EncodeToString(suffix)
+}
+
+// formatCostLine formats the spend of a run for the review comment
+func formatCostLine(cost *RunCost) string {
+	line := fmt.Sprintf("*_AI cost: $%.4f", cost.RunUSD)
+	if cost.PRUSD > 0 {
+		line += fmt.Sprintf(" (PR total: $%.4f", cost.PRUSD)
+		if cost.PRLimitUSD > 0 {
+			line += fmt.Sprintf(" of $%.2f", cost.PRLimitUSD)
+		}
+		line += ")"
+	}
+	if cost.Downgraded != "" {
+		line += fmt.Sprintf("; budget nearly spent, some executions used %s", cost.Downgraded)
+	}
+	return line + "_*\n"
+}
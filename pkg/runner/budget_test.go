@@ -0,0 +1,262 @@
+// Package runner provides budget enforcement tests
+package runner
+
+import (
+	"context"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/cicd-ai-toolkit/cicd-runner/pkg/ai"
+	"github.com/cicd-ai-toolkit/cicd-runner/pkg/buildcontext"
+	"github.com/cicd-ai-toolkit/cicd-runner/pkg/config"
+	"github.com/cicd-ai-toolkit/cicd-runner/pkg/errors"
+)
+
+// costBrain charges a fixed cost per execution and records the options
+type costBrain struct {
+	fakeBrain
+	cost float64
+	mu   sync.Mutex
+	opts []ai.ExecuteOptions
+}
+
+func (b *costBrain) Execute(ctx context.Context, prompt string, opts ai.ExecuteOptions) (*ai.Output, error) {
+	b.mu.Lock()
+	b.opts = append(b.opts, opts)
+	b.mu.Unlock()
+	return &ai.Output{
+		Backend:    ai.BackendClaude,
+		Model:      opts.Model,
+		TokensUsed: &ai.TokenUsage{InputTokens: 1000, OutputTokens: 200, CostUSD: b.cost},
+	}, nil
+}
+
+// newBudgetRunner creates a runner with a ledger in a temporary directory
+func newBudgetRunner(t *testing.T, brain ai.Brain, budget config.BudgetConfig) *DefaultRunner {
+	t.Helper()
+	cache, err := NewCache(t.TempDir(), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &DefaultRunner{
+		cfg:      &config.Config{Budget: budget},
+		platform: &mockPlatform{},
+		aiBrain:  brain,
+		cache:    cache,
+		ledger:   NewLedger(t.TempDir()),
+		repo:     "acme/widgets",
+	}
+}
+
+func TestReviewRecordsSpend(t *testing.T) {
+	brain := &costBrain{cost: 0.25}
+	r := newBudgetRunner(t, brain, config.BudgetConfig{PerPRUSD: 0.6})
+
+	result, err := r.Review(context.Background(), ReviewOptions{PRID: 7, Diff: "diff --git a/a.go b/a.go\n", Skills: []string{"code-reviewer"}})
+	if err != nil {
+		t.Fatalf("Review() error = %v", err)
+	}
+	if result.Cost == nil || result.Cost.RunUSD != 0.25 || result.Cost.PRUSD != 0.25 {
+		t.Fatalf("Cost = %+v", result.Cost)
+	}
+	if !strings.Contains(result.PlatformComment, "AI cost: $0.2500 (PR total: $0.2500 of $0.60)") {
+		t.Errorf("comment missing cost line:\n%s", result.PlatformComment)
+	}
+	if u := result.Usage; u == nil || u.Executions != 1 || u.InputTokens != 1000 || u.OutputTokens != 200 ||
+		len(u.Backends) != 1 || u.Backends[0] != "claude" {
+		t.Errorf("Usage = %+v", result.Usage)
+	}
+
+	entries, err := r.ledger.Entries(time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || entries[0].Repo != "acme/widgets" || entries[0].PRID != 7 ||
+		entries[0].Operation != "review" || entries[0].CostUSD != 0.25 || entries[0].InputTokens != 1000 {
+		t.Fatalf("ledger entries = %+v", entries)
+	}
+
+	// The second run on the PR sees the first run's spend
+	result, err = r.Review(context.Background(), ReviewOptions{PRID: 7, Diff: "diff --git a/b.go b/b.go\n", Skills: []string{"code-reviewer"}})
+	if err != nil {
+		t.Fatalf("second Review() error = %v", err)
+	}
+	if result.Cost.PRUSD != 0.5 {
+		t.Errorf("PR total = %v, want 0.5", result.Cost.PRUSD)
+	}
+	// Only $0.35 of the PR budget remained for the execution
+	if got := brain.opts[1].MaxBudgetUSD; got < 0.3499 || got > 0.3501 {
+		t.Errorf("MaxBudgetUSD = %v, want 0.35", got)
+	}
+
+	// The third run exceeds the PR budget and is refused up front
+	r.Review(context.Background(), ReviewOptions{PRID: 7, Diff: "diff --git a/c.go b/c.go\n", Skills: []string{"code-reviewer"}})
+	_, err = r.Review(context.Background(), ReviewOptions{PRID: 7, Diff: "diff --git a/d.go b/d.go\n", Skills: []string{"code-reviewer"}})
+	if !errors.IsType(err, errors.ErrBudget) {
+		t.Fatalf("Review() error = %v, want budget error", err)
+	}
+	if len(brain.opts) != 3 {
+		t.Errorf("executions = %d, want 3", len(brain.opts))
+	}
+
+	// Other PRs are not affected by the PR budget
+	if _, err := r.Review(context.Background(), ReviewOptions{PRID: 8, Diff: "diff --git a/d.go b/d.go\n", Skills: []string{"code-reviewer"}}); err != nil {
+		t.Errorf("Review() of another PR error = %v", err)
+	}
+}
+
+func TestBudgetRunAdmit(t *testing.T) {
+	brain := &costBrain{cost: 0.3}
+	r := newBudgetRunner(t, brain, config.BudgetConfig{PerRunUSD: 1.0, DowngradeModel: "haiku", DowngradeAt: 0.5})
+	ctx, run := r.startRun(context.Background(), "review", 0)
+
+	models := []string{}
+	for i := 0; i < 4; i++ {
+		_, err := r.execute(ctx, "prompt", ai.ExecuteOptions{Model: "opus", MaxBudgetUSD: 2.0}, diffPart{})
+		if err != nil {
+			t.Fatalf("execute() %d error = %v", i, err)
+		}
+		models = append(models, brain.opts[i].Model)
+	}
+	// The downgrade starts once $0.50 is spent
+	if got := strings.Join(models, ","); got != "opus,opus,haiku,haiku" {
+		t.Errorf("models = %s", got)
+	}
+	// The downgrade model is a model of the configured backend only
+	if got := brain.opts[2].ModelBackend; got != ai.BackendClaude {
+		t.Errorf("downgrade ModelBackend = %q, want claude", got)
+	}
+	if brain.opts[0].MaxBudgetUSD != 1.0 {
+		t.Errorf("first MaxBudgetUSD = %v, want capped to 1.0", brain.opts[0].MaxBudgetUSD)
+	}
+
+	// $1.20 spent: the run budget is exhausted
+	if _, err := r.execute(ctx, "prompt", ai.ExecuteOptions{}, diffPart{}); !errors.IsType(err, errors.ErrBudget) {
+		t.Errorf("execute() error = %v, want budget error", err)
+	}
+	if cost := run.cost(); cost.Downgraded != "haiku" || cost.RunUSD < 1.19 {
+		t.Errorf("cost = %+v", cost)
+	}
+}
+
+// capBrain spends the full cap of each execution after a short delay, so
+// parallel executions overlap
+type capBrain struct {
+	fakeBrain
+	mu    sync.Mutex
+	spent float64
+}
+
+func (b *capBrain) Execute(ctx context.Context, prompt string, opts ai.ExecuteOptions) (*ai.Output, error) {
+	time.Sleep(20 * time.Millisecond)
+	b.mu.Lock()
+	b.spent += opts.MaxBudgetUSD
+	b.mu.Unlock()
+	return &ai.Output{TokensUsed: &ai.TokenUsage{CostUSD: opts.MaxBudgetUSD}}, nil
+}
+
+func TestBudgetParallelChunks(t *testing.T) {
+	brain := &capBrain{}
+	r := newBudgetRunner(t, brain, config.BudgetConfig{PerRunUSD: 1.0})
+	r.cfg.Global = config.GlobalConfig{ParallelSkills: 4, MaxChunkKB: 1}
+	r.builder = buildcontext.NewBuilder(".", 0, nil)
+
+	// Four chunks reviewed at once share the run budget
+	result, err := r.Review(context.Background(), ReviewOptions{Diff: chunkTestDiff("a.go", "b.go", "c.go", "d.go")})
+	if err != nil {
+		t.Fatalf("Review() error = %v", err)
+	}
+	if result.Chunks != 4 {
+		t.Fatalf("Chunks = %d, want 4", result.Chunks)
+	}
+	if brain.spent > 1.0001 {
+		t.Errorf("parallel executions spent $%.4f, over the $1.00 limit", brain.spent)
+	}
+	if result.Cost == nil || result.Cost.RunUSD > 1.0001 {
+		t.Errorf("Cost = %+v", result.Cost)
+	}
+}
+
+func TestBudgetPerDayAcrossPRs(t *testing.T) {
+	r := newBudgetRunner(t, &costBrain{cost: 1.0}, config.BudgetConfig{PerDayUSD: 1.5})
+
+	// Spend from yesterday and from other repositories does not count
+	old := []LedgerEntry{
+		{Time: time.Now().Add(-48 * time.Hour), Repo: "acme/widgets", CostUSD: 5},
+		{Time: time.Now(), Repo: "acme/other", CostUSD: 5},
+		{Time: time.Now(), Repo: "acme/widgets", PRID: 1, CostUSD: 1},
+	}
+	for _, e := range old {
+		if err := r.ledger.Append(e); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	ctx, _ := r.startRun(context.Background(), "review", 2)
+	if _, err := r.execute(ctx, "prompt", ai.ExecuteOptions{}, diffPart{}); err != nil {
+		t.Fatalf("execute() error = %v", err)
+	}
+	if _, err := r.execute(ctx, "prompt", ai.ExecuteOptions{}, diffPart{}); err == nil || !strings.Contains(err.Error(), "per-day budget") {
+		t.Errorf("execute() error = %v, want per-day budget error", err)
+	}
+}
+
+func TestDowngradedReviewNotCached(t *testing.T) {
+	brain := &costBrain{cost: 0.05}
+	r := newBudgetRunner(t, brain, config.BudgetConfig{PerPRUSD: 1.0, DowngradeModel: "haiku"})
+	if err := r.ledger.Append(LedgerEntry{Time: time.Now(), Repo: "acme/widgets", PRID: 7, CostUSD: 0.9}); err != nil {
+		t.Fatal(err)
+	}
+
+	opts := ReviewOptions{PRID: 7, Diff: "diff --git a/a.go b/a.go\n", Skills: []string{"code-reviewer"}}
+	result, err := r.Review(context.Background(), opts)
+	if err != nil {
+		t.Fatalf("Review() error = %v", err)
+	}
+	if result.Cost == nil || result.Cost.Downgraded != "haiku" {
+		t.Fatalf("Cost = %+v, want downgraded review", result.Cost)
+	}
+
+	// The review is not reused for the configured model
+	result, err = r.Review(context.Background(), opts)
+	if err != nil {
+		t.Fatalf("second Review() error = %v", err)
+	}
+	if result.Cached || len(brain.opts) != 2 {
+		t.Errorf("Cached = %v after %d executions, want a fresh review", result.Cached, len(brain.opts))
+	}
+}
+
+// errBrain fails every execution with err
+type errBrain struct {
+	fakeBrain
+	err error
+}
+
+func (b *errBrain) Execute(ctx context.Context, prompt string, opts ai.ExecuteOptions) (*ai.Output, error) {
+	return nil, b.err
+}
+
+func TestReviewKeepsErrorType(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want errors.ErrorType
+	}{
+		{"budget", errors.BudgetError("PR budget of $0.50 exhausted", nil), errors.ErrBudget},
+		{"config", errors.ConfigError("unknown backend", nil), errors.ErrConfig},
+		{"unclassified", io.ErrUnexpectedEOF, errors.ErrClaude},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := newBudgetRunner(t, &errBrain{err: tt.err}, config.BudgetConfig{})
+			_, err := r.Review(context.Background(), ReviewOptions{PRID: 7, Diff: "diff --git a/a.go b/a.go\n", Skills: []string{"code-reviewer"}})
+			if !errors.IsType(err, tt.want) {
+				t.Errorf("Review() error = %v, want type %d", err, tt.want)
+			}
+		})
+	}
+}
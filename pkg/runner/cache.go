@@ -8,10 +8,14 @@ import (
 	"log"
 	"os"
 	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
 	"sync"
 	"time"
 
 	"github.com/cicd-ai-toolkit/cicd-runner/pkg/ai"
+	"github.com/cicd-ai-toolkit/cicd-runner/pkg/config"
 )
 
 const (
@@ -20,23 +24,70 @@ const (
 
 	// CacheFilePermissions is the file permissions for cache files
 	CacheFilePermissions = 0600
+
+	// reviewEntryPrefix prefixes content-addressed review entries
+	reviewEntryPrefix = "review-"
+
+	// prStatePrefix prefixes per-PR review state used for incremental review
+	prStatePrefix = "pr-"
 )
 
-// Cache provides caching for review results
+// validCacheKeyPattern matches content-addressed cache keys (hex digests)
+var validCacheKeyPattern = regexp.MustCompile(`^[0-9a-f]{16,64}$`)
+
+// Cache provides caching for review results.
+// Review results are stored content-addressed by a key derived from the
+// diff and everything that influences the review (see ReviewCacheKey).
+// The latest review of each PR is stored separately as the base for
+// incremental re-review.
 type Cache struct {
 	dir     string
 	enabled bool
 	mu      sync.RWMutex
 	ttl     time.Duration
+	maxSize int64 // Maximum total size in bytes; 0 = unbounded
+
+	hits      int64
+	misses    int64
+	evictions int64
 }
 
 // CachedReview represents a cached review result
 type CachedReview struct {
-	Summary  ReviewSummary
-	Issues   []ai.Issue
-	Comment  string
+	Key        string // Content-addressed key the review was stored under
+	ConfigHash string // Hash of the review configuration (skills, model, prompt)
+	Summary    ReviewSummary
+	Issues     []ai.Issue
+	Resolved   []ai.Issue // Findings resolved by later pushes
+	Comment    string
+	HeadSHA    string // Head commit the review covers
+	Skills     []SkillResult
+	CachedAt   time.Time
+	Duration   time.Duration // Original execution duration
+}
+
+// CacheStats contains cache statistics
+type CacheStats struct {
+	Entries   int   // Content-addressed review entries
+	PRStates  int   // Per-PR review states
+	Expired   int   // Entries past their TTL
+	SizeBytes int64 // Total size on disk
+	MaxBytes  int64 // Configured size bound (0 = unbounded)
+	Hits      int64 // Lookups served from cache by this process
+	Misses    int64 // Lookups not served from cache by this process
+	Evictions int64 // Entries evicted by this process to honor the size bound
+}
+
+// CacheEntry describes a file in the cache directory
+type CacheEntry struct {
+	Name     string
+	Kind     string // "review" or "pr"
+	Size     int64
+	ModTime  time.Time
 	CachedAt time.Time
-	Duration time.Duration // Original execution duration
+	HeadSHA  string
+	Issues   int
+	Expired  bool
 }
 
 // NewCache creates a new cache instance
@@ -54,7 +105,62 @@ func NewCache(dir string, enabled bool) (*Cache, error) {
 	}, nil
 }
 
-// GetReview retrieves a cached review
+// NewCacheFromConfig creates the review cache configured in cfg, relative to baseDir
+func NewCacheFromConfig(cfg *config.Config, baseDir string) (*Cache, error) {
+	cacheDir := baseDir + "/" + cfg.Global.CacheDir
+	cache, err := NewCache(cacheDir, cfg.Global.EnableCache)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize cache: %w", err)
+	}
+	cache.SetTTL(cfg.Global.GetCacheTTL())
+	cache.SetMaxSize(int64(cfg.Global.CacheMaxSizeMB) * 1024 * 1024)
+	return cache, nil
+}
+
+// Get retrieves a review by its content-addressed key
+func (c *Cache) Get(key string) (CachedReview, bool) {
+	if !c.enabled || !validCacheKeyPattern.MatchString(key) {
+		return CachedReview{}, false
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	path := c.entryPath(key)
+	cached, ok := c.read(path)
+	if !ok {
+		c.misses++
+		return CachedReview{}, false
+	}
+
+	// Touch the entry so size-bounded eviction removes least recently used first
+	now := time.Now()
+	_ = os.Chtimes(path, now, now)
+	c.hits++
+
+	return cached, true
+}
+
+// Set stores a review under a content-addressed key and evicts the least
+// recently used entries if the cache exceeds its size bound
+func (c *Cache) Set(key string, review CachedReview) {
+	if !c.enabled {
+		return
+	}
+	if !validCacheKeyPattern.MatchString(key) {
+		log.Printf("Warning: refusing to cache review under invalid key %q", key)
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	review.Key = key
+	c.write(c.entryPath(key), review)
+	c.evictLocked()
+}
+
+// GetReview retrieves the latest review state of a PR
 func (c *Cache) GetReview(prID int) (CachedReview, bool) {
 	if !c.enabled {
 		return CachedReview{}, false
@@ -63,8 +169,24 @@ func (c *Cache) GetReview(prID int) (CachedReview, bool) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
-	path := c.reviewPath(prID)
+	return c.read(c.reviewPath(prID))
+}
+
+// SetReview stores the latest review state of a PR
+func (c *Cache) SetReview(prID int, review CachedReview) {
+	if !c.enabled {
+		return
+	}
 
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.write(c.reviewPath(prID), review)
+	c.evictLocked()
+}
+
+// read loads a cache file, removing it if expired. Caller must hold c.mu.
+func (c *Cache) read(path string) (CachedReview, bool) {
 	// Stat first to avoid reading deleted files
 	// This check is kept under lock to prevent race with cache invalidation
 	if _, err := os.Stat(path); os.IsNotExist(err) {
@@ -91,24 +213,16 @@ func (c *Cache) GetReview(prID int) (CachedReview, bool) {
 	return cached, true
 }
 
-// SetReview stores a review in cache
-func (c *Cache) SetReview(prID int, review CachedReview) {
-	if !c.enabled {
-		return
-	}
-
-	c.mu.Lock()
-	defer c.mu.Unlock()
-
+// write stores a cache file. Caller must hold c.mu.
+func (c *Cache) write(path string, review CachedReview) {
 	review.CachedAt = time.Now()
 
 	data, err := json.Marshal(review)
 	if err != nil {
-		log.Printf("Warning: failed to marshal review data for PR %d: %v", prID, err)
+		log.Printf("Warning: failed to marshal review data for %s: %v", filepath.Base(path), err)
 		return
 	}
 
-	path := c.reviewPath(prID)
 	// Use CacheFilePermissions - cache files may contain sensitive code snippets
 	if err := os.WriteFile(path, data, CacheFilePermissions); err != nil {
 		log.Printf("Warning: failed to write cache file %s: %v", path, err)
@@ -124,7 +238,7 @@ func (c *Cache) Invalidate(prID int) {
 	_ = os.Remove(path)
 }
 
-// Clear clears all cached reviews
+// Clear clears all cached reviews. The budget ledger is kept.
 func (c *Cache) Clear() error {
 	c.mu.Lock()
 	defer c.mu.Unlock()
@@ -139,6 +253,10 @@ func (c *Cache) Clear() error {
 	}
 
 	for _, entry := range entries {
+		// Spend history and the session pool are not cache data
+		if entry.Name() == LedgerFileName || entry.IsDir() {
+			continue
+		}
 		path := filepath.Join(c.dir, entry.Name())
 		if err := os.Remove(path); err != nil {
 			// Log but continue - cache cleanup is not critical
@@ -149,6 +267,196 @@ func (c *Cache) Clear() error {
 	return nil
 }
 
+// List returns the entries in the cache directory, most recently used first
+func (c *Cache) List() ([]CacheEntry, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	if !c.enabled {
+		return nil, nil
+	}
+
+	files, err := c.files()
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make([]CacheEntry, 0, len(files))
+	for _, f := range files {
+		entry := CacheEntry{
+			Name:    f.name,
+			Kind:    f.kind,
+			Size:    f.size,
+			ModTime: f.modTime,
+		}
+		if data, err := os.ReadFile(filepath.Join(c.dir, f.name)); err == nil {
+			var cached CachedReview
+			if json.Unmarshal(data, &cached) == nil {
+				entry.CachedAt = cached.CachedAt
+				entry.HeadSHA = cached.HeadSHA
+				entry.Issues = len(cached.Issues)
+				entry.Expired = time.Since(cached.CachedAt) > c.ttl
+			}
+		}
+		entries = append(entries, entry)
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].ModTime.After(entries[j].ModTime)
+	})
+
+	return entries, nil
+}
+
+// Stats returns cache statistics
+func (c *Cache) Stats() (CacheStats, error) {
+	entries, err := c.List()
+	if err != nil {
+		return CacheStats{}, err
+	}
+
+	c.mu.RLock()
+	stats := CacheStats{
+		MaxBytes:  c.maxSize,
+		Hits:      c.hits,
+		Misses:    c.misses,
+		Evictions: c.evictions,
+	}
+	c.mu.RUnlock()
+
+	for _, e := range entries {
+		stats.SizeBytes += e.Size
+		if e.Kind == "pr" {
+			stats.PRStates++
+		} else {
+			stats.Entries++
+		}
+		if e.Expired {
+			stats.Expired++
+		}
+	}
+
+	return stats, nil
+}
+
+// Prune removes expired and unreadable entries, then enforces the size bound.
+// It returns the number of files removed.
+func (c *Cache) Prune() (int, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !c.enabled {
+		return 0, nil
+	}
+
+	files, err := c.files()
+	if err != nil {
+		return 0, err
+	}
+
+	removed := 0
+	for _, f := range files {
+		path := filepath.Join(c.dir, f.name)
+		data, err := os.ReadFile(path)
+		var cached CachedReview
+		if err == nil && json.Unmarshal(data, &cached) == nil && time.Since(cached.CachedAt) <= c.ttl {
+			continue
+		}
+		if err := os.Remove(path); err == nil {
+			removed++
+		}
+	}
+
+	before := c.evictions
+	c.evictLocked()
+	removed += int(c.evictions - before)
+
+	return removed, nil
+}
+
+// evictLocked removes least recently used files until the cache fits within
+// its size bound. Caller must hold c.mu.
+func (c *Cache) evictLocked() {
+	if c.maxSize <= 0 {
+		return
+	}
+
+	files, err := c.files()
+	if err != nil {
+		return
+	}
+
+	var total int64
+	for _, f := range files {
+		total += f.size
+	}
+	if total <= c.maxSize {
+		return
+	}
+
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].modTime.Before(files[j].modTime)
+	})
+
+	for _, f := range files {
+		if total <= c.maxSize {
+			break
+		}
+		if err := os.Remove(filepath.Join(c.dir, f.name)); err != nil {
+			continue
+		}
+		total -= f.size
+		c.evictions++
+	}
+}
+
+// cacheFile is a file in the cache directory
+type cacheFile struct {
+	name    string
+	kind    string
+	size    int64
+	modTime time.Time
+}
+
+// files lists the cache-managed files in the cache directory
+func (c *Cache) files() ([]cacheFile, error) {
+	dirEntries, err := os.ReadDir(c.dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var files []cacheFile
+	for _, e := range dirEntries {
+		if e.IsDir() || !strings.HasSuffix(e.Name(), ".json") {
+			continue
+		}
+		kind := ""
+		switch {
+		case strings.HasPrefix(e.Name(), reviewEntryPrefix):
+			kind = "review"
+		case strings.HasPrefix(e.Name(), prStatePrefix):
+			kind = "pr"
+		default:
+			continue
+		}
+		info, err := e.Info()
+		if err != nil {
+			continue
+		}
+		files = append(files, cacheFile{name: e.Name(), kind: kind, size: info.Size(), modTime: info.ModTime()})
+	}
+
+	return files, nil
+}
+
+// entryPath returns the file path for a content-addressed entry
+func (c *Cache) entryPath(key string) string {
+	return filepath.Join(c.dir, reviewEntryPrefix+key+".json")
+}
+
 // reviewPath returns the cache file path for a PR.
 // The filename includes both the PR ID and a hash suffix to:
 // 1. Prevent key collisions (the PR ID prefix ensures uniqueness)
@@ -176,3 +484,11 @@ func (c *Cache) SetTTL(ttl time.Duration) {
 	defer c.mu.Unlock()
 	c.ttl = ttl
 }
+
+// SetMaxSize sets the maximum total size of the cache directory in bytes.
+// Zero disables size-bounded eviction.
+func (c *Cache) SetMaxSize(bytes int64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.maxSize = bytes
+}
@@ -0,0 +1,220 @@
+// Package runner provides cache tests
+package runner
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cicd-ai-toolkit/cicd-runner/pkg/ai"
+	"github.com/cicd-ai-toolkit/cicd-runner/pkg/config"
+)
+
+func TestReviewCacheKeyBackendModels(t *testing.T) {
+	base := func() *config.Config {
+		return &config.Config{
+			AIBackend: "chain",
+			Claude:    config.ClaudeConfig{Model: "sonnet"},
+			API:       config.APIConfig{Provider: "openai", Model: "gpt-4o"},
+			Crush:     config.CrushConfig{Provider: "ollama", Model: "llama3:70b"},
+			Replay:    config.ReplayConfig{Dir: "testdata/fixtures"},
+			Chain: config.ChainConfig{
+				Backends: []string{"claude", "api"},
+				Routes:   []config.RouteConfig{{Operation: "review", Backend: "api", Model: "gpt-4o-mini"}},
+			},
+		}
+	}
+	key := func(cfg *config.Config) string {
+		return (&DefaultRunner{cfg: cfg}).reviewCacheKey("diff", nil).String()
+	}
+
+	tests := []struct {
+		name    string
+		backend string
+		modify  func(cfg *config.Config)
+	}{
+		{"chain member model", "chain", func(cfg *config.Config) { cfg.Claude.Model = "opus" }},
+		{"other chain member model", "chain", func(cfg *config.Config) { cfg.API.Model = "gpt-4.1" }},
+		{"route model", "chain", func(cfg *config.Config) { cfg.Chain.Routes[0].Model = "o3" }},
+		{"route condition", "chain", func(cfg *config.Config) { cfg.Chain.Routes[0].MaxDiffLines = 50 }},
+		{"replay fixtures", "replay", func(cfg *config.Config) { cfg.Replay.Dir = "testdata/other" }},
+		{"replay recorded model", "replay", func(cfg *config.Config) { cfg.Claude.Model = "opus" }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			before, after := base(), base()
+			before.AIBackend, after.AIBackend = tt.backend, tt.backend
+			tt.modify(after)
+			if key(after) == key(before) {
+				t.Error("cache key unchanged")
+			}
+		})
+	}
+
+	// Models of backends outside the chain do not matter
+	cfg := base()
+	cfg.Crush.Model = "qwen2.5-coder:32b"
+	if key(cfg) != key(base()) {
+		t.Error("cache key changed with the model of a backend outside the chain")
+	}
+}
+
+func TestCache_ContentAddressed(t *testing.T) {
+	cache, err := NewCache(t.TempDir(), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	key := ReviewCacheKey{DiffHash: GetDiffHash("diff"), Skills: []string{"code-reviewer"}, Model: "sonnet"}.String()
+	cache.Set(key, CachedReview{Issues: []ai.Issue{{Message: "x"}}})
+
+	got, ok := cache.Get(key)
+	if !ok || len(got.Issues) != 1 || got.Key != key {
+		t.Fatalf("Get() = %+v, %v", got, ok)
+	}
+
+	if _, ok := cache.Get(strings.Repeat("0", 64)); ok {
+		t.Error("expected miss for unknown key")
+	}
+	if _, ok := cache.Get("../../etc/passwd"); ok {
+		t.Error("expected miss for invalid key")
+	}
+
+	stats, err := cache.Stats()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.Entries != 1 || stats.Hits != 1 || stats.Misses != 1 || stats.SizeBytes == 0 {
+		t.Errorf("unexpected stats: %+v", stats)
+	}
+}
+
+func TestReviewCacheKey(t *testing.T) {
+	base := ReviewCacheKey{
+		DiffHash:       GetDiffHash("diff"),
+		Skills:         []string{"code-reviewer@1.0:abc"},
+		Backend:        "claude",
+		Model:          "sonnet",
+		PromptTemplate: "tpl",
+	}
+
+	tests := []struct {
+		name       string
+		modify     func(k *ReviewCacheKey)
+		sameConfig bool
+	}{
+		{"diff changed", func(k *ReviewCacheKey) { k.DiffHash = GetDiffHash("other") }, true},
+		{"skill version changed", func(k *ReviewCacheKey) { k.Skills = []string{"code-reviewer@1.1:abc"} }, false},
+		{"model changed", func(k *ReviewCacheKey) { k.Model = "opus" }, false},
+		{"prompt changed", func(k *ReviewCacheKey) { k.PromptTemplate = "tpl2" }, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			k := base
+			tt.modify(&k)
+			if k.String() == base.String() {
+				t.Error("expected different cache key")
+			}
+			if (k.ConfigHash() == base.ConfigHash()) != tt.sameConfig {
+				t.Errorf("ConfigHash equality = %v, want %v", !tt.sameConfig, tt.sameConfig)
+			}
+		})
+	}
+}
+
+func TestCache_SizeBoundedEviction(t *testing.T) {
+	dir := t.TempDir()
+	cache, err := NewCache(dir, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	body := strings.Repeat("x", 1000)
+	keys := []string{strings.Repeat("a", 64), strings.Repeat("b", 64), strings.Repeat("c", 64)}
+	for i, key := range keys {
+		cache.Set(key, CachedReview{Comment: body})
+		// Space out modification times so LRU order is deterministic
+		old := time.Now().Add(time.Duration(i-len(keys)) * time.Minute)
+		_ = os.Chtimes(filepath.Join(dir, reviewEntryPrefix+key+".json"), old, old)
+	}
+
+	// Using the oldest entry makes it most recently used
+	if _, ok := cache.Get(keys[0]); !ok {
+		t.Fatal("expected hit for first key")
+	}
+
+	// Allow room for two entries; the next write must evict the LRU entry (b)
+	cache.SetMaxSize(3000)
+	cache.Set(strings.Repeat("d", 64), CachedReview{Comment: body})
+
+	if _, ok := cache.Get(keys[1]); ok {
+		t.Error("expected least recently used entry to be evicted")
+	}
+	if _, ok := cache.Get(keys[0]); !ok {
+		t.Error("expected recently used entry to survive eviction")
+	}
+
+	stats, _ := cache.Stats()
+	if stats.SizeBytes > 3000 || stats.Evictions == 0 {
+		t.Errorf("unexpected stats after eviction: %+v", stats)
+	}
+}
+
+func TestCache_Prune(t *testing.T) {
+	dir := t.TempDir()
+	cache, err := NewCache(dir, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cache.Set(strings.Repeat("a", 64), CachedReview{})
+	cache.SetReview(7, CachedReview{HeadSHA: "abc"})
+	if err := os.WriteFile(filepath.Join(dir, reviewEntryPrefix+strings.Repeat("f", 64)+".json"), []byte("{corrupt"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	// Unrelated files in the cache directory are left alone
+	if err := os.WriteFile(filepath.Join(dir, "notes.txt"), []byte("keep"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	removed, err := cache.Prune()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if removed != 1 {
+		t.Errorf("Prune() removed %d, want 1 (corrupt entry)", removed)
+	}
+
+	cache.SetTTL(time.Nanosecond)
+	time.Sleep(time.Millisecond)
+	removed, err = cache.Prune()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if removed != 2 {
+		t.Errorf("Prune() removed %d expired entries, want 2", removed)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "notes.txt")); err != nil {
+		t.Error("Prune() removed an unrelated file")
+	}
+}
+
+func TestNewCacheFromConfig(t *testing.T) {
+	cfg := &config.Config{Global: config.GlobalConfig{
+		CacheDir:       "cache",
+		EnableCache:    true,
+		CacheTTL:       "2h",
+		CacheMaxSizeMB: 1,
+	}}
+
+	cache, err := NewCacheFromConfig(cfg, t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cache.ttl != 2*time.Hour || cache.maxSize != 1024*1024 {
+		t.Errorf("unexpected cache settings: ttl=%v maxSize=%d", cache.ttl, cache.maxSize)
+	}
+}
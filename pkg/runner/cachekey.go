@@ -0,0 +1,105 @@
+// Package runner provides content-addressed cache keys for reviews
+package runner
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ReviewCacheKey identifies a review by everything that influences its result
+type ReviewCacheKey struct {
+	DiffHash       string
+	Skills         []string // name@version:contenthash, sorted
+	Backend        string
+	Model          string
+	PromptTemplate string // Hash of the review prompt template
+}
+
+// ConfigHash returns a hash of the review configuration, excluding the diff.
+// Prior results are only reused for incremental review when it matches.
+func (k ReviewCacheKey) ConfigHash() string {
+	h := sha256.New()
+	fmt.Fprintf(h, "skills=%s\nbackend=%s\nmodel=%s\nprompt=%s\n",
+		strings.Join(k.Skills, ","), k.Backend, k.Model, k.PromptTemplate)
+	return fmt.Sprintf("%x", h.Sum(nil))
+}
+
+// String returns the content-addressed cache key
+func (k ReviewCacheKey) String() string {
+	h := sha256.New()
+	fmt.Fprintf(h, "diff=%s\nconfig=%s\n", k.DiffHash, k.ConfigHash())
+	return fmt.Sprintf("%x", h.Sum(nil))
+}
+
+// reviewCacheKey builds the cache key for reviewing diff with the given skills
+func (r *DefaultRunner) reviewCacheKey(diff string, skills []string) ReviewCacheKey {
+	key := ReviewCacheKey{
+		DiffHash: GetDiffHash(diff),
+		Skills:   r.skillIdentities(skills),
+		// The template is hashed with an empty diff so any wording change invalidates entries
+		PromptTemplate: GetDiffHash(r.buildDiffContext("", 0)),
+	}
+
+	if r.cfg != nil {
+		key.Backend = r.cfg.AIBackend
+		if key.Backend == "" {
+			key.Backend = "claude"
+		}
+		key.Model = r.backendModel(key.Backend)
+	}
+
+	return key
+}
+
+// backendModel identifies the model a backend reviews with. A chain is
+// identified by the model of each member and its routes, and replay by its
+// fixture directory and the backend it recorded.
+func (r *DefaultRunner) backendModel(backend string) string {
+	switch backend {
+	case "crush":
+		return r.cfg.Crush.Provider + "/" + r.cfg.Crush.Model
+	case "api":
+		return r.cfg.API.Provider + "/" + r.cfg.API.Model
+	case "replay":
+		recorded := r.cfg.Replay.Backend
+		if recorded == "" || recorded == "replay" {
+			recorded = "claude"
+		}
+		return "fixtures=" + r.cfg.Replay.Dir + " " + recorded + ":" + r.backendModel(recorded)
+	case "chain":
+		members := make([]string, 0, len(r.cfg.Chain.Backends))
+		for _, b := range r.cfg.Chain.Backends {
+			if b == "chain" {
+				continue
+			}
+			members = append(members, b+":"+r.backendModel(b))
+		}
+		routes := make([]string, 0, len(r.cfg.Chain.Routes))
+		for _, rt := range r.cfg.Chain.Routes {
+			routes = append(routes, fmt.Sprintf("skills=%s,op=%s,lines=%d,backend=%s,model=%s",
+				strings.Join(rt.Skills, "+"), rt.Operation, rt.MaxDiffLines, rt.Backend, rt.Model))
+		}
+		return strings.Join(members, ">") + " routes=" + strings.Join(routes, ";")
+	default:
+		return r.cfg.Claude.Model
+	}
+}
+
+// skillIdentities returns sorted skill identities including version and a
+// hash of the skill prompt, so editing a SKILL.md invalidates cached reviews
+func (r *DefaultRunner) skillIdentities(skills []string) []string {
+	ids := make([]string, 0, len(skills))
+	for _, name := range skills {
+		id := name
+		if r.skillLoader != nil {
+			if s, err := r.skillLoader.Load(name); err == nil {
+				id = fmt.Sprintf("%s@%s:%s", name, s.Version, GetDiffHash(s.Content)[:12])
+			}
+		}
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
@@ -0,0 +1,166 @@
+// Package runner provides chunked map-reduce review of large diffs
+package runner
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/cicd-ai-toolkit/cicd-runner/pkg/ai"
+	"github.com/cicd-ai-toolkit/cicd-runner/pkg/buildcontext"
+	"github.com/cicd-ai-toolkit/cicd-runner/pkg/perf"
+)
+
+// DefaultMaxChunkKB is the default maximum diff size sent in one AI call
+const DefaultMaxChunkKB = 40
+
+// ChunkFailure describes a failed skill execution on a diff chunk
+type ChunkFailure struct {
+	Index int      // 1-based chunk number
+	Skill string   // Skill that failed; empty when no skill was requested
+	Files []string // Files (partly) contained in the chunk
+	Err   string
+}
+
+// chunkedReview is the reduced result of reviewing a diff in chunks
+type chunkedReview struct {
+	Issues []ai.Issue
+	Chunks int
+	Skills []SkillResult
+	Failed []ChunkFailure
+}
+
+// reviewTask is one skill execution on one diff chunk
+type reviewTask struct {
+	chunk int
+	skill int
+}
+
+// chunkResult is the outcome of a review task
+type chunkResult struct {
+	issues   []ai.Issue
+	err      error
+	duration time.Duration
+}
+
+// reviewDiff reviews a diff with the given skills. Diffs larger than the
+// chunk size are split into file-aware chunks, and every skill runs as an
+// independent execution on every chunk, in parallel bounded by
+// parallel_skills. Findings are merged in skill priority order with
+// de-duplication. Failed executions are reported rather than failing the
+// whole review; an error is returned only when every execution fails.
+func (r *DefaultRunner) reviewDiff(ctx context.Context, diff string, prID int, skills []string) (*chunkedReview, error) {
+	chunks := []string{diff}
+	if r.builder != nil {
+		chunks = r.builder.Chunks(diff, r.maxChunkSize())
+	}
+
+	skills = r.orderSkills(skills)
+	if len(skills) == 0 {
+		// Run once without a named skill
+		skills = []string{""}
+	}
+
+	tasks := make([]reviewTask, 0, len(chunks)*len(skills))
+	for c := range chunks {
+		for s := range skills {
+			tasks = append(tasks, reviewTask{chunk: c, skill: s})
+		}
+	}
+
+	budgetRunFrom(ctx).parallel(min(len(tasks), r.parallelism()))
+	results, err := perf.Map(ctx, tasks, func(t reviewTask) (chunkResult, error) {
+		prompt := r.buildDiffContext(chunks[t.chunk], prID)
+		if len(chunks) > 1 {
+			prompt += fmt.Sprintf("\nThis is part %d of %d of the change; other parts are reviewed separately.\n", t.chunk+1, len(chunks))
+		}
+		start := time.Now()
+		part := diffPart{total: len(chunks), lines: buildcontext.ChangedLines(chunks[t.chunk])}
+		if len(chunks) > 1 {
+			part.index = t.chunk + 1
+		}
+		issues, err := r.executeSkill(ctx, prompt, skills[t.skill], part)
+		// Failures are collected, not propagated, so other executions keep running
+		return chunkResult{issues: issues, err: err, duration: time.Since(start)}, nil
+	}, r.parallelism())
+	if err != nil {
+		return nil, err
+	}
+
+	review := &chunkedReview{Chunks: len(chunks)}
+	seen := make(map[string]bool)
+	var firstErr error
+
+	for s, name := range skills {
+		sr := SkillResult{Name: name, Priority: r.skillPriority(name)}
+		var skillErr error
+
+		for c := range chunks {
+			res := results[c*len(skills)+s]
+			sr.Duration += res.duration
+			if res.err != nil {
+				log.Printf("[WARNING] review of chunk %d/%d failed (skill %q): %v", c+1, len(chunks), name, res.err)
+				if firstErr == nil {
+					firstErr = res.err
+				}
+				skillErr = res.err
+				sr.Failed++
+				review.Failed = append(review.Failed, ChunkFailure{
+					Index: c + 1,
+					Skill: name,
+					Files: chunkFiles(chunks[c]),
+					Err:   res.err.Error(),
+				})
+				continue
+			}
+			for _, issue := range res.issues {
+				key := issueKey(issue)
+				if seen[key] {
+					continue
+				}
+				seen[key] = true
+				sr.Issues++
+				review.Issues = append(review.Issues, issue)
+			}
+		}
+
+		if sr.Failed == len(chunks) {
+			sr.Err = skillErr.Error()
+		}
+		if name != "" {
+			review.Skills = append(review.Skills, sr)
+		}
+	}
+
+	if len(review.Failed) == len(tasks) {
+		return nil, fmt.Errorf("all %d review executions failed: %w", len(tasks), firstErr)
+	}
+
+	return review, nil
+}
+
+// maxChunkSize returns the configured maximum chunk size in bytes
+func (r *DefaultRunner) maxChunkSize() int {
+	if r.cfg != nil && r.cfg.Global.MaxChunkKB > 0 {
+		return r.cfg.Global.MaxChunkKB * 1024
+	}
+	return DefaultMaxChunkKB * 1024
+}
+
+// parallelism returns the number of review executions run concurrently
+func (r *DefaultRunner) parallelism() int {
+	if r.cfg != nil && r.cfg.Global.ParallelSkills > 0 {
+		return r.cfg.Global.ParallelSkills
+	}
+	return 1
+}
+
+// chunkFiles returns the paths of the files in a diff chunk
+func chunkFiles(chunk string) []string {
+	var files []string
+	for _, f := range buildcontext.ParseDiff(chunk) {
+		files = append(files, f.Path())
+	}
+	return files
+}
@@ -0,0 +1,96 @@
+// Package runner provides chunked review tests
+package runner
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/cicd-ai-toolkit/cicd-runner/pkg/ai"
+	"github.com/cicd-ai-toolkit/cicd-runner/pkg/buildcontext"
+	"github.com/cicd-ai-toolkit/cicd-runner/pkg/config"
+)
+
+// chunkBrain reports one issue per file in the prompt plus a shared duplicate,
+// and fails prompts containing failFile
+type chunkBrain struct {
+	fakeBrain
+	mu       sync.Mutex
+	calls    int
+	failFile string
+}
+
+func (b *chunkBrain) Execute(ctx context.Context, prompt string, opts ai.ExecuteOptions) (*ai.Output, error) {
+	b.mu.Lock()
+	b.calls++
+	b.mu.Unlock()
+
+	if b.failFile != "" && strings.Contains(prompt, b.failFile) {
+		return nil, fmt.Errorf("rate_limit_exceeded")
+	}
+
+	issues := []ai.Issue{{Severity: "low", Category: "style", File: "shared.go", Line: 1, Message: "duplicate"}}
+	for _, f := range buildcontext.ParseDiff(prompt) {
+		issues = append(issues, ai.Issue{Severity: "medium", Category: "logic", File: f.Path(), Line: 1, Message: "finding"})
+	}
+	return &ai.Output{Issues: issues}, nil
+}
+
+func chunkTestDiff(files ...string) string {
+	var b strings.Builder
+	for _, f := range files {
+		fmt.Fprintf(&b, "diff --git a/%s b/%s\n--- a/%s\n+++ b/%s\n@@ -1,1 +1,2 @@\n ctx\n+%s\n", f, f, f, f, strings.Repeat("x", 600))
+	}
+	return b.String()
+}
+
+func TestReviewDiffChunked(t *testing.T) {
+	brain := &chunkBrain{failFile: "c.go"}
+	r := &DefaultRunner{
+		cfg:      &config.Config{Global: config.GlobalConfig{ParallelSkills: 2, MaxChunkKB: 1}},
+		platform: &mockPlatform{},
+		builder:  buildcontext.NewBuilder(".", 0, nil),
+		aiBrain:  brain,
+	}
+
+	review, err := r.reviewDiff(context.Background(), chunkTestDiff("a.go", "b.go", "c.go"), 0, nil)
+	if err != nil {
+		t.Fatalf("reviewDiff() error = %v", err)
+	}
+
+	if review.Chunks != 3 || brain.calls != 3 {
+		t.Fatalf("expected 3 chunks and calls, got %d chunks and %d calls", review.Chunks, brain.calls)
+	}
+	if len(review.Failed) != 1 || review.Failed[0].Index != 3 || review.Failed[0].Files[0] != "c.go" {
+		t.Errorf("unexpected failed chunks: %+v", review.Failed)
+	}
+
+	// One shared duplicate plus one finding per successful chunk
+	if len(review.Issues) != 3 {
+		t.Fatalf("expected 3 de-duplicated issues, got %d: %+v", len(review.Issues), review.Issues)
+	}
+	if review.Issues[1].File != "a.go" || review.Issues[2].File != "b.go" {
+		t.Errorf("issues not in chunk order: %+v", review.Issues)
+	}
+
+	result := &ReviewResult{Chunks: review.Chunks, FailedChunks: review.Failed}
+	if comment := r.formatReviewComment(result); !strings.Contains(comment, "1 of 3 review executions failed") {
+		t.Errorf("comment does not report failed chunk:\n%s", comment)
+	}
+}
+
+func TestReviewDiffAllChunksFail(t *testing.T) {
+	brain := &chunkBrain{failFile: "diff --git"}
+	r := &DefaultRunner{
+		cfg:      &config.Config{Global: config.GlobalConfig{ParallelSkills: 2, MaxChunkKB: 1}},
+		platform: &mockPlatform{},
+		builder:  buildcontext.NewBuilder(".", 0, nil),
+		aiBrain:  brain,
+	}
+
+	if _, err := r.reviewDiff(context.Background(), chunkTestDiff("a.go", "b.go"), 0, nil); err == nil {
+		t.Error("expected error when every chunk fails")
+	}
+}
@@ -0,0 +1,78 @@
+// Package runner provides the explanation of a changed line
+package runner
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/cicd-ai-toolkit/cicd-runner/pkg/buildcontext"
+)
+
+// ExplainOptions selects the changed line to explain
+type ExplainOptions struct {
+	PRID int
+	Diff string
+	File string
+	Line int // Line in the new version of File
+}
+
+// ExplainResult contains the explanation of a changed line
+type ExplainResult struct {
+	File        string
+	Line        int
+	Explanation string    // Markdown
+	Cost        *RunCost  // AI spend; nil when no AI execution ran
+	Usage       *RunUsage // AI usage; nil when no AI execution ran
+	Duration    time.Duration
+}
+
+// Explain explains the change at a line of a PR. The line must be an added
+// or context line of the diff; the AI sees the file's diff.
+func (r *DefaultRunner) Explain(ctx context.Context, opts ExplainOptions) (*ExplainResult, error) {
+	start := time.Now()
+
+	files := buildcontext.ParseDiff(opts.Diff)
+	find := func(path string) *buildcontext.FileDiff {
+		for i := range files {
+			if files[i].Path() == path {
+				return &files[i]
+			}
+		}
+		return nil
+	}
+	file := find(buildcontext.MatchDiffPath(opts.File, func(p string) bool { return find(p) != nil }))
+	if file == nil {
+		return nil, fmt.Errorf("%s is not changed in this pull request", opts.File)
+	}
+	line, ok := file.FindLine(opts.Line)
+	if !ok {
+		return nil, fmt.Errorf("line %d of %s is not in the diff", opts.Line, opts.File)
+	}
+
+	ctx, run := r.startRun(ctx, "explain", opts.PRID)
+	if err := run.check(); err != nil {
+		return nil, err
+	}
+
+	var prompt strings.Builder
+	prompt.WriteString("# Code Explanation\n\n")
+	fmt.Fprintf(&prompt, "Explain line %d of `%s`:\n\n```\n%s\n```\n\n", opts.Line, file.Path(), line.Content)
+	prompt.WriteString("Describe what the changed code around it does, why it was likely changed and any risk it introduces, in a few short markdown paragraphs.\n\n")
+	prompt.WriteString("```diff\n" + file.Text() + "\n```\n")
+
+	output, err := r.executeRawWithSkill(ctx, prompt.String(), nil, "explain")
+	if err != nil {
+		return nil, fmt.Errorf("explanation failed: %w", err)
+	}
+
+	return &ExplainResult{
+		File:        file.Path(),
+		Line:        opts.Line,
+		Explanation: strings.TrimSpace(output),
+		Cost:        run.cost(),
+		Usage:       run.usage(),
+		Duration:    time.Since(start),
+	}, nil
+}
@@ -0,0 +1,110 @@
+// Package runner provides explanation and ignored rule tests
+package runner
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/cicd-ai-toolkit/cicd-runner/pkg/ai"
+	"github.com/cicd-ai-toolkit/cicd-runner/pkg/buildcontext"
+	"github.com/cicd-ai-toolkit/cicd-runner/pkg/config"
+)
+
+// explainBrain answers with a fixed raw explanation
+type explainBrain struct {
+	fakeBrain
+}
+
+func (b *explainBrain) Execute(ctx context.Context, prompt string, opts ai.ExecuteOptions) (*ai.Output, error) {
+	b.prompts = append(b.prompts, prompt)
+	return &ai.Output{Raw: "  It retries the call.\n"}, nil
+}
+
+const explainDiff = `diff --git a/pkg/a.go b/pkg/a.go
+--- a/pkg/a.go
++++ b/pkg/a.go
+@@ -1,2 +1,3 @@
+ package a
++var retries = 3
+ func f() {}
+diff --git a/pkg/b.go b/pkg/b.go
+--- a/pkg/b.go
++++ b/pkg/b.go
+@@ -1,1 +1,2 @@
+ package b
++var unrelated = 1
+`
+
+func TestExplain(t *testing.T) {
+	brain := &explainBrain{}
+	r := &DefaultRunner{
+		cfg:      &config.Config{},
+		platform: &mockPlatform{},
+		builder:  buildcontext.NewBuilder(".", 0, nil),
+		aiBrain:  brain,
+	}
+
+	result, err := r.Explain(context.Background(), ExplainOptions{PRID: 1, Diff: explainDiff, File: "./pkg/a.go", Line: 2})
+	if err != nil {
+		t.Fatalf("Explain() error = %v", err)
+	}
+	if result.File != "pkg/a.go" || result.Explanation != "It retries the call." {
+		t.Errorf("Explain() = %+v", result)
+	}
+	prompt := brain.prompts[0]
+	if !strings.Contains(prompt, "var retries = 3") || strings.Contains(prompt, "unrelated") {
+		t.Errorf("prompt should contain only the file's diff:\n%s", prompt)
+	}
+
+	for _, opts := range []ExplainOptions{
+		{Diff: explainDiff, File: "pkg/c.go", Line: 1},
+		{Diff: explainDiff, File: "pkg/a.go", Line: 40},
+	} {
+		if _, err := r.Explain(context.Background(), opts); err == nil {
+			t.Errorf("Explain(%s:%d) succeeded", opts.File, opts.Line)
+		}
+	}
+	if len(brain.prompts) != 1 {
+		t.Errorf("invalid locations ran the AI")
+	}
+}
+
+func TestReviewIgnoresRules(t *testing.T) {
+	brain := &fakeBrain{issues: []ai.Issue{
+		{Severity: "high", Category: "security", File: "a.go", Line: 1, Rule: "G104", Message: "unchecked error"},
+		{Severity: "low", Category: "style", File: "b.go", Line: 2, Message: "naming"},
+	}}
+	cache, err := NewCache(t.TempDir(), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := &DefaultRunner{
+		cfg:      &config.Config{},
+		platform: &mockPlatform{},
+		builder:  buildcontext.NewBuilder(".", 0, nil),
+		aiBrain:  brain,
+		cache:    cache,
+	}
+	ctx := context.Background()
+
+	result, err := r.Review(ctx, ReviewOptions{PRID: 1, Diff: "diff", Ignore: []string{"g104"}})
+	if err != nil {
+		t.Fatalf("Review() error = %v", err)
+	}
+	if len(result.Issues) != 1 || result.Summary.High != 0 || result.Summary.TotalIssues != 1 || strings.Contains(result.PlatformComment, "unchecked error") {
+		t.Errorf("ignored rule reported: %+v", result.Summary)
+	}
+
+	// The cache keeps every issue, so the rule can be ignored per run
+	result, err = r.Review(ctx, ReviewOptions{PRID: 1, Diff: "diff"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !result.Cached || len(result.Issues) != 2 {
+		t.Errorf("cached review = cached %v, %d issues", result.Cached, len(result.Issues))
+	}
+	if len(brain.prompts) != 1 {
+		t.Errorf("AI ran %d times, want 1", len(brain.prompts))
+	}
+}
@@ -0,0 +1,101 @@
+// Package runner provides quality gate evaluation of review results
+package runner
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/cicd-ai-toolkit/cicd-runner/pkg/ai"
+	"github.com/cicd-ai-toolkit/cicd-runner/pkg/config"
+)
+
+// gateSeverities lists severities in the order gate rules are reported
+var gateSeverities = []string{"critical", "high", "medium", "low"}
+
+// GateViolation is a quality gate rule broken by a review
+type GateViolation struct {
+	// Rule describes the broken rule, e.g. "high issues: 4 > 3"
+	Rule string
+
+	// Issues are the offending issues
+	Issues []ai.Issue
+}
+
+// GateResult is the outcome of evaluating a quality gate
+type GateResult struct {
+	Violations []GateViolation
+}
+
+// Passed returns true if no gate rule was broken
+func (g *GateResult) Passed() bool {
+	return len(g.Violations) == 0
+}
+
+// EvaluateGate checks review issues against the quality gate rules.
+// Severities and categories are matched case-insensitively.
+func EvaluateGate(gate config.QualityGate, issues []ai.Issue) *GateResult {
+	result := &GateResult{}
+
+	limits := make(map[string]int, len(gate.MaxIssues))
+	for severity, limit := range gate.MaxIssues {
+		limits[strings.ToLower(severity)] = limit
+	}
+
+	for _, severity := range gateSeverities {
+		limit, ok := limits[severity]
+		if !ok {
+			continue
+		}
+		matched := filterIssues(issues, func(i ai.Issue) bool {
+			return strings.EqualFold(i.Severity, severity)
+		})
+		if len(matched) > limit {
+			result.Violations = append(result.Violations, GateViolation{
+				Rule:   fmt.Sprintf("%s issues: %d > %d", severity, len(matched), limit),
+				Issues: matched,
+			})
+		}
+	}
+
+	for _, category := range gate.FailOnCategories {
+		matched := filterIssues(issues, func(i ai.Issue) bool {
+			return strings.EqualFold(i.Category, category)
+		})
+		if len(matched) > 0 {
+			result.Violations = append(result.Violations, GateViolation{
+				Rule:   fmt.Sprintf("%s issues: %d found", strings.ToLower(category), len(matched)),
+				Issues: matched,
+			})
+		}
+	}
+
+	return result
+}
+
+// Report formats the gate result, naming the offending issues
+func (g *GateResult) Report() string {
+	if g.Passed() {
+		return "Quality gate passed\n"
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Quality gate failed (%d rules broken)\n", len(g.Violations))
+	for _, v := range g.Violations {
+		fmt.Fprintf(&sb, "\n  %s\n", v.Rule)
+		for _, issue := range v.Issues {
+			fmt.Fprintf(&sb, "    - [%s/%s] %s:%d %s\n", issue.Severity, issue.Category, issue.File, issue.Line, issue.Message)
+		}
+	}
+	return sb.String()
+}
+
+// filterIssues returns the issues matching keep
+func filterIssues(issues []ai.Issue, keep func(ai.Issue) bool) []ai.Issue {
+	var matched []ai.Issue
+	for _, issue := range issues {
+		if keep(issue) {
+			matched = append(matched, issue)
+		}
+	}
+	return matched
+}
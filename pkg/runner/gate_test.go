@@ -0,0 +1,91 @@
+// Package runner provides quality gate tests
+package runner
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/cicd-ai-toolkit/cicd-runner/pkg/ai"
+	"github.com/cicd-ai-toolkit/cicd-runner/pkg/config"
+)
+
+func TestEvaluateGate(t *testing.T) {
+	issues := []ai.Issue{
+		{Severity: "critical", Category: "security", File: "auth.go", Line: 11, Message: "SQL injection"},
+		{Severity: "high", Category: "logic", File: "main.go", Line: 3, Message: "nil dereference"},
+		{Severity: "high", Category: "performance", File: "main.go", Line: 9, Message: "quadratic loop"},
+		{Severity: "low", Category: "style", File: "main.go", Line: 1, Message: "naming"},
+	}
+
+	tests := []struct {
+		name      string
+		gate      config.QualityGate
+		wantRules []string
+	}{
+		{
+			name: "no rules",
+			gate: config.QualityGate{},
+		},
+		{
+			name:      "any critical",
+			gate:      config.QualityGate{MaxIssues: map[string]int{"critical": 0}},
+			wantRules: []string{"critical issues: 1 > 0"},
+		},
+		{
+			name: "high within limit",
+			gate: config.QualityGate{MaxIssues: map[string]int{"HIGH": 3}},
+		},
+		{
+			name:      "high over limit",
+			gate:      config.QualityGate{MaxIssues: map[string]int{"high": 1}},
+			wantRules: []string{"high issues: 2 > 1"},
+		},
+		{
+			name:      "security category",
+			gate:      config.QualityGate{FailOnCategories: []string{"Security", "architecture"}},
+			wantRules: []string{"security issues: 1 found"},
+		},
+		{
+			name: "severity rules reported before categories",
+			gate: config.QualityGate{
+				MaxIssues:        map[string]int{"low": 0, "critical": 0},
+				FailOnCategories: []string{"performance"},
+			},
+			wantRules: []string{"critical issues: 1 > 0", "low issues: 1 > 0", "performance issues: 1 found"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := EvaluateGate(tt.gate, issues)
+			if result.Passed() != (len(tt.wantRules) == 0) {
+				t.Fatalf("Passed() = %v, violations = %+v", result.Passed(), result.Violations)
+			}
+			if len(result.Violations) != len(tt.wantRules) {
+				t.Fatalf("got %d violations, want %d", len(result.Violations), len(tt.wantRules))
+			}
+			for i, want := range tt.wantRules {
+				if result.Violations[i].Rule != want {
+					t.Errorf("violation[%d] = %q, want %q", i, result.Violations[i].Rule, want)
+				}
+			}
+		})
+	}
+}
+
+func TestGateResult_Report(t *testing.T) {
+	result := EvaluateGate(config.QualityGate{MaxIssues: map[string]int{"critical": 0}}, []ai.Issue{
+		{Severity: "critical", Category: "security", File: "auth.go", Line: 11, Message: "SQL injection"},
+	})
+
+	report := result.Report()
+	for _, want := range []string{"Quality gate failed", "critical issues: 1 > 0", "auth.go:11 SQL injection"} {
+		if !strings.Contains(report, want) {
+			t.Errorf("Report() missing %q:\n%s", want, report)
+		}
+	}
+
+	if got := EvaluateGate(config.QualityGate{}, nil).Report(); !strings.Contains(got, "passed") {
+		t.Errorf("Report() for passing gate = %q", got)
+	}
+}
@@ -5,13 +5,16 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"strings"
 	"sync"
 	"time"
 
 	"github.com/cicd-ai-toolkit/cicd-runner/pkg/ai"
 	"github.com/cicd-ai-toolkit/cicd-runner/pkg/buildcontext"
 	"github.com/cicd-ai-toolkit/cicd-runner/pkg/config"
+	"github.com/cicd-ai-toolkit/cicd-runner/pkg/errors"
 	"github.com/cicd-ai-toolkit/cicd-runner/pkg/platform"
+	"github.com/cicd-ai-toolkit/cicd-runner/pkg/retry"
 	"github.com/cicd-ai-toolkit/cicd-runner/pkg/skill"
 )
 
@@ -26,20 +29,40 @@ type DefaultRunner struct {
 	platform    platform.Platform
 	builder     *buildcontext.Builder
 	aiBrain     ai.Brain
+	progress    ai.ProgressFunc
+	retry       retry.Policy
 	cache       *Cache
+	ledger      *Ledger
+	repo        string // Repository name recorded in the budget ledger
 	skillLoader *skill.Loader
 }
 
-// NewRunner creates a new runner instance
+// NewRunner creates a new runner instance with the AI backend of the
+// configuration
 func NewRunner(cfg *config.Config, platform platform.Platform, baseDir string) (*DefaultRunner, error) {
-	if cfg == nil {
-		return nil, fmt.Errorf("config cannot be nil")
+	if err := checkRunnerArgs(cfg, platform, baseDir); err != nil {
+		return nil, err
 	}
-	if platform == nil {
-		return nil, fmt.Errorf("platform cannot be nil")
+
+	// Create AI Brain using factory
+	factory := ai.NewFactory(baseDir)
+	aiBrain, err := factory.CreateFromConfig(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create AI brain: %w", err)
 	}
-	if baseDir == "" {
-		return nil, fmt.Errorf("baseDir cannot be empty")
+
+	return NewRunnerWithBrain(cfg, platform, baseDir, aiBrain)
+}
+
+// NewRunnerWithBrain creates a runner using an existing AI backend, so
+// runners created for many jobs share one backend and its session pool.
+// The caller closes the backend.
+func NewRunnerWithBrain(cfg *config.Config, platform platform.Platform, baseDir string, aiBrain ai.Brain) (*DefaultRunner, error) {
+	if err := checkRunnerArgs(cfg, platform, baseDir); err != nil {
+		return nil, err
+	}
+	if aiBrain == nil {
+		return nil, fmt.Errorf("AI brain cannot be nil")
 	}
 
 	builder := buildcontext.NewBuilder(
@@ -48,79 +71,225 @@ func NewRunner(cfg *config.Config, platform platform.Platform, baseDir string) (
 		cfg.Global.Exclude,
 	)
 
-	cacheDir := baseDir + "/" + cfg.Global.CacheDir
-	cache, err := NewCache(cacheDir, cfg.Global.EnableCache)
+	cache, err := NewCacheFromConfig(cfg, baseDir)
 	if err != nil {
-		return nil, fmt.Errorf("failed to initialize cache: %w", err)
+		return nil, err
 	}
 
 	// Initialize skill loader from skills directory
 	skillsDir := baseDir + "/skills"
 	skillLoader := skill.NewLoader(skillsDir)
 
-	// Create AI Brain using factory
-	factory := ai.NewFactory(baseDir)
-	aiBrain, err := factory.CreateFromConfig(cfg)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create AI brain: %w", err)
-	}
-
-	return &DefaultRunner{
+	r := &DefaultRunner{
 		cfg:         cfg,
 		platform:    platform,
 		builder:     builder,
 		aiBrain:     aiBrain,
 		cache:       cache,
+		ledger:      NewLedgerFromConfig(cfg, baseDir),
+		repo:        repositoryName(platform, baseDir),
 		skillLoader: skillLoader,
-	}, nil
+	}
+	r.SetRetryPolicy(retry.FromConfig(cfg))
+	return r, nil
+}
+
+// checkRunnerArgs validates the arguments of the runner constructors
+func checkRunnerArgs(cfg *config.Config, platform platform.Platform, baseDir string) error {
+	if cfg == nil {
+		return fmt.Errorf("config cannot be nil")
+	}
+	if platform == nil {
+		return fmt.Errorf("platform cannot be nil")
+	}
+	if baseDir == "" {
+		return fmt.Errorf("baseDir cannot be empty")
+	}
+	return nil
+}
+
+// SetRetryPolicy sets the policy under which failed AI executions and
+// platform API requests are retried. A rate-limited or unavailable backend
+// is retried only after a fallback chain has no backend left to try.
+func (r *DefaultRunner) SetRetryPolicy(policy retry.Policy) {
+	r.retry = policy
+	platform.SetRetryPolicy(r.platform, policy)
+}
+
+// SetCache replaces the review cache, so one configuration can serve many
+// repositories without their PR review states colliding
+func (r *DefaultRunner) SetCache(cache *Cache) {
+	r.cache = cache
 }
 
-// Review runs code review on a pull/merge request
+// Review runs code review on a pull/merge request.
+// Results are cached by a key derived from the diff, skills, model and
+// prompt template. When a head SHA is given and a prior review of the PR
+// exists with the same configuration, only the changes since the
+// previously reviewed head are reviewed and merged. AI spend is checked
+// against the configured budget and recorded in the ledger. Issues of
+// ignored rules are dropped after caching, so ignoring a rule needs no new
+// AI execution.
 func (r *DefaultRunner) Review(ctx context.Context, opts ReviewOptions) (*ReviewResult, error) {
+	result, err := r.review(ctx, opts)
+	if err != nil || len(opts.Ignore) == 0 {
+		return result, err
+	}
+	return r.ignoreRules(result, opts.Ignore), nil
+}
+
+// review runs a review without applying ignored rules
+func (r *DefaultRunner) review(ctx context.Context, opts ReviewOptions) (*ReviewResult, error) {
 	start := time.Now()
 	result := &ReviewResult{}
+	headSHA := r.resolveHeadSHA(ctx, opts.HeadSHA)
+	ctx, run := r.startRun(ctx, "review", opts.PRID)
+
+	// Get enabled review skills
+	skills := r.getReviewSkills(opts.Skills)
+	key := r.reviewCacheKey(opts.Diff, skills)
+	configHash := key.ConfigHash()
 
 	// Check cache first
 	if !opts.Force {
-		if cached, ok := r.cache.GetReview(opts.PRID); ok {
-			result.Cached = true
-			result.Summary = cached.Summary
-			result.Issues = cached.Issues
-			result.PlatformComment = cached.Comment
-			result.Duration = cached.Duration
-			return result, nil
+		if cached, ok := r.cache.Get(key.String()); ok {
+			return cachedResult(cached), nil
+		}
+
+		if err := run.check(); err != nil {
+			return nil, err
+		}
+
+		if prior, ok := r.cache.GetReview(opts.PRID); ok && prior.ConfigHash == configHash && prior.HeadSHA != "" && headSHA != "" {
+			if prior.HeadSHA == headSHA {
+				return cachedResult(prior), nil
+			}
+
+			incremental, err := r.reviewIncremental(ctx, opts, prior, headSHA)
+			if err == nil {
+				incremental.Cost = run.cost()
+				incremental.Usage = run.usage()
+				incremental.PlatformComment = r.formatReviewComment(incremental)
+				incremental.Duration = time.Since(start)
+				r.storeReview(opts.PRID, key, incremental)
+				return incremental, nil
+			}
+			if ctx.Err() != nil {
+				return nil, err
+			}
+			log.Printf("[WARNING] incremental review failed, running full review: %v", err)
 		}
 	}
 
-	// Get enabled review skills
-	skills := r.getReviewSkills(opts.Skills)
+	if err := run.check(); err != nil {
+		return nil, err
+	}
 
-	// Build context and execute
-	diffContext := r.buildDiffContext(opts.Diff, opts.PRID)
-	issues, err := r.executeWithSkill(ctx, diffContext, skills, "review")
+	// Review the diff, in chunks if it is large
+	review, err := r.reviewDiff(ctx, opts.Diff, opts.PRID, skills)
 	if err != nil {
-		return nil, fmt.Errorf("review execution failed: %w", err)
+		// Keep the type of config, validation and budget errors so callers
+		// still decide on them; only unclassified failures are the backend's
+		if errors.IsClassified(err) {
+			return nil, fmt.Errorf("review execution failed: %w", err)
+		}
+		return nil, errors.ClaudeError("review execution failed", err)
 	}
 
-	result.Issues = issues
-	result.Summary = r.summarizeIssues(issues)
+	result.Issues = review.Issues
+	result.Chunks = review.Chunks
+	result.FailedChunks = review.Failed
+	result.Skills = review.Skills
+	result.Summary = r.summarizeIssues(review.Issues)
+	result.HeadSHA = headSHA
+	result.Cost = run.cost()
+	result.Usage = run.usage()
 	result.PlatformComment = r.formatReviewComment(result)
 	result.Duration = time.Since(start)
 
 	// Cache the result
-	r.cache.SetReview(opts.PRID, CachedReview{
-		Summary:  result.Summary,
-		Issues:   result.Issues,
-		Comment:  result.PlatformComment,
-		Duration: result.Duration,
-	})
+	r.storeReview(opts.PRID, key, result)
 
 	return result, nil
 }
 
+// cachedResult converts a cached review into a result
+func cachedResult(cached CachedReview) *ReviewResult {
+	return &ReviewResult{
+		Cached:          true,
+		Summary:         cached.Summary,
+		Issues:          cached.Issues,
+		Resolved:        cached.Resolved,
+		PlatformComment: cached.Comment,
+		HeadSHA:         cached.HeadSHA,
+		Skills:          cached.Skills,
+		Duration:        cached.Duration,
+	}
+}
+
+// ignoreRules drops the issues whose rule is one of rules (compared
+// case-insensitively) and updates the summary and comment
+func (r *DefaultRunner) ignoreRules(result *ReviewResult, rules []string) *ReviewResult {
+	kept := make([]ai.Issue, 0, len(result.Issues))
+	for _, issue := range result.Issues {
+		ignored := false
+		for _, rule := range rules {
+			if issue.Rule != "" && strings.EqualFold(issue.Rule, rule) {
+				ignored = true
+				break
+			}
+		}
+		if !ignored {
+			kept = append(kept, issue)
+		}
+	}
+	if len(kept) == len(result.Issues) {
+		return result
+	}
+
+	filtered := *result
+	filtered.Issues = kept
+	filtered.Summary = r.summarizeIssues(kept)
+	filtered.PlatformComment = r.formatReviewComment(&filtered)
+	return &filtered
+}
+
+// storeReview caches a review result under its content key and records it
+// as the PR's latest review for incremental re-review. Partial reviews
+// with failed chunks are not cached so the next run retries them, and
+// neither are reviews that used the downgrade model: the key names the
+// configured model.
+func (r *DefaultRunner) storeReview(prID int, key ReviewCacheKey, result *ReviewResult) {
+	if len(result.FailedChunks) > 0 {
+		return
+	}
+	if result.Cost != nil && result.Cost.Downgraded != "" {
+		return
+	}
+
+	entry := CachedReview{
+		ConfigHash: key.ConfigHash(),
+		Summary:    result.Summary,
+		Issues:     result.Issues,
+		Resolved:   result.Resolved,
+		Comment:    result.PlatformComment,
+		HeadSHA:    result.HeadSHA,
+		Skills:     result.Skills,
+		Duration:   result.Duration,
+	}
+	r.cache.Set(key.String(), entry)
+	if prID > 0 {
+		r.cache.SetReview(prID, entry)
+	}
+}
+
 // Analyze runs change analysis on a pull/merge request
 func (r *DefaultRunner) Analyze(ctx context.Context, opts AnalyzeOptions) (*AnalyzeResult, error) {
 	start := time.Now()
+	ctx, run := r.startRun(ctx, "analyze", opts.PRID)
+	if err := run.check(); err != nil {
+		return nil, err
+	}
 
 	skills := r.getAnalysisSkills(opts.Skills)
 
@@ -144,6 +313,8 @@ func (r *DefaultRunner) Analyze(ctx context.Context, opts AnalyzeOptions) (*Anal
 		Risk: RiskAssessment{
 			Score: 5, // Default mid-range score
 		},
+		Cost:     run.cost(),
+		Usage:    run.usage(),
 		Duration: time.Since(start),
 	}
 
@@ -153,6 +324,10 @@ func (r *DefaultRunner) Analyze(ctx context.Context, opts AnalyzeOptions) (*Anal
 // GenerateTests generates tests based on code changes
 func (r *DefaultRunner) GenerateTests(ctx context.Context, opts TestGenOptions) (*TestGenResult, error) {
 	start := time.Now()
+	ctx, run := r.startRun(ctx, "test-gen", 0)
+	if err := run.check(); err != nil {
+		return nil, err
+	}
 
 	skills := []string{"test-generator"}
 
@@ -182,6 +357,8 @@ func (r *DefaultRunner) GenerateTests(ctx context.Context, opts TestGenOptions)
 			TotalTests:   estimateTestCount(output),
 			CoverageEst:  "~70-80%",
 		},
+		Cost:     run.cost(),
+		Usage:    run.usage(),
 		Duration: time.Since(start),
 	}
 
@@ -217,13 +394,10 @@ func (r *DefaultRunner) buildDiffContext(diff string, prID int) string {
 func (r *DefaultRunner) executeWithSkill(ctx context.Context, context string, skills []string, operation string) ([]ai.Issue, error) {
 	opts := ai.ExecuteOptions{
 		OutputFormat: r.cfg.Claude.OutputFormat,
-		Timeout:      DefaultTimeout,
+		Timeout:      r.backendTimeout(),
 		Skills:       skills,
-	}
-
-	// Get timeout from config
-	if t, err := r.cfg.Claude.GetTimeout(); err == nil && t > 0 {
-		opts.Timeout = t
+		Operation:    operation,
+		DiffLines:    buildcontext.ChangedLines(context),
 	}
 
 	// Validate prompt
@@ -232,7 +406,7 @@ func (r *DefaultRunner) executeWithSkill(ctx context.Context, context string, sk
 	}
 
 	// Execute
-	output, err := r.aiBrain.Execute(ctx, context, opts)
+	output, err := r.execute(ctx, context, opts, diffPart{lines: opts.DiffLines})
 	if err != nil {
 		return nil, err
 	}
@@ -244,19 +418,17 @@ func (r *DefaultRunner) executeWithSkill(ctx context.Context, context string, sk
 func (r *DefaultRunner) executeRawWithSkill(ctx context.Context, context string, skills []string, operation string) (string, error) {
 	opts := ai.ExecuteOptions{
 		OutputFormat: r.cfg.Claude.OutputFormat,
-		Timeout:      DefaultTimeout,
+		Timeout:      r.backendTimeout(),
 		Skills:       skills,
-	}
-
-	if t, err := r.cfg.Claude.GetTimeout(); err == nil && t > 0 {
-		opts.Timeout = t
+		Operation:    operation,
+		DiffLines:    buildcontext.ChangedLines(context),
 	}
 
 	if err := ai.ValidatePrompt(context, opts); err != nil {
 		return "", fmt.Errorf("prompt validation failed: %w", err)
 	}
 
-	output, err := r.aiBrain.Execute(ctx, context, opts)
+	output, err := r.execute(ctx, context, opts, diffPart{lines: opts.DiffLines})
 	if err != nil {
 		return "", err
 	}
@@ -294,6 +466,12 @@ func (r *DefaultRunner) summarizeIssues(issues []ai.Issue) ReviewSummary {
 
 // formatReviewComment formats the review result as a markdown comment
 func (r *DefaultRunner) formatReviewComment(result *ReviewResult) string {
+	return r.formatSummaryComment(result, result.Issues, 0)
+}
+
+// formatSummaryComment formats the review summary, listing only the given
+// issues; inline is the number of issues already posted as inline comments
+func (r *DefaultRunner) formatSummaryComment(result *ReviewResult, issues []ai.Issue, inline int) string {
 	comment := "## 🔍 Code Review Results\n\n### Summary\n\n"
 	comment += fmt.Sprintf("- **Files Changed**: %d\n", result.Summary.FilesChanged)
 	comment += fmt.Sprintf("- **Total Issues**: %d\n", result.Summary.TotalIssues)
@@ -311,22 +489,54 @@ func (r *DefaultRunner) formatReviewComment(result *ReviewResult) string {
 		comment += fmt.Sprintf("- **🟢 Low**: %d\n", result.Summary.Low)
 	}
 
+	if inline > 0 {
+		comment += fmt.Sprintf("- **Inline Comments**: %d\n", inline)
+	}
+
 	comment += "\n"
 
-	if len(result.Issues) > 0 {
-		comment += "### Issues Found\n\n"
-		for _, issue := range result.Issues {
-			icon := severityIcon(issue.Severity)
-			comment += fmt.Sprintf("%s **%s** - `%s:%d`\n", icon, issue.Category, issue.File, issue.Line)
-			comment += fmt.Sprintf("%s\n\n", issue.Message)
-			if issue.Suggestion != "" {
-				comment += fmt.Sprintf("**Suggestion**: %s\n\n", issue.Suggestion)
-			}
+	if len(result.Skills) > 1 {
+		comment += formatSkillSections(result.Skills, issues, inline > 0)
+	} else if len(issues) > 0 {
+		if inline > 0 {
+			comment += "### Issues Outside the Diff\n\n"
+		} else {
+			comment += "### Issues Found\n\n"
 		}
-	} else {
+		comment += formatIssueList(issues)
+	} else if inline == 0 {
 		comment += "### ✅ No Issues Found\n\nGreat job! No issues were detected.\n\n"
 	}
 
+	if len(result.Resolved) > 0 {
+		comment += fmt.Sprintf("### ✔️ Resolved (%d)\n\n", len(result.Resolved))
+		for _, issue := range result.Resolved {
+			comment += fmt.Sprintf("- ~~**%s** - `%s:%d` %s~~\n", issue.Category, issue.File, issue.Line, issue.Message)
+		}
+		comment += "\n"
+	}
+
+	if len(result.FailedChunks) > 0 {
+		executions := result.Chunks * max(1, len(result.Skills))
+		comment += fmt.Sprintf("### ⚠️ Partial Review\n\n%d of %d review executions failed; findings may be incomplete:\n\n", len(result.FailedChunks), executions)
+		for _, f := range result.FailedChunks {
+			where := fmt.Sprintf("Chunk %d/%d", f.Index, result.Chunks)
+			if f.Skill != "" {
+				where = fmt.Sprintf("`%s` on chunk %d/%d", f.Skill, f.Index, result.Chunks)
+			}
+			comment += fmt.Sprintf("- %s (`%s`): %s\n", where, strings.Join(f.Files, "`, `"), f.Err)
+		}
+		comment += "\n"
+	}
+
+	if result.Cost != nil {
+		comment += formatCostLine(result.Cost)
+	}
+
+	if result.PreviousSHA != "" {
+		comment += fmt.Sprintf("*_Incremental review of changes since `%s`_*\n", shortSHA(result.PreviousSHA))
+	}
+
 	if result.Cached {
 		comment += "*_Results served from cache_*\n"
 	}
@@ -334,6 +544,61 @@ func (r *DefaultRunner) formatReviewComment(result *ReviewResult) string {
 	return comment
 }
 
+// formatIssueList formats issues as a markdown list
+func formatIssueList(issues []ai.Issue) string {
+	var list string
+	for _, issue := range issues {
+		icon := severityIcon(issue.Severity)
+		list += fmt.Sprintf("%s **%s** - `%s:%d`\n", icon, issue.Category, issue.File, issue.Line)
+		list += fmt.Sprintf("%s\n\n", issue.Message)
+		if issue.Suggestion != "" {
+			list += fmt.Sprintf("**Suggestion**: %s\n\n", issue.Suggestion)
+		}
+	}
+	return list
+}
+
+// formatSkillSections formats one result section per skill, in skill
+// priority order, listing the given issues under the skill that found them
+func formatSkillSections(skills []SkillResult, issues []ai.Issue, outsideDiff bool) string {
+	bySkill := make(map[string][]ai.Issue)
+	for _, issue := range issues {
+		bySkill[issue.Skill] = append(bySkill[issue.Skill], issue)
+	}
+
+	var sections string
+	for _, s := range skills {
+		sections += fmt.Sprintf("### 🧩 %s (%d issues, %s)\n\n", s.Name, s.Issues, s.Duration.Round(time.Second))
+		listed := bySkill[s.Name]
+		delete(bySkill, s.Name)
+
+		switch {
+		case s.Err != "":
+			sections += fmt.Sprintf("⚠️ Skill failed: %s\n\n", s.Err)
+		case len(listed) > 0:
+			if outsideDiff {
+				sections += "Issues outside the diff:\n\n"
+			}
+			sections += formatIssueList(listed)
+		case s.Issues == 0:
+			sections += "✅ No issues found.\n\n"
+		}
+	}
+
+	// Findings carried over from earlier reviews may come from other skills
+	var other []ai.Issue
+	for _, issue := range issues {
+		if _, ok := bySkill[issue.Skill]; ok {
+			other = append(other, issue)
+		}
+	}
+	if len(other) > 0 {
+		sections += "### Other Findings\n\n" + formatIssueList(other)
+	}
+
+	return sections
+}
+
 // getReviewSkills returns enabled review skills
 func (r *DefaultRunner) getReviewSkills(requested []string) []string {
 	if len(requested) > 0 {
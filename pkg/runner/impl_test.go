@@ -29,6 +29,9 @@ func (m *mockPlatform) GetFile(ctx context.Context, path, ref string) (string, e
 func (m *mockPlatform) GetPRInfo(ctx context.Context, prID int) (*platform.PRInfo, error) {
 	return &platform.PRInfo{Number: prID}, nil
 }
+func (m *mockPlatform) SetStatus(ctx context.Context, status platform.CommitStatus) error {
+	return nil
+}
 func (m *mockPlatform) Health(ctx context.Context) error { return nil }
 
 func TestNewRunner(t *testing.T) {
@@ -0,0 +1,168 @@
+// Package runner provides incremental re-review of pull requests
+package runner
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/cicd-ai-toolkit/cicd-runner/pkg/ai"
+	"github.com/cicd-ai-toolkit/cicd-runner/pkg/buildcontext"
+)
+
+// resolveHeadSHA resolves the requested head ref to a commit SHA.
+// An empty result disables incremental review.
+func (r *DefaultRunner) resolveHeadSHA(ctx context.Context, ref string) string {
+	if ref == "" || r.builder == nil {
+		return ""
+	}
+	sha, err := r.builder.ResolveRef(ctx, ref)
+	if err != nil {
+		return ""
+	}
+	return sha
+}
+
+// reviewIncremental reviews only the changes between the previously
+// reviewed head and the new head, merging the findings into the prior result
+func (r *DefaultRunner) reviewIncremental(ctx context.Context, opts ReviewOptions, prior CachedReview, headSHA string) (*ReviewResult, error) {
+	delta, err := r.builder.BuildDiff(ctx, buildcontext.DiffOptions{
+		TargetRef: prior.HeadSHA,
+		SourceRef: headSHA,
+	})
+	if err != nil {
+		// The previous head may be unreachable after a force-push
+		return nil, fmt.Errorf("failed to diff against previous head %s: %w", shortSHA(prior.HeadSHA), err)
+	}
+
+	var fresh []ai.Issue
+	var skillResults []SkillResult
+	if strings.TrimSpace(delta) != "" {
+		skills := r.getReviewSkills(opts.Skills)
+		review, err := r.reviewDiff(ctx, delta, opts.PRID, skills)
+		if err != nil {
+			return nil, fmt.Errorf("incremental review execution failed: %w", err)
+		}
+		// Prior findings in unreviewed chunks would be wrongly resolved
+		if len(review.Failed) > 0 {
+			return nil, fmt.Errorf("incremental review incomplete: %d review executions failed", len(review.Failed))
+		}
+		fresh = review.Issues
+		skillResults = review.Skills
+	}
+
+	issues, resolved := mergeIncremental(prior.Issues, delta, fresh)
+
+	return &ReviewResult{
+		Issues:      issues,
+		Resolved:    mergeResolved(prior.Resolved, resolved, issues),
+		Summary:     r.summarizeIssues(issues),
+		HeadSHA:     headSHA,
+		PreviousSHA: prior.HeadSHA,
+		Skills:      skillResults,
+	}, nil
+}
+
+// mergeIncremental carries prior findings forward through the delta diff.
+// Findings on lines that the delta removed or modified, or in files it
+// deleted, are returned as resolved; the remaining findings are re-anchored
+// to the new head and merged with the fresh findings from the delta review.
+func mergeIncremental(prior []ai.Issue, delta string, fresh []ai.Issue) ([]ai.Issue, []ai.Issue) {
+	changed := make(map[string]*buildcontext.FileDiff)
+	parsed := buildcontext.ParseDiff(delta)
+	for i := range parsed {
+		if parsed[i].OldPath != "" {
+			changed[parsed[i].OldPath] = &parsed[i]
+		}
+	}
+
+	var issues, resolved []ai.Issue
+	seen := make(map[string]bool)
+
+	for _, issue := range prior {
+		file, ok := changed[buildcontext.MatchDiffPath(issue.File, func(p string) bool { return changed[p] != nil })]
+		if ok {
+			if file.NewPath == "" {
+				resolved = append(resolved, issue)
+				continue
+			}
+			if issue.Line > 0 {
+				line, ok := file.MapOldLine(issue.Line)
+				if !ok {
+					resolved = append(resolved, issue)
+					continue
+				}
+				issue.Line = line
+			}
+			issue.File = file.NewPath
+		}
+		seen[issueKey(issue)] = true
+		issues = append(issues, issue)
+	}
+
+	// The delta review may re-report a carried finding on unchanged context lines
+	for _, issue := range fresh {
+		key := issueKey(issue)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		issues = append(issues, issue)
+	}
+
+	return issues, resolved
+}
+
+// maxResolved is the number of resolved findings kept across pushes
+const maxResolved = 50
+
+// mergeResolved returns the findings resolved by earlier and by this push in
+// a new slice, so the cached prior result is never modified. Findings that
+// are reported again are no longer resolved, duplicates are dropped and
+// only the maxResolved most recently resolved findings are kept.
+func mergeResolved(prior, resolved, current []ai.Issue) []ai.Issue {
+	open := make(map[string]bool, len(current))
+	for _, issue := range current {
+		open[resolvedKey(issue)] = true
+	}
+
+	// Walk from the most recent, keeping the latest copy of each finding
+	all := append(append([]ai.Issue{}, prior...), resolved...)
+	seen := make(map[string]bool)
+	var kept []ai.Issue
+	for i := len(all) - 1; i >= 0 && len(kept) < maxResolved; i-- {
+		key := resolvedKey(all[i])
+		if open[key] || seen[key] {
+			continue
+		}
+		seen[key] = true
+		kept = append(kept, all[i])
+	}
+
+	// Restore oldest-first order
+	for i, j := 0, len(kept)-1; i < j; i, j = i+1, j-1 {
+		kept[i], kept[j] = kept[j], kept[i]
+	}
+	return kept
+}
+
+// resolvedKey identifies a finding across pushes; unlike issueKey it
+// ignores the line, which moves as the code around it changes
+func resolvedKey(issue ai.Issue) string {
+	return fmt.Sprintf("%s:%s:%s", buildcontext.NormalizeDiffPath(issue.File),
+		strings.ToLower(issue.Category), strings.TrimSpace(issue.Message))
+}
+
+// issueKey identifies a finding for de-duplication
+func issueKey(issue ai.Issue) string {
+	return fmt.Sprintf("%s:%d:%s:%s", buildcontext.NormalizeDiffPath(issue.File), issue.Line,
+		strings.ToLower(issue.Category), strings.TrimSpace(issue.Message))
+}
+
+// shortSHA abbreviates a commit SHA for display
+func shortSHA(sha string) string {
+	if len(sha) > 7 {
+		return sha[:7]
+	}
+	return sha
+}
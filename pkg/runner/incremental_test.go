@@ -0,0 +1,230 @@
+// Package runner provides incremental review tests
+package runner
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/cicd-ai-toolkit/cicd-runner/pkg/ai"
+	"github.com/cicd-ai-toolkit/cicd-runner/pkg/buildcontext"
+	"github.com/cicd-ai-toolkit/cicd-runner/pkg/config"
+)
+
+// fakeBrain returns canned issues and records prompts
+type fakeBrain struct {
+	issues  []ai.Issue
+	prompts []string
+}
+
+func (b *fakeBrain) Execute(ctx context.Context, prompt string, opts ai.ExecuteOptions) (*ai.Output, error) {
+	b.prompts = append(b.prompts, prompt)
+	return &ai.Output{Issues: b.issues}, nil
+}
+func (b *fakeBrain) ExecuteWithSkill(ctx context.Context, prompt string, skill string, opts ai.ExecuteOptions) (*ai.Output, error) {
+	return b.Execute(ctx, prompt, opts)
+}
+func (b *fakeBrain) Validate(ctx context.Context) error { return nil }
+func (b *fakeBrain) Type() ai.BackendType               { return ai.BackendClaude }
+func (b *fakeBrain) Version(ctx context.Context) (string, error) {
+	return "test", nil
+}
+
+func TestMergeIncremental(t *testing.T) {
+	delta := `diff --git a/a.go b/a.go
+--- a/a.go
++++ b/a.go
+@@ -1,3 +1,4 @@
++// header
+ package a
+-var x = 1
++var x = 2
+ func f() {}
+diff --git a/gone.go b/gone.go
+deleted file mode 100644
+--- a/gone.go
++++ /dev/null
+@@ -1 +0,0 @@
+-package gone
+diff --git a/old.go b/new.go
+similarity index 100%
+rename from old.go
+rename to new.go
+`
+	prior := []ai.Issue{
+		{File: "a.go", Line: 2, Message: "bad x"},           // modified line
+		{File: "a.go", Line: 3, Message: "f is unused"},     // shifted by one
+		{File: "gone.go", Line: 1, Message: "dead package"}, // file deleted
+		{File: "old.go", Line: 5, Message: "renamed"},       // file renamed
+		{File: "b.go", Line: 9, Message: "untouched"},       // not in delta
+	}
+	fresh := []ai.Issue{
+		{File: "a.go", Line: 1, Message: "new header"},
+		{File: "b.go", Line: 9, Message: "untouched"}, // duplicate of carried finding
+	}
+
+	issues, resolved := mergeIncremental(prior, delta, fresh)
+
+	if len(resolved) != 2 {
+		t.Fatalf("expected 2 resolved, got %d: %+v", len(resolved), resolved)
+	}
+	if resolved[0].Message != "bad x" || resolved[1].Message != "dead package" {
+		t.Errorf("unexpected resolved issues: %+v", resolved)
+	}
+
+	want := []struct {
+		file string
+		line int
+		msg  string
+	}{
+		{"a.go", 4, "f is unused"},
+		{"new.go", 5, "renamed"},
+		{"b.go", 9, "untouched"},
+		{"a.go", 1, "new header"},
+	}
+	if len(issues) != len(want) {
+		t.Fatalf("expected %d issues, got %d: %+v", len(want), len(issues), issues)
+	}
+	for i, w := range want {
+		if issues[i].File != w.file || issues[i].Line != w.line || issues[i].Message != w.msg {
+			t.Errorf("issue %d = %s:%d %q, want %s:%d %q", i, issues[i].File, issues[i].Line, issues[i].Message, w.file, w.line, w.msg)
+		}
+	}
+}
+
+func TestMergeResolved(t *testing.T) {
+	prior := make([]ai.Issue, 2, 8) // Spare capacity must not be written to
+	prior[0] = ai.Issue{File: "a.go", Line: 3, Message: "bad x"}
+	prior[1] = ai.Issue{File: "b.go", Line: 7, Message: "leak"}
+	resolved := []ai.Issue{
+		{File: "c.go", Line: 1, Message: "typo"},
+		{File: "a.go", Line: 5, Message: "bad x"}, // resolved again
+	}
+	current := []ai.Issue{{File: "b.go", Line: 9, Message: "leak"}} // reported again
+
+	got := mergeResolved(prior, resolved, current)
+	if len(got) != 2 || got[0].Message != "typo" || got[1].Message != "bad x" || got[1].Line != 5 {
+		t.Errorf("mergeResolved() = %+v", got)
+	}
+	if extended := prior[:3]; extended[2].Message != "" {
+		t.Errorf("prior backing array was modified: %+v", extended[2])
+	}
+
+	many := make([]ai.Issue, maxResolved+10)
+	for i := range many {
+		many[i] = ai.Issue{File: "a.go", Message: fmt.Sprintf("finding %d", i)}
+	}
+	if got := mergeResolved(many, nil, nil); len(got) != maxResolved || got[len(got)-1].Message != many[len(many)-1].Message {
+		t.Errorf("mergeResolved() kept %d findings ending with %+v", len(got), got[len(got)-1])
+	}
+}
+
+func TestReviewIncremental(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not found")
+	}
+
+	dir := t.TempDir()
+	git := func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v failed: %v\n%s", args, err, out)
+		}
+	}
+	write := func(content string) {
+		t.Helper()
+		if err := os.WriteFile(filepath.Join(dir, "main.go"), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	git("init", "-q")
+	git("config", "user.email", "test@example.com")
+	git("config", "user.name", "Test")
+	write("package main\nvar secret = \"hunter2\"\nfunc main() {}\n")
+	git("add", ".")
+	git("commit", "-q", "-m", "first")
+	firstSHA := revParse(t, dir)
+	write("package main\nfunc main() {}\nfunc helper() {}\n")
+	git("commit", "-q", "-am", "second")
+
+	cache, err := NewCache(filepath.Join(dir, ".cache"), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	brain := &fakeBrain{}
+	r := &DefaultRunner{
+		cfg:      &config.Config{},
+		platform: &mockPlatform{},
+		builder:  buildcontext.NewBuilder(dir, 0, nil),
+		aiBrain:  brain,
+		cache:    cache,
+	}
+	ctx := context.Background()
+
+	// First review at the first commit
+	brain.issues = []ai.Issue{
+		{Severity: "critical", Category: "security", File: "main.go", Line: 2, Message: "hardcoded secret"},
+		{Severity: "low", Category: "style", File: "main.go", Line: 3, Message: "empty main"},
+	}
+	first, err := r.Review(ctx, ReviewOptions{PRID: 1, Diff: "full diff at first", HeadSHA: firstSHA[:10]})
+	if err != nil {
+		t.Fatalf("first Review() error = %v", err)
+	}
+	if first.HeadSHA != firstSHA || first.PreviousSHA != "" {
+		t.Fatalf("unexpected first review SHAs: head=%q previous=%q", first.HeadSHA, first.PreviousSHA)
+	}
+
+	// Second review at HEAD only sees the delta
+	brain.issues = []ai.Issue{
+		{Severity: "medium", Category: "logic", File: "main.go", Line: 3, Message: "helper is unused"},
+	}
+	second, err := r.Review(ctx, ReviewOptions{PRID: 1, Diff: "full diff at second", HeadSHA: "HEAD"})
+	if err != nil {
+		t.Fatalf("second Review() error = %v", err)
+	}
+
+	if second.Cached || second.PreviousSHA != first.HeadSHA {
+		t.Errorf("expected incremental review since %s, got cached=%v previous=%q", first.HeadSHA, second.Cached, second.PreviousSHA)
+	}
+	lastPrompt := brain.prompts[len(brain.prompts)-1]
+	if strings.Contains(lastPrompt, "full diff") || !strings.Contains(lastPrompt, "helper") {
+		t.Errorf("expected prompt to contain only the delta, got:\n%s", lastPrompt)
+	}
+	if len(second.Resolved) != 1 || second.Resolved[0].Message != "hardcoded secret" {
+		t.Errorf("expected secret finding resolved, got %+v", second.Resolved)
+	}
+	if len(second.Issues) != 2 || second.Issues[0].Line != 2 {
+		t.Errorf("expected carried finding re-anchored to line 2 plus new finding, got %+v", second.Issues)
+	}
+	if !strings.Contains(second.PlatformComment, "Resolved (1)") || !strings.Contains(second.PlatformComment, "Incremental review") {
+		t.Errorf("comment missing incremental sections:\n%s", second.PlatformComment)
+	}
+
+	// Re-running at the same head is served from cache
+	third, err := r.Review(ctx, ReviewOptions{PRID: 1, HeadSHA: "HEAD"})
+	if err != nil {
+		t.Fatalf("third Review() error = %v", err)
+	}
+	if !third.Cached || len(third.Resolved) != 1 {
+		t.Errorf("expected cached result with resolved findings, got cached=%v resolved=%d", third.Cached, len(third.Resolved))
+	}
+}
+
+// revParse returns the HEAD commit SHA of a repository
+func revParse(t *testing.T, dir string) string {
+	t.Helper()
+	cmd := exec.Command("git", "rev-parse", "HEAD")
+	cmd.Dir = dir
+	out, err := cmd.Output()
+	if err != nil {
+		t.Fatalf("git rev-parse failed: %v", err)
+	}
+	return strings.TrimSpace(string(out))
+}
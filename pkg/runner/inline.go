@@ -0,0 +1,155 @@
+// Package runner provides inline review comment posting
+package runner
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/cicd-ai-toolkit/cicd-runner/pkg/ai"
+	"github.com/cicd-ai-toolkit/cicd-runner/pkg/buildcontext"
+	"github.com/cicd-ai-toolkit/cicd-runner/pkg/platform"
+)
+
+// InlineComment is a review issue anchored to a line of the diff
+type InlineComment struct {
+	Issue    ai.Issue
+	Position platform.Position
+}
+
+// InlineResult reports how review issues were posted to the platform
+type InlineResult struct {
+	// Posted is the number of issues posted as inline comments
+	Posted int
+
+	// Fallback contains issues that were included in the summary comment
+	// because they fall outside the diff hunks or failed to post inline
+	Fallback []ai.Issue
+}
+
+// PartitionIssues splits issues into those that can be anchored to a line
+// inside the diff hunks and those that cannot. Only lines present in the
+// new version of a file (added or context lines) can be anchored.
+func PartitionIssues(diff string, issues []ai.Issue, sha string) ([]InlineComment, []ai.Issue) {
+	files := make(map[string]*buildcontext.FileDiff)
+	parsed := buildcontext.ParseDiff(diff)
+	for i := range parsed {
+		if parsed[i].NewPath != "" {
+			files[parsed[i].NewPath] = &parsed[i]
+		}
+	}
+
+	var inline []InlineComment
+	var outside []ai.Issue
+	for _, issue := range issues {
+		path := buildcontext.MatchDiffPath(issue.File, func(p string) bool { return files[p] != nil })
+		file, ok := files[path]
+		if !ok {
+			outside = append(outside, issue)
+			continue
+		}
+
+		line, ok := file.FindLine(issue.Line)
+		if !ok {
+			outside = append(outside, issue)
+			continue
+		}
+
+		inline = append(inline, InlineComment{
+			Issue: issue,
+			Position: platform.Position{
+				Path:         path,
+				Line:         line.NewLine,
+				OldLine:      line.OldLine,
+				SHA:          sha,
+				DiffPosition: line.Position,
+			},
+		})
+	}
+
+	return inline, outside
+}
+
+// PostInlineReview posts each issue that falls inside the diff as an inline
+// comment, then posts a summary comment listing the remaining issues.
+// Anchors are resolved against the platform's view of the PR diff when
+// available, falling back to the reviewed diff.
+func (r *DefaultRunner) PostInlineReview(ctx context.Context, prID int, diff string, result *ReviewResult) (*InlineResult, error) {
+	if prID <= 0 {
+		return nil, fmt.Errorf("PR ID is required for inline comments")
+	}
+
+	if prDiff, err := r.platform.GetDiff(ctx, prID); err == nil && prDiff != "" {
+		diff = prDiff
+	} else if err != nil {
+		log.Printf("[WARNING] failed to fetch PR diff, anchoring to local diff: %v", err)
+	}
+
+	// Resolve the head SHA once rather than per comment
+	sha := ""
+	if info, err := r.platform.GetPRInfo(ctx, prID); err == nil {
+		sha = info.SHA
+	}
+
+	inline, fallback := PartitionIssues(diff, result.Issues, sha)
+
+	posted := 0
+	for _, c := range inline {
+		pos := c.Position
+		err := r.platform.PostComment(ctx, platform.CommentOptions{
+			PRID:     prID,
+			Body:     formatInlineComment(c.Issue),
+			AsReview: true,
+			Position: &pos,
+		})
+		if err != nil {
+			// The platform may reject anchors (e.g. outdated diff); keep the issue in the summary
+			log.Printf("[WARNING] failed to post inline comment on %s:%d: %v", pos.Path, pos.Line, err)
+			fallback = append(fallback, c.Issue)
+			continue
+		}
+		posted++
+	}
+
+	if err := r.platform.PostComment(ctx, platform.CommentOptions{
+		PRID:     prID,
+		Body:     r.formatSummaryComment(result, fallback, posted),
+		AsReview: r.summaryAsReview(),
+	}); err != nil {
+		return nil, fmt.Errorf("failed to post summary comment: %w", err)
+	}
+
+	return &InlineResult{Posted: posted, Fallback: fallback}, nil
+}
+
+// summaryAsReview reports whether summary comments should be posted as a
+// review (GitHub) or a resolvable discussion (GitLab)
+func (r *DefaultRunner) summaryAsReview() bool {
+	switch r.platform.Name() {
+	case "github":
+		return r.cfg.Platform.GitHub.PostAsReview
+	case "gitlab":
+		return r.cfg.Platform.GitLab.MergeRequestDiscussion
+	default:
+		return false
+	}
+}
+
+// formatInlineComment formats a single issue as an inline comment body
+func formatInlineComment(issue ai.Issue) string {
+	comment := fmt.Sprintf("%s **%s**", severityIcon(issue.Severity), issue.Severity)
+	if issue.Category != "" {
+		comment += fmt.Sprintf(" · %s", issue.Category)
+	}
+	if issue.Rule != "" {
+		comment += fmt.Sprintf(" · `%s`", issue.Rule)
+	}
+	if issue.Skill != "" {
+		comment += fmt.Sprintf(" · _%s_", issue.Skill)
+	}
+	comment += fmt.Sprintf("\n\n%s\n", issue.Message)
+	if issue.Suggestion != "" {
+		comment += fmt.Sprintf("\n**Suggestion**: %s\n", issue.Suggestion)
+	}
+	return comment
+}
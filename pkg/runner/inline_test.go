@@ -0,0 +1,134 @@
+// Package runner provides inline comment tests
+package runner
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/cicd-ai-toolkit/cicd-runner/pkg/ai"
+	"github.com/cicd-ai-toolkit/cicd-runner/pkg/config"
+	"github.com/cicd-ai-toolkit/cicd-runner/pkg/platform"
+)
+
+const inlineTestDiff = `diff --git a/auth.go b/auth.go
+--- a/auth.go
++++ b/auth.go
+@@ -10,3 +10,4 @@ func login() {
+ 	user := input()
++	query := "SELECT * FROM users WHERE name = '" + user + "'"
+ 	db.Exec(query)
+ 	return
+`
+
+// recordingPlatform records posted comments
+type recordingPlatform struct {
+	mockPlatform
+	name     string
+	comments []platform.CommentOptions
+	rejectAt map[int]bool // Lines whose inline comments are rejected
+}
+
+func (p *recordingPlatform) Name() string { return p.name }
+func (p *recordingPlatform) GetDiff(ctx context.Context, prID int) (string, error) {
+	return inlineTestDiff, nil
+}
+func (p *recordingPlatform) GetPRInfo(ctx context.Context, prID int) (*platform.PRInfo, error) {
+	return &platform.PRInfo{Number: prID, SHA: "head123"}, nil
+}
+func (p *recordingPlatform) PostComment(ctx context.Context, opts platform.CommentOptions) error {
+	if opts.Position != nil && p.rejectAt[opts.Position.Line] {
+		return fmt.Errorf("line not part of the diff")
+	}
+	p.comments = append(p.comments, opts)
+	return nil
+}
+
+func TestPartitionIssues(t *testing.T) {
+	issues := []ai.Issue{
+		{Severity: "critical", File: "auth.go", Line: 11, Message: "SQL injection"},
+		{Severity: "low", File: "./auth.go", Line: 12, Message: "context line"},
+		{Severity: "medium", File: "auth.go", Line: 40, Message: "outside hunk"},
+		{Severity: "low", File: "other.go", Line: 1, Message: "file not in diff"},
+		{Severity: "low", Message: "no location"},
+	}
+
+	inline, outside := PartitionIssues(inlineTestDiff, issues, "sha")
+
+	if len(inline) != 2 {
+		t.Fatalf("expected 2 inline comments, got %d", len(inline))
+	}
+	if len(outside) != 3 {
+		t.Fatalf("expected 3 fallback issues, got %d", len(outside))
+	}
+
+	added := inline[0].Position
+	if added.Path != "auth.go" || added.Line != 11 || added.OldLine != 0 || added.DiffPosition != 2 || added.SHA != "sha" {
+		t.Errorf("unexpected position for added line: %+v", added)
+	}
+
+	context := inline[1].Position
+	if context.Line != 12 || context.OldLine != 11 || context.DiffPosition != 3 {
+		t.Errorf("unexpected position for context line: %+v", context)
+	}
+}
+
+func TestPostInlineReview(t *testing.T) {
+	p := &recordingPlatform{name: "gitlab", rejectAt: map[int]bool{12: true}}
+	cfg := &config.Config{
+		Platform: config.PlatformConfig{
+			GitLab: config.GitLabConfig{MergeRequestDiscussion: true},
+		},
+	}
+	r := &DefaultRunner{cfg: cfg, platform: p}
+
+	issues := []ai.Issue{
+		{Severity: "critical", Category: "security", File: "auth.go", Line: 11, Message: "SQL injection", Suggestion: "Use placeholders"},
+		{Severity: "low", Category: "style", File: "auth.go", Line: 12, Message: "rejected by platform"},
+		{Severity: "medium", Category: "logic", File: "auth.go", Line: 40, Message: "outside hunk"},
+	}
+	result := &ReviewResult{Issues: issues, Summary: r.summarizeIssues(issues)}
+
+	got, err := r.PostInlineReview(context.Background(), 5, "", result)
+	if err != nil {
+		t.Fatalf("PostInlineReview() error = %v", err)
+	}
+
+	if got.Posted != 1 || len(got.Fallback) != 2 {
+		t.Errorf("Posted = %d, Fallback = %d; want 1, 2", got.Posted, len(got.Fallback))
+	}
+
+	if len(p.comments) != 2 {
+		t.Fatalf("expected 2 posted comments, got %d", len(p.comments))
+	}
+
+	inline := p.comments[0]
+	if inline.Position == nil || inline.Position.SHA != "head123" {
+		t.Errorf("expected inline comment anchored to head SHA, got %+v", inline.Position)
+	}
+	if !strings.Contains(inline.Body, "SQL injection") || !strings.Contains(inline.Body, "Use placeholders") {
+		t.Errorf("unexpected inline body: %s", inline.Body)
+	}
+
+	summary := p.comments[1]
+	if summary.Position != nil || !summary.AsReview {
+		t.Errorf("expected summary posted as discussion without position: %+v", summary)
+	}
+	for _, want := range []string{"Inline Comments**: 1", "Issues Outside the Diff", "rejected by platform", "outside hunk"} {
+		if !strings.Contains(summary.Body, want) {
+			t.Errorf("summary missing %q:\n%s", want, summary.Body)
+		}
+	}
+	if strings.Contains(summary.Body, "SQL injection") {
+		t.Error("summary should not repeat issues posted inline")
+	}
+}
+
+func TestPostInlineReview_RequiresPRID(t *testing.T) {
+	r := &DefaultRunner{cfg: &config.Config{}, platform: &recordingPlatform{name: "github"}}
+
+	if _, err := r.PostInlineReview(context.Background(), 0, inlineTestDiff, &ReviewResult{}); err == nil {
+		t.Error("expected error without PR ID")
+	}
+}
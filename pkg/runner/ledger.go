@@ -0,0 +1,235 @@
+// Package runner provides the AI spend ledger
+package runner
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/cicd-ai-toolkit/cicd-runner/pkg/config"
+)
+
+// LedgerFileName is the name of the spend ledger in the cache directory.
+// It is kept when the review cache is cleared.
+const LedgerFileName = "budget-ledger.jsonl"
+
+// LedgerEntry records the spend of one AI execution
+type LedgerEntry struct {
+	Time         time.Time `json:"time"`
+	RunID        string    `json:"run_id"`
+	Repo         string    `json:"repo"`
+	PRID         int       `json:"pr,omitempty"`
+	Operation    string    `json:"operation"`
+	Skills       []string  `json:"skills,omitempty"`
+	Backend      string    `json:"backend,omitempty"`
+	Model        string    `json:"model,omitempty"`
+	InputTokens  int       `json:"input_tokens"`
+	OutputTokens int       `json:"output_tokens"`
+	CostUSD      float64   `json:"cost_usd"`
+}
+
+// Day returns the UTC day of the entry in YYYY-MM-DD format
+func (e LedgerEntry) Day() string {
+	return e.Time.UTC().Format("2006-01-02")
+}
+
+// LedgerTotal aggregates ledger entries sharing a key
+type LedgerTotal struct {
+	Key          string
+	Runs         int
+	Executions   int
+	InputTokens  int
+	OutputTokens int
+	CostUSD      float64
+}
+
+// Ledger is an append-only JSON Lines file of AI executions and their cost.
+// Appends are single writes to a file opened with O_APPEND, so concurrent
+// runs sharing a cache directory do not interleave entries.
+type Ledger struct {
+	path string
+	mu   sync.Mutex
+}
+
+// NewLedger creates a ledger stored in dir
+func NewLedger(dir string) *Ledger {
+	return &Ledger{path: filepath.Join(dir, LedgerFileName)}
+}
+
+// NewLedgerFromConfig creates the ledger in the cache directory configured
+// in cfg, relative to baseDir
+func NewLedgerFromConfig(cfg *config.Config, baseDir string) *Ledger {
+	return NewLedger(filepath.Join(baseDir, cfg.Global.CacheDir))
+}
+
+// Path returns the ledger file path
+func (l *Ledger) Path() string {
+	return l.path
+}
+
+// Append records an execution
+func (l *Ledger) Append(entry LedgerEntry) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("failed to marshal ledger entry: %w", err)
+	}
+	data = append(data, '\n')
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if err := os.MkdirAll(filepath.Dir(l.path), 0755); err != nil {
+		return fmt.Errorf("failed to create ledger directory: %w", err)
+	}
+	f, err := os.OpenFile(l.path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, CacheFilePermissions)
+	if err != nil {
+		return fmt.Errorf("failed to open ledger: %w", err)
+	}
+	defer f.Close()
+
+	if _, err := f.Write(data); err != nil {
+		return fmt.Errorf("failed to write ledger: %w", err)
+	}
+	return nil
+}
+
+// Entries returns the recorded executions at or after since, oldest first.
+// Malformed lines are skipped. A missing ledger has no entries.
+func (l *Ledger) Entries(since time.Time) ([]LedgerEntry, error) {
+	var entries []LedgerEntry
+	err := l.scan(nil, func(e LedgerEntry) {
+		if !e.Time.Before(since) {
+			entries = append(entries, e)
+		}
+	})
+	return entries, err
+}
+
+// Spend returns what was spent in a repository on a PR, across days, and on
+// a UTC day (YYYY-MM-DD). Only the lines of that PR or day are decoded and
+// no entries are kept, so the cost does not grow with the memory of the
+// whole ledger. prID 0 counts the day only.
+func (l *Ledger) Spend(repo string, prID int, day string) (prUSD, dayUSD float64, err error) {
+	// Entries are marshaled with these fields in this order, and record
+	// writes their time in UTC
+	repoJSON, _ := json.Marshal(repo)
+	repoNeedle := []byte(`"repo":` + string(repoJSON) + `,`)
+	prNeedle := []byte(`"pr":` + strconv.Itoa(prID) + `,`)
+	dayNeedle := []byte(`"time":"` + day)
+	keep := func(line []byte) bool {
+		if !bytes.Contains(line, repoNeedle) {
+			return false
+		}
+		return bytes.Contains(line, dayNeedle) || (prID > 0 && bytes.Contains(line, prNeedle))
+	}
+
+	err = l.scan(keep, func(e LedgerEntry) {
+		if e.Repo != repo {
+			return
+		}
+		if prID > 0 && e.PRID == prID {
+			prUSD += e.CostUSD
+		}
+		if e.Day() == day {
+			dayUSD += e.CostUSD
+		}
+	})
+	return prUSD, dayUSD, err
+}
+
+// scan calls fn with the entries of the ledger, oldest first. Lines keep
+// rejects are skipped before they are decoded; nil keeps every line.
+// Malformed lines are skipped. A missing ledger has no entries.
+func (l *Ledger) scan(keep func(line []byte) bool, fn func(LedgerEntry)) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	f, err := os.Open(l.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return fmt.Errorf("failed to open ledger: %w", err)
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		if keep != nil && !keep(scanner.Bytes()) {
+			continue
+		}
+		var e LedgerEntry
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			continue
+		}
+		fn(e)
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read ledger: %w", err)
+	}
+	return nil
+}
+
+// SummarizeLedger aggregates entries by run, pr, repo or day. Totals are
+// ordered by key, except that days are listed newest first.
+func SummarizeLedger(entries []LedgerEntry, by string) ([]LedgerTotal, error) {
+	var keyOf func(LedgerEntry) string
+	switch by {
+	case "run":
+		keyOf = func(e LedgerEntry) string { return e.RunID }
+	case "pr":
+		keyOf = func(e LedgerEntry) string {
+			if e.PRID == 0 {
+				return e.Repo
+			}
+			return e.Repo + "#" + strconv.Itoa(e.PRID)
+		}
+	case "repo":
+		keyOf = func(e LedgerEntry) string { return e.Repo }
+	case "day":
+		keyOf = LedgerEntry.Day
+	default:
+		return nil, fmt.Errorf("unsupported grouping %q (expected run, pr, repo or day)", by)
+	}
+
+	totals := make(map[string]*LedgerTotal)
+	runs := make(map[string]map[string]bool)
+	for _, e := range entries {
+		key := keyOf(e)
+		t, ok := totals[key]
+		if !ok {
+			t = &LedgerTotal{Key: key}
+			totals[key] = t
+			runs[key] = make(map[string]bool)
+		}
+		t.Executions++
+		t.InputTokens += e.InputTokens
+		t.OutputTokens += e.OutputTokens
+		t.CostUSD += e.CostUSD
+		if !runs[key][e.RunID] {
+			runs[key][e.RunID] = true
+			t.Runs++
+		}
+	}
+
+	result := make([]LedgerTotal, 0, len(totals))
+	for _, t := range totals {
+		result = append(result, *t)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if by == "day" {
+			return result[i].Key > result[j].Key
+		}
+		return result[i].Key < result[j].Key
+	})
+
+	return result, nil
+}
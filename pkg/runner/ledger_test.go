@@ -0,0 +1,139 @@
+// Package runner provides budget ledger tests
+package runner
+
+import (
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestLedgerAppendAndSummarize(t *testing.T) {
+	dir := t.TempDir()
+	ledger := NewLedger(dir)
+
+	day1 := time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)
+	day2 := day1.Add(24 * time.Hour)
+	entries := []LedgerEntry{
+		{Time: day1, RunID: "r1", Repo: "acme/api", PRID: 1, InputTokens: 100, OutputTokens: 10, CostUSD: 0.10},
+		{Time: day1, RunID: "r1", Repo: "acme/api", PRID: 1, InputTokens: 200, OutputTokens: 20, CostUSD: 0.20},
+		{Time: day2, RunID: "r2", Repo: "acme/api", PRID: 2, CostUSD: 0.50},
+		{Time: day2, RunID: "r3", Repo: "acme/web", CostUSD: 1.00},
+	}
+	for _, e := range entries {
+		if err := ledger.Append(e); err != nil {
+			t.Fatalf("Append() error = %v", err)
+		}
+	}
+
+	// Malformed lines are skipped
+	f, err := os.OpenFile(filepath.Join(dir, LedgerFileName), os.O_WRONLY|os.O_APPEND, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.WriteString("not json\n")
+	f.Close()
+
+	all, err := ledger.Entries(time.Time{})
+	if err != nil || len(all) != 4 {
+		t.Fatalf("Entries() = %d entries, %v", len(all), err)
+	}
+	recent, _ := ledger.Entries(day2)
+	if len(recent) != 2 {
+		t.Errorf("Entries(since) = %d entries, want 2", len(recent))
+	}
+
+	tests := []struct {
+		by       string
+		wantKeys []string
+		wantRuns []int
+	}{
+		{"day", []string{"2026-03-02", "2026-03-01"}, []int{2, 1}},
+		{"repo", []string{"acme/api", "acme/web"}, []int{2, 1}},
+		{"pr", []string{"acme/api#1", "acme/api#2", "acme/web"}, []int{1, 1, 1}},
+		{"run", []string{"r1", "r2", "r3"}, []int{1, 1, 1}},
+	}
+	for _, tt := range tests {
+		totals, err := SummarizeLedger(all, tt.by)
+		if err != nil {
+			t.Fatalf("SummarizeLedger(%s) error = %v", tt.by, err)
+		}
+		if len(totals) != len(tt.wantKeys) {
+			t.Fatalf("SummarizeLedger(%s) = %+v", tt.by, totals)
+		}
+		for i, total := range totals {
+			if total.Key != tt.wantKeys[i] || total.Runs != tt.wantRuns[i] {
+				t.Errorf("SummarizeLedger(%s)[%d] = %+v", tt.by, i, total)
+			}
+		}
+	}
+
+	byRepo, _ := SummarizeLedger(all, "repo")
+	if api := byRepo[0]; api.Executions != 3 || api.InputTokens != 300 || api.CostUSD < 0.79 || api.CostUSD > 0.81 {
+		t.Errorf("acme/api total = %+v", api)
+	}
+
+	if _, err := SummarizeLedger(all, "week"); err == nil {
+		t.Error("SummarizeLedger() should reject unknown groupings")
+	}
+}
+
+func TestLedgerSpend(t *testing.T) {
+	ledger := NewLedger(t.TempDir())
+	day1 := time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)
+	day2 := day1.Add(24 * time.Hour)
+	for _, e := range []LedgerEntry{
+		{Time: day1, Repo: "acme/api", PRID: 1, CostUSD: 0.10},
+		{Time: day2, Repo: "acme/api", PRID: 1, CostUSD: 0.20},
+		{Time: day2, Repo: "acme/api", PRID: 12, CostUSD: 0.40},
+		{Time: day2, Repo: "acme/api-v2", PRID: 1, CostUSD: 0.80},
+		{Time: day1, Repo: "acme/api", PRID: 2, CostUSD: 1.60},
+	} {
+		if err := ledger.Append(e); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		prID    int
+		day     string
+		wantPR  float64
+		wantDay float64
+	}{
+		{1, "2026-03-02", 0.30, 0.60},
+		{0, "2026-03-01", 0, 1.70},
+		{3, "2026-03-03", 0, 0},
+	}
+	for _, tt := range tests {
+		pr, day, err := ledger.Spend("acme/api", tt.prID, tt.day)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if math.Abs(pr-tt.wantPR) > 1e-9 || math.Abs(day-tt.wantDay) > 1e-9 {
+			t.Errorf("Spend(#%d, %s) = %v, %v; want %v, %v", tt.prID, tt.day, pr, day, tt.wantPR, tt.wantDay)
+		}
+	}
+}
+
+func TestCacheClearKeepsLedger(t *testing.T) {
+	dir := t.TempDir()
+	cache, err := NewCache(dir, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cache.Set("0123456789abcdef", CachedReview{})
+	if err := NewLedger(dir).Append(LedgerEntry{Time: time.Now(), CostUSD: 1}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := cache.Clear(); err != nil {
+		t.Fatalf("Clear() error = %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, LedgerFileName)); err != nil {
+		t.Errorf("ledger removed by Clear(): %v", err)
+	}
+	if stats, _ := cache.Stats(); stats.Entries != 0 {
+		t.Errorf("Clear() left %d entries", stats.Entries)
+	}
+}
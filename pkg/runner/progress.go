@@ -0,0 +1,140 @@
+// Package runner provides live progress reporting of AI executions
+package runner
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cicd-ai-toolkit/cicd-runner/pkg/ai"
+)
+
+// SetProgress sets the function receiving progress events of AI executions.
+// It is called concurrently when skills or chunks run in parallel.
+func (r *DefaultRunner) SetProgress(fn ai.ProgressFunc) {
+	r.progress = fn
+}
+
+// progressReporter returns a function that stamps progress events with the
+// execution they belong to and passes them to the runner's progress function
+func (r *DefaultRunner) progressReporter(opts ai.ExecuteOptions, part diffPart) ai.ProgressFunc {
+	if r.progress == nil {
+		return func(ai.ProgressEvent) {}
+	}
+	skill := strings.Join(opts.Skills, ",")
+	return func(event ai.ProgressEvent) {
+		if event.Time.IsZero() {
+			event.Time = time.Now()
+		}
+		event.Operation = opts.Operation
+		event.Skill = skill
+		if part.index > 0 {
+			event.Chunk, event.Chunks = part.index, part.total
+		}
+		r.progress(event)
+	}
+}
+
+// doneEvent summarizes a finished execution
+func doneEvent(output *ai.Output, elapsed time.Duration) ai.ProgressEvent {
+	event := ai.ProgressEvent{
+		Type:       ai.ProgressDone,
+		Backend:    string(output.Backend),
+		Issues:     len(output.Issues),
+		DurationMS: elapsed.Milliseconds(),
+	}
+	if output.TokensUsed != nil {
+		event.Tokens = output.TokensUsed.OutputTokens
+		event.CostUSD = output.TokensUsed.CostUSD
+	}
+	return event
+}
+
+// ProgressWriter prints progress events as one line each, so long reviews
+// show activity in CI logs, and optionally records every event as JSON Lines
+type ProgressWriter struct {
+	mu     sync.Mutex
+	out    io.Writer // Human-readable lines; nil to disable
+	events io.Writer // JSON Lines; nil to disable
+	start  time.Time
+}
+
+// NewProgressWriter creates a progress writer. Either writer may be nil.
+func NewProgressWriter(out, events io.Writer) *ProgressWriter {
+	return &ProgressWriter{out: out, events: events, start: time.Now()}
+}
+
+// Handle writes a progress event; it is an ai.ProgressFunc
+func (w *ProgressWriter) Handle(event ai.ProgressEvent) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.events != nil {
+		if data, err := json.Marshal(event); err == nil {
+			_, _ = w.events.Write(append(data, '\n'))
+		}
+	}
+
+	if w.out != nil {
+		if line := w.format(event); line != "" {
+			fmt.Fprintln(w.out, line)
+		}
+	}
+}
+
+// format renders an event as a log line, or "" for events not shown
+func (w *ProgressWriter) format(event ai.ProgressEvent) string {
+	elapsed := event.Time.Sub(w.start)
+	if elapsed < 0 {
+		elapsed = 0
+	}
+	prefix := fmt.Sprintf("[%02d:%02d] %s", int(elapsed.Minutes()), int(elapsed.Seconds())%60, progressLabel(event))
+	took := (time.Duration(event.DurationMS) * time.Millisecond).Round(time.Second)
+
+	switch event.Type {
+	case ai.ProgressStart:
+		return prefix + ": started"
+	case ai.ProgressToolUse:
+		if event.Detail != "" {
+			return fmt.Sprintf("%s: %s %s", prefix, event.Tool, event.Detail)
+		}
+		return fmt.Sprintf("%s: %s", prefix, event.Tool)
+	case ai.ProgressThinking:
+		return prefix + ": thinking"
+	case ai.ProgressTokens:
+		return fmt.Sprintf("%s: ~%d tokens so far", prefix, event.Tokens)
+	case ai.ProgressDone:
+		details := []string{fmt.Sprintf("%d issues", event.Issues)}
+		if event.Tokens > 0 {
+			details = append(details, fmt.Sprintf("%d tokens", event.Tokens))
+		}
+		if event.CostUSD > 0 {
+			details = append(details, fmt.Sprintf("$%.4f", event.CostUSD))
+		}
+		if event.Backend != "" {
+			details = append(details, event.Backend)
+		}
+		return fmt.Sprintf("%s: done in %s (%s)", prefix, took, strings.Join(details, ", "))
+	case ai.ProgressError:
+		return fmt.Sprintf("%s: failed after %s: %s", prefix, took, event.Error)
+	case ai.ProgressRetry:
+		return fmt.Sprintf("%s: %s, retrying in %s", prefix, event.Error, took)
+	default:
+		return ""
+	}
+}
+
+// progressLabel names the execution an event belongs to
+func progressLabel(event ai.ProgressEvent) string {
+	label := event.Skill
+	if label == "" {
+		label = event.Operation
+	}
+	if event.Chunk > 0 {
+		label += fmt.Sprintf(" (chunk %d/%d)", event.Chunk, event.Chunks)
+	}
+	return label
+}
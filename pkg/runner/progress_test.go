@@ -0,0 +1,94 @@
+// Package runner provides progress reporting tests
+package runner
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/cicd-ai-toolkit/cicd-runner/pkg/ai"
+	"github.com/cicd-ai-toolkit/cicd-runner/pkg/buildcontext"
+	"github.com/cicd-ai-toolkit/cicd-runner/pkg/config"
+)
+
+func TestReviewReportsProgress(t *testing.T) {
+	r := &DefaultRunner{
+		cfg:      &config.Config{Global: config.GlobalConfig{ParallelSkills: 2, MaxChunkKB: 1}},
+		platform: &mockPlatform{},
+		builder:  buildcontext.NewBuilder(".", 0, nil),
+		aiBrain:  &chunkBrain{failFile: "b.go"},
+	}
+
+	var mu sync.Mutex
+	var events []ai.ProgressEvent
+	r.SetProgress(func(e ai.ProgressEvent) {
+		mu.Lock()
+		events = append(events, e)
+		mu.Unlock()
+	})
+
+	if _, err := r.reviewDiff(context.Background(), chunkTestDiff("a.go", "b.go"), 0, []string{"code-reviewer"}); err != nil {
+		t.Fatalf("reviewDiff() error = %v", err)
+	}
+
+	var got []string
+	for _, e := range events {
+		if e.Operation != "review" || e.Skill != "code-reviewer" || e.Chunks != 2 || e.Time.IsZero() {
+			t.Errorf("event not stamped: %+v", e)
+		}
+		got = append(got, fmt.Sprintf("%s %d", e.Type, e.Chunk))
+		if e.Type == ai.ProgressDone && e.Issues != 2 {
+			t.Errorf("done event issues = %d, want 2", e.Issues)
+		}
+		if e.Type == ai.ProgressError && !strings.Contains(e.Error, "rate_limit") {
+			t.Errorf("error event = %+v", e)
+		}
+	}
+	sort.Strings(got)
+	if strings.Join(got, ",") != "done 1,error 2,start 1,start 2" {
+		t.Errorf("events = %v", got)
+	}
+}
+
+func TestProgressWriter(t *testing.T) {
+	var out, events bytes.Buffer
+	w := NewProgressWriter(&out, &events)
+	at := func(d time.Duration) time.Time { return w.start.Add(d) }
+
+	w.Handle(ai.ProgressEvent{Time: at(0), Type: ai.ProgressStart, Operation: "review", Skill: "code-reviewer", Chunk: 1, Chunks: 2})
+	w.Handle(ai.ProgressEvent{Time: at(3 * time.Second), Type: ai.ProgressToolUse, Skill: "code-reviewer", Tool: "Read", Detail: "a.go"})
+	w.Handle(ai.ProgressEvent{Time: at(12 * time.Second), Type: ai.ProgressTokens, Skill: "code-reviewer", Tokens: 500})
+	w.Handle(ai.ProgressEvent{Time: at(75 * time.Second), Type: ai.ProgressDone, Operation: "analyze", Issues: 3, Tokens: 900, CostUSD: 0.0125, Backend: "claude", DurationMS: 75000})
+	w.Handle(ai.ProgressEvent{Time: at(80 * time.Second), Type: ai.ProgressError, Operation: "test-gen", Error: "timeout", DurationMS: 4600})
+	w.Handle(ai.ProgressEvent{Time: at(81 * time.Second), Type: ai.ProgressRetry, Operation: "test-gen", Error: "timeout", DurationMS: 2000})
+
+	want := []string{
+		"[00:00] code-reviewer (chunk 1/2): started",
+		"[00:03] code-reviewer: Read a.go",
+		"[00:12] code-reviewer: ~500 tokens so far",
+		"[01:15] analyze: done in 1m15s (3 issues, 900 tokens, $0.0125, claude)",
+		"[01:20] test-gen: failed after 5s: timeout",
+		"[01:21] test-gen: timeout, retrying in 2s",
+	}
+	if got := strings.TrimSpace(out.String()); got != strings.Join(want, "\n") {
+		t.Errorf("output =\n%s\nwant\n%s", got, strings.Join(want, "\n"))
+	}
+
+	lines := strings.Split(strings.TrimSpace(events.String()), "\n")
+	if len(lines) != 6 {
+		t.Fatalf("events file has %d lines, want 6", len(lines))
+	}
+	var first ai.ProgressEvent
+	if err := json.Unmarshal([]byte(lines[0]), &first); err != nil {
+		t.Fatalf("invalid JSON line: %v", err)
+	}
+	if first.Type != ai.ProgressStart || first.Chunk != 1 || first.Skill != "code-reviewer" {
+		t.Errorf("first event = %+v", first)
+	}
+}
@@ -31,7 +31,8 @@ type ReviewOptions struct {
 	BaseSHA string
 	HeadSHA string
 	Skills  []string
-	Force   bool // Skip cache
+	Force   bool     // Skip cache and incremental review
+	Ignore  []string // Rules whose issues are left out of the result
 }
 
 // AnalyzeOptions contains options for change analysis
@@ -60,12 +61,38 @@ type ReviewResult struct {
 	// Issues contains all found issues
 	Issues []ai.Issue
 
+	// Resolved contains prior findings whose code was changed or removed
+	// since the last reviewed head
+	Resolved []ai.Issue
+
 	// PlatformComment is the formatted comment for PR
 	PlatformComment string
 
 	// Cached indicates if result was from cache
 	Cached bool
 
+	// HeadSHA is the head commit covered by this review
+	HeadSHA string
+
+	// PreviousSHA is the previously reviewed head when the review is incremental
+	PreviousSHA string
+
+	// Chunks is the number of chunks the diff was reviewed in
+	Chunks int
+
+	// FailedChunks describes failed skill executions; their files were not
+	// reviewed by that skill
+	FailedChunks []ChunkFailure
+
+	// Skills contains per-skill results, ordered by skill priority
+	Skills []SkillResult
+
+	// Cost is the AI spend of this review; nil when no AI execution ran
+	Cost *RunCost
+
+	// Usage is the AI usage of this review; nil when no AI execution ran
+	Usage *RunUsage
+
 	// Duration is how long the review took
 	Duration time.Duration
 }
@@ -87,6 +114,8 @@ type AnalyzeResult struct {
 	Risk        RiskAssessment
 	Changelog   ChangelogEntry
 	Suggestions []string
+	Cost        *RunCost  // AI spend; nil when no AI execution ran
+	Usage       *RunUsage // AI usage; nil when no AI execution ran
 	Duration    time.Duration
 }
 
@@ -129,6 +158,8 @@ type ChangelogEntry struct {
 type TestGenResult struct {
 	TestFiles []GeneratedTest
 	Summary   TestGenSummary
+	Cost      *RunCost  // AI spend; nil when no AI execution ran
+	Usage     *RunUsage // AI usage; nil when no AI execution ran
 	Duration  time.Duration
 }
 
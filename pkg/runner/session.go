@@ -0,0 +1,37 @@
+// Package runner provides multi-turn conversations across runs on a PR
+package runner
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/cicd-ai-toolkit/cicd-runner/pkg/ai"
+	"github.com/cicd-ai-toolkit/cicd-runner/pkg/claude"
+)
+
+// sessionStatter is implemented by backends that keep a session pool
+type sessionStatter interface {
+	SessionStats() (claude.PoolStats, bool)
+}
+
+// conversationKey identifies the conversation of an operation and its skills
+// on a PR. Executions on the PR pass it to the backend, so with sessions
+// enabled later runs resume the conversation and the model sees what it
+// reported before. Each chunk of a chunked diff is its own conversation, so
+// chunks reviewed in parallel do not wait for each other's session.
+func conversationKey(repo string, prID int, opts ai.ExecuteOptions, part diffPart) string {
+	key := fmt.Sprintf("%s#%d/%s/%s", repo, prID, opts.Operation, strings.Join(opts.Skills, ","))
+	if part.index > 0 {
+		key += fmt.Sprintf("/chunk-%d-of-%d", part.index, part.total)
+	}
+	return key
+}
+
+// SessionStats returns the statistics of the AI backend's session pool, or
+// false if the backend keeps no sessions
+func (r *DefaultRunner) SessionStats() (claude.PoolStats, bool) {
+	if s, ok := r.aiBrain.(sessionStatter); ok {
+		return s.SessionStats()
+	}
+	return claude.PoolStats{}, false
+}
@@ -0,0 +1,54 @@
+// Package runner provides conversation tests
+package runner
+
+import (
+	"context"
+	"testing"
+
+	"github.com/cicd-ai-toolkit/cicd-runner/pkg/buildcontext"
+	"github.com/cicd-ai-toolkit/cicd-runner/pkg/config"
+)
+
+func TestReviewConversationPerPR(t *testing.T) {
+	brain := &costBrain{}
+	r := newBudgetRunner(t, brain, config.BudgetConfig{})
+
+	diff := "diff --git a/a.go b/a.go\n"
+	if _, err := r.Review(context.Background(), ReviewOptions{PRID: 7, Diff: diff, Skills: []string{"code-reviewer"}}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.Review(context.Background(), ReviewOptions{Diff: diff + "+x\n", Skills: []string{"code-reviewer"}}); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := brain.opts[0].Conversation; got != "acme/widgets#7/review/code-reviewer" {
+		t.Errorf("Conversation = %q", got)
+	}
+	// Reviews outside a PR have no conversation to resume
+	if got := brain.opts[1].Conversation; got != "" {
+		t.Errorf("Conversation without PR = %q, want empty", got)
+	}
+
+	if _, ok := r.SessionStats(); ok {
+		t.Error("SessionStats() of a backend without sessions should report false")
+	}
+}
+
+func TestReviewConversationPerChunk(t *testing.T) {
+	brain := &costBrain{}
+	r := newBudgetRunner(t, brain, config.BudgetConfig{})
+	r.cfg.Global.MaxChunkKB = 1
+	r.builder = buildcontext.NewBuilder(".", 0, nil)
+
+	if _, err := r.Review(context.Background(), ReviewOptions{PRID: 7, Diff: chunkTestDiff("a.go", "b.go"), Skills: []string{"code-reviewer"}}); err != nil {
+		t.Fatal(err)
+	}
+
+	keys := make(map[string]bool)
+	for _, opts := range brain.opts {
+		keys[opts.Conversation] = true
+	}
+	if len(brain.opts) != 2 || !keys["acme/widgets#7/review/code-reviewer/chunk-1-of-2"] || !keys["acme/widgets#7/review/code-reviewer/chunk-2-of-2"] {
+		t.Errorf("conversations = %v", keys)
+	}
+}
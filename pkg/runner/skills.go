@@ -0,0 +1,178 @@
+// Package runner provides independent per-skill execution
+package runner
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/cicd-ai-toolkit/cicd-runner/pkg/ai"
+)
+
+// SkillResult is the outcome of one skill's executions during a review
+type SkillResult struct {
+	Name     string
+	Priority int
+	Issues   int           // Issues reported by the skill after de-duplication
+	Duration time.Duration // Total execution time across diff chunks
+	Failed   int           // Failed executions (one per diff chunk)
+	Err      string        // Set when every execution of the skill failed
+}
+
+// diffPart identifies the part of a diff an execution works on
+type diffPart struct {
+	index int // 1-based chunk number; 0 when the diff is not chunked
+	total int // Number of chunks
+	lines int // Changed lines in the part
+}
+
+// executeSkill runs a single skill as an independent review execution with
+// its own budget and timeout, tagging the issues it reports with the skill
+// name. An empty name runs the prompt without a skill.
+func (r *DefaultRunner) executeSkill(ctx context.Context, prompt, name string, part diffPart) ([]ai.Issue, error) {
+	opts := r.skillExecuteOptions(name)
+	opts.Operation = "review"
+	opts.DiffLines = part.lines
+
+	if err := ai.ValidatePrompt(prompt, opts); err != nil {
+		return nil, fmt.Errorf("prompt validation failed: %w", err)
+	}
+
+	output, err := r.execute(ctx, prompt, opts, part)
+	if err != nil {
+		return nil, err
+	}
+
+	issues := make([]ai.Issue, len(output.Issues))
+	for i, issue := range output.Issues {
+		issue.Skill = name
+		issues[i] = issue
+	}
+	return issues, nil
+}
+
+// execute runs a prompt on the AI backend within the budget of the run
+// carried by ctx, records the spend in the ledger and reports progress
+func (r *DefaultRunner) execute(ctx context.Context, prompt string, opts ai.ExecuteOptions, part diffPart) (*ai.Output, error) {
+	report := r.progressReporter(opts, part)
+	start := time.Now()
+
+	run := budgetRunFrom(ctx)
+	var held float64
+	if run != nil {
+		var err error
+		if held, err = run.admit(&opts); err != nil {
+			report(ai.ProgressEvent{Type: ai.ProgressError, Error: err.Error()})
+			return nil, err
+		}
+	}
+
+	if r.progress != nil {
+		opts.Progress = report
+	}
+	if run != nil && run.prID > 0 {
+		opts.Conversation = conversationKey(run.repo, run.prID, opts, part)
+	}
+	report(ai.ProgressEvent{Type: ai.ProgressStart})
+
+	var output *ai.Output
+	policy := r.retry
+	policy.OnRetry = func(attempt int, delay time.Duration, err error) {
+		report(ai.ProgressEvent{Type: ai.ProgressRetry, Error: err.Error(), DurationMS: delay.Milliseconds()})
+	}
+	err := policy.Do(ctx, string(r.aiBrain.Type()), opts.Operation, func() error {
+		var err error
+		output, err = r.aiBrain.Execute(ctx, prompt, opts)
+		return err
+	})
+	if err != nil {
+		if run != nil {
+			run.release(held)
+		}
+		report(ai.ProgressEvent{Type: ai.ProgressError, Error: err.Error(), DurationMS: time.Since(start).Milliseconds()})
+		return nil, err
+	}
+
+	if run != nil {
+		run.record(opts, output, held)
+	}
+	report(doneEvent(output, time.Since(start)))
+
+	return output, nil
+}
+
+// skillExecuteOptions returns the execution options for a skill. Limits
+// declared in the skill's SKILL.md override the global backend settings,
+// and the skill's entry in the config file overrides both.
+func (r *DefaultRunner) skillExecuteOptions(name string) ai.ExecuteOptions {
+	opts := ai.ExecuteOptions{
+		OutputFormat: r.cfg.Claude.OutputFormat,
+		Timeout:      r.backendTimeout(),
+	}
+
+	if name == "" {
+		return opts
+	}
+	opts.Skills = []string{name}
+
+	if r.skillLoader != nil {
+		if s, err := r.skillLoader.Load(name); err == nil {
+			opts.MaxTurns = s.Options.MaxTurns
+			opts.MaxBudgetUSD = s.Options.BudgetUSD
+		}
+	}
+
+	if sc, ok := r.cfg.GetSkill(name); ok {
+		if t, err := time.ParseDuration(sc.Timeout); err == nil && t > 0 {
+			opts.Timeout = t
+		}
+		if sc.BudgetUSD > 0 {
+			opts.MaxBudgetUSD = sc.BudgetUSD
+		}
+	}
+
+	return opts
+}
+
+// backendTimeout returns the execution timeout configured for the selected
+// AI backend. A fallback chain returns 0 so that every backend in the chain
+// applies its own configured timeout.
+func (r *DefaultRunner) backendTimeout() time.Duration {
+	var (
+		t   time.Duration
+		err error
+	)
+	switch ai.BackendType(r.cfg.AIBackend) {
+	case ai.BackendChain:
+		return 0
+	case ai.BackendCrush:
+		t, err = r.cfg.Crush.GetTimeout()
+	case ai.BackendAPI:
+		t, err = r.cfg.API.GetTimeout()
+	default:
+		t, err = r.cfg.Claude.GetTimeout()
+	}
+	if err != nil || t <= 0 {
+		return DefaultTimeout
+	}
+	return t
+}
+
+// skillPriority returns the configured priority of a skill (0 if unset)
+func (r *DefaultRunner) skillPriority(name string) int {
+	if sc, ok := r.cfg.GetSkill(name); ok {
+		return sc.Priority
+	}
+	return 0
+}
+
+// orderSkills returns skills sorted by descending priority, keeping the
+// requested order among skills of equal priority
+func (r *DefaultRunner) orderSkills(skills []string) []string {
+	ordered := append([]string(nil), skills...)
+	sort.SliceStable(ordered, func(i, j int) bool {
+		return r.skillPriority(ordered[i]) > r.skillPriority(ordered[j])
+	})
+	return ordered
+}
@@ -0,0 +1,161 @@
+// Package runner provides per-skill execution tests
+package runner
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/cicd-ai-toolkit/cicd-runner/pkg/ai"
+	"github.com/cicd-ai-toolkit/cicd-runner/pkg/config"
+	"github.com/cicd-ai-toolkit/cicd-runner/pkg/errors"
+	"github.com/cicd-ai-toolkit/cicd-runner/pkg/retry"
+)
+
+// skillBrain reports one issue per skill, records the options each skill
+// ran with, and fails the skill named failSkill
+type skillBrain struct {
+	fakeBrain
+	mu        sync.Mutex
+	opts      map[string]ai.ExecuteOptions
+	failSkill string
+}
+
+func (b *skillBrain) Execute(ctx context.Context, prompt string, opts ai.ExecuteOptions) (*ai.Output, error) {
+	name := strings.Join(opts.Skills, ",")
+
+	b.mu.Lock()
+	b.opts[name] = opts
+	b.mu.Unlock()
+
+	if name == b.failSkill {
+		return nil, fmt.Errorf("timeout")
+	}
+	return &ai.Output{Issues: []ai.Issue{
+		{Severity: "high", Category: "logic", File: "main.go", Line: 1, Message: "found by " + name},
+	}}, nil
+}
+
+func TestReviewDiffSkills(t *testing.T) {
+	brain := &skillBrain{opts: make(map[string]ai.ExecuteOptions), failSkill: "perf-auditor"}
+	r := &DefaultRunner{
+		cfg: &config.Config{
+			Claude: config.ClaudeConfig{Timeout: "10m"},
+			Global: config.GlobalConfig{ParallelSkills: 3},
+			Skills: []config.SkillConfig{
+				{Name: "code-reviewer", Priority: 10},
+				{Name: "security-scanner", Priority: 90, Timeout: "2m", BudgetUSD: 0.5},
+				{Name: "perf-auditor", Priority: 50},
+			},
+		},
+		platform: &mockPlatform{},
+		aiBrain:  brain,
+	}
+
+	review, err := r.reviewDiff(context.Background(), "diff --git a/main.go b/main.go\n", 0,
+		[]string{"code-reviewer", "security-scanner", "perf-auditor"})
+	if err != nil {
+		t.Fatalf("reviewDiff() error = %v", err)
+	}
+
+	// Skills are reported in priority order
+	var names []string
+	for _, s := range review.Skills {
+		names = append(names, s.Name)
+	}
+	if got := strings.Join(names, ","); got != "security-scanner,perf-auditor,code-reviewer" {
+		t.Fatalf("skills not in priority order: %s", got)
+	}
+
+	if review.Skills[1].Err == "" || review.Skills[1].Failed != 1 {
+		t.Errorf("expected perf-auditor to fail, got %+v", review.Skills[1])
+	}
+	if len(review.Failed) != 1 || review.Failed[0].Skill != "perf-auditor" {
+		t.Errorf("unexpected failed executions: %+v", review.Failed)
+	}
+
+	// Each successful skill contributes its own tagged issue
+	if len(review.Issues) != 2 || review.Issues[0].Skill != "security-scanner" || review.Issues[1].Skill != "code-reviewer" {
+		t.Fatalf("unexpected issues: %+v", review.Issues)
+	}
+
+	// Per-skill limits override the global ones
+	if opts := brain.opts["security-scanner"]; opts.Timeout != 2*time.Minute || opts.MaxBudgetUSD != 0.5 {
+		t.Errorf("security-scanner ran with %+v", opts)
+	}
+	if opts := brain.opts["code-reviewer"]; opts.Timeout != 10*time.Minute {
+		t.Errorf("code-reviewer ran with %+v", opts)
+	}
+
+	result := &ReviewResult{Issues: review.Issues, Skills: review.Skills, Chunks: review.Chunks, FailedChunks: review.Failed}
+	comment := r.formatReviewComment(result)
+	for _, want := range []string{"### 🧩 security-scanner (1 issues", "found by security-scanner", "Skill failed: timeout", "1 of 3 review executions failed"} {
+		if !strings.Contains(comment, want) {
+			t.Errorf("comment missing %q:\n%s", want, comment)
+		}
+	}
+	if strings.Index(comment, "security-scanner") > strings.Index(comment, "code-reviewer") {
+		t.Errorf("skill sections not in priority order:\n%s", comment)
+	}
+}
+
+// flakyBrain fails its first executions with a retryable error
+type flakyBrain struct {
+	fakeBrain
+	failures int
+	calls    int
+}
+
+func (b *flakyBrain) Execute(ctx context.Context, prompt string, opts ai.ExecuteOptions) (*ai.Output, error) {
+	b.calls++
+	if b.calls <= b.failures {
+		return nil, errors.UnavailableError("model API unavailable", nil)
+	}
+	return &ai.Output{}, nil
+}
+
+func TestExecuteRetries(t *testing.T) {
+	policy := retry.Policy{MaxRetries: 2, BaseDelay: time.Millisecond, MaxDelay: time.Millisecond}
+
+	tests := []struct {
+		name      string
+		failures  int
+		wantCalls int
+		wantErr   bool
+	}{
+		{"recovers from transient failures", 2, 3, false},
+		{"gives up after max retries", 5, 3, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			brain := &flakyBrain{failures: tt.failures}
+			r := &DefaultRunner{cfg: &config.Config{}, platform: &mockPlatform{}, aiBrain: brain}
+			r.SetRetryPolicy(policy)
+
+			var mu sync.Mutex
+			var retries int
+			r.SetProgress(func(e ai.ProgressEvent) {
+				mu.Lock()
+				defer mu.Unlock()
+				if e.Type == ai.ProgressRetry {
+					retries++
+				}
+			})
+
+			_, err := r.execute(context.Background(), "prompt", ai.ExecuteOptions{Operation: "review"}, diffPart{})
+			if (err != nil) != tt.wantErr {
+				t.Errorf("execute() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if brain.calls != tt.wantCalls {
+				t.Errorf("executions = %d, want %d", brain.calls, tt.wantCalls)
+			}
+			if retries != 2 {
+				t.Errorf("retry events = %d, want 2", retries)
+			}
+		})
+	}
+}
@@ -0,0 +1,95 @@
+// Package runner provides commit status reporting for reviews
+package runner
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/cicd-ai-toolkit/cicd-runner/pkg/platform"
+)
+
+// StatusSHA resolves the commit to report review status on: the PR head
+// when a PR is given, otherwise the reviewed head (default HEAD) of the
+// local repository. It returns "" when no commit can be resolved.
+func (r *DefaultRunner) StatusSHA(ctx context.Context, opts ReviewOptions) string {
+	if opts.PRID > 0 {
+		if info, err := r.platform.GetPRInfo(ctx, opts.PRID); err == nil && info.SHA != "" {
+			return info.SHA
+		}
+	}
+
+	ref := opts.HeadSHA
+	if ref == "" {
+		ref = "HEAD"
+	}
+	return r.resolveHeadSHA(ctx, ref)
+}
+
+// ReportStatus sets a commit status on the platform. Status reporting is
+// best effort: failures are logged and never fail the review.
+func (r *DefaultRunner) ReportStatus(ctx context.Context, status platform.CommitStatus) {
+	if status.SHA == "" {
+		return
+	}
+	if err := r.platform.SetStatus(ctx, status); err != nil {
+		log.Printf("[WARNING] failed to set %s status on %s: %v", status.State, shortSHA(status.SHA), err)
+	}
+}
+
+// ReviewStatus builds the final commit status of a review. The status
+// fails when the quality gate is broken; a nil gate always succeeds.
+func ReviewStatus(sha string, result *ReviewResult, gate *GateResult) platform.CommitStatus {
+	status := platform.CommitStatus{
+		SHA:         sha,
+		State:       platform.StatusSuccess,
+		Description: describeSummary(result.Summary),
+		Summary:     result.PlatformComment,
+	}
+
+	if gate != nil && !gate.Passed() {
+		status.State = platform.StatusFailed
+		rules := make([]string, 0, len(gate.Violations))
+		for _, v := range gate.Violations {
+			rules = append(rules, v.Rule)
+		}
+		status.Description = "Quality gate failed: " + strings.Join(rules, "; ")
+	}
+
+	for _, issue := range result.Issues {
+		if issue.File == "" || issue.Line <= 0 {
+			continue
+		}
+		status.Annotations = append(status.Annotations, platform.StatusAnnotation{
+			Path:    issue.File,
+			Line:    issue.Line,
+			Level:   annotationLevel(issue.Severity),
+			Title:   fmt.Sprintf("%s: %s", issue.Severity, issue.Category),
+			Message: issue.Message,
+		})
+	}
+
+	return status
+}
+
+// describeSummary formats issue counts as a one-line status description
+func describeSummary(summary ReviewSummary) string {
+	if summary.TotalIssues == 0 {
+		return "No issues found"
+	}
+	return fmt.Sprintf("%d issues (%d critical, %d high, %d medium, %d low)",
+		summary.TotalIssues, summary.Critical, summary.High, summary.Medium, summary.Low)
+}
+
+// annotationLevel maps an issue severity to an annotation level
+func annotationLevel(severity string) platform.AnnotationLevel {
+	switch strings.ToLower(severity) {
+	case "critical", "high":
+		return platform.AnnotationFailure
+	case "medium":
+		return platform.AnnotationWarning
+	default:
+		return platform.AnnotationNotice
+	}
+}
@@ -0,0 +1,45 @@
+// Package runner provides commit status tests
+package runner
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/cicd-ai-toolkit/cicd-runner/pkg/ai"
+	"github.com/cicd-ai-toolkit/cicd-runner/pkg/config"
+	"github.com/cicd-ai-toolkit/cicd-runner/pkg/platform"
+)
+
+func TestReviewStatus(t *testing.T) {
+	issues := []ai.Issue{
+		{Severity: "critical", Category: "security", File: "auth.go", Line: 11, Message: "SQL injection"},
+		{Severity: "low", Category: "style", Message: "no location"},
+	}
+	result := &ReviewResult{
+		Issues:          issues,
+		Summary:         ReviewSummary{TotalIssues: 2, Critical: 1, Low: 1},
+		PlatformComment: "## Review",
+	}
+
+	status := ReviewStatus("abc123", result, nil)
+	if status.State != platform.StatusSuccess || status.SHA != "abc123" || status.Summary != "## Review" {
+		t.Errorf("unexpected status without gate: %+v", status)
+	}
+	if status.Description != "2 issues (1 critical, 0 high, 0 medium, 1 low)" {
+		t.Errorf("Description = %q", status.Description)
+	}
+	if len(status.Annotations) != 1 || status.Annotations[0].Level != platform.AnnotationFailure {
+		t.Errorf("unexpected annotations: %+v", status.Annotations)
+	}
+
+	gate := EvaluateGate(config.QualityGate{MaxIssues: map[string]int{"critical": 0}}, issues)
+	status = ReviewStatus("abc123", result, gate)
+	if status.State != platform.StatusFailed || !strings.Contains(status.Description, "critical issues: 1 > 0") {
+		t.Errorf("unexpected status with broken gate: %+v", status)
+	}
+
+	empty := ReviewStatus("abc123", &ReviewResult{}, nil)
+	if empty.Description != "No issues found" {
+		t.Errorf("Description = %q", empty.Description)
+	}
+}
@@ -0,0 +1,218 @@
+// Package webhook handles incoming webhooks from Bitbucket Cloud and Data Center
+package webhook
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// BitbucketCloudWebhook represents a Bitbucket Cloud pull request webhook payload
+type BitbucketCloudWebhook struct {
+	// Actor is the user who triggered the event
+	Actor struct {
+		DisplayName string `json:"display_name"`
+		Nickname    string `json:"nickname"`
+	} `json:"actor"`
+
+	// PullRequest contains PR details
+	PullRequest struct {
+		ID          int    `json:"id"`
+		Title       string `json:"title"`
+		Description string `json:"description"`
+		State       string `json:"state"`
+		Author      struct {
+			DisplayName string `json:"display_name"`
+			Nickname    string `json:"nickname"`
+		} `json:"author"`
+		Source struct {
+			Branch struct {
+				Name string `json:"name"`
+			} `json:"branch"`
+			Commit struct {
+				Hash string `json:"hash"`
+			} `json:"commit"`
+		} `json:"source"`
+		Destination struct {
+			Branch struct {
+				Name string `json:"name"`
+			} `json:"branch"`
+		} `json:"destination"`
+	} `json:"pullrequest"`
+
+	// Repository contains repo details
+	Repository struct {
+		Name      string `json:"name"`
+		FullName  string `json:"full_name"`
+		Workspace struct {
+			Slug string `json:"slug"`
+		} `json:"workspace"`
+	} `json:"repository"`
+}
+
+// BitbucketServerWebhook represents a Bitbucket Data Center pull request webhook payload
+type BitbucketServerWebhook struct {
+	// EventKey is the event type, also sent in the X-Event-Key header
+	EventKey string `json:"eventKey"`
+
+	// Actor is the user who triggered the event
+	Actor struct {
+		Name string `json:"name"`
+	} `json:"actor"`
+
+	// PullRequest contains PR details
+	PullRequest struct {
+		ID          int    `json:"id"`
+		Title       string `json:"title"`
+		Description string `json:"description"`
+		State       string `json:"state"`
+		Author      struct {
+			User struct {
+				Name string `json:"name"`
+			} `json:"user"`
+		} `json:"author"`
+		FromRef bitbucketServerWebhookRef `json:"fromRef"`
+		ToRef   bitbucketServerWebhookRef `json:"toRef"`
+	} `json:"pullRequest"`
+}
+
+// bitbucketServerWebhookRef is a branch endpoint of a Data Center pull request
+type bitbucketServerWebhookRef struct {
+	DisplayID    string `json:"displayId"`
+	LatestCommit string `json:"latestCommit"`
+	Repository   struct {
+		ID      int    `json:"id"`
+		Slug    string `json:"slug"`
+		Name    string `json:"name"`
+		Project struct {
+			Key string `json:"key"`
+		} `json:"project"`
+	} `json:"repository"`
+}
+
+// ParseBitbucketEvent parses a Bitbucket webhook event. eventKey is the
+// X-Event-Key header: pullrequest:* for Bitbucket Cloud, pr:* for Bitbucket
+// Data Center. Data Center payloads also carry the key in the body.
+func ParseBitbucketEvent(data []byte, eventKey string) (*Event, error) {
+	if eventKey == "" {
+		var probe struct {
+			EventKey string `json:"eventKey"`
+		}
+		if err := json.Unmarshal(data, &probe); err != nil {
+			return nil, fmt.Errorf("failed to parse Bitbucket payload: %w", err)
+		}
+		eventKey = probe.EventKey
+	}
+
+	if strings.HasPrefix(eventKey, "pullrequest:") {
+		return parseBitbucketCloudEvent(data, eventKey)
+	}
+	if strings.HasPrefix(eventKey, "pr:") {
+		return parseBitbucketServerEvent(data, eventKey)
+	}
+
+	// Ignore pushes, diagnostics:ping and other events
+	return nil, nil
+}
+
+// parseBitbucketCloudEvent parses a Bitbucket Cloud pull request event
+func parseBitbucketCloudEvent(data []byte, eventKey string) (*Event, error) {
+	var payload BitbucketCloudWebhook
+	if err := json.Unmarshal(data, &payload); err != nil {
+		return nil, fmt.Errorf("failed to parse Bitbucket payload: %w", err)
+	}
+
+	var evtType EventType
+	switch eventKey {
+	case "pullrequest:created":
+		evtType = EventPROpened
+	case "pullrequest:updated":
+		// Sent for new commits as well as title/description edits
+		evtType = EventPRSynchronize
+	default:
+		// Ignore other events like fulfilled, rejected, approved, etc.
+		return nil, nil
+	}
+
+	// Validate required fields
+	if payload.PullRequest.ID <= 0 {
+		return nil, fmt.Errorf("invalid PR number: %d", payload.PullRequest.ID)
+	}
+
+	owner := payload.Repository.Workspace.Slug
+	if owner == "" {
+		owner, _, _ = strings.Cut(payload.Repository.FullName, "/")
+	}
+
+	return &Event{
+		Platform:    PlatformBitbucket,
+		Type:        evtType,
+		PRID:        payload.PullRequest.ID,
+		Repo:        nonEmptyString(payload.Repository.Name, "unknown"),
+		Owner:       nonEmptyString(owner, "unknown"),
+		FullName:    nonEmptyString(payload.Repository.FullName, "unknown"),
+		SHA:         payload.PullRequest.Source.Commit.Hash,
+		BaseRef:     payload.PullRequest.Destination.Branch.Name,
+		HeadRef:     payload.PullRequest.Source.Branch.Name,
+		Title:       nonEmptyString(payload.PullRequest.Title, "Untitled"),
+		Description: payload.PullRequest.Description,
+		Author:      nonEmptyString(payload.PullRequest.Author.Nickname, nonEmptyString(payload.PullRequest.Author.DisplayName, "unknown")),
+		RawPayload:  limitRawPayload(data),
+	}, nil
+}
+
+// parseBitbucketServerEvent parses a Bitbucket Data Center pull request event
+func parseBitbucketServerEvent(data []byte, eventKey string) (*Event, error) {
+	var payload BitbucketServerWebhook
+	if err := json.Unmarshal(data, &payload); err != nil {
+		return nil, fmt.Errorf("failed to parse Bitbucket payload: %w", err)
+	}
+
+	var evtType EventType
+	switch eventKey {
+	case "pr:opened", "pr:modified":
+		evtType = EventPROpened
+	case "pr:from_ref_updated":
+		evtType = EventPRSynchronize
+	default:
+		// Ignore other events like merged, declined, comments, etc.
+		return nil, nil
+	}
+
+	// Validate required fields
+	if payload.PullRequest.ID <= 0 {
+		return nil, fmt.Errorf("invalid PR number: %d", payload.PullRequest.ID)
+	}
+
+	// Pull requests live in the target repository
+	repo := payload.PullRequest.ToRef.Repository
+	fullName := ""
+	if repo.Project.Key != "" && repo.Slug != "" {
+		fullName = repo.Project.Key + "/" + repo.Slug
+	}
+
+	return &Event{
+		Platform:    PlatformBitbucket,
+		Type:        evtType,
+		PRID:        payload.PullRequest.ID,
+		Repo:        nonEmptyString(repo.Slug, "unknown"),
+		RepoID:      repo.ID,
+		Owner:       nonEmptyString(repo.Project.Key, "unknown"),
+		FullName:    nonEmptyString(fullName, "unknown"),
+		SHA:         payload.PullRequest.FromRef.LatestCommit,
+		BaseRef:     payload.PullRequest.ToRef.DisplayID,
+		HeadRef:     payload.PullRequest.FromRef.DisplayID,
+		Title:       nonEmptyString(payload.PullRequest.Title, "Untitled"),
+		Description: payload.PullRequest.Description,
+		Author:      nonEmptyString(payload.PullRequest.Author.User.Name, "unknown"),
+		RawPayload:  limitRawPayload(data),
+	}, nil
+}
+
+// limitRawPayload limits raw payload size to prevent memory issues
+func limitRawPayload(data []byte) []byte {
+	if len(data) > MaxRawPayloadSize {
+		return data[:MaxRawPayloadSize]
+	}
+	return data
+}
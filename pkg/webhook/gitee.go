@@ -0,0 +1,147 @@
+// Package webhook handles incoming webhooks from Gitee
+package webhook
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// GiteeWebhook represents a Gitee pull request or note webhook payload
+type GiteeWebhook struct {
+	// HookName is the event type, e.g. merge_request_hooks
+	HookName string `json:"hook_name"`
+
+	// Action is open, update, reopen, close, merge, ... or comment
+	Action string `json:"action"`
+
+	// NoteableType is what a note event comments on, e.g. PullRequest
+	NoteableType string `json:"noteable_type"` //nolint:misspell // Gitee API uses "noteable"
+
+	// Comment is the comment of a note event. Older payloads send it as
+	// note.
+	Comment *giteeComment `json:"comment"`
+	Note    *giteeComment `json:"note"`
+
+	// PullRequest contains PR details
+	PullRequest struct {
+		Number int    `json:"number"`
+		Title  string `json:"title"`
+		Body   string `json:"body"`
+		State  string `json:"state"`
+		User   struct {
+			Login string `json:"login"`
+		} `json:"user"`
+		Head struct {
+			Ref string `json:"ref"`
+			SHA string `json:"sha"`
+		} `json:"head"`
+		Base struct {
+			Ref string `json:"ref"`
+		} `json:"base"`
+	} `json:"pull_request"`
+
+	// Repository contains repo details
+	Repository struct {
+		ID       int64  `json:"id"`
+		Name     string `json:"name"`
+		FullName string `json:"full_name"`
+		Owner    struct {
+			Login string `json:"login"`
+		} `json:"owner"`
+	} `json:"repository"`
+}
+
+// giteeComment is a PR comment of a Gitee note event
+type giteeComment struct {
+	ID   int64  `json:"id"`
+	Body string `json:"body"`
+	User struct {
+		Login string `json:"login"`
+	} `json:"user"`
+}
+
+// ParseGiteeEvent parses a Gitee webhook event. eventType is the
+// X-Gitee-Event header ("Merge Request Hook" or "Note Hook"); the
+// payload's hook_name is used when it is empty.
+func ParseGiteeEvent(data []byte, eventType string) (*Event, error) {
+	var payload GiteeWebhook
+	if err := json.Unmarshal(data, &payload); err != nil {
+		return nil, fmt.Errorf("failed to parse Gitee payload: %w", err)
+	}
+
+	if eventType == "Note Hook" || payload.HookName == "note_hooks" {
+		return parseGiteeComment(payload, data)
+	}
+
+	// Only process pull request events
+	if eventType != "Merge Request Hook" && payload.HookName != "merge_request_hooks" {
+		return nil, nil
+	}
+
+	var evtType EventType
+	switch payload.Action {
+	case "open":
+		evtType = EventPROpened
+	case "update":
+		evtType = EventPRSynchronize
+	case "reopen":
+		evtType = EventPRReopened
+	default:
+		// Ignore close, merge, assign, test, tested, approved, etc.
+		return nil, nil
+	}
+
+	if payload.PullRequest.Number <= 0 {
+		return nil, fmt.Errorf("invalid PR number: %d", payload.PullRequest.Number)
+	}
+
+	return &Event{
+		Platform:    PlatformGitee,
+		Type:        evtType,
+		PRID:        payload.PullRequest.Number,
+		Repo:        nonEmptyString(payload.Repository.Name, "unknown"),
+		RepoID:      int(payload.Repository.ID),
+		Owner:       nonEmptyString(payload.Repository.Owner.Login, "unknown"),
+		FullName:    nonEmptyString(payload.Repository.FullName, "unknown"),
+		SHA:         payload.PullRequest.Head.SHA,
+		BaseRef:     payload.PullRequest.Base.Ref,
+		HeadRef:     payload.PullRequest.Head.Ref,
+		Title:       nonEmptyString(payload.PullRequest.Title, "Untitled"),
+		Description: payload.PullRequest.Body,
+		Author:      nonEmptyString(payload.PullRequest.User.Login, "unknown"),
+		RawPayload:  limitRawPayload(data),
+	}, nil
+}
+
+// parseGiteeComment parses a note event. Only new comments on pull
+// requests are returned.
+func parseGiteeComment(payload GiteeWebhook, data []byte) (*Event, error) {
+	comment := payload.Comment
+	if comment == nil {
+		comment = payload.Note
+	}
+	if comment == nil || payload.NoteableType != "PullRequest" || (payload.Action != "" && payload.Action != "comment") {
+		return nil, nil
+	}
+	if payload.PullRequest.Number <= 0 {
+		return nil, fmt.Errorf("invalid PR number: %d", payload.PullRequest.Number)
+	}
+
+	return &Event{
+		Platform:   PlatformGitee,
+		Type:       EventPRComment,
+		PRID:       payload.PullRequest.Number,
+		Repo:       nonEmptyString(payload.Repository.Name, "unknown"),
+		RepoID:     int(payload.Repository.ID),
+		Owner:      nonEmptyString(payload.Repository.Owner.Login, "unknown"),
+		FullName:   nonEmptyString(payload.Repository.FullName, "unknown"),
+		SHA:        payload.PullRequest.Head.SHA,
+		BaseRef:    payload.PullRequest.Base.Ref,
+		HeadRef:    payload.PullRequest.Head.Ref,
+		Title:      nonEmptyString(payload.PullRequest.Title, "Untitled"),
+		Author:     comment.User.Login, // Empty when missing, so the commenter is refused
+		CommentID:  comment.ID,
+		Comment:    comment.Body,
+		RawPayload: limitRawPayload(data),
+	}, nil
+}
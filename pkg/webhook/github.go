@@ -5,6 +5,8 @@ import (
 	"encoding/json"
 	"fmt"
 	"time"
+
+	"github.com/cicd-ai-toolkit/cicd-runner/pkg/platform"
 )
 
 // Event represents a normalized webhook event
@@ -32,7 +34,11 @@ type Event struct {
 	// PR/MR metadata
 	Title       string
 	Description string
-	Author      string
+	Author      string // PR author, or the commenter of a comment event
+
+	// Comment of an EventPRComment
+	CommentID int64
+	Comment   string
 
 	// Raw payload for debugging
 	RawPayload json.RawMessage
@@ -48,6 +54,8 @@ const (
 	PlatformGitHub    Platform = "github"
 	PlatformGitLab    Platform = "gitlab"
 	PlatformGitee     Platform = "gitee"
+	PlatformBitbucket Platform = "bitbucket"
+	PlatformJenkins   Platform = "jenkins"
 	MaxRawPayloadSize          = 10 * 1024 * 1024 // 10MB limit for raw payload storage
 )
 
@@ -60,8 +68,9 @@ const (
 	EventPRReopened    EventType = "reopened"
 	EventPRClosed      EventType = "closed"
 	EventPRMerged      EventType = "merged"
-	EventPROpenedGL    EventType = "open"   // GitLab
-	EventPRUpdatedGL   EventType = "update" // GitLab
+	EventPRComment     EventType = "comment" // New comment on a PR
+	EventPROpenedGL    EventType = "open"    // GitLab
+	EventPRUpdatedGL   EventType = "update"  // GitLab
 )
 
 // String returns the string representation of the event type
@@ -79,6 +88,40 @@ func (e *Event) ShouldTriggerReview() bool {
 	}
 }
 
+// Normalize converts the event into the platform-independent form work is
+// triggered with. GitLab's open and update actions become opened and
+// synchronize, Repo is the full repository name the platform clients act
+// on, and comment events carry their comment.
+func (e *Event) Normalize() platform.WebhookEvent {
+	evtType := platform.EventType(e.Type)
+	switch e.Type {
+	case EventPROpenedGL:
+		evtType = platform.EventPROpened
+	case EventPRUpdatedGL:
+		evtType = platform.EventPRSynchronize
+	}
+
+	timestamp := e.Timestamp
+	if timestamp.IsZero() {
+		timestamp = time.Now()
+	}
+
+	normalized := platform.WebhookEvent{
+		Type:      evtType,
+		Platform:  string(e.Platform),
+		PRID:      e.PRID,
+		Repo:      e.FullName,
+		SHA:       e.SHA,
+		BaseRef:   e.BaseRef,
+		HeadRef:   e.HeadRef,
+		Timestamp: timestamp.Unix(),
+	}
+	if e.Type == EventPRComment {
+		normalized.Comment = &platform.WebhookComment{ID: e.CommentID, Author: e.Author, Body: e.Comment}
+	}
+	return normalized
+}
+
 // GitHubWebhook represents a GitHub webhook event payload
 type GitHubWebhook struct {
 	// Action is the action that triggered the event
@@ -132,6 +175,24 @@ type GitHubWebhook struct {
 		Private bool `json:"private"`
 	} `json:"repository"`
 
+	// Issue is the PR of an issue_comment event; PullRequest is set only
+	// for comments on pull requests
+	Issue struct {
+		Number      int       `json:"number"`
+		Title       string    `json:"title"`
+		PullRequest *struct{} `json:"pull_request"`
+	} `json:"issue"`
+
+	// Comment is the comment of an issue_comment event
+	Comment struct {
+		ID   int64  `json:"id"`
+		Body string `json:"body"`
+		User struct {
+			Login string `json:"login"`
+			Type  string `json:"type"` // User or Bot
+		} `json:"user"`
+	} `json:"comment"`
+
 	// Sender contains the user who triggered the event
 	Sender struct {
 		Login string `json:"login"`
@@ -155,6 +216,10 @@ func ParseGitHubEvent(data []byte, eventType string) (*Event, error) {
 		return nil, fmt.Errorf("failed to parse GitHub payload: %w", err)
 	}
 
+	if eventType == "issue_comment" {
+		return parseGitHubComment(payload, data)
+	}
+
 	// Only process pull_request events
 	if eventType != "pull_request" {
 		return nil, nil
@@ -208,6 +273,32 @@ func ParseGitHubEvent(data []byte, eventType string) (*Event, error) {
 	return event, nil
 }
 
+// parseGitHubComment parses an issue_comment event. Only new comments on
+// pull requests by users (not bots) are returned.
+func parseGitHubComment(payload GitHubWebhook, data []byte) (*Event, error) {
+	if payload.Action != "created" || payload.Issue.PullRequest == nil || payload.Comment.User.Type == "Bot" {
+		return nil, nil
+	}
+	if payload.Issue.Number <= 0 {
+		return nil, fmt.Errorf("invalid PR number: %d", payload.Issue.Number)
+	}
+
+	return &Event{
+		Platform:   PlatformGitHub,
+		Type:       EventPRComment,
+		PRID:       payload.Issue.Number,
+		Repo:       nonEmptyString(payload.Repository.Name, "unknown"),
+		RepoID:     int(payload.Repository.ID),
+		Owner:      nonEmptyString(payload.Repository.Owner.Login, "unknown"),
+		FullName:   nonEmptyString(payload.Repository.FullName, "unknown"),
+		Title:      nonEmptyString(payload.Issue.Title, "Untitled"),
+		Author:     payload.Comment.User.Login, // Empty when missing, so the commenter is refused
+		CommentID:  payload.Comment.ID,
+		Comment:    payload.Comment.Body,
+		RawPayload: limitRawPayload(data),
+	}, nil
+}
+
 // nonEmptyString returns the string if non-empty, otherwise returns the default value
 func nonEmptyString(s, defaultValue string) string {
 	if s == "" {
@@ -291,13 +382,16 @@ func ParseGitLabEvent(data []byte, eventType string) (*Event, error) {
 	// Map GitLab action to our event type
 	var evtType EventType
 	switch payload.ObjectAttributes.Action {
-	case "open", "reopen", "update", "merge":
+	case "open", "reopen", "update":
 		evtType = EventPROpenedGL
 		if payload.ObjectAttributes.Action == "update" {
 			// For GitLab, update can mean many things
 			// We'll treat it as a potential review trigger
 			evtType = EventPRUpdatedGL
 		}
+	case "merge":
+		// A merged MR needs no review
+		evtType = EventPRMerged
 	default:
 		// Ignore other actions like close, approved, etc.
 		return nil, nil
@@ -0,0 +1,68 @@
+// Package webhook handles build notifications from Jenkins
+package webhook
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// JenkinsWebhook represents a Jenkins build notification. The Notification
+// plugin nests the build under "build"; simpler senders put its fields at
+// the top level.
+type JenkinsWebhook struct {
+	// Name is the job name
+	Name   string `json:"name"`
+	Number int    `json:"number"`
+	Phase  string `json:"phase"` // QUEUED, STARTED, COMPLETED, FINALIZED
+
+	Build *struct {
+		Number int    `json:"number"`
+		Phase  string `json:"phase"`
+		SCM    struct {
+			Branch string `json:"branch"`
+			Commit string `json:"commit"`
+		} `json:"scm"`
+	} `json:"build"`
+}
+
+// ParseJenkinsEvent parses a Jenkins build notification. A started build
+// is reported as an opened event whose PRID is the build number, so its
+// changes are reviewed; other phases are ignored.
+func ParseJenkinsEvent(data []byte) (*Event, error) {
+	var payload JenkinsWebhook
+	if err := json.Unmarshal(data, &payload); err != nil {
+		return nil, fmt.Errorf("failed to parse Jenkins payload: %w", err)
+	}
+
+	number, phase := payload.Number, payload.Phase
+	var sha, branch string
+	if b := payload.Build; b != nil {
+		number, phase = b.Number, b.Phase
+		sha, branch = b.SCM.Commit, strings.TrimPrefix(b.SCM.Branch, "origin/")
+	}
+
+	if !strings.EqualFold(phase, "STARTED") {
+		return nil, nil
+	}
+	if number <= 0 {
+		return nil, fmt.Errorf("invalid build number: %d", number)
+	}
+	if payload.Name == "" {
+		return nil, fmt.Errorf("missing job name")
+	}
+
+	return &Event{
+		Platform:   PlatformJenkins,
+		Type:       EventPROpened,
+		PRID:       number,
+		Repo:       payload.Name,
+		Owner:      "unknown",
+		FullName:   payload.Name,
+		SHA:        sha,
+		HeadRef:    branch,
+		Title:      fmt.Sprintf("%s #%d", payload.Name, number),
+		Author:     "unknown",
+		RawPayload: limitRawPayload(data),
+	}, nil
+}
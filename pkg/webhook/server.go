@@ -0,0 +1,417 @@
+// Package webhook provides the HTTP server that receives webhooks from all
+// supported platforms and queues work on pull request events
+package webhook
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cicd-ai-toolkit/cicd-runner/pkg/chatops"
+	"github.com/cicd-ai-toolkit/cicd-runner/pkg/queue"
+)
+
+// Secrets verify the deliveries of each platform. A platform without a
+// secret is not accepted.
+type Secrets struct {
+	GitHub    string // HMAC-SHA256 key of X-Hub-Signature-256
+	GitLab    string // Expected X-Gitlab-Token
+	Gitee     string // HMAC-SHA256 key of X-Gitee-Token
+	Bitbucket string // HMAC-SHA256 key of X-Hub-Signature
+	Jenkins   string // Expected bearer token or token query parameter
+}
+
+// get returns the secret of a platform
+func (s Secrets) get(p Platform) string {
+	switch p {
+	case PlatformGitHub:
+		return s.GitHub
+	case PlatformGitLab:
+		return s.GitLab
+	case PlatformGitee:
+		return s.Gitee
+	case PlatformBitbucket:
+		return s.Bitbucket
+	case PlatformJenkins:
+		return s.Jenkins
+	default:
+		return ""
+	}
+}
+
+// ServerOptions configures a webhook server
+type ServerOptions struct {
+	// Address is the listen address (default: :8080)
+	Address string
+	// Secrets verify deliveries; platforms without one are rejected
+	Secrets Secrets
+	// APIToken is the bearer token of the job API under /jobs; without
+	// one the API is disabled
+	APIToken string
+	// Commands queues PR comments with an /ai command as jobs
+	Commands bool
+	// Logger receives one line per delivery (default: discard)
+	Logger func(format string, args ...interface{})
+}
+
+// Stats counts the deliveries of a server
+type Stats struct {
+	Received   int64 `json:"received"`
+	Rejected   int64 `json:"rejected"`   // Bad signature or payload
+	Ignored    int64 `json:"ignored"`    // Not a pull request event or command that triggers work
+	Accepted   int64 `json:"accepted"`   // Queued as a new job
+	Duplicates int64 `json:"duplicates"` // Same head commit as an unfinished job
+}
+
+// webhookPlatforms are the platforms served under /webhook/<platform>
+var webhookPlatforms = []Platform{PlatformGitHub, PlatformGitLab, PlatformGitee, PlatformBitbucket, PlatformJenkins}
+
+// Server receives webhooks on POST /webhook/<platform>, verifies and
+// normalizes them, and queues a job for pull request events. GET /healthz
+// reports liveness, delivery counts and queue stats; /jobs serves the job
+// API.
+type Server struct {
+	opts    ServerOptions
+	queue   *queue.Queue
+	http    *http.Server
+	started time.Time
+
+	mu      sync.Mutex
+	closing bool
+	stats   Stats
+}
+
+// NewServer creates a webhook server that queues pull request events on q
+func NewServer(opts ServerOptions, q *queue.Queue) *Server {
+	if opts.Address == "" {
+		opts.Address = ":8080"
+	}
+	if opts.Logger == nil {
+		opts.Logger = func(format string, args ...interface{}) {}
+	}
+
+	s := &Server{
+		opts:    opts,
+		queue:   q,
+		started: time.Now(),
+	}
+	s.http = &http.Server{
+		Addr:              opts.Address,
+		Handler:           s.Handler(),
+		ReadHeaderTimeout: 10 * time.Second,
+		ReadTimeout:       30 * time.Second,
+		WriteTimeout:      30 * time.Second,
+	}
+	return s
+}
+
+// Handler returns the server's routes
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	for _, p := range webhookPlatforms {
+		p := p
+		mux.HandleFunc("/webhook/"+string(p), func(w http.ResponseWriter, r *http.Request) {
+			s.handleWebhook(w, r, p)
+		})
+	}
+	mux.HandleFunc("/healthz", s.handleHealth)
+	api := queue.NewAPI(s.queue, s.opts.APIToken)
+	mux.Handle("/jobs", api)
+	mux.Handle("/jobs/", api)
+	return mux
+}
+
+// Platforms returns the platforms a secret is configured for
+func (s *Server) Platforms() []string {
+	var names []string
+	for _, p := range webhookPlatforms {
+		if s.opts.Secrets.get(p) != "" {
+			names = append(names, string(p))
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Stats returns the delivery counts
+func (s *Server) Stats() Stats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.stats
+}
+
+// ListenAndServe serves until Shutdown is called
+func (s *Server) ListenAndServe() error {
+	s.opts.Logger("webhook server listening on %s (platforms: %s)", s.opts.Address, strings.Join(s.Platforms(), ", "))
+	if err := s.http.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return fmt.Errorf("webhook server failed: %w", err)
+	}
+	return nil
+}
+
+// Shutdown stops accepting deliveries and waits for in-flight requests.
+// Queued jobs are left to the queue, which the caller stops afterwards.
+func (s *Server) Shutdown(ctx context.Context) error {
+	s.mu.Lock()
+	s.closing = true
+	s.mu.Unlock()
+
+	return s.http.Shutdown(ctx)
+}
+
+// handleWebhook verifies, parses and dispatches a delivery
+func (s *Server) handleWebhook(w http.ResponseWriter, r *http.Request, p Platform) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	s.count(func(st *Stats) { st.Received++ })
+
+	secret := s.opts.Secrets.get(p)
+	if secret == "" {
+		s.reject(w, p, http.StatusNotFound, fmt.Errorf("no webhook secret configured for %s", p))
+		return
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, MaxRawPayloadSize))
+	if err != nil {
+		s.reject(w, p, http.StatusBadRequest, fmt.Errorf("failed to read body: %w", err))
+		return
+	}
+
+	if err := verify(p, r, body, secret); err != nil {
+		s.reject(w, p, http.StatusUnauthorized, err)
+		return
+	}
+
+	event, err := parse(p, r, body)
+	if err != nil {
+		s.reject(w, p, http.StatusBadRequest, err)
+		return
+	}
+	if !s.triggers(event) {
+		s.count(func(st *Stats) { st.Ignored++ })
+		writeJSON(w, http.StatusOK, map[string]string{"status": "ignored"})
+		return
+	}
+
+	normalized := event.Normalize()
+	s.mu.Lock()
+	closing := s.closing
+	s.mu.Unlock()
+	if closing {
+		writeJSON(w, http.StatusServiceUnavailable, map[string]string{"status": "unavailable", "error": "server is shutting down"})
+		return
+	}
+
+	job, created, err := s.queue.Enqueue(normalized)
+	switch {
+	case errors.Is(err, queue.ErrStopped):
+		writeJSON(w, http.StatusServiceUnavailable, map[string]string{"status": "unavailable", "error": err.Error()})
+		return
+	case err != nil:
+		s.opts.Logger("%s %s#%d not queued: %v", p, normalized.Repo, normalized.PRID, err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"status": "error", "error": err.Error()})
+		return
+	}
+
+	status, code := "queued", http.StatusAccepted
+	if created {
+		s.count(func(st *Stats) { st.Accepted++ })
+	} else {
+		status, code = "duplicate", http.StatusOK
+		s.count(func(st *Stats) { st.Duplicates++ })
+	}
+	s.opts.Logger("%s %s#%d %s at %s %s as job %s", p, normalized.Repo, normalized.PRID, normalized.Type, shortSHA(normalized.SHA), status, job.ID)
+	writeJSON(w, code, map[string]interface{}{
+		"status":   status,
+		"job":      job.ID,
+		"platform": normalized.Platform,
+		"repo":     normalized.Repo,
+		"pr":       normalized.PRID,
+	})
+}
+
+// triggers reports whether an event queues a job: pull request events
+// that trigger a review and, with Commands, comments with an /ai command
+func (s *Server) triggers(event *Event) bool {
+	switch {
+	case event == nil:
+		return false
+	case event.Type == EventPRComment:
+		if !s.opts.Commands {
+			return false
+		}
+		_, ok := chatops.Parse(event.Comment)
+		return ok
+	default:
+		return event.ShouldTriggerReview()
+	}
+}
+
+// handleHealth reports liveness, the served platforms, delivery counts and
+// queue stats. It answers 503 while shutting down so load balancers stop routing here.
+func (s *Server) handleHealth(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	closing, stats := s.closing, s.stats
+	s.mu.Unlock()
+
+	status, code := "ok", http.StatusOK
+	if closing {
+		status, code = "shutting_down", http.StatusServiceUnavailable
+	}
+	writeJSON(w, code, map[string]interface{}{
+		"status":         status,
+		"uptime_seconds": int64(time.Since(s.started).Seconds()),
+		"platforms":      s.Platforms(),
+		"stats":          stats,
+		"queue":          s.queue.Stats(),
+	})
+}
+
+// reject answers a delivery that cannot be processed
+func (s *Server) reject(w http.ResponseWriter, p Platform, code int, err error) {
+	s.count(func(st *Stats) { st.Rejected++ })
+	s.opts.Logger("%s delivery rejected: %v", p, err)
+	http.Error(w, err.Error(), code)
+}
+
+// count updates the stats
+func (s *Server) count(update func(*Stats)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	update(&s.stats)
+}
+
+// verify checks that a delivery was signed with the platform's secret
+func verify(p Platform, r *http.Request, body []byte, secret string) error {
+	switch p {
+	case PlatformGitHub:
+		return verifyHMAC(r.Header.Get("X-Hub-Signature-256"), "sha256=", body, secret)
+	case PlatformBitbucket:
+		return verifyHMAC(r.Header.Get("X-Hub-Signature"), "sha256=", body, secret)
+	case PlatformGitLab:
+		return verifyToken(r.Header.Get("X-Gitlab-Token"), secret)
+	case PlatformGitee:
+		return verifyGitee(r, body, secret)
+	case PlatformJenkins:
+		token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if token == "" {
+			token = r.URL.Query().Get("token")
+		}
+		return verifyToken(token, secret)
+	default:
+		return fmt.Errorf("unsupported platform: %s", p)
+	}
+}
+
+// verifyHMAC checks a hex HMAC-SHA256 signature of the body
+func verifyHMAC(signature, prefix string, body []byte, secret string) error {
+	if signature == "" {
+		return fmt.Errorf("missing signature")
+	}
+	got, err := hex.DecodeString(strings.TrimPrefix(signature, prefix))
+	if err != nil {
+		return fmt.Errorf("invalid signature format: %w", err)
+	}
+	if !hmac.Equal(got, hmacSHA256(secret, body)) {
+		return fmt.Errorf("signature verification failed")
+	}
+	return nil
+}
+
+// verifyToken compares a shared token in constant time
+func verifyToken(token, secret string) error {
+	if token == "" {
+		return fmt.Errorf("missing token")
+	}
+	if subtle.ConstantTimeCompare([]byte(token), []byte(secret)) != 1 {
+		return fmt.Errorf("token verification failed")
+	}
+	return nil
+}
+
+// giteeTimestampWindow is how far X-Gitee-Timestamp may be from now. The
+// timestamp signature does not cover the body, so an old signature must
+// not be replayable.
+const giteeTimestampWindow = time.Hour
+
+// verifyGitee checks X-Gitee-Token. With X-Gitee-Timestamp it is Gitee's
+// signature, base64(HMAC-SHA256(timestamp + "\n" + secret)), and the
+// timestamp (milliseconds since the epoch) must be within
+// giteeTimestampWindow of now; otherwise it is a hex HMAC-SHA256 of the
+// body, as sent by platform.WebhookClient.
+func verifyGitee(r *http.Request, body []byte, secret string) error {
+	token := r.Header.Get("X-Gitee-Token")
+	timestamp := r.Header.Get("X-Gitee-Timestamp")
+	if token == "" || timestamp == "" {
+		return verifyHMAC(token, "", body, secret)
+	}
+
+	ms, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid X-Gitee-Timestamp: %q", timestamp)
+	}
+	if age := time.Since(time.UnixMilli(ms)); age > giteeTimestampWindow || age < -giteeTimestampWindow {
+		return fmt.Errorf("X-Gitee-Timestamp is %s from now, outside the allowed %s", age.Round(time.Second), giteeTimestampWindow)
+	}
+
+	expected := base64.StdEncoding.EncodeToString(hmacSHA256(secret, []byte(timestamp+"\n"+secret)))
+	if !hmac.Equal([]byte(token), []byte(expected)) {
+		return fmt.Errorf("signature verification failed")
+	}
+	return nil
+}
+
+// hmacSHA256 returns the HMAC-SHA256 of data
+func hmacSHA256(secret string, data []byte) []byte {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(data)
+	return mac.Sum(nil)
+}
+
+// parse parses a delivery with the platform's event header
+func parse(p Platform, r *http.Request, body []byte) (*Event, error) {
+	switch p {
+	case PlatformGitHub:
+		return ParseGitHubEvent(body, r.Header.Get("X-GitHub-Event"))
+	case PlatformGitLab:
+		return ParseGitLabEvent(body, r.Header.Get("X-Gitlab-Event"))
+	case PlatformGitee:
+		return ParseGiteeEvent(body, r.Header.Get("X-Gitee-Event"))
+	case PlatformBitbucket:
+		return ParseBitbucketEvent(body, r.Header.Get("X-Event-Key"))
+	case PlatformJenkins:
+		return ParseJenkinsEvent(body)
+	default:
+		return nil, fmt.Errorf("unsupported platform: %s", p)
+	}
+}
+
+// writeJSON writes a JSON response
+func writeJSON(w http.ResponseWriter, code int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+// shortSHA abbreviates a commit SHA for logs
+func shortSHA(sha string) string {
+	if len(sha) > 12 {
+		return sha[:12]
+	}
+	return nonEmptyString(sha, "unknown head")
+}
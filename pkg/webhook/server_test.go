@@ -0,0 +1,385 @@
+// Package webhook provides webhook server tests
+package webhook
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/cicd-ai-toolkit/cicd-runner/pkg/platform"
+	"github.com/cicd-ai-toolkit/cicd-runner/pkg/queue"
+)
+
+const githubPROpened = `{
+	"action": "synchronize",
+	"pull_request": {"number": 42, "head": {"ref": "feature", "sha": "abc123"}, "base": {"ref": "main"}},
+	"repository": {"name": "repo", "full_name": "owner/repo", "owner": {"login": "owner"}}
+}`
+
+// recorder collects the events of the jobs a server queues
+type recorder struct {
+	mu     sync.Mutex
+	events []platform.WebhookEvent
+	done   chan struct{}
+}
+
+func newRecorder() *recorder {
+	return &recorder{done: make(chan struct{}, 10)}
+}
+
+func (rec *recorder) handle(ctx context.Context, job queue.Job) error {
+	rec.mu.Lock()
+	rec.events = append(rec.events, job.Event)
+	rec.mu.Unlock()
+	rec.done <- struct{}{}
+	return nil
+}
+
+// wait waits for n run events
+func (rec *recorder) wait(t *testing.T, n int) []platform.WebhookEvent {
+	t.Helper()
+	for i := 0; i < n; i++ {
+		select {
+		case <-rec.done:
+		case <-time.After(5 * time.Second):
+			t.Fatalf("timed out waiting for event %d", i+1)
+		}
+	}
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+	return append([]platform.WebhookEvent(nil), rec.events...)
+}
+
+// newTestServer returns a server whose jobs run handler on a queue in a
+// temporary directory
+func newTestServer(t *testing.T, opts ServerOptions, handler queue.Handler) (*Server, *queue.Queue) {
+	t.Helper()
+	q, err := queue.Open(queue.Options{Dir: t.TempDir()}, handler)
+	if err != nil {
+		t.Fatal(err)
+	}
+	q.Start()
+	t.Cleanup(func() { _ = q.Stop(context.Background()) })
+	return NewServer(opts, q), q
+}
+
+func sign(secret, body string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(body))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func deliver(t *testing.T, handler http.Handler, path, body string, header http.Header) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	return w
+}
+
+func TestServerVerifiesAndTriggers(t *testing.T) {
+	secrets := Secrets{GitHub: "gh-secret", GitLab: "gl-token", Gitee: "gitee-secret", Jenkins: "jenkins-token"}
+
+	gitlabBody := `{"object_kind": "merge_request", "project": {"name": "app", "path_with_namespace": "group/app"},
+		"object_attributes": {"iid": 7, "action": "update", "last_commit": {"id": "def456"}}}`
+	giteeBody := `{"hook_name": "merge_request_hooks", "action": "open",
+		"pull_request": {"number": 3, "head": {"sha": "789abc"}}, "repository": {"full_name": "org/lib"}}`
+	jenkinsBody := `{"name": "app-build", "build": {"number": 15, "phase": "STARTED", "scm": {"commit": "f00", "branch": "origin/main"}}}`
+	giteeSign := func(timestamp string) string {
+		return base64.StdEncoding.EncodeToString(hmacSHA256("gitee-secret", []byte(timestamp+"\ngitee-secret")))
+	}
+	giteeTimestamp := strconv.FormatInt(time.Now().UnixMilli(), 10)
+	staleTimestamp := strconv.FormatInt(time.Now().Add(-2*time.Hour).UnixMilli(), 10)
+
+	tests := []struct {
+		name       string
+		path       string
+		body       string
+		header     http.Header
+		wantStatus int
+		want       *platform.WebhookEvent
+	}{
+		{
+			name:       "github signed",
+			path:       "/webhook/github",
+			body:       githubPROpened,
+			header:     http.Header{"X-Github-Event": {"pull_request"}, "X-Hub-Signature-256": {"sha256=" + sign("gh-secret", githubPROpened)}},
+			wantStatus: http.StatusAccepted,
+			want:       &platform.WebhookEvent{Type: platform.EventPRSynchronize, Platform: "github", PRID: 42, Repo: "owner/repo", SHA: "abc123", BaseRef: "main", HeadRef: "feature"},
+		},
+		{
+			name:       "github bad signature",
+			path:       "/webhook/github",
+			body:       githubPROpened,
+			header:     http.Header{"X-Github-Event": {"pull_request"}, "X-Hub-Signature-256": {"sha256=" + sign("other", githubPROpened)}},
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name:       "github unsigned",
+			path:       "/webhook/github",
+			body:       githubPROpened,
+			header:     http.Header{"X-Github-Event": {"pull_request"}},
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name:       "github ping ignored",
+			path:       "/webhook/github",
+			body:       `{}`,
+			header:     http.Header{"X-Github-Event": {"ping"}, "X-Hub-Signature-256": {"sha256=" + sign("gh-secret", `{}`)}},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "gitlab token",
+			path:       "/webhook/gitlab",
+			body:       gitlabBody,
+			header:     http.Header{"X-Gitlab-Token": {"gl-token"}},
+			wantStatus: http.StatusAccepted,
+			want:       &platform.WebhookEvent{Type: platform.EventPRSynchronize, Platform: "gitlab", PRID: 7, Repo: "group/app", SHA: "def456"},
+		},
+		{
+			name:       "gitlab wrong token",
+			path:       "/webhook/gitlab",
+			body:       gitlabBody,
+			header:     http.Header{"X-Gitlab-Token": {"nope"}},
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name:       "gitee timestamp signature",
+			path:       "/webhook/gitee",
+			body:       giteeBody,
+			header:     http.Header{"X-Gitee-Event": {"Merge Request Hook"}, "X-Gitee-Token": {giteeSign(giteeTimestamp)}, "X-Gitee-Timestamp": {giteeTimestamp}},
+			wantStatus: http.StatusAccepted,
+			want:       &platform.WebhookEvent{Type: platform.EventPROpened, Platform: "gitee", PRID: 3, Repo: "org/lib", SHA: "789abc"},
+		},
+		{
+			name:       "gitee replayed timestamp signature",
+			path:       "/webhook/gitee",
+			body:       giteeBody,
+			header:     http.Header{"X-Gitee-Event": {"Merge Request Hook"}, "X-Gitee-Token": {giteeSign(staleTimestamp)}, "X-Gitee-Timestamp": {staleTimestamp}},
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name:       "gitee body signature",
+			path:       "/webhook/gitee",
+			body:       giteeBody,
+			header:     http.Header{"X-Gitee-Token": {sign("gitee-secret", giteeBody)}},
+			wantStatus: http.StatusAccepted,
+			want:       &platform.WebhookEvent{Type: platform.EventPROpened, Platform: "gitee", PRID: 3, Repo: "org/lib", SHA: "789abc"},
+		},
+		{
+			name:       "jenkins bearer token",
+			path:       "/webhook/jenkins",
+			body:       jenkinsBody,
+			header:     http.Header{"Authorization": {"Bearer jenkins-token"}},
+			wantStatus: http.StatusAccepted,
+			want:       &platform.WebhookEvent{Type: platform.EventPROpened, Platform: "jenkins", PRID: 15, Repo: "app-build", SHA: "f00", HeadRef: "main"},
+		},
+		{
+			name:       "bitbucket without secret",
+			path:       "/webhook/bitbucket",
+			body:       `{}`,
+			wantStatus: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := newRecorder()
+			srv, q := newTestServer(t, ServerOptions{Secrets: secrets}, rec.handle)
+
+			w := deliver(t, srv.Handler(), tt.path, tt.body, tt.header)
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body.String())
+			}
+			if tt.want == nil {
+				if n := len(q.List("")); n != 0 {
+					t.Errorf("queued %d jobs, want none", n)
+				}
+				return
+			}
+
+			got := rec.wait(t, 1)[0]
+			got.Timestamp = 0
+			if got != *tt.want {
+				t.Errorf("event = %+v, want %+v", got, *tt.want)
+			}
+		})
+	}
+}
+
+func TestServerQueuesJobs(t *testing.T) {
+	release := make(chan struct{})
+	started := make(chan string, 10)
+	srv, q := newTestServer(t, ServerOptions{Secrets: Secrets{GitLab: "t"}}, func(ctx context.Context, job queue.Job) error {
+		started <- job.Event.SHA
+		select {
+		case <-release:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	})
+
+	delivery := func(sha string) string {
+		return `{"object_kind": "merge_request", "project": {"path_with_namespace": "g/a"},
+			"object_attributes": {"iid": 1, "action": "update", "last_commit": {"id": "` + sha + `"}}}`
+	}
+	header := http.Header{"X-Gitlab-Token": {"t"}}
+
+	w := deliver(t, srv.Handler(), "/webhook/gitlab", delivery("aaa"), header)
+	var accepted struct {
+		Status string `json:"status"`
+		Job    string `json:"job"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &accepted); err != nil {
+		t.Fatal(err)
+	}
+	if w.Code != http.StatusAccepted || accepted.Status != "queued" || accepted.Job == "" {
+		t.Fatalf("first delivery = %d %s", w.Code, w.Body.String())
+	}
+	<-started
+
+	// A redelivery is a duplicate of the running job
+	if w := deliver(t, srv.Handler(), "/webhook/gitlab", delivery("aaa"), header); w.Code != http.StatusOK || !strings.Contains(w.Body.String(), accepted.Job) {
+		t.Errorf("redelivery = %d %s, want duplicate of %s", w.Code, w.Body.String(), accepted.Job)
+	}
+
+	// A new push supersedes it
+	if w := deliver(t, srv.Handler(), "/webhook/gitlab", delivery("bbb"), header); w.Code != http.StatusAccepted {
+		t.Fatalf("push delivery status = %d", w.Code)
+	}
+	if sha := <-started; sha != "bbb" {
+		t.Errorf("started %s, want bbb", sha)
+	}
+	if job, _ := q.Get(accepted.Job); job.State != queue.StateSuperseded {
+		t.Errorf("first job = %s, want superseded", job.State)
+	}
+	close(release)
+
+	if stats := srv.Stats(); stats.Accepted != 2 || stats.Duplicates != 1 {
+		t.Errorf("stats = %+v", stats)
+	}
+}
+
+func TestServerCommands(t *testing.T) {
+	comment := func(body string) string {
+		return `{"action": "created", "issue": {"number": 9, "pull_request": {}},
+			"comment": {"id": 12, "body": "` + body + `", "user": {"login": "alice", "type": "User"}},
+			"repository": {"full_name": "owner/repo"}}`
+	}
+	header := func(body string) http.Header {
+		return http.Header{"X-Github-Event": {"issue_comment"}, "X-Hub-Signature-256": {"sha256=" + sign("s", body)}}
+	}
+
+	rec := newRecorder()
+	srv, _ := newTestServer(t, ServerOptions{Secrets: Secrets{GitHub: "s"}, Commands: true}, rec.handle)
+	handler := srv.Handler()
+
+	body := comment("/ai review")
+	if w := deliver(t, handler, "/webhook/github", body, header(body)); w.Code != http.StatusAccepted {
+		t.Fatalf("command status = %d, want 202: %s", w.Code, w.Body.String())
+	}
+	events := rec.wait(t, 1)
+	if c := events[0].Comment; events[0].Type != platform.EventPRComment || c == nil || c.Body != "/ai review" || c.Author != "alice" {
+		t.Errorf("event = %+v", events[0])
+	}
+
+	body = comment("LGTM")
+	if w := deliver(t, handler, "/webhook/github", body, header(body)); w.Code != http.StatusOK {
+		t.Errorf("plain comment status = %d, want 200", w.Code)
+	}
+
+	// Commands are ignored unless enabled
+	srv, q := newTestServer(t, ServerOptions{Secrets: Secrets{GitHub: "s"}}, newRecorder().handle)
+	body = comment("/ai review")
+	if w := deliver(t, srv.Handler(), "/webhook/github", body, header(body)); w.Code != http.StatusOK {
+		t.Errorf("disabled command status = %d, want 200", w.Code)
+	}
+	if n := len(q.List("")); n != 0 {
+		t.Errorf("queued %d jobs with commands disabled", n)
+	}
+}
+
+func TestServerShutdown(t *testing.T) {
+	srv, q := newTestServer(t, ServerOptions{Secrets: Secrets{Jenkins: "t"}}, newRecorder().handle)
+	handler := srv.Handler()
+
+	if err := srv.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Shutdown() error = %v", err)
+	}
+
+	// Deliveries after shutdown are refused and health reports it
+	body := `{"name": "job", "number": 2, "phase": "STARTED"}`
+	header := http.Header{"Authorization": {"Bearer t"}}
+	if w := deliver(t, handler, "/webhook/jenkins", body, header); w.Code != http.StatusServiceUnavailable {
+		t.Errorf("delivery after shutdown status = %d, want 503", w.Code)
+	}
+	if n := len(q.List("")); n != 0 {
+		t.Errorf("queued %d jobs after shutdown", n)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/healthz", nil)
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	var health struct {
+		Status    string   `json:"status"`
+		Platforms []string `json:"platforms"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &health); err != nil {
+		t.Fatal(err)
+	}
+	if w.Code != http.StatusServiceUnavailable || health.Status != "shutting_down" || len(health.Platforms) != 1 {
+		t.Errorf("healthz = %d %+v", w.Code, health)
+	}
+
+	// A stopped queue refuses deliveries too
+	srv, q = newTestServer(t, ServerOptions{Secrets: Secrets{Jenkins: "t"}}, newRecorder().handle)
+	if err := q.Stop(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if w := deliver(t, srv.Handler(), "/webhook/jenkins", body, header); w.Code != http.StatusServiceUnavailable {
+		t.Errorf("delivery to stopped queue status = %d, want 503", w.Code)
+	}
+}
+
+func TestServerHealth(t *testing.T) {
+	srv, _ := newTestServer(t, ServerOptions{Secrets: Secrets{GitHub: "a", Gitee: "b"}, APIToken: "api"}, newRecorder().handle)
+
+	req := httptest.NewRequest(http.MethodGet, "/healthz", nil)
+	w := httptest.NewRecorder()
+	srv.Handler().ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"platforms":["gitee","github"]`) || !strings.Contains(w.Body.String(), `"queue":{"queued":0`) {
+		t.Errorf("healthz = %d %s", w.Code, w.Body.String())
+	}
+
+	// The job API is mounted with its token
+	req = httptest.NewRequest(http.MethodGet, "/jobs", nil)
+	req.Header.Set("Authorization", "Bearer api")
+	w = httptest.NewRecorder()
+	srv.Handler().ServeHTTP(w, req)
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"jobs":[]`) {
+		t.Errorf("GET /jobs = %d %s", w.Code, w.Body.String())
+	}
+
+	// Webhooks only accept POST
+	req = httptest.NewRequest(http.MethodGet, "/webhook/github", nil)
+	w = httptest.NewRecorder()
+	srv.Handler().ServeHTTP(w, req)
+	if w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("GET /webhook/github status = %d, want 405", w.Code)
+	}
+}
@@ -5,8 +5,11 @@ package webhook
 
 import (
 	"encoding/json"
+	"strings"
 	"testing"
 	"time"
+
+	"github.com/cicd-ai-toolkit/cicd-runner/pkg/platform"
 )
 
 // TestPlatformConstants verifies platform constants are properly defined
@@ -15,9 +18,10 @@ func TestPlatformConstants(t *testing.T) {
 		PlatformGitHub,
 		PlatformGitLab,
 		PlatformGitee,
+		PlatformBitbucket,
 	}
 
-	expected := []string{"github", "gitlab", "gitee"}
+	expected := []string{"github", "gitlab", "gitee", "bitbucket"}
 	for i, p := range platforms {
 		if string(p) != expected[i] {
 			t.Errorf("Platform[%d] = %s, want %s", i, p, expected[i])
@@ -298,3 +302,266 @@ func TestParseGitLabEventIgnoresNonMergeRequest(t *testing.T) {
 		t.Error("Non-merge_request event should return nil event")
 	}
 }
+
+// TestParseBitbucketCloudEvent verifies Bitbucket Cloud event parsing
+func TestParseBitbucketCloudEvent(t *testing.T) {
+	payload := []byte(`{
+		"actor": {"display_name": "Test User", "nickname": "testuser"},
+		"pullrequest": {
+			"id": 42,
+			"title": "Test PR",
+			"description": "Test description",
+			"state": "OPEN",
+			"author": {"display_name": "Test User", "nickname": "testuser"},
+			"source": {"branch": {"name": "feature"}, "commit": {"hash": "abc123"}},
+			"destination": {"branch": {"name": "main"}}
+		},
+		"repository": {
+			"name": "test-repo",
+			"full_name": "team/test-repo",
+			"workspace": {"slug": "team"}
+		}
+	}`)
+
+	event, err := ParseBitbucketEvent(payload, "pullrequest:updated")
+	if err != nil {
+		t.Fatalf("ParseBitbucketEvent failed: %v", err)
+	}
+	if event == nil {
+		t.Fatal("Event should not be nil")
+	}
+
+	if event.Platform != PlatformBitbucket || event.Type != EventPRSynchronize {
+		t.Errorf("Platform/Type = %s/%s, want bitbucket/synchronize", event.Platform, event.Type)
+	}
+	if event.PRID != 42 || event.FullName != "team/test-repo" || event.Owner != "team" {
+		t.Errorf("unexpected PR/repo: %+v", event)
+	}
+	if event.SHA != "abc123" || event.BaseRef != "main" || event.HeadRef != "feature" || event.Author != "testuser" {
+		t.Errorf("unexpected refs/author: %+v", event)
+	}
+}
+
+// TestParseBitbucketServerEvent verifies Bitbucket Data Center event parsing
+func TestParseBitbucketServerEvent(t *testing.T) {
+	payload := []byte(`{
+		"eventKey": "pr:opened",
+		"actor": {"name": "testuser"},
+		"pullRequest": {
+			"id": 7,
+			"title": "Test PR",
+			"state": "OPEN",
+			"author": {"user": {"name": "testuser"}},
+			"fromRef": {"displayId": "feature", "latestCommit": "def456", "repository": {"id": 3, "slug": "repo", "project": {"key": "PROJ"}}},
+			"toRef": {"displayId": "main", "latestCommit": "0000", "repository": {"id": 3, "slug": "repo", "project": {"key": "PROJ"}}}
+		}
+	}`)
+
+	// The event key falls back to the payload when the header is missing
+	event, err := ParseBitbucketEvent(payload, "")
+	if err != nil {
+		t.Fatalf("ParseBitbucketEvent failed: %v", err)
+	}
+	if event == nil {
+		t.Fatal("Event should not be nil")
+	}
+
+	if event.Type != EventPROpened || event.PRID != 7 {
+		t.Errorf("Type/PRID = %s/%d, want opened/7", event.Type, event.PRID)
+	}
+	if event.FullName != "PROJ/repo" || event.RepoID != 3 || event.SHA != "def456" || event.BaseRef != "main" {
+		t.Errorf("unexpected event: %+v", event)
+	}
+}
+
+// TestParseBitbucketEventIgnored verifies non-review events are ignored
+func TestParseBitbucketEventIgnored(t *testing.T) {
+	for _, key := range []string{"repo:push", "pullrequest:fulfilled", "pr:merged", "diagnostics:ping"} {
+		event, err := ParseBitbucketEvent([]byte(`{"pullrequest": {"id": 1}, "pullRequest": {"id": 1}}`), key)
+		if err != nil {
+			t.Fatalf("ParseBitbucketEvent(%s) failed: %v", key, err)
+		}
+		if event != nil {
+			t.Errorf("%s should return nil event", key)
+		}
+	}
+}
+
+// TestParseGitLabEventMergeDoesNotTrigger verifies merged MRs are not reviewed
+func TestParseGitLabEventMergeDoesNotTrigger(t *testing.T) {
+	payload := []byte(`{"object_kind": "merge_request", "object_attributes": {"iid": 5, "action": "merge"}}`)
+
+	event, err := ParseGitLabEvent(payload, "Merge Request Hook")
+	if err != nil {
+		t.Fatalf("ParseGitLabEvent failed: %v", err)
+	}
+	if event.Type != EventPRMerged || event.ShouldTriggerReview() {
+		t.Errorf("Type = %s, ShouldTriggerReview = %v", event.Type, event.ShouldTriggerReview())
+	}
+}
+
+// TestParseGiteeEvent verifies Gitee pull request event parsing
+func TestParseGiteeEvent(t *testing.T) {
+	payload := []byte(`{
+		"hook_name": "merge_request_hooks",
+		"action": "update",
+		"pull_request": {
+			"number": 12,
+			"title": "Add cache",
+			"user": {"login": "dev"},
+			"head": {"ref": "feature", "sha": "abc123"},
+			"base": {"ref": "master"}
+		},
+		"repository": {"id": 9, "name": "lib", "full_name": "org/lib", "owner": {"login": "org"}}
+	}`)
+
+	event, err := ParseGiteeEvent(payload, "Merge Request Hook")
+	if err != nil {
+		t.Fatalf("ParseGiteeEvent failed: %v", err)
+	}
+	if event.Platform != PlatformGitee || event.Type != EventPRSynchronize || event.PRID != 12 {
+		t.Errorf("event = %+v", event)
+	}
+	if event.FullName != "org/lib" || event.SHA != "abc123" || event.BaseRef != "master" || event.Author != "dev" {
+		t.Errorf("event = %+v", event)
+	}
+
+	for _, data := range []string{
+		`{"hook_name": "merge_request_hooks", "action": "merge", "pull_request": {"number": 12}}`,
+		`{"hook_name": "push_hooks", "ref": "refs/heads/master"}`,
+	} {
+		if event, err := ParseGiteeEvent([]byte(data), ""); err != nil || event != nil {
+			t.Errorf("ParseGiteeEvent(%s) = %+v, %v; want ignored", data, event, err)
+		}
+	}
+}
+
+// TestParseJenkinsEvent verifies Jenkins build notification parsing
+func TestParseCommentEvents(t *testing.T) {
+	github := `{
+		"action": "created",
+		"issue": {"number": 8, "title": "Fix", "pull_request": {"url": "https://api.github.com/repos/o/r/pulls/8"}},
+		"comment": {"id": 501, "body": "/ai explain a.go:3", "user": {"login": "alice", "type": "User"}},
+		"repository": {"name": "r", "full_name": "o/r", "owner": {"login": "o"}}
+	}`
+	gitee := `{
+		"hook_name": "note_hooks",
+		"action": "comment",
+		"noteable_type": "PullRequest",
+		"comment": {"id": 77, "body": "/ai review", "user": {"login": "bob"}},
+		"pull_request": {"number": 4, "head": {"sha": "abc"}},
+		"repository": {"name": "lib", "full_name": "org/lib"}
+	}`
+	giteeNote := strings.Replace(gitee, `"comment": {`, `"note": {`, 1)
+
+	tests := []struct {
+		name  string
+		parse func() (*Event, error)
+		want  Event
+	}{
+		{
+			name:  "github issue_comment",
+			parse: func() (*Event, error) { return ParseGitHubEvent([]byte(github), "issue_comment") },
+			want:  Event{Platform: PlatformGitHub, PRID: 8, FullName: "o/r", Author: "alice", CommentID: 501, Comment: "/ai explain a.go:3"},
+		},
+		{
+			name:  "gitee note",
+			parse: func() (*Event, error) { return ParseGiteeEvent([]byte(gitee), "Note Hook") },
+			want:  Event{Platform: PlatformGitee, PRID: 4, FullName: "org/lib", SHA: "abc", Author: "bob", CommentID: 77, Comment: "/ai review"},
+		},
+		{
+			name:  "gitee legacy note field",
+			parse: func() (*Event, error) { return ParseGiteeEvent([]byte(giteeNote), "") },
+			want:  Event{Platform: PlatformGitee, PRID: 4, FullName: "org/lib", SHA: "abc", Author: "bob", CommentID: 77, Comment: "/ai review"},
+		},
+		{
+			// Authorization refuses an empty author; "unknown" could be a login
+			name: "gitee note without login",
+			parse: func() (*Event, error) {
+				return ParseGiteeEvent([]byte(strings.Replace(gitee, `"login": "bob"`, `"login": ""`, 1)), "Note Hook")
+			},
+			want: Event{Platform: PlatformGitee, PRID: 4, FullName: "org/lib", SHA: "abc", CommentID: 77, Comment: "/ai review"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			event, err := tt.parse()
+			if err != nil || event == nil {
+				t.Fatalf("parse = %v, %v", event, err)
+			}
+			if event.Type != EventPRComment || event.Platform != tt.want.Platform || event.PRID != tt.want.PRID ||
+				event.FullName != tt.want.FullName || event.SHA != tt.want.SHA || event.Author != tt.want.Author ||
+				event.CommentID != tt.want.CommentID || event.Comment != tt.want.Comment {
+				t.Errorf("event = %+v", event)
+			}
+			if event.ShouldTriggerReview() {
+				t.Error("comment triggers a review")
+			}
+
+			normalized := event.Normalize()
+			if normalized.Type != platform.EventPRComment || normalized.Comment == nil ||
+				normalized.Comment.ID != tt.want.CommentID || normalized.Comment.Author != tt.want.Author {
+				t.Errorf("normalized = %+v", normalized)
+			}
+		})
+	}
+
+	// Edits, bot comments and comments on issues are ignored
+	ignored := []struct{ data, eventType string }{
+		{strings.Replace(github, `"created"`, `"edited"`, 1), "issue_comment"},
+		{strings.Replace(github, `"User"`, `"Bot"`, 1), "issue_comment"},
+		{strings.Replace(github, `"pull_request": {"url": "https://api.github.com/repos/o/r/pulls/8"}`, `"state": "open"`, 1), "issue_comment"},
+	}
+	for _, tt := range ignored {
+		if event, err := ParseGitHubEvent([]byte(tt.data), tt.eventType); err != nil || event != nil {
+			t.Errorf("ParseGitHubEvent(%s) = %+v, %v; want ignored", tt.data, event, err)
+		}
+	}
+	if event, err := ParseGiteeEvent([]byte(strings.Replace(gitee, "PullRequest", "Issue", 1)), "Note Hook"); err != nil || event != nil {
+		t.Errorf("Gitee issue note = %+v, %v; want ignored", event, err)
+	}
+}
+
+func TestParseJenkinsEvent(t *testing.T) {
+	event, err := ParseJenkinsEvent([]byte(`{"name": "app", "number": 8, "phase": "STARTED"}`))
+	if err != nil {
+		t.Fatalf("ParseJenkinsEvent failed: %v", err)
+	}
+	if event.Platform != PlatformJenkins || event.PRID != 8 || event.FullName != "app" || !event.ShouldTriggerReview() {
+		t.Errorf("event = %+v", event)
+	}
+
+	if event, err := ParseJenkinsEvent([]byte(`{"name": "app", "build": {"number": 8, "phase": "COMPLETED"}}`)); err != nil || event != nil {
+		t.Errorf("completed build = %+v, %v; want ignored", event, err)
+	}
+	if _, err := ParseJenkinsEvent([]byte(`{"name": "app", "phase": "STARTED"}`)); err == nil {
+		t.Error("expected error for missing build number")
+	}
+}
+
+// TestEventNormalize verifies events are normalized for the platform layer
+func TestEventNormalize(t *testing.T) {
+	received := time.Unix(1700000000, 0)
+	event := &Event{
+		Platform:  PlatformGitLab,
+		Type:      EventPRUpdatedGL,
+		PRID:      4,
+		Repo:      "app",
+		FullName:  "group/app",
+		SHA:       "abc",
+		BaseRef:   "main",
+		HeadRef:   "feature",
+		Timestamp: received,
+	}
+
+	got := event.Normalize()
+	if got.Type != platform.EventPRSynchronize || got.Platform != "gitlab" || got.Repo != "group/app" || got.Timestamp != received.Unix() {
+		t.Errorf("Normalize() = %+v", got)
+	}
+
+	event.Type = EventPROpenedGL
+	if got := event.Normalize(); got.Type != platform.EventPROpened {
+		t.Errorf("GitLab open normalized to %s", got.Type)
+	}
+}